@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"market_order/application/projection"
+	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+)
+
+// AdminHandler handles operator endpoints
+type AdminHandler struct {
+	manualReviewRepo repository.ManualReviewStore
+	eventStore       eventstore.EventStore
+	processedEvents  idempotency.ProcessedEventStore
+	orderReporter    *projection.OrderReporter
+
+	// Consumers - optional: per-queue consumer counts of GET /admin/stats
+	Consumers ConsumerStats
+}
+
+func NewAdminHandler(
+	manualReviewRepo repository.ManualReviewStore,
+	eventStore eventstore.EventStore,
+	processedEvents idempotency.ProcessedEventStore,
+) *AdminHandler {
+	return &AdminHandler{
+		manualReviewRepo: manualReviewRepo,
+		eventStore:       eventStore,
+		processedEvents:  processedEvents,
+		orderReporter:    projection.NewOrderReporter(eventStore),
+	}
+}
+
+// Processing history page size: default and maximum of ?limit=
+const (
+	defaultProcessedEventsLimit = 100
+	maxProcessedEventsLimit     = 1000
+)
+
+// defaultReportWindow - period of GET /admin/reports/orders when since is not specified
+const defaultReportWindow = time.Hour
+
+// ManualReviewResponse is the response for the manual review queue
+type ManualReviewResponse struct {
+	Orders []repository.ManualReview `json:"orders"`
+	Count  int                       `json:"count"`
+}
+
+// ListManualReview handles GET /admin/manual-review
+// Orders whose completion kept failing after the swap executed (oldest first)
+func (h *AdminHandler) ListManualReview(w http.ResponseWriter, r *http.Request) {
+	ctx := context.Background()
+	reviews, err := h.manualReviewRepo.ListPending(ctx)
+	if err != nil {
+		log.Printf("Failed to list manual review queue: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to list manual review queue")
+		return
+	}
+
+	response := ManualReviewResponse{
+		Orders: reviews,
+		Count:  len(reviews),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// AggregateEventsResponse is the raw event stream of an aggregate
+type AggregateEventsResponse struct {
+	AggregateID   string        `json:"aggregate_id"`
+	AggregateType string        `json:"aggregate_type"` // From the first event
+	Events        []StoredEvent `json:"events"`
+	Count         int           `json:"count"`
+}
+
+// StoredEvent is an event exactly as stored in the event store
+type StoredEvent struct {
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Version        int             `json:"version"`
+	GlobalSequence int64           `json:"global_sequence"`
+	CreatedAt      string          `json:"created_at"`
+	Data           json.RawMessage `json:"data"`
+	Metadata       json.RawMessage `json:"metadata,omitempty"`
+}
+
+// GetAggregateEvents handles GET /admin/aggregates/{id}/events
+// Any aggregate (order, position, order book), ordered by version, without domain interpretation
+func (h *AdminHandler) GetAggregateEvents(w http.ResponseWriter, r *http.Request) {
+	aggregateID := r.PathValue("id")
+
+	events, err := h.eventStore.Load(r.Context(), aggregateID)
+	if err != nil {
+		if errors.Is(err, eventstore.ErrAggregateNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeAggregateNotFound, "Aggregate not found")
+			return
+		}
+		log.Printf("Failed to load events: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load events")
+		return
+	}
+
+	response := AggregateEventsResponse{
+		AggregateID:   aggregateID,
+		AggregateType: events[0].AggregateType,
+		Events:        make([]StoredEvent, 0, len(events)),
+		Count:         len(events),
+	}
+	for _, e := range events {
+		response.Events = append(response.Events, StoredEvent{
+			EventID:        e.EventID,
+			EventType:      e.EventType,
+			Version:        e.Version,
+			GlobalSequence: e.GlobalSequence,
+			CreatedAt:      e.CreatedAt,
+			Data:           e.EventData,
+			Metadata:       e.Metadata,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// OrderReportResponse is the response for GET /admin/reports/orders
+type OrderReportResponse struct {
+	Since     time.Time `json:"since"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+
+	// Volumes - traded volume per currency, e.g. {"USDT": {"sold": 1500, ...}}
+	Volumes map[string]CurrencyVolumeResponse `json:"volumes"`
+}
+
+// CurrencyVolumeResponse is the traded volume of one currency
+type CurrencyVolumeResponse struct {
+	Sold         decimal.Decimal `json:"sold"`
+	Bought       decimal.Decimal `json:"bought"`
+	PlatformFees decimal.Decimal `json:"platform_fees"`
+}
+
+// GetOrderReport handles GET /admin/reports/orders?since=1h
+// Counts orders completed and failed within the window and sums the traded volume per currency
+func (h *AdminHandler) GetOrderReport(w http.ResponseWriter, r *http.Request) {
+	window := defaultReportWindow
+	if v := r.URL.Query().Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "since must be a positive duration, e.g. 1h")
+			return
+		}
+		window = d
+	}
+
+	report, err := h.orderReporter.Report(r.Context(), time.Now().Add(-window))
+	if err != nil {
+		log.Printf("Failed to build order report: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to build order report")
+		return
+	}
+
+	response := OrderReportResponse{
+		Since:     report.Since.UTC(),
+		Completed: report.Completed,
+		Failed:    report.Failed,
+		Volumes:   make(map[string]CurrencyVolumeResponse, len(report.Volumes)),
+	}
+	for currency, v := range report.Volumes {
+		response.Volumes[currency] = CurrencyVolumeResponse{
+			Sold:         v.Sold,
+			Bought:       v.Bought,
+			PlatformFees: v.PlatformFees,
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ProcessingHistoryResponse is the response for GET /admin/aggregates/{id}/processed-events
+type ProcessingHistoryResponse struct {
+	AggregateID string                `json:"aggregate_id"`
+	Events      []ProcessedEventEntry `json:"events"`
+	Count       int                   `json:"count"`
+	Consumers   []ConsumerActivity    `json:"consumers"` // All consumers, not only this page
+
+	// NextSince - pass as ?since= to get the next page (omitted on the last page)
+	NextSince *time.Time `json:"next_since,omitempty"`
+}
+
+// ProcessedEventEntry is one event handled by one consumer
+type ProcessedEventEntry struct {
+	EventID     string    `json:"event_id"` // Projections store a consumer-specific key, not the raw event ID
+	EventType   string    `json:"event_type"`
+	ProcessedBy string    `json:"processed_by"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// ConsumerActivity is the per-consumer breakdown of an aggregate's processing history
+type ConsumerActivity struct {
+	ProcessedBy      string    `json:"processed_by"`
+	Events           int       `json:"events"`
+	FirstProcessedAt time.Time `json:"first_processed_at"`
+	LastProcessedAt  time.Time `json:"last_processed_at"`
+}
+
+// GetProcessingHistory handles GET /admin/aggregates/{id}/processed-events?since=&limit=100
+// Which consumer (processed_by) handled each event of the aggregate and when, oldest first
+func (h *AdminHandler) GetProcessingHistory(w http.ResponseWriter, r *http.Request) {
+	aggregateID := r.PathValue("id")
+	query := r.URL.Query()
+
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = t.UTC()
+	}
+
+	limit := defaultProcessedEventsLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxProcessedEventsLimit {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "limit must be an integer between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	events, err := h.processedEvents.GetProcessedEvents(r.Context(), aggregateID, since, limit)
+	if err != nil {
+		log.Printf("Failed to load processed events: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load processed events")
+		return
+	}
+
+	activity, err := h.processedEvents.GetConsumerActivity(r.Context(), aggregateID)
+	if err != nil {
+		log.Printf("Failed to load consumer activity: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load processed events")
+		return
+	}
+
+	response := ProcessingHistoryResponse{
+		AggregateID: aggregateID,
+		Events:      make([]ProcessedEventEntry, 0, len(events)),
+		Count:       len(events),
+		Consumers:   make([]ConsumerActivity, 0, len(activity)),
+	}
+	for _, e := range events {
+		response.Events = append(response.Events, ProcessedEventEntry{
+			EventID:     e.EventID,
+			EventType:   e.EventType,
+			ProcessedBy: e.ProcessedBy,
+			ProcessedAt: e.ProcessedAt,
+		})
+	}
+	for _, a := range activity {
+		response.Consumers = append(response.Consumers, ConsumerActivity{
+			ProcessedBy:      a.ProcessedBy,
+			Events:           a.Events,
+			FirstProcessedAt: a.FirstProcessedAt,
+			LastProcessedAt:  a.LastProcessedAt,
+		})
+	}
+	if len(events) == limit {
+		next := events[len(events)-1].ProcessedAt
+		response.NextSince = &next
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
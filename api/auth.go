@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// KeyStore resolves an API key to the user it was issued to
+type KeyStore interface {
+	Lookup(apiKey string) (userID string, ok bool)
+}
+
+// StaticKeyStore keeps API keys in memory, indexed by their SHA-256 hash
+type StaticKeyStore struct {
+	users map[[sha256.Size]byte]string
+}
+
+// NewStaticKeyStore builds a key store from an apiKey → userID map
+func NewStaticKeyStore(keys map[string]string) *StaticKeyStore {
+	users := make(map[[sha256.Size]byte]string, len(keys))
+	for key, userID := range keys {
+		users[sha256.Sum256([]byte(key))] = userID
+	}
+	return &StaticKeyStore{users: users}
+}
+
+// ParseAPIKeys parses "key1:user-1,key2:user-2" (the API_KEYS format)
+func ParseAPIKeys(s string) (*StaticKeyStore, error) {
+	keys := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, userID, ok := strings.Cut(entry, ":")
+		if !ok || key == "" || userID == "" {
+			return nil, fmt.Errorf("invalid API key entry %q: want key:user_id", entry)
+		}
+		keys[key] = userID
+	}
+	return NewStaticKeyStore(keys), nil
+}
+
+// Lookup implements KeyStore
+// Keys are compared by hash, so lookup time does not depend on how much of the key matches
+func (s *StaticKeyStore) Lookup(apiKey string) (string, bool) {
+	userID, ok := s.users[sha256.Sum256([]byte(apiKey))]
+	return userID, ok
+}
+
+type userContextKey struct{}
+
+// UserFromContext returns the authenticated user set by AuthMiddleware
+func UserFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userContextKey{}).(string)
+	return userID, ok
+}
+
+// publicPaths are served without an API key (probes and scraping)
+var publicPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+}
+
+// AuthMiddleware requires "Authorization: Bearer <api key>" on every request
+// except publicPaths and puts the key's user into the request context
+// Missing or unknown key → 401
+func AuthMiddleware(keys KeyStore, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if publicPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		apiKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || strings.TrimSpace(apiKey) == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="market_order"`)
+			writeJSONError(w, http.StatusUnauthorized, ErrCodeUnauthenticated, "Missing API key")
+			return
+		}
+
+		userID, ok := keys.Lookup(strings.TrimSpace(apiKey))
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="market_order", error="invalid_token"`)
+			writeJSONError(w, http.StatusUnauthorized, ErrCodeUnauthenticated, "Invalid API key")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey{}, userID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// ParseAdminUsers parses "user-1,user-2" (the ADMIN_USERS format)
+func ParseAdminUsers(s string) map[string]bool {
+	admins := make(map[string]bool)
+	for _, userID := range strings.Split(s, ",") {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			admins[userID] = true
+		}
+	}
+	return admins
+}
+
+// AdminMiddleware lets only admin users through; runs behind AuthMiddleware
+// Authenticated non-admin user → 403
+func AdminMiddleware(admins map[string]bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := UserFromContext(r.Context())
+		if !ok || !admins[userID] {
+			writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, "Admin access required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthFailuresReturnErrorEnvelope(t *testing.T) {
+	keys := NewStaticKeyStore(map[string]string{"key-1": "user-1"})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	handler := AuthMiddleware(keys, AdminMiddleware(map[string]bool{"user-admin": true}, ok))
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantCode      int
+		wantError     string
+	}{
+		{name: "missing key", wantCode: http.StatusUnauthorized, wantError: ErrCodeUnauthenticated},
+		{name: "unknown key", authorization: "Bearer key-2", wantCode: http.StatusUnauthorized, wantError: ErrCodeUnauthenticated},
+		{name: "not an admin", authorization: "Bearer key-1", wantCode: http.StatusForbidden, wantError: ErrCodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/admin/manual-review", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", ct)
+			}
+
+			var resp ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode error envelope: %v", err)
+			}
+			if resp.Error.Code != tt.wantError || resp.Error.Message == "" {
+				t.Errorf("error = %+v, want code %s with a message", resp.Error, tt.wantError)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+)
+
+// ConsumerStats reports the message consumers running per queue (messaging.RabbitMQ)
+type ConsumerStats interface {
+	ConsumerCounts() map[string]int
+}
+
+// RuntimeStatsResponse is the response of GET /admin/stats
+type RuntimeStatsResponse struct {
+	Goroutines     int            `json:"goroutines"`
+	HeapAllocBytes uint64         `json:"heap_alloc_bytes"`
+	HeapObjects    uint64         `json:"heap_objects"`
+	NumGC          uint32         `json:"num_gc"`
+	Consumers      map[string]int `json:"consumers,omitempty"` // queue → running consumer goroutines
+}
+
+// GetRuntimeStats handles GET /admin/stats
+// Goroutine count, heap and per-queue consumers: a quick look for leaks before reaching for pprof
+func (h *AdminHandler) GetRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	response := RuntimeStatsResponse{
+		Goroutines:     runtime.NumGoroutine(),
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapObjects:    mem.HeapObjects,
+		NumGC:          mem.NumGC,
+	}
+	if h.Consumers != nil {
+		response.Consumers = h.Consumers.ConsumerCounts()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RegisterPprof serves net/http/pprof under /debug/pprof/ on mux, for admins only
+// The profiles are never registered on http.DefaultServeMux, so they are not public
+func RegisterPprof(mux *http.ServeMux, admins map[string]bool) {
+	mux.Handle("/debug/pprof/", AdminMiddleware(admins, http.HandlerFunc(pprof.Index)))
+	mux.Handle("/debug/pprof/cmdline", AdminMiddleware(admins, http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("/debug/pprof/profile", AdminMiddleware(admins, http.HandlerFunc(pprof.Profile)))
+	mux.Handle("/debug/pprof/symbol", AdminMiddleware(admins, http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("/debug/pprof/trace", AdminMiddleware(admins, http.HandlerFunc(pprof.Trace)))
+}
@@ -3,40 +3,75 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"market_order/application/aggregates"
 	"market_order/application/usecases"
+	"market_order/domain/order"
 	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	"market_order/pkg/tracing"
 	pkguuid "market_order/pkg/uuid"
 )
 
+// Timeline pagination limits for GET /orders/{orderID}
+const (
+	defaultTimelineLimit = 50
+	maxTimelineLimit     = 500
+)
+
 // OrderHandler handles HTTP requests for orders
 type OrderHandler struct {
-	createOrderUC *usecases.CreateOrderUseCase
-	eventStore    eventstore.EventStore // For reading event history
+	createOrderUC  *usecases.CreateOrderUseCase
+	cancelOrderUC  *usecases.CancelOrderUseCase
+	amendOrderUC   *usecases.AmendOrderUseCase
+	aggregateStore *aggregates.AggregateStore            // For replaying order state
+	eventStore     eventstore.EventStore                 // For reading event history
+	sagaRepo       repository.SagaStore                  // For reading saga progress
+	statusViewRepo *repository.OrderStatusViewRepository // Read model for ?view=projection
 }
 
 func NewOrderHandler(
 	createOrderUC *usecases.CreateOrderUseCase,
+	cancelOrderUC *usecases.CancelOrderUseCase,
+	amendOrderUC *usecases.AmendOrderUseCase,
+	aggregateStore *aggregates.AggregateStore,
 	eventStore eventstore.EventStore,
+	sagaRepo repository.SagaStore,
+	statusViewRepo *repository.OrderStatusViewRepository,
 ) *OrderHandler {
 	return &OrderHandler{
-		createOrderUC: createOrderUC,
-		eventStore:    eventStore,
+		createOrderUC:  createOrderUC,
+		cancelOrderUC:  cancelOrderUC,
+		amendOrderUC:   amendOrderUC,
+		aggregateStore: aggregateStore,
+		eventStore:     eventStore,
+		sagaRepo:       sagaRepo,
+		statusViewRepo: statusViewRepo,
 	}
 }
 
 // CreateOrderRequest is the HTTP request body for creating an order
 type CreateOrderRequest struct {
-	UserID       string  `json:"user_id"`
-	FromAmount   float64 `json:"from_amount"`
-	FromCurrency string  `json:"from_currency"`
-	ToCurrency   string  `json:"to_currency"`
-	OrderType    string  `json:"order_type"` // "market" or "limit"
+	UserID       string          `json:"user_id,omitempty"` // Optional: defaults to the API key's user
+	FromAmount   decimal.Decimal `json:"from_amount"`       // Number or decimal string, e.g. "0.1"
+	FromCurrency string          `json:"from_currency"`
+	ToCurrency   string          `json:"to_currency"`
+	OrderType    string          `json:"order_type"`              // "market" or "limit"
+	LimitPrice   decimal.Decimal `json:"limit_price"`             // Required for "limit" orders
+	MaxSlippage  float64         `json:"max_slippage,omitempty"`  // Swap slippage tolerance in %, 0-5, default 0.5
+	TimeInForce  string          `json:"time_in_force,omitempty"` // Limit orders: "GTC" (default), "IOC" or "GTD"
+	ExpiresAt    time.Time       `json:"expires_at,omitempty"`    // Required for "GTD" orders, optional market order TTL (RFC 3339)
+
+	// Metadata - optional client data echoed in the order history, e.g. {"client_order_id": "abc-1"}
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 // CreateOrderResponse is the HTTP response
@@ -46,33 +81,101 @@ type CreateOrderResponse struct {
 	Message string `json:"message"`
 }
 
+// Machine-readable error codes: stable, clients branch on them instead of messages
+const (
+	ErrCodeInvalidRequest      = "INVALID_REQUEST"
+	ErrCodeValidationFailed    = "VALIDATION_FAILED"
+	ErrCodeUnauthenticated     = "UNAUTHENTICATED"
+	ErrCodeForbidden           = "FORBIDDEN"
+	ErrCodeMethodNotAllowed    = "METHOD_NOT_ALLOWED"
+	ErrCodeOrderNotFound       = "ORDER_NOT_FOUND"
+	ErrCodeSagaNotFound        = "SAGA_NOT_FOUND"
+	ErrCodePositionNotFound    = "POSITION_NOT_FOUND"
+	ErrCodeOrderBookNotFound   = "ORDER_BOOK_NOT_FOUND"
+	ErrCodeAggregateNotFound   = "AGGREGATE_NOT_FOUND"
+	ErrCodeRequestInProgress   = "REQUEST_IN_PROGRESS"
+	ErrCodeOrderExists         = "ORDER_EXISTS"
+	ErrCodeOrderNotAmendable   = "ORDER_NOT_AMENDABLE"
+	ErrCodeOrderNotCancellable = "ORDER_NOT_CANCELLABLE"
+	ErrCodePositionNotClosable = "POSITION_NOT_CLOSABLE"
+	ErrCodePriceUnavailable    = "PRICE_UNAVAILABLE"
+	ErrCodeRateLimited         = "RATE_LIMITED"
+	ErrCodeInternal            = "INTERNAL"
+)
+
+// ErrorResponse is the JSON envelope of every error: {"error": {"code": ..., "message": ...}}
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes a failed request
+type APIError struct {
+	Code    string                  `json:"code"`
+	Message string                  `json:"message"`
+	Fields  []order.ValidationError `json:"fields,omitempty"` // VALIDATION_FAILED only
+}
+
+// writeJSONError responds with the error envelope
+func writeJSONError(w http.ResponseWriter, status int, errCode, message string) {
+	writeAPIError(w, status, APIError{Code: errCode, Message: message})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, apiErr APIError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: apiErr})
+}
+
+// writeValidationErrors responds 400 with field-level validation errors
+func writeValidationErrors(w http.ResponseWriter, fields order.ValidationErrors) {
+	writeAPIError(w, http.StatusBadRequest, APIError{
+		Code:    ErrCodeValidationFailed,
+		Message: "validation failed",
+		Fields:  fields,
+	})
+}
+
 // CreateOrder handles POST /orders
 func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
 	// Parse request body
 	var req CreateOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body")
 		return
 	}
 
-	// Validate request
-	if req.UserID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	// Orders are placed for the API key's user: user_id may be omitted, but must not differ
+	authUserID, ok := UserFromContext(r.Context())
+	if !ok {
+		writeJSONError(w, http.StatusUnauthorized, ErrCodeUnauthenticated, "Unauthenticated")
 		return
 	}
-	if req.FromAmount <= 0 {
-		http.Error(w, "from_amount must be positive", http.StatusBadRequest)
+	if req.UserID == "" {
+		req.UserID = authUserID
+	}
+	if req.UserID != authUserID {
+		writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, "user_id does not match the API key")
 		return
 	}
-	if req.FromCurrency == "" || req.ToCurrency == "" {
-		http.Error(w, "from_currency and to_currency are required", http.StatusBadRequest)
+
+	// Validate request shape (business rules are checked by the aggregate)
+	var violations order.ValidationErrors
+	if req.FromCurrency == "" {
+		violations = append(violations, order.ValidationError{Field: "from_currency", Message: "is required"})
+	}
+	if req.ToCurrency == "" {
+		violations = append(violations, order.ValidationError{Field: "to_currency", Message: "is required"})
+	}
+	if len(violations) > 0 {
+		writeValidationErrors(w, violations)
 		return
 	}
+
 	if req.OrderType == "" {
 		req.OrderType = "market" // Default to market order
 	}
@@ -80,20 +183,63 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	// Generate order ID
 	orderID := pkguuid.New()
 
+	// Root span of the order's trace: propagated to every saga step via event metadata
+	ctx, span := tracing.StartSpan(context.Background(), "POST /orders")
+	defer span.End()
+
 	// Execute use case
-	ctx := context.Background()
-	err := h.createOrderUC.Execute(ctx, usecases.CreateOrderRequest{
-		OrderID:      orderID,
-		UserID:       req.UserID,
-		FromAmount:   req.FromAmount,
-		FromCurrency: req.FromCurrency,
-		ToCurrency:   req.ToCurrency,
-		OrderType:    req.OrderType,
+	result, err := h.createOrderUC.Execute(ctx, usecases.CreateOrderRequest{
+		OrderID:        orderID,
+		UserID:         req.UserID,
+		FromAmount:     req.FromAmount,
+		FromCurrency:   req.FromCurrency,
+		ToCurrency:     req.ToCurrency,
+		OrderType:      req.OrderType,
+		LimitPrice:     req.LimitPrice,
+		MaxSlippage:    req.MaxSlippage,
+		TimeInForce:    req.TimeInForce,
+		ExpiresAt:      req.ExpiresAt,
+		Metadata:       req.Metadata,
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
 	})
 
 	if err != nil {
+		span.RecordError(err)
+
+		// Rejected by AcceptOrder: client error, not an infrastructure failure
+		var validationErrs order.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			writeValidationErrors(w, validationErrs)
+			return
+		}
+		if errors.Is(err, usecases.ErrUnsupportedPair) {
+			writeValidationErrors(w, order.ValidationErrors{{Field: "currency_pair", Message: err.Error()}})
+			return
+		}
+		if errors.Is(err, usecases.ErrRequestInProgress) {
+			writeJSONError(w, http.StatusConflict, ErrCodeRequestInProgress, err.Error())
+			return
+		}
+		if errors.Is(err, usecases.ErrOrderExists) {
+			writeJSONError(w, http.StatusConflict, ErrCodeOrderExists, err.Error())
+			return
+		}
 		log.Printf("Failed to create order: %v", err)
-		http.Error(w, "Failed to create order: "+err.Error(), http.StatusInternalServerError)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to create order")
+		return
+	}
+
+	// Repeated Idempotency-Key: return the original order instead of creating a new one
+	if result.Replayed {
+		resp := CreateOrderResponse{
+			OrderID: result.OrderID,
+			Status:  "pending",
+			Message: "Order already accepted for this idempotency key",
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(resp)
 		return
 	}
 
@@ -111,8 +257,199 @@ func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	log.Printf("✅ Order created: %s", orderID)
 }
 
+// CancelOrderResponse is the HTTP response for order cancellation
+type CancelOrderResponse struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// CancelOrder handles DELETE /orders/{id}
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := strings.TrimSpace(r.PathValue("id"))
+	if orderID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "order_id is required")
+		return
+	}
+
+	userID, _ := UserFromContext(r.Context())
+	o, err := h.cancelOrderUC.Execute(r.Context(), orderID, userID, "cancelled_by_user")
+	if err != nil {
+		switch {
+		case errors.Is(err, eventstore.ErrAggregateNotFound):
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderNotFound, "Order not found")
+		case errors.Is(err, usecases.ErrNotOrderOwner):
+			writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, err.Error())
+		case errors.Is(err, usecases.ErrOrderNotCancellable):
+			writeJSONError(w, http.StatusConflict, ErrCodeOrderNotCancellable, err.Error())
+		default:
+			log.Printf("Failed to cancel order: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to cancel order")
+		}
+		return
+	}
+
+	resp := CancelOrderResponse{
+		OrderID: orderID,
+		Status:  string(o.Status),
+		Message: "Order cancelled",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+
+	log.Printf("🚫 Order cancelled: %s", orderID)
+}
+
+// AmendOrderResponse is the HTTP response for order amendment
+type AmendOrderResponse struct {
+	OrderID    string          `json:"order_id"`
+	Status     string          `json:"status"`
+	FromAmount decimal.Decimal `json:"from_amount"`
+	Version    int             `json:"version"`
+	Message    string          `json:"message"`
+}
+
+// AmendOrder handles PATCH /orders/{orderID}
+// Body: the fields to change, e.g. {"from_amount": 1500}; the saga re-prices the order
+func (h *OrderHandler) AmendOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := strings.TrimSpace(r.PathValue("id"))
+	if orderID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "order_id is required")
+		return
+	}
+
+	// UseNumber: amounts reach the order as exact decimals, not float64
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(r.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	userID, _ := UserFromContext(r.Context())
+	o, err := h.amendOrderUC.Execute(r.Context(), orderID, userID, fields)
+	if err != nil {
+		var validationErrs order.ValidationErrors
+		switch {
+		case errors.As(err, &validationErrs):
+			writeValidationErrors(w, validationErrs)
+		case errors.Is(err, eventstore.ErrAggregateNotFound):
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderNotFound, "Order not found")
+		case errors.Is(err, usecases.ErrNotOrderOwner):
+			writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, err.Error())
+		case errors.Is(err, usecases.ErrUnsupportedPair):
+			writeValidationErrors(w, order.ValidationErrors{{Field: "currency_pair", Message: err.Error()}})
+		case errors.Is(err, usecases.ErrOrderNotAmendable):
+			writeJSONError(w, http.StatusConflict, ErrCodeOrderNotAmendable, err.Error())
+		default:
+			log.Printf("Failed to amend order: %v", err)
+			writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to amend order")
+		}
+		return
+	}
+
+	resp := AmendOrderResponse{
+		OrderID:    orderID,
+		Status:     string(o.Status),
+		FromAmount: o.FromAmount,
+		Version:    o.Version,
+		Message:    "Order amended and will be re-priced",
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+
+	log.Printf("✏️  Order amended: %s", orderID)
+}
+
+// SagaStatusResponse is the response for saga progress
+type SagaStatusResponse struct {
+	OrderID       string    `json:"order_id"`
+	CurrentStep   string    `json:"current_step"`
+	Description   string    `json:"description"`
+	Status        string    `json:"status"`
+	PositionID    string    `json:"position_id,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	StepEnteredAt time.Time `json:"step_entered_at"`
+}
+
+// GetSagaStatus handles GET /orders/{orderID}/saga
+// Read-only: served from saga_instances, does not touch the event store
+func (h *OrderHandler) GetSagaStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// URL format: /orders/{orderID}/saga
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/orders/"), "/saga")
+	orderID := strings.TrimSpace(path)
+
+	if orderID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "order_id is required")
+		return
+	}
+
+	ctx := context.Background()
+	inst, err := h.sagaRepo.Get(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrSagaNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeSagaNotFound, "Saga not found")
+			return
+		}
+		log.Printf("Failed to load saga: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load saga status")
+		return
+	}
+
+	response := SagaStatusResponse{
+		OrderID:       inst.OrderID,
+		CurrentStep:   inst.CurrentStep,
+		Description:   describeSagaStep(inst.CurrentStep),
+		Status:        inst.Status,
+		PositionID:    inst.PositionID,
+		StartedAt:     inst.StartedAt,
+		StepEnteredAt: inst.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// describeSagaStep returns a human-readable saga step name
+func describeSagaStep(step string) string {
+	switch step {
+	case repository.SagaStepCheckingBalance:
+		return "Checking balance"
+	case repository.SagaStepPricing:
+		return "Pricing"
+	case repository.SagaStepCreatingPosition:
+		return "Creating position"
+	case repository.SagaStepExecutingSwap:
+		return "Executing swap"
+	case repository.SagaStepCompleting:
+		return "Completing"
+	case repository.SagaStepInOrderBook:
+		return "Waiting in order book"
+	case repository.SagaStepDone:
+		return "Finished"
+	default:
+		return step
+	}
+}
+
 // HealthCheck handles GET /health
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(map[string]string{
@@ -122,18 +459,27 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 // OrderHistoryResponse is the response for order history
 type OrderHistoryResponse struct {
-	OrderID       string          `json:"order_id"`
-	UserID        string          `json:"user_id"`
-	FromAmount    float64         `json:"from_amount"`
-	FromCurrency  string          `json:"from_currency"`
-	ToCurrency    string          `json:"to_currency"`
-	ToAmount      float64         `json:"to_amount"`
-	ExecutedPrice float64         `json:"executed_price"`
-	OrderType     string          `json:"order_type"`
-	Status        string          `json:"status"`
-	CreatedAt     time.Time       `json:"created_at"`
-	UpdatedAt     time.Time       `json:"updated_at"`
-	Timeline      []TimelineEvent `json:"timeline"`
+	OrderID       string            `json:"order_id"`
+	UserID        string            `json:"user_id"`
+	FromAmount    decimal.Decimal   `json:"from_amount"`
+	FromCurrency  string            `json:"from_currency"`
+	ToCurrency    string            `json:"to_currency"`
+	ToAmount      decimal.Decimal   `json:"to_amount"`
+	ExecutedPrice decimal.Decimal   `json:"executed_price"`
+	OrderType     string            `json:"order_type"`
+	MaxSlippage   float64           `json:"max_slippage"`            // Swap slippage tolerance in %
+	PositionID    string            `json:"position_id,omitempty"`   // Linked by the saga once the position is created
+	TimeInForce   string            `json:"time_in_force,omitempty"` // Limit orders only
+	ExpiresAt     *time.Time        `json:"expires_at,omitempty"`    // GTD and market orders
+	Metadata      map[string]string `json:"metadata,omitempty"`      // Client metadata from the create request
+	Status        string            `json:"status"`
+	Version       int               `json:"version"` // Aggregate version, also sent as ETag
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Timeline      []TimelineEvent   `json:"timeline"`
+
+	// NextBeforeVersion - pass as ?before_version= to get the previous page (omitted on the first event)
+	NextBeforeVersion int `json:"next_before_version,omitempty"`
 }
 
 // TimelineEvent represents a single event in order history
@@ -145,10 +491,13 @@ type TimelineEvent struct {
 	Details     map[string]interface{} `json:"details,omitempty"`
 }
 
-// GetOrderHistory handles GET /orders/{orderID}
+// GetOrderHistory handles GET /orders/{orderID}?limit=50&before_version=N
+// Timeline is paginated backwards from the latest event
+// ?view=projection returns the order_status_view row instead (no replay, no timeline)
+// ETag is the aggregate version: If-None-Match with the current version returns 304
 func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
 		return
 	}
 
@@ -158,91 +507,75 @@ func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 	orderID := strings.TrimSpace(path)
 
 	if orderID == "" {
-		http.Error(w, "order_id is required", http.StatusBadRequest)
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "order_id is required")
 		return
 	}
 
+	switch r.URL.Query().Get("view") {
+	case "", "events":
+	case "projection":
+		h.getOrderStatusView(r.Context(), w, r, orderID)
+		return
+	default:
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "view must be 'events' or 'projection'")
+		return
+	}
+
+	limit := defaultTimelineLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxTimelineLimit {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest,
+				fmt.Sprintf("limit must be an integer between 1 and %d", maxTimelineLimit))
+			return
+		}
+		limit = n
+	}
+
+	beforeVersion := 0 // 0 = start from the latest event
+	if v := r.URL.Query().Get("before_version"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 1 {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "before_version must be an integer greater than 1")
+			return
+		}
+		beforeVersion = n
+	}
+
 	ctx := context.Background()
 
-	// Load all events for timeline (from EventStore - source of truth)
-	events, err := h.eventStore.Load(ctx, orderID)
+	// Summary comes from the replayed aggregate - the same state the saga sees
+	o, err := h.aggregateStore.LoadOrderAggregate(ctx, orderID)
 	if err != nil {
-		log.Printf("Failed to load events: %v", err)
-		http.Error(w, "Failed to load order history", http.StatusInternalServerError)
+		if errors.Is(err, eventstore.ErrAggregateNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		log.Printf("Failed to load order: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load order history")
 		return
 	}
 
-	if len(events) == 0 {
-		http.Error(w, "Order not found", http.StatusNotFound)
+	// Nothing happened since the client's copy - skip the timeline query
+	etag := versionETag(o.Version)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	// Extract order summary from events (aggregate state)
-	var (
-		userID        string
-		fromAmount    float64
-		fromCurrency  string
-		toCurrency    string
-		toAmount      float64
-		executedPrice float64
-		orderType     string
-		status        string
-		createdAt     time.Time
-		updatedAt     time.Time
-	)
-
-	// Parse first event (OrderAccepted) for basic info
-	var firstEvent map[string]interface{}
-	if err := json.Unmarshal(events[0].EventData, &firstEvent); err == nil {
-		userID, _ = firstEvent["user_id"].(string)
-		fromAmount, _ = firstEvent["from_amount"].(float64)
-		fromCurrency, _ = firstEvent["from_currency"].(string)
-		toCurrency, _ = firstEvent["to_currency"].(string)
-		orderType, _ = firstEvent["order_type"].(string)
-		status = "pending"
-		createdAt, _ = time.Parse(time.RFC3339, events[0].CreatedAt)
-	}
-
-	// Parse last event for latest state
-	lastEvent := events[len(events)-1]
-	updatedAt, _ = time.Parse(time.RFC3339, lastEvent.CreatedAt)
-
-	// Update state based on event type
-	for _, evt := range events {
-		var eventData map[string]interface{}
-		json.Unmarshal(evt.EventData, &eventData)
+	// Raw events are only used for the timeline - load just the requested page
+	if beforeVersion == 0 || beforeVersion > o.Version+1 {
+		beforeVersion = o.Version + 1
+	}
+	toVersion := beforeVersion - 1
+	fromVersion := max(toVersion-limit+1, 1)
 
-		switch evt.EventType {
-		case "PriceQuoted":
-			if p, ok := eventData["price"].(float64); ok {
-				executedPrice = p
-			}
-			if ta, ok := eventData["to_amount"].(float64); ok {
-				toAmount = ta
-			}
-		case "SwapExecuting":
-			status = "executing"
-		case "SwapExecuted":
-			if ta, ok := eventData["to_amount"].(float64); ok {
-				toAmount = ta
-			}
-			if p, ok := eventData["executed_price"].(float64); ok {
-				executedPrice = p
-			}
-		case "OrderCompleted":
-			status = "completed"
-			if fa, ok := eventData["from_amount"].(float64); ok {
-				fromAmount = fa
-			}
-			if ta, ok := eventData["to_amount"].(float64); ok {
-				toAmount = ta
-			}
-			if p, ok := eventData["executed_price"].(float64); ok {
-				executedPrice = p
-			}
-		case "OrderFailed":
-			status = "failed"
-		}
+	events, err := h.eventStore.LoadRangeByAggregateType(ctx, orderID, "Order", fromVersion, toVersion)
+	if err != nil {
+		log.Printf("Failed to load events: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load order history")
+		return
 	}
 
 	// Build timeline from events
@@ -251,66 +584,35 @@ func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 		// Parse timestamp from string
 		timestamp, _ := time.Parse(time.RFC3339, evt.CreatedAt)
 
-		timelineEvent := TimelineEvent{
-			Timestamp: timestamp,
-			EventType: evt.EventType,
-			Version:   evt.Version,
-		}
-
-		// Parse event data for details
-		var eventData map[string]interface{}
-		if err := json.Unmarshal(evt.EventData, &eventData); err == nil {
-			timelineEvent.Details = eventData
-		}
-
-		// Add human-readable description
-		switch evt.EventType {
-		case "OrderAccepted":
-			timelineEvent.Description = "Order created and accepted for processing"
-		case "PriceQuoted":
-			if price, ok := eventData["price"].(float64); ok {
-				if toAmount, ok := eventData["to_amount"].(float64); ok {
-					timelineEvent.Description = fmt.Sprintf("Price quoted: %.2f per unit, receiving %.8f units", price, toAmount)
-				}
-			}
-		case "SwapExecuting":
-			timelineEvent.Description = "Swap execution started"
-		case "SwapExecuted":
-			if txHash, ok := eventData["transaction_hash"].(string); ok {
-				timelineEvent.Description = "Swap executed successfully: " + txHash
-			}
-		case "OrderCompleted":
-			timelineEvent.Description = "Order completed successfully"
-		case "OrderFailed":
-			if reason, ok := eventData["reason"].(string); ok {
-				timelineEvent.Description = "Order failed: " + reason
-			}
-		case "PositionCreated":
-			timelineEvent.Description = "Position created"
-		case "PositionUpdated":
-			timelineEvent.Description = "Position updated with order results"
-		default:
-			timelineEvent.Description = evt.EventType
-		}
-
-		timeline = append(timeline, timelineEvent)
+		timeline = append(timeline, newTimelineEvent(evt.EventType, evt.Version, timestamp, evt.EventData))
 	}
 
-	// Build response (from events - source of truth)
+	// Build response (from replayed aggregate - source of truth)
 	response := OrderHistoryResponse{
-		OrderID:       orderID,
-		UserID:        userID,
-		FromAmount:    fromAmount,
-		FromCurrency:  fromCurrency,
-		ToCurrency:    toCurrency,
-		ToAmount:      toAmount,
-		ExecutedPrice: executedPrice,
-		OrderType:     orderType,
-		Status:        status,
-		CreatedAt:     createdAt,
-		UpdatedAt:     updatedAt,
+		OrderID:       o.ID,
+		UserID:        o.UserID,
+		FromAmount:    o.FromAmount,
+		FromCurrency:  o.FromCurrency,
+		ToCurrency:    o.ToCurrency,
+		ToAmount:      o.ToAmount,
+		ExecutedPrice: o.ExecutedPrice,
+		OrderType:     o.OrderType,
+		MaxSlippage:   o.MaxSlippage,
+		TimeInForce:   o.TimeInForce,
+		PositionID:    o.PositionID,
+		Metadata:      o.ClientMetadata,
+		Status:        string(o.Status),
+		Version:       o.Version,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
 		Timeline:      timeline,
 	}
+	if !o.ExpiresAt.IsZero() {
+		response.ExpiresAt = &o.ExpiresAt
+	}
+	if fromVersion > 1 {
+		response.NextBeforeVersion = fromVersion
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -318,3 +620,125 @@ func (h *OrderHandler) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("📊 Order history retrieved: %s", orderID)
 }
+
+// getOrderStatusView serves the fast path of GET /orders/{orderID}?view=projection
+// The view is eventually consistent: it may lag the event store by the projection delay
+func (h *OrderHandler) getOrderStatusView(ctx context.Context, w http.ResponseWriter, r *http.Request, orderID string) {
+	view, err := h.statusViewRepo.Get(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderStatusViewNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		log.Printf("Failed to load order status view: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load order")
+		return
+	}
+
+	etag := versionETag(view.Version)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(view)
+}
+
+// versionETag formats an aggregate version as an ETag value
+func versionETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// etagMatches reports whether an If-None-Match header lists etag (or is "*")
+// Weak validators (W/"...") match too - If-None-Match uses weak comparison
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// newTimelineEvent builds a timeline entry with a human-readable description
+func newTimelineEvent(eventType string, version int, timestamp time.Time, data []byte) TimelineEvent {
+	timelineEvent := TimelineEvent{
+		Timestamp: timestamp,
+		EventType: eventType,
+		Version:   version,
+	}
+
+	// Parse event data for details
+	var eventData map[string]interface{}
+	if err := json.Unmarshal(data, &eventData); err == nil {
+		timelineEvent.Details = eventData
+	}
+
+	// Add human-readable description
+	switch eventType {
+	case "OrderAccepted":
+		timelineEvent.Description = "Order created and accepted for processing"
+	case "PriceQuoted":
+		if price, ok := order.ParseAmount(eventData["price"]); ok {
+			if toAmount, ok := order.ParseAmount(eventData["to_amount"]); ok {
+				timelineEvent.Description = fmt.Sprintf("Price quoted: %s per unit, receiving %s units", price, toAmount)
+				if requote, _ := eventData["requote"].(bool); requote {
+					timelineEvent.Description = fmt.Sprintf("Price re-quoted after amendment: %s per unit, receiving %s units", price, toAmount)
+				}
+			}
+		}
+	case "QuoteRefreshed":
+		if price, ok := order.ParseAmount(eventData["price"]); ok {
+			if toAmount, ok := order.ParseAmount(eventData["to_amount"]); ok {
+				timelineEvent.Description = fmt.Sprintf("Stale quote refreshed before swap: %s per unit, receiving %s units", price, toAmount)
+			}
+		}
+	case "SwapExecuting":
+		timelineEvent.Description = "Swap execution started"
+	case "SwapExecuted":
+		if txHash, ok := eventData["transaction_hash"].(string); ok {
+			timelineEvent.Description = "Swap executed successfully: " + txHash
+		}
+	case "OrderCompleted":
+		timelineEvent.Description = "Order completed successfully"
+		if fee, ok := order.ParseAmount(eventData["platform_fee"]); ok && fee.IsPositive() {
+			timelineEvent.Description += fmt.Sprintf(" (platform fee %s)", fee)
+		}
+	case "LimitOrderExpired":
+		if tif, ok := eventData["time_in_force"].(string); ok {
+			timelineEvent.Description = "Unfilled limit order remainder expired (" + tif + ")"
+		}
+	case "OrderExpired":
+		timelineEvent.Description = "Market order expired before execution"
+		if expiresAt, ok := eventData["expires_at"].(string); ok {
+			timelineEvent.Description += " (expires_at " + expiresAt + ")"
+		}
+	case "OrderFailed":
+		if reason, ok := eventData["reason"].(string); ok {
+			timelineEvent.Description = "Order failed: " + reason
+		}
+	case "OrderUpdated":
+		timelineEvent.Description = "Order amended"
+		// Legacy or upcasted events may lack updated_fields: keep the generic description
+		fields, _ := eventData["updated_fields"].(map[string]interface{})
+		if fromAmount, ok := order.ParseAmount(fields["from_amount"]); ok {
+			timelineEvent.Description = fmt.Sprintf("Order amended: from_amount %s", fromAmount)
+		}
+	case "PositionLinkedToOrder":
+		if positionID, ok := eventData["position_id"].(string); ok {
+			timelineEvent.Description = "Position linked: " + positionID
+		}
+	case "PositionCreated":
+		timelineEvent.Description = "Position created"
+	case "PositionUpdated":
+		timelineEvent.Description = "Position updated with order results"
+	default:
+		timelineEvent.Description = eventType
+	}
+
+	return timelineEvent
+}
@@ -0,0 +1,290 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/application/usecases"
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// newTestOrderHandler serves orders from an in-memory event store
+func newTestOrderHandler(t *testing.T) (*OrderHandler, *aggregates.AggregateStore) {
+	t.Helper()
+
+	es := eventstore.NewMemoryEventStore()
+	store := aggregates.NewAggregateStore(es)
+	cancelOrderUC := usecases.NewCancelOrderUseCase(store)
+	amendOrderUC := usecases.NewAmendOrderUseCase(store)
+	return NewOrderHandler(nil, cancelOrderUC, amendOrderUC, store, es, repository.NewMemorySagaStore(), nil), store
+}
+
+// acceptTestOrder saves an accepted market order
+func acceptTestOrder(t *testing.T, store *aggregates.AggregateStore, fromAmount string) *order.Order {
+	t.Helper()
+
+	o := order.NewOrder()
+	if err := o.AcceptOrder(pkguuid.New(), "user-1", decimal.MustParse(fromAmount), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := store.SaveOrderAggregate(context.Background(), o); err != nil {
+		t.Fatalf("SaveOrderAggregate: %v", err)
+	}
+	return o
+}
+
+func TestGetOrderHistorySummaryFromAggregate(t *testing.T) {
+	h, store := newTestOrderHandler(t)
+
+	// More significant digits than a float64 holds: amounts must reach the client as exact strings
+	o := acceptTestOrder(t, store, "1234567890123456789.123456789")
+	if err := store.MutateOrder(context.Background(), o.ID, func(o *order.Order) error {
+		return o.QuotePrice(decimal.MustParse("0.0000155"), decimal.MustParse("19135802291358.022913580229135802"))
+	}); err != nil {
+		t.Fatalf("QuotePrice: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.GetOrderHistory(rec, httptest.NewRequest(http.MethodGet, "/orders/"+o.ID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var raw struct {
+		FromAmount string `json:"from_amount"`
+		ToAmount   string `json:"to_amount"`
+	}
+	body := rec.Body.Bytes()
+	if err := json.Unmarshal(body, &raw); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if raw.FromAmount != "1234567890123456789.123456789" {
+		t.Errorf("from_amount = %s, want 1234567890123456789.123456789", raw.FromAmount)
+	}
+	if raw.ToAmount != "19135802291358.022913580229135802" {
+		t.Errorf("to_amount = %s, want 19135802291358.022913580229135802", raw.ToAmount)
+	}
+
+	var response OrderHistoryResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if response.Status != string(order.OrderStatusPending) || response.Version != 2 || response.FromCurrency != "USDT" {
+		t.Errorf("summary = %s v%d %s, want pending v2 USDT", response.Status, response.Version, response.FromCurrency)
+	}
+	if len(response.Timeline) != 2 || response.Timeline[1].EventType != "PriceQuoted" {
+		t.Errorf("timeline = %+v, want OrderAccepted, PriceQuoted", response.Timeline)
+	}
+	if rec.Header().Get("ETag") != versionETag(2) {
+		t.Errorf("ETag = %q, want %q", rec.Header().Get("ETag"), versionETag(2))
+	}
+}
+
+func TestGetOrderHistoryNotFound(t *testing.T) {
+	h, _ := newTestOrderHandler(t)
+
+	rec := httptest.NewRecorder()
+	h.GetOrderHistory(rec, httptest.NewRequest(http.MethodGet, "/orders/"+pkguuid.New(), nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+// amendRequest builds PATCH /orders/{id} of user-1
+func amendRequest(orderID, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPatch, "/orders/"+orderID, strings.NewReader(body))
+	r.SetPathValue("id", orderID)
+	return r.WithContext(context.WithValue(r.Context(), userContextKey{}, "user-1"))
+}
+
+func TestAmendOrder(t *testing.T) {
+	h, store := newTestOrderHandler(t)
+	o := acceptTestOrder(t, store, "100")
+
+	rec := httptest.NewRecorder()
+	h.AmendOrder(rec, amendRequest(o.ID, `{"from_amount": "150.5"}`))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	amended, err := store.LoadOrderAggregate(context.Background(), o.ID)
+	if err != nil {
+		t.Fatalf("LoadOrderAggregate: %v", err)
+	}
+	if !amended.FromAmount.Equal(decimal.MustParse("150.5")) {
+		t.Errorf("from_amount = %s, want 150.5", amended.FromAmount)
+	}
+	events, err := store.LoadEvents(context.Background(), o.ID)
+	if err != nil {
+		t.Fatalf("LoadEvents: %v", err)
+	}
+	if last := events[len(events)-1]; last.EventType != "OrderUpdated" {
+		t.Errorf("last event = %s, want OrderUpdated", last.EventType)
+	}
+}
+
+func TestNewTimelineEventOrderUpdated(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		wantDescription string
+	}{
+		{name: "amended amount", data: `{"updated_fields": {"from_amount": "150.5"}}`, wantDescription: "Order amended: from_amount 150.5"},
+		{name: "no updated_fields", data: `{}`, wantDescription: "Order amended"},
+		{name: "updated_fields of another shape", data: `{"updated_fields": ["from_amount"]}`, wantDescription: "Order amended"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := newTimelineEvent("OrderUpdated", 2, time.Now(), []byte(tt.data))
+			if e.Description != tt.wantDescription {
+				t.Errorf("description = %q, want %q", e.Description, tt.wantDescription)
+			}
+		})
+	}
+}
+
+func TestAmendOrderRejections(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		setup    func(o *order.Order) error
+		wantCode int
+	}{
+		{
+			name:     "field not amendable",
+			body:     `{"to_amount": "1"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name: "swap already executing",
+			body: `{"from_amount": "150"}`,
+			setup: func(o *order.Order) error {
+				return errors.Join(
+					o.QuotePrice(decimal.MustParse("0.00002"), decimal.MustParse("0.002")),
+					o.StartSwapExecution("swap-key"),
+				)
+			},
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "order cancelled",
+			body:     `{"from_amount": "150"}`,
+			setup:    func(o *order.Order) error { return o.CancelOrder("user_requested") },
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := newTestOrderHandler(t)
+			o := acceptTestOrder(t, store, "100")
+			if tt.setup != nil {
+				if err := store.MutateOrder(context.Background(), o.ID, tt.setup); err != nil {
+					t.Fatalf("setup: %v", err)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			h.AmendOrder(rec, amendRequest(o.ID, tt.body))
+			if rec.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d; body %s", rec.Code, tt.wantCode, rec.Body)
+			}
+
+			events, err := store.LoadEvents(context.Background(), o.ID)
+			if err != nil {
+				t.Fatalf("LoadEvents: %v", err)
+			}
+			for _, e := range events {
+				if e.EventType == "OrderUpdated" {
+					t.Error("rejected amendment saved OrderUpdated")
+				}
+			}
+		})
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		userID    string
+		wantCode  int
+		wantError string
+	}{
+		{name: "own order", userID: "user-1", wantCode: http.StatusOK},
+		{name: "another user's order", userID: "user-2", wantCode: http.StatusForbidden, wantError: ErrCodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, store := newTestOrderHandler(t)
+			o := acceptTestOrder(t, store, "100")
+
+			r := httptest.NewRequest(http.MethodDelete, "/orders/"+o.ID, nil)
+			r.SetPathValue("id", o.ID)
+			r = r.WithContext(context.WithValue(r.Context(), userContextKey{}, tt.userID))
+
+			rec := httptest.NewRecorder()
+			h.CancelOrder(rec, r)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantCode, rec.Body)
+			}
+
+			if tt.wantError != "" {
+				var resp ErrorResponse
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("decode error envelope: %v", err)
+				}
+				if resp.Error.Code != tt.wantError {
+					t.Errorf("error code = %q, want %q", resp.Error.Code, tt.wantError)
+				}
+			}
+
+			cancelled, err := store.LoadOrderAggregate(context.Background(), o.ID)
+			if err != nil {
+				t.Fatalf("LoadOrderAggregate: %v", err)
+			}
+			if got := cancelled.Status == order.OrderStatusFailed; got != (tt.wantCode == http.StatusOK) {
+				t.Errorf("status = %s after %s", cancelled.Status, tt.name)
+			}
+		})
+	}
+}
+
+func TestGetOrderHistoryEchoesClientMetadata(t *testing.T) {
+	h, store := newTestOrderHandler(t)
+
+	o := order.NewOrder()
+	metadata := map[string]string{order.ClientOrderIDKey: "client-42", "strategy": "dca"}
+	if err := o.AcceptOrder(pkguuid.New(), "user-1", decimal.MustParse("100"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, metadata); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := store.SaveOrderAggregate(context.Background(), o); err != nil {
+		t.Fatalf("SaveOrderAggregate: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.GetOrderHistory(rec, httptest.NewRequest(http.MethodGet, "/orders/"+o.ID, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var response OrderHistoryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if response.Metadata[order.ClientOrderIDKey] != "client-42" || response.Metadata["strategy"] != "dca" {
+		t.Errorf("metadata = %v, want %v", response.Metadata, metadata)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// readinessTimeout bounds every dependency check of /ready
+const readinessTimeout = 2 * time.Second
+
+// BrokerHealth reports the state of the message broker connection
+type BrokerHealth interface {
+	Healthy() error
+}
+
+// SwapHealth reports whether swaps can be executed (the TradeWorker circuit breaker)
+type SwapHealth interface {
+	Healthy() error
+}
+
+// HealthChecker checks the dependencies the service can't work without
+type HealthChecker struct {
+	db     *sql.DB
+	broker BrokerHealth
+
+	// BrokerName - the broker's key in the checks ("rabbitmq", "kafka")
+	BrokerName string
+
+	// Swaps - optional: not ready while the TradeWorker circuit is open, so new orders are shed
+	Swaps SwapHealth
+}
+
+func NewHealthChecker(db *sql.DB, broker BrokerHealth) *HealthChecker {
+	return &HealthChecker{db: db, broker: broker, BrokerName: "rabbitmq"}
+}
+
+// ReadinessResponse is the response of GET /ready
+type ReadinessResponse struct {
+	Status string            `json:"status"` // "ready" or "not_ready"
+	Checks map[string]string `json:"checks"` // dependency → "ok" or the failure
+}
+
+// Ready handles GET /ready: 503 when Postgres, the message broker or swaps (Swaps) are unavailable
+// /health stays a cheap liveness probe, this one is for readiness probes
+func (h *HealthChecker) Ready(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	response := ReadinessResponse{
+		Status: "ready",
+		Checks: map[string]string{
+			"postgres":   checkResult(h.db.PingContext(ctx)),
+			h.BrokerName: checkResult(h.broker.Healthy()),
+		},
+	}
+	if h.Swaps != nil {
+		response.Checks["trade_worker"] = checkResult(h.Swaps.Healthy())
+	}
+
+	status := http.StatusOK
+	for _, result := range response.Checks {
+		if result != "ok" {
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
+
+func checkResult(err error) string {
+	if err != nil {
+		return "down: " + err.Error()
+	}
+	return "ok"
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/domain/orderbook"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+)
+
+// defaultDepthLevels is the number of price levels returned when not specified
+const defaultDepthLevels = 10
+
+// OrderBookHandler handles HTTP requests for order books
+type OrderBookHandler struct {
+	orderBookRepo *repository.OrderBookRepository // EventStore
+	orderBooks    *aggregates.OrderBookRegistry   // Order book per trading pair
+}
+
+func NewOrderBookHandler(orderBookRepo *repository.OrderBookRepository, orderBooks *aggregates.OrderBookRegistry) *OrderBookHandler {
+	return &OrderBookHandler{orderBookRepo: orderBookRepo, orderBooks: orderBooks}
+}
+
+// OrderBookSummary is an active order book in the GET /orderbooks response
+type OrderBookSummary struct {
+	OrderBookID string          `json:"order_book_id"`
+	TradingPair string          `json:"trading_pair"`
+	LastPrice   decimal.Decimal `json:"last_price"`
+	Status      string          `json:"status"`
+	BidOrders   int             `json:"bid_orders"`
+	AskOrders   int             `json:"ask_orders"`
+	Version     int             `json:"version"`
+}
+
+// ListOrderBooksResponse is the response for GET /orderbooks
+type ListOrderBooksResponse struct {
+	OrderBooks []OrderBookSummary `json:"order_books"`
+	Total      int                `json:"total"`
+}
+
+// ListOrderBooks handles GET /orderbooks
+// Lists the books created so far (first limit order or price update of the pair), closed books excluded
+func (h *OrderBookHandler) ListOrderBooks(w http.ResponseWriter, r *http.Request) {
+	books, err := h.orderBooks.List(r.Context())
+	if err != nil {
+		log.Printf("Failed to list order books: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to list order books")
+		return
+	}
+
+	response := ListOrderBooksResponse{OrderBooks: make([]OrderBookSummary, 0, len(books))}
+	for _, ob := range books {
+		if ob.Status == orderbook.OrderBookStatusClosed {
+			continue
+		}
+		response.OrderBooks = append(response.OrderBooks, OrderBookSummary{
+			OrderBookID: ob.ID,
+			TradingPair: ob.TradingPair,
+			LastPrice:   ob.LastPrice,
+			Status:      string(ob.Status),
+			BidOrders:   len(ob.BuyOrders),
+			AskOrders:   len(ob.SellOrders),
+			Version:     ob.Version,
+		})
+	}
+	response.Total = len(response.OrderBooks)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DepthResponse is the response for market depth
+type DepthResponse struct {
+	OrderBookID string          `json:"order_book_id"`
+	TradingPair string          `json:"trading_pair"`
+	LastPrice   decimal.Decimal `json:"last_price"`
+	Bids        []DepthLevel    `json:"bids"`
+	Asks        []DepthLevel    `json:"asks"`
+	Version     int             `json:"version"`
+}
+
+// DepthLevel is one aggregated price level of the depth
+type DepthLevel struct {
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"` // Total remaining amount at the price
+	Orders int             `json:"orders"`
+}
+
+// depthLevels converts one side of the book's depth
+func depthLevels(levels []orderbook.PriceLevel) []DepthLevel {
+	result := make([]DepthLevel, 0, len(levels))
+	for _, l := range levels {
+		result = append(result, DepthLevel{Price: l.Price, Amount: l.Amount, Orders: l.Orders})
+	}
+	return result
+}
+
+// GetDepth handles GET /orderbooks/{orderBookID}/depth?levels=10
+func (h *OrderBookHandler) GetDepth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// URL format: /orderbooks/{orderBookID}/depth
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/orderbooks/"), "/depth")
+	orderBookID := strings.TrimSpace(path)
+
+	if orderBookID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "order_book_id is required")
+		return
+	}
+
+	levels := defaultDepthLevels
+	if v := r.URL.Query().Get("levels"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "levels must be a positive integer")
+			return
+		}
+		levels = n
+	}
+
+	ctx := context.Background()
+
+	// Rebuild aggregate from EventStore (source of truth)
+	ob, err := h.orderBookRepo.Get(ctx, orderBookID)
+	if err != nil {
+		if errors.Is(err, repository.ErrOrderBookNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderBookNotFound, "Order book not found")
+			return
+		}
+		log.Printf("Failed to load order book: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load order book")
+		return
+	}
+
+	bids, asks := ob.Depth(levels)
+
+	response := DepthResponse{
+		OrderBookID: ob.ID,
+		TradingPair: ob.TradingPair,
+		LastPrice:   ob.LastPrice,
+		Bids:        depthLevels(bids),
+		Asks:        depthLevels(asks),
+		Version:     ob.Version,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// OrderBookSnapshotResponse is the response for GET /admin/orderbooks/{id}/snapshot
+// Bids and asks are the resting orders in matching priority (best price, then earliest placed):
+// enough to rebuild the book at Version
+type OrderBookSnapshotResponse struct {
+	OrderBookID string          `json:"order_book_id"`
+	TradingPair string          `json:"trading_pair"`
+	Status      string          `json:"status"`
+	LastPrice   decimal.Decimal `json:"last_price"`
+	Bids        []SnapshotOrder `json:"bids"`
+	Asks        []SnapshotOrder `json:"asks"`
+	Version     int             `json:"version"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// SnapshotOrder is a resting limit order in an order book snapshot
+type SnapshotOrder struct {
+	OrderID         string          `json:"order_id"`
+	UserID          string          `json:"user_id"`
+	Price           decimal.Decimal `json:"price"`
+	Amount          decimal.Decimal `json:"amount"`
+	RemainingAmount decimal.Decimal `json:"remaining_amount"`
+	PlacedAt        time.Time       `json:"placed_at"`
+	TimeInForce     string          `json:"time_in_force"`
+	ExpiresAt       *time.Time      `json:"expires_at,omitempty"` // GTD only
+}
+
+// GetSnapshot handles GET /admin/orderbooks/{id}/snapshot (operators: lists every user's resting orders)
+func (h *OrderBookHandler) GetSnapshot(w http.ResponseWriter, r *http.Request) {
+	orderBookID := strings.TrimSpace(r.PathValue("id"))
+	if orderBookID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "order_book_id is required")
+		return
+	}
+
+	ob, err := h.orderBooks.Get(r.Context(), orderBookID)
+	if err != nil {
+		if errors.Is(err, aggregates.ErrAggregateNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderBookNotFound, "Order book not found")
+			return
+		}
+		log.Printf("Failed to load order book: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load order book")
+		return
+	}
+
+	response := OrderBookSnapshotResponse{
+		OrderBookID: ob.ID,
+		TradingPair: ob.TradingPair,
+		Status:      string(ob.Status),
+		LastPrice:   ob.LastPrice,
+		Bids:        snapshotOrders(ob.BuyOrders),
+		Asks:        snapshotOrders(ob.SellOrders),
+		Version:     ob.Version,
+		UpdatedAt:   ob.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// snapshotOrders converts one side of the book, keeping its priority order
+func snapshotOrders(orders []orderbook.LimitOrder) []SnapshotOrder {
+	result := make([]SnapshotOrder, 0, len(orders))
+	for _, o := range orders {
+		so := SnapshotOrder{
+			OrderID:         o.OrderID,
+			UserID:          o.UserID,
+			Price:           o.Price,
+			Amount:          o.Amount,
+			RemainingAmount: o.RemainingAmount,
+			PlacedAt:        o.PlacedAt,
+			TimeInForce:     o.TimeInForce,
+		}
+		if !o.ExpiresAt.IsZero() {
+			expiresAt := o.ExpiresAt
+			so.ExpiresAt = &expiresAt
+		}
+		result = append(result, so)
+	}
+	return result
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"market_order/application/aggregates"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+)
+
+func TestListOrderBooks(t *testing.T) {
+	ctx := context.Background()
+	registry := aggregates.NewOrderBookRegistry(aggregates.NewAggregateStore(eventstore.NewMemoryEventStore()), []string{"BTC/USDT", "ETH/USDT"})
+	h := NewOrderBookHandler(nil, registry)
+
+	// Only BTC/USDT has seen a price so far
+	ob, err := registry.LoadOrCreate(ctx, "BTC/USDT")
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	if err := ob.UpdatePrice(decimal.MustParse("50000"), "binance"); err != nil {
+		t.Fatalf("UpdatePrice: %v", err)
+	}
+	if err := registry.Save(ctx, ob); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ListOrderBooks(rec, httptest.NewRequest(http.MethodGet, "/orderbooks", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+	}
+
+	var resp ListOrderBooksResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Total != 1 || len(resp.OrderBooks) != 1 {
+		t.Fatalf("got %d order books, want 1: %s", resp.Total, rec.Body)
+	}
+	if got := resp.OrderBooks[0]; got.TradingPair != "BTC/USDT" || !got.LastPrice.Equal(decimal.NewFromInt(50000)) || got.OrderBookID != registry.BookID("BTC/USDT") {
+		t.Errorf("order book = %+v, want BTC/USDT at 50000", got)
+	}
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"market_order/domain/position"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+)
+
+// PositionHandler handles HTTP requests for positions
+type PositionHandler struct {
+	positionRepo *repository.PositionRepository // EventStore
+}
+
+func NewPositionHandler(positionRepo *repository.PositionRepository) *PositionHandler {
+	return &PositionHandler{positionRepo: positionRepo}
+}
+
+// PositionResponse is the response for a single position
+type PositionResponse struct {
+	PositionID        string          `json:"position_id"`
+	UserID            string          `json:"user_id"`
+	Status            string          `json:"status"`
+	RemainingAmount   decimal.Decimal `json:"remaining_amount"`
+	AverageEntryPrice decimal.Decimal `json:"average_entry_price"`
+	TotalValue        decimal.Decimal `json:"total_value"`
+	RealizedPnL       decimal.Decimal `json:"realized_pnl"`
+	UnrealizedPnL     decimal.Decimal `json:"unrealized_pnl"`
+	PnL               decimal.Decimal `json:"pnl"`
+	OrderIDs          []string        `json:"order_ids"` // Orders that contributed to the position
+	Version           int             `json:"version"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+// ClosePositionRequest is the optional body of POST /positions/{id}/close
+type ClosePositionRequest struct {
+	Reason       string          `json:"reason,omitempty"` // Default "closed_by_user"
+	ClosingPrice decimal.Decimal `json:"closing_price"`    // Realizes the remaining amount at this price
+}
+
+// GetPosition handles GET /positions/{positionID}
+func (h *PositionHandler) GetPosition(w http.ResponseWriter, r *http.Request) {
+	p, ok := h.loadOwnPosition(w, r)
+	if !ok {
+		return
+	}
+
+	writePosition(w, p)
+}
+
+// ClosePosition handles POST /positions/{positionID}/close
+func (h *PositionHandler) ClosePosition(w http.ResponseWriter, r *http.Request) {
+	var req ClosePositionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body")
+			return
+		}
+	}
+	if req.Reason == "" {
+		req.Reason = "closed_by_user"
+	}
+	if req.ClosingPrice.Sign() < 0 {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "closing_price must not be negative")
+		return
+	}
+
+	p, ok := h.loadOwnPosition(w, r)
+	if !ok {
+		return
+	}
+
+	// ClosePosition is idempotent for the saga - an explicit close of a closed position is a conflict
+	if p.Status == position.PositionStatusClosed {
+		writeJSONError(w, http.StatusConflict, ErrCodePositionNotClosable, "Position is already closed")
+		return
+	}
+
+	if err := p.ClosePosition(req.Reason, req.ClosingPrice); err != nil {
+		writeJSONError(w, http.StatusConflict, ErrCodePositionNotClosable, err.Error())
+		return
+	}
+
+	if err := h.positionRepo.Save(context.Background(), p); err != nil {
+		log.Printf("Failed to close position: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to close position")
+		return
+	}
+
+	writePosition(w, p)
+
+	log.Printf("🔒 Position closed: %s (%s)", p.ID, req.Reason)
+}
+
+// loadOwnPosition rebuilds the position and checks it belongs to the authenticated user
+// Writes the error response and returns false on failure
+func (h *PositionHandler) loadOwnPosition(w http.ResponseWriter, r *http.Request) (*position.Position, bool) {
+	positionID := r.PathValue("id")
+
+	// Rebuild aggregate from EventStore (source of truth)
+	p, err := h.positionRepo.Get(context.Background(), positionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrPositionNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodePositionNotFound, "Position not found")
+			return nil, false
+		}
+		log.Printf("Failed to load position: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load position")
+		return nil, false
+	}
+
+	if userID, _ := UserFromContext(r.Context()); userID != p.UserID {
+		writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, "Position belongs to another user")
+		return nil, false
+	}
+
+	return p, true
+}
+
+func writePosition(w http.ResponseWriter, p *position.Position) {
+	response := PositionResponse{
+		PositionID:        p.ID,
+		UserID:            p.UserID,
+		Status:            string(p.Status),
+		RemainingAmount:   p.RemainingAmount,
+		AverageEntryPrice: p.AverageEntryPrice,
+		TotalValue:        p.TotalValue,
+		RealizedPnL:       p.RealizedPnL,
+		UnrealizedPnL:     p.UnrealizedPnL,
+		PnL:               p.PnL,
+		OrderIDs:          p.OrderIDs,
+		Version:           p.Version,
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
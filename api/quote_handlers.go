@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"market_order/application/saga"
+	"market_order/application/usecases"
+	"market_order/domain/order"
+	pricefeed "market_order/infrastructure/price"
+	"market_order/pkg/decimal"
+)
+
+// QuoteHandler handles order quotes: pricing without execution
+type QuoteHandler struct {
+	quoter *saga.PriceQuoter
+}
+
+func NewQuoteHandler(quoter *saga.PriceQuoter) *QuoteHandler {
+	return &QuoteHandler{quoter: quoter}
+}
+
+// QuoteResponse is the estimated execution of the order at the current market price
+type QuoteResponse struct {
+	FromAmount    decimal.Decimal `json:"from_amount"`
+	FromCurrency  string          `json:"from_currency"`
+	ToCurrency    string          `json:"to_currency"`
+	Price         decimal.Decimal `json:"price"`
+	EstimatedFees decimal.Decimal `json:"estimated_fees"` // In to_currency
+	ToAmount      decimal.Decimal `json:"to_amount"`      // After estimated fees
+	QuotedAt      time.Time       `json:"quoted_at"`
+	ExpiresAt     time.Time       `json:"expires_at"`
+}
+
+// QuoteOrder handles POST /orders/quote
+// Takes the POST /orders body and prices it at market; nothing is written to the event store
+func (h *QuoteHandler) QuoteOrder(w http.ResponseWriter, r *http.Request) {
+	var req CreateOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid request body")
+		return
+	}
+
+	var violations order.ValidationErrors
+	if !req.FromAmount.IsPositive() {
+		violations = append(violations, order.ValidationError{Field: "from_amount", Message: "must be positive"})
+	}
+	if req.FromCurrency == "" {
+		violations = append(violations, order.ValidationError{Field: "from_currency", Message: "is required"})
+	}
+	if req.ToCurrency == "" {
+		violations = append(violations, order.ValidationError{Field: "to_currency", Message: "is required"})
+	}
+	if len(violations) > 0 {
+		writeValidationErrors(w, violations)
+		return
+	}
+
+	quote, err := h.quoter.Quote(r.Context(), req.FromCurrency, req.ToCurrency, req.FromAmount)
+	if err != nil {
+		var validationErrs order.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			writeValidationErrors(w, validationErrs)
+			return
+		}
+		if errors.Is(err, usecases.ErrUnsupportedPair) || errors.Is(err, pricefeed.ErrPairNotSupported) {
+			writeValidationErrors(w, order.ValidationErrors{{Field: "currency_pair", Message: err.Error()}})
+			return
+		}
+		log.Printf("Failed to quote %s/%s: %v", req.FromCurrency, req.ToCurrency, err)
+		writeJSONError(w, http.StatusServiceUnavailable, ErrCodePriceUnavailable, "Market price unavailable")
+		return
+	}
+
+	resp := QuoteResponse{
+		FromAmount:    quote.FromAmount,
+		FromCurrency:  quote.FromCurrency,
+		ToCurrency:    quote.ToCurrency,
+		Price:         quote.Price,
+		EstimatedFees: quote.EstimatedFees,
+		ToAmount:      quote.ToAmount,
+		QuotedAt:      quote.QuotedAt,
+		ExpiresAt:     quote.ExpiresAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(resp)
+}
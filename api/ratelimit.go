@@ -0,0 +1,47 @@
+package api
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"market_order/infrastructure/ratelimit"
+)
+
+// RateLimitMiddleware limits POST requests per authenticated user (token bucket)
+// Must run after AuthMiddleware. Over the limit → 429 with Retry-After (seconds)
+// If the limiter store fails the request is let through: availability over strictness
+func RateLimitMiddleware(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		userID, ok := UserFromContext(r.Context())
+		if !ok {
+			writeJSONError(w, http.StatusUnauthorized, ErrCodeUnauthenticated, "Unauthenticated")
+			return
+		}
+
+		allowed, retryAfter, err := limiter.Allow(r.Context(), userID)
+		if err != nil {
+			log.Printf("⚠️  Rate limiter unavailable, allowing request: %v", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			writeJSONError(w, http.StatusTooManyRequests, ErrCodeRateLimited, "Too many orders, retry later")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/application/stream"
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/websocket"
+)
+
+// WebSocket keepalive: a ping every streamPingInterval, the client must answer within streamPongWait
+const (
+	streamPingInterval = 30 * time.Second
+	streamPongWait     = 60 * time.Second
+	streamWriteWait    = 10 * time.Second
+)
+
+// terminalEvents end the stream: the order will not change anymore
+var terminalEvents = map[string]bool{
+	"OrderCompleted": true,
+	"OrderFailed":    true,
+	"OrderCancelled": true,
+}
+
+// orderFinished reports whether the event ends the stream
+// LimitOrderExpired only does when the order closed without fills
+func orderFinished(eventType string, data []byte) bool {
+	if eventType == "LimitOrderExpired" {
+		var expired order.LimitOrderExpired
+		return json.Unmarshal(data, &expired) == nil && expired.Status != ""
+	}
+	return terminalEvents[eventType]
+}
+
+// StreamHandler handles live order updates over WebSocket
+type StreamHandler struct {
+	hub            *stream.OrderEventHub
+	aggregateStore *aggregates.AggregateStore
+}
+
+func NewStreamHandler(hub *stream.OrderEventHub, aggregateStore *aggregates.AggregateStore) *StreamHandler {
+	return &StreamHandler{hub: hub, aggregateStore: aggregateStore}
+}
+
+// StreamOrder handles GET /orders/{id}/stream
+// Upgrades to a WebSocket and pushes every new timeline event of the order as JSON text messages
+// The socket is closed once the order is completed or failed
+func (h *StreamHandler) StreamOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("id")
+
+	// Subscribe before loading: events persisted in between are not missed (duplicates are skipped by version)
+	events, unsubscribe := h.hub.Subscribe(orderID)
+	defer unsubscribe()
+
+	o, err := h.aggregateStore.LoadOrderAggregate(context.Background(), orderID)
+	if err != nil {
+		if errors.Is(err, eventstore.ErrAggregateNotFound) {
+			writeJSONError(w, http.StatusNotFound, ErrCodeOrderNotFound, "Order not found")
+			return
+		}
+		log.Printf("Failed to load order: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to load order")
+		return
+	}
+
+	if userID, _ := UserFromContext(r.Context()); userID != o.UserID {
+		writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, "Order belongs to another user")
+		return
+	}
+
+	conn, err := websocket.Upgrade(w, r)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	if o.Status == order.OrderStatusCompleted || o.Status == order.OrderStatusFailed {
+		conn.WriteClose(websocket.CloseNormalClosure, "order is "+string(o.Status))
+		return
+	}
+
+	// Reader: answers pings, extends the deadline on pongs, detects disconnects
+	disconnected := make(chan struct{})
+	conn.SetReadDeadline(time.Now().Add(streamPongWait))
+	conn.PongHandler = func() { conn.SetReadDeadline(time.Now().Add(streamPongWait)) }
+	go func() {
+		defer close(disconnected)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(streamPingInterval)
+	defer ping.Stop()
+
+	lastVersion := o.Version
+	log.Printf("📡 Streaming order %s from version %d", orderID, lastVersion)
+
+	for {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				// Shutdown or client too slow
+				conn.WriteClose(websocket.CloseGoingAway, "stream closed")
+				return
+			}
+
+			// Already sent (or part of the initial state), e.g. SwapExecuted published twice
+			if evt.Version <= lastVersion {
+				continue
+			}
+			lastVersion = evt.Version
+
+			payload, err := json.Marshal(newTimelineEvent(evt.EventType, evt.Version, evt.Timestamp, evt.Data))
+			if err != nil {
+				log.Printf("Failed to encode stream event: %v", err)
+				continue
+			}
+
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+
+			if orderFinished(evt.EventType, evt.Data) {
+				conn.WriteClose(websocket.CloseNormalClosure, "order finished")
+				return
+			}
+
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			if err := conn.WritePing(nil); err != nil {
+				return
+			}
+
+		case <-disconnected:
+			return
+		}
+	}
+}
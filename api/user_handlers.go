@@ -0,0 +1,186 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"market_order/infrastructure/repository"
+)
+
+// User order/position list pagination limits for GET /users/{userID}/orders and /positions
+const (
+	defaultUserOrdersLimit = 50
+	maxUserOrdersLimit     = 500
+)
+
+// UserHandler handles HTTP requests for user-scoped queries
+type UserHandler struct {
+	orderProjectionRepo    *repository.OrderProjectionRepository    // Read model
+	positionProjectionRepo *repository.PositionProjectionRepository // Read model
+
+	// Admins - users that may read any user's lists (ADMIN_USERS)
+	Admins map[string]bool
+}
+
+func NewUserHandler(
+	orderProjectionRepo *repository.OrderProjectionRepository,
+	positionProjectionRepo *repository.PositionProjectionRepository,
+) *UserHandler {
+	return &UserHandler{
+		orderProjectionRepo:    orderProjectionRepo,
+		positionProjectionRepo: positionProjectionRepo,
+	}
+}
+
+// UserOrdersResponse is the response for a user's order list
+type UserOrdersResponse struct {
+	UserID string                       `json:"user_id"`
+	Orders []repository.OrderProjection `json:"orders"`
+	Limit  int                          `json:"limit"`
+	Offset int                          `json:"offset"`
+}
+
+// GetUserOrders handles GET /users/{userID}/orders?status=&client_order_id=&limit=50&offset=0
+// Served from the order_projection read model (eventually consistent)
+func (h *UserHandler) GetUserOrders(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, ErrCodeMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	// URL format: /users/{userID}/orders
+	path := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/orders")
+	userID := strings.TrimSpace(path)
+
+	if userID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "user_id is required")
+		return
+	}
+	if !h.authorizeUser(w, r, userID) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := defaultUserOrdersLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxUserOrdersLimit {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxUserOrdersLimit))
+			return
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+
+	ctx := context.Background()
+	orders, err := h.orderProjectionRepo.ListByUser(ctx, userID, query.Get("status"), query.Get("client_order_id"), limit, offset)
+	if err != nil {
+		log.Printf("Failed to list user orders: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to list orders")
+		return
+	}
+
+	response := UserOrdersResponse{
+		UserID: userID,
+		Orders: orders,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// authorizeUser checks that the authenticated user may read userID's data:
+// their own, or anyone's as an admin. Writes 403 and returns false otherwise
+func (h *UserHandler) authorizeUser(w http.ResponseWriter, r *http.Request, userID string) bool {
+	authUserID, _ := UserFromContext(r.Context())
+	if authUserID == userID || h.Admins[authUserID] {
+		return true
+	}
+	writeJSONError(w, http.StatusForbidden, ErrCodeForbidden, "user_id does not match the API key")
+	return false
+}
+
+// UserPositionsResponse is the response for a user's position list
+type UserPositionsResponse struct {
+	UserID    string                          `json:"user_id"`
+	Positions []repository.PositionProjection `json:"positions"`
+	Limit     int                             `json:"limit"`
+	Offset    int                             `json:"offset"`
+}
+
+// GetUserPositions handles GET /users/{userID}/positions?status=open&limit=50&offset=0
+// Served from the position_projection read model (eventually consistent)
+func (h *UserHandler) GetUserPositions(w http.ResponseWriter, r *http.Request) {
+	userID := strings.TrimSpace(r.PathValue("id"))
+	if userID == "" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "user_id is required")
+		return
+	}
+	if !h.authorizeUser(w, r, userID) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	status := query.Get("status")
+	if status != "" && status != "open" && status != "closed" {
+		writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "status must be 'open' or 'closed'")
+		return
+	}
+
+	limit := defaultUserOrdersLimit
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxUserOrdersLimit {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, fmt.Sprintf("limit must be an integer between 1 and %d", maxUserOrdersLimit))
+			return
+		}
+		limit = n
+	}
+
+	offset := 0
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeJSONError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+
+	positions, err := h.positionProjectionRepo.ListByUser(r.Context(), userID, status, limit, offset)
+	if err != nil {
+		log.Printf("Failed to list user positions: %v", err)
+		writeJSONError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to list positions")
+		return
+	}
+
+	response := UserPositionsResponse{
+		UserID:    userID,
+		Positions: positions,
+		Limit:     limit,
+		Offset:    offset,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"market_order/infrastructure/repository"
+)
+
+// newTestUserHandler serves the read models from sqlmock; user-admin is an admin
+func newTestUserHandler(t *testing.T) (*UserHandler, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	h := NewUserHandler(repository.NewOrderProjectionRepository(db), repository.NewPositionProjectionRepository(db))
+	h.Admins = map[string]bool{"user-admin": true}
+	return h, mock
+}
+
+// userListRequest builds GET path for the authenticated authUserID
+func userListRequest(path, userID, authUserID string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	r.SetPathValue("id", userID)
+	return r.WithContext(context.WithValue(r.Context(), userContextKey{}, authUserID))
+}
+
+func TestGetUserOrdersAuthorization(t *testing.T) {
+	tests := []struct {
+		name       string
+		authUserID string
+		wantCode   int
+	}{
+		{name: "own orders", authUserID: "user-1", wantCode: http.StatusOK},
+		{name: "admin", authUserID: "user-admin", wantCode: http.StatusOK},
+		{name: "another user's orders", authUserID: "user-2", wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestUserHandler(t)
+			if tt.wantCode == http.StatusOK {
+				mock.ExpectQuery("FROM order_projection").
+					WithArgs("user-1", "", "", defaultUserOrdersLimit, 0).
+					WillReturnRows(sqlmock.NewRows(nil))
+			}
+
+			rec := httptest.NewRecorder()
+			h.GetUserOrders(rec, userListRequest("/users/user-1/orders", "user-1", tt.authUserID))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestGetUserPositionsAuthorization(t *testing.T) {
+	tests := []struct {
+		name       string
+		authUserID string
+		wantCode   int
+	}{
+		{name: "own positions", authUserID: "user-1", wantCode: http.StatusOK},
+		{name: "admin", authUserID: "user-admin", wantCode: http.StatusOK},
+		{name: "another user's positions", authUserID: "user-2", wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, mock := newTestUserHandler(t)
+			if tt.wantCode == http.StatusOK {
+				mock.ExpectQuery("FROM position_projection").
+					WithArgs("user-1", "open", defaultUserOrdersLimit, 0).
+					WillReturnRows(sqlmock.NewRows(nil))
+			}
+
+			rec := httptest.NewRecorder()
+			h.GetUserPositions(rec, userListRequest("/users/user-1/positions?status=open", "user-1", tt.authUserID))
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d; body %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -4,36 +4,91 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
+	"math"
 
 	"market_order/domain/order"
+	"market_order/domain/orderbook"
 	"market_order/domain/position"
 	"market_order/infrastructure/eventstore"
 )
 
+// ErrAggregateNotFound is returned when an aggregate has no events
+// Alias of eventstore.ErrAggregateNotFound, so either sentinel matches with errors.Is
+var ErrAggregateNotFound = eventstore.ErrAggregateNotFound
+
+// DefaultSnapshotInterval is the number of events between Order and OrderBook snapshots
+const DefaultSnapshotInterval = 50
+
+// DefaultMaxConcurrencyRetries - reloads after an optimistic locking conflict in Mutate*
+const DefaultMaxConcurrencyRetries = 3
+
 // AggregateStore provides high-level methods for loading and saving aggregates
 type AggregateStore struct {
-	eventStore eventstore.EventStore
+	eventStore    eventstore.EventStore
+	snapshotStore eventstore.SnapshotStore // nil = snapshots disabled
+
+	// SnapshotInterval - take an Order or OrderBook snapshot every N events (0 disables)
+	SnapshotInterval int
+
+	// MaxConcurrencyRetries - how many times Mutate* reloads and retries on ErrConcurrencyConflict
+	MaxConcurrencyRetries int
 }
 
 func NewAggregateStore(es eventstore.EventStore) *AggregateStore {
-	return &AggregateStore{eventStore: es}
+	return &AggregateStore{
+		eventStore:            es,
+		MaxConcurrencyRetries: DefaultMaxConcurrencyRetries,
+	}
+}
+
+// NewAggregateStoreWithSnapshots creates an AggregateStore that bounds replay cost with snapshots
+func NewAggregateStoreWithSnapshots(es eventstore.EventStore, ss eventstore.SnapshotStore) *AggregateStore {
+	return &AggregateStore{
+		eventStore:            es,
+		snapshotStore:         ss,
+		SnapshotInterval:      DefaultSnapshotInterval,
+		MaxConcurrencyRetries: DefaultMaxConcurrencyRetries,
+	}
 }
 
 // LoadOrderAggregate loads an Order aggregate from events
 func (as *AggregateStore) LoadOrderAggregate(ctx context.Context, aggregateID string) (*order.Order, error) {
-	events, err := as.eventStore.Load(ctx, aggregateID)
+	return as.LoadOrderAggregateAtVersion(ctx, aggregateID, math.MaxInt)
+}
+
+// LoadOrderAggregateAtVersion loads an Order aggregate as it was at the given version
+// Only events up to and including that version are replayed (point-in-time debugging)
+func (as *AggregateStore) LoadOrderAggregateAtVersion(ctx context.Context, aggregateID string, version int) (*order.Order, error) {
+	if version < 1 {
+		return nil, fmt.Errorf("invalid version: %d", version)
+	}
+
+	// Start from the latest snapshot (if any) and replay only newer events
+	o, err := as.loadSnapshot(ctx, aggregateID, version)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load events: %w", err)
+		return nil, err
 	}
 
-	if len(events) == 0 {
-		return nil, fmt.Errorf("aggregate not found: %s", aggregateID)
+	fromVersion := 1
+	if o != nil {
+		fromVersion = o.Version + 1
+	}
+	events, err := as.eventStore.LoadRangeByAggregateType(ctx, aggregateID, "Order", fromVersion, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
 	}
 
-	// Create new aggregate
-	o := order.NewOrder()
+	if o == nil {
+		if len(events) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrAggregateNotFound, aggregateID)
+		}
 
-	// Replay all events
+		// Create new aggregate
+		o = order.NewOrder()
+	}
+
+	// Replay events up to the requested version
 	for _, evt := range events {
 		domainEvent, err := deserializeOrderEvent(evt)
 		if err != nil {
@@ -46,9 +101,33 @@ func (as *AggregateStore) LoadOrderAggregate(ctx context.Context, aggregateID st
 		}
 	}
 
+	if version != math.MaxInt && o.Version < version {
+		return nil, fmt.Errorf("aggregate %s has only %d versions, requested version %d", aggregateID, o.Version, version)
+	}
+
 	return o, nil
 }
 
+// LoadEvents returns the raw stored events of an aggregate (saga recovery, debugging)
+func (as *AggregateStore) LoadEvents(ctx context.Context, aggregateID string) ([]eventstore.Event, error) {
+	events, err := as.eventStore.Load(ctx, aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+
+	return events, nil
+}
+
+// Exists reports whether an aggregate has any events, without loading its stream
+func (as *AggregateStore) Exists(ctx context.Context, aggregateID string) (bool, error) {
+	return as.eventStore.Exists(ctx, aggregateID)
+}
+
+// WithAggregateLock runs fn while holding the aggregate's lock (see eventstore.EventStore)
+func (as *AggregateStore) WithAggregateLock(ctx context.Context, aggregateID string, fn func(ctx context.Context) error) error {
+	return as.eventStore.WithAggregateLock(ctx, aggregateID, fn)
+}
+
 // SaveOrderAggregate saves Order aggregate changes (uncommitted events)
 func (as *AggregateStore) SaveOrderAggregate(ctx context.Context, o *order.Order) error {
 	if len(o.Changes) == 0 {
@@ -60,23 +139,141 @@ func (as *AggregateStore) SaveOrderAggregate(ctx context.Context, o *order.Order
 		return fmt.Errorf("failed to save events: %w", err)
 	}
 
+	// Take a snapshot when this save crossed a snapshot boundary
+	if as.snapshotDue(o.Version, len(o.Changes)) {
+		// Snapshot is an optimization - failure must not fail the save
+		if err := as.SaveSnapshot(ctx, o); err != nil {
+			log.Printf("⚠️  Failed to save snapshot for order %s: %v", o.ID, err)
+		}
+	}
+
 	// Clear uncommitted events after successful save
 	o.Changes = make([]interface{}, 0)
 
 	return nil
 }
 
+// OrderBatch wraps the uncommitted events of an Order for SaveInTx
+func OrderBatch(o *order.Order) eventstore.EventBatch {
+	return eventstore.NewEventBatch(o.ID, o.Version, o.Changes)
+}
+
+// PositionBatch wraps the uncommitted events of a Position for SaveInTx
+func PositionBatch(p *position.Position) eventstore.EventBatch {
+	return eventstore.NewEventBatch(p.ID, p.Version, p.Changes)
+}
+
+// SaveInTx saves the changes of several aggregates in a single transaction:
+// either every batch is persisted or none is
+// The caller clears the aggregates' Changes after a successful save
+func (as *AggregateStore) SaveInTx(ctx context.Context, batches ...eventstore.EventBatch) error {
+	if err := as.eventStore.SaveInTx(ctx, batches); err != nil {
+		return fmt.Errorf("failed to save events: %w", err)
+	}
+	return nil
+}
+
+// snapshotDue reports whether a save of saved events ending at version crossed a snapshot boundary
+func (as *AggregateStore) snapshotDue(version, saved int) bool {
+	if as.snapshotStore == nil || as.SnapshotInterval <= 0 {
+		return false
+	}
+	return version/as.SnapshotInterval > (version-saved)/as.SnapshotInterval
+}
+
+// SaveSnapshot serializes the current Order state together with its version
+func (as *AggregateStore) SaveSnapshot(ctx context.Context, o *order.Order) error {
+	// Uncommitted changes are not part of the snapshot
+	state := *o
+	state.Changes = nil
+
+	return as.saveSnapshot(ctx, o.ID, "Order", o.Version, state)
+}
+
+// SaveOrderBookSnapshot serializes the current OrderBook state together with its version
+// Resting orders keep their slice order, so a restored book matches in the same priority
+func (as *AggregateStore) SaveOrderBookSnapshot(ctx context.Context, ob *orderbook.OrderBook) error {
+	state := *ob
+	state.Changes = nil
+
+	return as.saveSnapshot(ctx, ob.ID, "OrderBook", ob.Version, state)
+}
+
+// saveSnapshot stores the JSON of state as the aggregate's snapshot at version
+func (as *AggregateStore) saveSnapshot(ctx context.Context, aggregateID, aggregateType string, version int, state interface{}) error {
+	if as.snapshotStore == nil {
+		return fmt.Errorf("snapshots are not enabled")
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to serialize snapshot: %w", err)
+	}
+
+	return as.snapshotStore.SaveSnapshot(ctx, eventstore.Snapshot{
+		AggregateID:   aggregateID,
+		AggregateType: aggregateType,
+		Version:       version,
+		State:         data,
+	})
+}
+
+// loadSnapshot restores an Order from the latest snapshot not newer than maxVersion
+// Returns nil, nil when snapshots are disabled or none exists
+func (as *AggregateStore) loadSnapshot(ctx context.Context, aggregateID string, maxVersion int) (*order.Order, error) {
+	o := order.NewOrder()
+	version, found, err := as.loadSnapshotState(ctx, aggregateID, maxVersion, o)
+	if err != nil || !found {
+		return nil, err
+	}
+	o.Version = version
+	o.Changes = make([]interface{}, 0)
+
+	return o, nil
+}
+
+// loadOrderBookSnapshot restores an OrderBook from its latest snapshot
+// Returns nil, nil when snapshots are disabled or none exists
+func (as *AggregateStore) loadOrderBookSnapshot(ctx context.Context, aggregateID string) (*orderbook.OrderBook, error) {
+	ob := orderbook.NewOrderBook()
+	version, found, err := as.loadSnapshotState(ctx, aggregateID, math.MaxInt, ob)
+	if err != nil || !found {
+		return nil, err
+	}
+	ob.Version = version
+	ob.Changes = make([]interface{}, 0)
+
+	return ob, nil
+}
+
+// loadSnapshotState decodes the latest snapshot not newer than maxVersion into state
+// found = false when snapshots are disabled or none exists
+func (as *AggregateStore) loadSnapshotState(ctx context.Context, aggregateID string, maxVersion int, state interface{}) (version int, found bool, err error) {
+	if as.snapshotStore == nil {
+		return 0, false, nil
+	}
+
+	snapshot, err := as.snapshotStore.LoadSnapshot(ctx, aggregateID, maxVersion)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+	if snapshot == nil {
+		return 0, false, nil
+	}
+
+	if err := json.Unmarshal(snapshot.State, state); err != nil {
+		return 0, false, fmt.Errorf("failed to deserialize snapshot: %w", err)
+	}
+	return snapshot.Version, true, nil
+}
+
 // LoadPositionAggregate loads a Position aggregate from events
 func (as *AggregateStore) LoadPositionAggregate(ctx context.Context, aggregateID string) (*position.Position, error) {
-	events, err := as.eventStore.Load(ctx, aggregateID)
+	events, err := as.eventStore.LoadByAggregateType(ctx, aggregateID, "Position")
 	if err != nil {
 		return nil, fmt.Errorf("failed to load events: %w", err)
 	}
 
-	if len(events) == 0 {
-		return nil, fmt.Errorf("aggregate not found: %s", aggregateID)
-	}
-
 	// Create new aggregate
 	p := position.NewPosition()
 
@@ -110,6 +307,63 @@ func (as *AggregateStore) SavePositionAggregate(ctx context.Context, p *position
 	return nil
 }
 
+// LoadOrderBookAggregate loads an OrderBook aggregate from events
+// Starts from the latest snapshot (if any): a busy book replays only the events after it
+func (as *AggregateStore) LoadOrderBookAggregate(ctx context.Context, aggregateID string) (*orderbook.OrderBook, error) {
+	ob, err := as.loadOrderBookSnapshot(ctx, aggregateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []eventstore.Event
+	if ob != nil {
+		events, err = as.eventStore.LoadRangeByAggregateType(ctx, aggregateID, "OrderBook", ob.Version+1, math.MaxInt)
+	} else {
+		events, err = as.eventStore.LoadByAggregateType(ctx, aggregateID, "OrderBook")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %w", err)
+	}
+
+	if ob == nil {
+		ob = orderbook.NewOrderBook()
+	}
+
+	for _, evt := range events {
+		domainEvent, err := deserializeOrderBookEvent(evt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to deserialize event: %w", err)
+		}
+
+		if err := ob.When(domainEvent); err != nil {
+			return nil, fmt.Errorf("failed to apply event: %w", err)
+		}
+	}
+
+	return ob, nil
+}
+
+// SaveOrderBookAggregate saves OrderBook aggregate changes
+func (as *AggregateStore) SaveOrderBookAggregate(ctx context.Context, ob *orderbook.OrderBook) error {
+	if len(ob.Changes) == 0 {
+		return nil
+	}
+
+	if err := as.eventStore.Save(ctx, ob.Changes); err != nil {
+		return fmt.Errorf("failed to save events: %w", err)
+	}
+
+	if as.snapshotDue(ob.Version, len(ob.Changes)) {
+		// Snapshot is an optimization - failure must not fail the save
+		if err := as.SaveOrderBookSnapshot(ctx, ob); err != nil {
+			log.Printf("⚠️  Failed to save snapshot for order book %s: %v", ob.ID, err)
+		}
+	}
+
+	ob.Changes = make([]interface{}, 0)
+	return nil
+}
+
 // deserializeOrderEvent converts stored event to domain event
 func deserializeOrderEvent(evt eventstore.Event) (interface{}, error) {
 	switch evt.EventType {
@@ -127,6 +381,13 @@ func deserializeOrderEvent(evt eventstore.Event) (interface{}, error) {
 		}
 		return e, nil
 
+	case "QuoteRefreshed":
+		var e order.QuoteRefreshed
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
 	case "SwapExecuting":
 		var e order.SwapExecuting
 		if err := json.Unmarshal(evt.EventData, &e); err != nil {
@@ -155,6 +416,104 @@ func deserializeOrderEvent(evt eventstore.Event) (interface{}, error) {
 		}
 		return e, nil
 
+	case "OrderInitialized":
+		var e order.OrderInitialized
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitPriceSet":
+		var e order.LimitPriceSet
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderUpdated":
+		var e order.OrderUpdated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "PositionLinkedToOrder":
+		var e order.PositionLinkedToOrder
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderCancelled":
+		var e order.OrderCancelled
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "BalanceCheckPassed":
+		var e order.BalanceCheckPassed
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "BalanceCheckFailed":
+		var e order.BalanceCheckFailed
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderPlacedInBook":
+		var e order.OrderPlacedInBook
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderPartiallyFilled":
+		var e order.OrderPartiallyFilled
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitOrderExpired":
+		var e order.LimitOrderExpired
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderExpired":
+		var e order.OrderExpired
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "SwapRejectedSlippage":
+		var e order.SwapRejectedSlippage
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "SwapTimedOut":
+		var e order.SwapTimedOut
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderNeedsManualReview":
+		var e order.OrderNeedsManualReview
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
 	default:
 		return nil, fmt.Errorf("unknown event type: %s", evt.EventType)
 	}
@@ -170,6 +529,13 @@ func deserializePositionEvent(evt eventstore.Event) (interface{}, error) {
 		}
 		return e, nil
 
+	case "PositionUpdated":
+		var e position.PositionUpdated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
 	case "PositionClosed":
 		var e position.PositionClosed
 		if err := json.Unmarshal(evt.EventData, &e); err != nil {
@@ -181,3 +547,46 @@ func deserializePositionEvent(evt eventstore.Event) (interface{}, error) {
 		return nil, fmt.Errorf("unknown event type: %s", evt.EventType)
 	}
 }
+
+// deserializeOrderBookEvent converts stored event to domain event
+func deserializeOrderBookEvent(evt eventstore.Event) (interface{}, error) {
+	switch evt.EventType {
+	case "OrderBookCreated":
+		var e orderbook.OrderBookCreated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitOrderAdded":
+		var e orderbook.LimitOrderAdded
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrdersMatched":
+		var e orderbook.OrdersMatched
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitOrderCancelled":
+		var e orderbook.LimitOrderCancelled
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "PriceUpdated":
+		var e orderbook.PriceUpdated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	default:
+		return nil, fmt.Errorf("unknown event type: %s", evt.EventType)
+	}
+}
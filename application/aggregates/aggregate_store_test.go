@@ -0,0 +1,250 @@
+package aggregates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+func TestLoadOrderAggregateIgnoresForeignEvents(t *testing.T) {
+	ctx := context.Background()
+	es := eventstore.NewMemoryEventStore()
+	store := NewAggregateStore(es)
+
+	// An ID with only Position events is not an order
+	p := position.NewPosition()
+	positionID := pkguuid.New()
+	if err := p.CreatePosition(positionID, "user-1", "USDT"); err != nil {
+		t.Fatalf("CreatePosition: %v", err)
+	}
+	if err := store.SavePositionAggregate(ctx, p); err != nil {
+		t.Fatalf("SavePositionAggregate: %v", err)
+	}
+
+	if _, err := store.LoadOrderAggregate(ctx, positionID); !errors.Is(err, ErrAggregateNotFound) {
+		t.Errorf("LoadOrderAggregate(position ID) error = %v, want ErrAggregateNotFound", err)
+	}
+	if _, err := store.LoadOrderAggregateAtVersion(ctx, positionID, 1); !errors.Is(err, ErrAggregateNotFound) {
+		t.Errorf("LoadOrderAggregateAtVersion(position ID) error = %v, want ErrAggregateNotFound", err)
+	}
+
+	// A foreign event under an order's ID is not replayed into the order
+	o := order.NewOrder()
+	orderID := pkguuid.New()
+	if err := o.AcceptOrder(orderID, "user-1", decimal.MustParse("100"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := store.SaveOrderAggregate(ctx, o); err != nil {
+		t.Fatalf("SaveOrderAggregate: %v", err)
+	}
+	foreign := position.PositionUpdated{
+		BaseEvent: position.BaseEvent{
+			EventID:       pkguuid.New(),
+			AggregateID:   orderID,
+			AggregateType: "Position",
+			EventType:     "PositionUpdated",
+			Version:       2,
+			Timestamp:     time.Now(),
+		},
+	}
+	if err := es.Save(ctx, []interface{}{foreign}); err != nil {
+		t.Fatalf("Save foreign event: %v", err)
+	}
+
+	loaded, err := store.LoadOrderAggregate(ctx, orderID)
+	if err != nil {
+		t.Fatalf("LoadOrderAggregate: %v", err)
+	}
+	if loaded.Version != 1 || loaded.Status != order.OrderStatusPending {
+		t.Errorf("order version/status = %d/%s, want 1/%s", loaded.Version, loaded.Status, order.OrderStatusPending)
+	}
+
+	if _, err := store.LoadOrderAggregateAtVersion(ctx, orderID, 1); err != nil {
+		t.Errorf("LoadOrderAggregateAtVersion(1): %v", err)
+	}
+	if _, err := store.LoadOrderAggregateAtVersion(ctx, orderID, 2); err == nil {
+		t.Error("LoadOrderAggregateAtVersion(2) of a foreign version succeeded, want error")
+	}
+}
+
+func TestSnapshotLoadEqualsFullReplay(t *testing.T) {
+	ctx := context.Background()
+	es := eventstore.NewMemoryEventStore()
+	snapshots := eventstore.NewMemorySnapshotStore()
+	store := NewAggregateStoreWithSnapshots(es, snapshots)
+
+	// A limit order filled in 197 steps: 200 events, snapshots at versions 50, 100, 150, 200
+	o := order.NewOrder()
+	orderID := pkguuid.New()
+	if err := o.AcceptOrder(orderID, "user-1", decimal.MustParse("1000"), "USDT", "BTC", "limit", decimal.MustParse("50000"), 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := o.PlaceInOrderBook("book-1"); err != nil {
+		t.Fatalf("PlaceInOrderBook: %v", err)
+	}
+	if err := o.StartSwapExecution("fill-key"); err != nil {
+		t.Fatalf("StartSwapExecution: %v", err)
+	}
+	if err := store.SaveOrderAggregate(ctx, o); err != nil {
+		t.Fatalf("SaveOrderAggregate: %v", err)
+	}
+	for i := 0; o.Version < 200; i++ {
+		err := store.MutateOrder(ctx, orderID, func(o *order.Order) error {
+			return o.PartiallyFill(decimal.MustParse("1.5"), decimal.MustParse("0.00003"), decimal.MustParse("50000"), fmt.Sprintf("0x%d", i))
+		})
+		if err != nil {
+			t.Fatalf("fill %d: %v", i, err)
+		}
+		if o, err = store.LoadOrderAggregate(ctx, orderID); err != nil {
+			t.Fatalf("LoadOrderAggregate: %v", err)
+		}
+	}
+
+	snapshot, err := snapshots.LoadSnapshot(ctx, orderID, 199)
+	if err != nil || snapshot == nil {
+		t.Fatalf("LoadSnapshot(199) = %v, %v, want the snapshot at version 150", snapshot, err)
+	}
+	if snapshot.Version != 150 {
+		t.Errorf("latest snapshot before 200 at version %d, want 150", snapshot.Version)
+	}
+
+	replay := NewAggregateStore(es) // No snapshots: every event is replayed
+	for _, version := range []int{49, 50, 51, 175, 200} {
+		fromSnapshot, err := store.LoadOrderAggregateAtVersion(ctx, orderID, version)
+		if err != nil {
+			t.Fatalf("LoadOrderAggregateAtVersion(%d) with snapshots: %v", version, err)
+		}
+		full, err := replay.LoadOrderAggregateAtVersion(ctx, orderID, version)
+		if err != nil {
+			t.Fatalf("LoadOrderAggregateAtVersion(%d) full replay: %v", version, err)
+		}
+		if got, want := orderState(t, fromSnapshot), orderState(t, full); got != want {
+			t.Errorf("version %d from snapshot:\n%s\nwant (full replay):\n%s", version, got, want)
+		}
+	}
+}
+
+// orderState renders the persistent state of an order for comparison
+func orderState(t *testing.T, o *order.Order) string {
+	t.Helper()
+
+	o.Changes = nil
+	data, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("marshal order: %v", err)
+	}
+	return string(data)
+}
+
+func TestOrderCommandsRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands func(o *order.Order) error
+	}{
+		{
+			name: "limit order filled from the book",
+			commands: func(o *order.Order) error {
+				return errors.Join(
+					o.InitializeOrder(),
+					o.SetLimitPrice(decimal.MustParse("49000")),
+					o.UpdateOrder(map[string]interface{}{"from_amount": "800"}),
+					o.CheckBalances(decimal.MustParse("5000")),
+					o.PlaceInOrderBook("book-1"),
+					o.StartSwapExecution("fill-key"),
+					o.PartiallyFill(decimal.MustParse("300"), decimal.MustParse("0.006"), decimal.MustParse("50000"), "0xfill"),
+				)
+			},
+		},
+		{
+			name:     "insufficient balance",
+			commands: func(o *order.Order) error { return o.CheckBalances(decimal.MustParse("10")) },
+		},
+		{
+			name:     "cancelled",
+			commands: func(o *order.Order) error { return o.CancelOrder("user_requested") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := NewAggregateStore(eventstore.NewMemoryEventStore())
+
+			o := order.NewOrder()
+			orderID := pkguuid.New()
+			if err := o.AcceptOrder(orderID, "user-1", decimal.MustParse("1000"), "USDT", "BTC", "limit", decimal.MustParse("50000"), 0, "", time.Time{}, nil); err != nil {
+				t.Fatalf("AcceptOrder: %v", err)
+			}
+			if err := tt.commands(o); err != nil {
+				t.Fatalf("commands: %v", err)
+			}
+			if err := store.SaveOrderAggregate(ctx, o); err != nil {
+				t.Fatalf("SaveOrderAggregate: %v", err)
+			}
+
+			loaded, err := store.LoadOrderAggregate(ctx, orderID)
+			if err != nil {
+				t.Fatalf("LoadOrderAggregate: %v", err)
+			}
+			if got, want := orderState(t, loaded), orderState(t, o); got != want {
+				t.Errorf("reloaded order:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadPositionAggregateReplaysPositionUpdated(t *testing.T) {
+	ctx := context.Background()
+	store := NewAggregateStore(eventstore.NewMemoryEventStore())
+
+	p := position.NewPosition()
+	positionID := pkguuid.New()
+	if err := p.CreatePosition(positionID, "user-1", "USDT"); err != nil {
+		t.Fatalf("CreatePosition: %v", err)
+	}
+	if err := p.AddOrder("order-1", decimal.MustParse("0.01"), decimal.MustParse("50000"), decimal.MustParse("500")); err != nil {
+		t.Fatalf("AddOrder: %v", err)
+	}
+	if err := store.SavePositionAggregate(ctx, p); err != nil {
+		t.Fatalf("SavePositionAggregate: %v", err)
+	}
+
+	// The second order reloads the position, like the complete-order use case does
+	err := store.MutatePosition(ctx, positionID, func(p *position.Position) error {
+		return p.AddOrder("order-2", decimal.MustParse("0.03"), decimal.MustParse("60000"), decimal.MustParse("1800"))
+	})
+	if err != nil {
+		t.Fatalf("MutatePosition: %v", err)
+	}
+
+	loaded, err := store.LoadPositionAggregate(ctx, positionID)
+	if err != nil {
+		t.Fatalf("LoadPositionAggregate: %v", err)
+	}
+	if loaded.Version != 3 {
+		t.Errorf("version = %d, want 3", loaded.Version)
+	}
+	if !loaded.HasOrder("order-1") || !loaded.HasOrder("order-2") {
+		t.Errorf("order IDs = %v, want order-1 and order-2", loaded.OrderIDs)
+	}
+	// (0.01*50000 + 0.03*60000) / 0.04 = 57500
+	for field, tc := range map[string]struct{ got, want decimal.Decimal }{
+		"remaining":           {loaded.RemainingAmount, decimal.MustParse("0.04")},
+		"average entry price": {loaded.AverageEntryPrice, decimal.MustParse("57500")},
+		"cost":                {loaded.Cost, decimal.MustParse("2300")},
+		"unrealized pnl":      {loaded.UnrealizedPnL, decimal.MustParse("100")},
+	} {
+		if !tc.got.Equal(tc.want) {
+			t.Errorf("%s = %s, want %s", field, tc.got, tc.want)
+		}
+	}
+}
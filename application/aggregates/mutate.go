@@ -0,0 +1,59 @@
+package aggregates
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/infrastructure/eventstore"
+)
+
+// MutateOrder loads an Order, runs the command fn and saves the new events
+// If another writer appended to the stream first (ErrConcurrencyConflict), the order is
+// reloaded and fn runs again on the fresh state, up to MaxConcurrencyRetries times
+// fn may run more than once: it must only issue aggregate commands, no external side effects
+func (as *AggregateStore) MutateOrder(ctx context.Context, aggregateID string, fn func(*order.Order) error) error {
+	return as.retryOnConflict(aggregateID, func() error {
+		o, err := as.LoadOrderAggregate(ctx, aggregateID)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(o); err != nil {
+			return err
+		}
+
+		return as.SaveOrderAggregate(ctx, o)
+	})
+}
+
+// MutatePosition is MutateOrder for Position aggregates
+func (as *AggregateStore) MutatePosition(ctx context.Context, aggregateID string, fn func(*position.Position) error) error {
+	return as.retryOnConflict(aggregateID, func() error {
+		p, err := as.LoadPositionAggregate(ctx, aggregateID)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(p); err != nil {
+			return err
+		}
+
+		return as.SavePositionAggregate(ctx, p)
+	})
+}
+
+// retryOnConflict repeats load-mutate-save while it fails with ErrConcurrencyConflict
+func (as *AggregateStore) retryOnConflict(aggregateID string, attempt func() error) error {
+	var err error
+	for i := 0; i <= as.MaxConcurrencyRetries; i++ {
+		err = attempt()
+		if !errors.Is(err, eventstore.ErrConcurrencyConflict) {
+			return err
+		}
+		log.Printf("⚠️  Concurrency conflict on %s, reloading (attempt %d/%d)", aggregateID, i+1, as.MaxConcurrencyRetries+1)
+	}
+	return err
+}
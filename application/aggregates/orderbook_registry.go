@@ -0,0 +1,86 @@
+package aggregates
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"market_order/domain/orderbook"
+)
+
+// OrderBookRegistry maps trading pairs ("BTC/USDT") to their order book aggregates
+//
+// Book IDs are derived from the pair (orderbook.IDForPair), so every instance resolves
+// the same book without a lookup table. A book is created on first use: by the first
+// limit order or price update of its pair
+type OrderBookRegistry struct {
+	store *AggregateStore
+	pairs []string // Tradeable pairs, listed by List
+}
+
+func NewOrderBookRegistry(store *AggregateStore, pairs []string) *OrderBookRegistry {
+	normalized := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		normalized = append(normalized, normalizePair(pair))
+	}
+	return &OrderBookRegistry{store: store, pairs: normalized}
+}
+
+// normalizePair - "btc/usdt " → "BTC/USDT"
+func normalizePair(pair string) string {
+	return strings.ToUpper(strings.TrimSpace(pair))
+}
+
+// Pairs returns the registered trading pairs
+func (r *OrderBookRegistry) Pairs() []string {
+	return append([]string(nil), r.pairs...)
+}
+
+// BookID returns the order book aggregate ID of the pair
+func (r *OrderBookRegistry) BookID(pair string) string {
+	return orderbook.IDForPair(normalizePair(pair))
+}
+
+// LoadOrCreate returns the pair's order book
+// A missing book is returned with a pending OrderBookCreated event:
+// it is saved together with the caller's first changes (Save)
+func (r *OrderBookRegistry) LoadOrCreate(ctx context.Context, pair string) (*orderbook.OrderBook, error) {
+	pair = normalizePair(pair)
+	orderBookID := orderbook.IDForPair(pair)
+
+	ob, err := r.store.LoadOrderBookAggregate(ctx, orderBookID)
+	if errors.Is(err, ErrAggregateNotFound) {
+		ob = orderbook.NewOrderBook()
+		err = ob.CreateOrderBook(orderBookID, pair)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ob, nil
+}
+
+// Get returns the order book by ID (snapshot + newer events); ErrAggregateNotFound if it was never created
+func (r *OrderBookRegistry) Get(ctx context.Context, orderBookID string) (*orderbook.OrderBook, error) {
+	return r.store.LoadOrderBookAggregate(ctx, orderBookID)
+}
+
+// Save persists the order book's pending events
+func (r *OrderBookRegistry) Save(ctx context.Context, ob *orderbook.OrderBook) error {
+	return r.store.SaveOrderBookAggregate(ctx, ob)
+}
+
+// List returns the order books created so far for the registered pairs, in pair order
+func (r *OrderBookRegistry) List(ctx context.Context) ([]*orderbook.OrderBook, error) {
+	books := make([]*orderbook.OrderBook, 0, len(r.pairs))
+	for _, pair := range r.pairs {
+		ob, err := r.store.LoadOrderBookAggregate(ctx, orderbook.IDForPair(pair))
+		if errors.Is(err, ErrAggregateNotFound) {
+			continue // No order or price for this pair yet
+		}
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, ob)
+	}
+	return books, nil
+}
@@ -0,0 +1,58 @@
+package aggregates
+
+import (
+	"context"
+	"testing"
+
+	"market_order/domain/orderbook"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+)
+
+func TestOrderBookRegistryCreatesBookOnFirstUse(t *testing.T) {
+	ctx := context.Background()
+	store := NewAggregateStore(eventstore.NewMemoryEventStore())
+	r := NewOrderBookRegistry(store, []string{"BTC/USDT", " eth/usdt"})
+
+	if got := r.Pairs(); len(got) != 2 || got[1] != "ETH/USDT" {
+		t.Errorf("pairs = %v, want normalized BTC/USDT and ETH/USDT", got)
+	}
+	if r.BookID("btc/usdt") != orderbook.IDForPair("BTC/USDT") || r.BookID("BTC/USDT") == r.BookID("ETH/USDT") {
+		t.Error("book IDs must be per pair and case-insensitive")
+	}
+
+	if books, err := r.List(ctx); err != nil || len(books) != 0 {
+		t.Fatalf("List before first use = %d books, err %v; want none", len(books), err)
+	}
+
+	ob, err := r.LoadOrCreate(ctx, "eth/usdt")
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	if ob.ID != r.BookID("ETH/USDT") || ob.TradingPair != "ETH/USDT" {
+		t.Errorf("created book %s (%s), want ETH/USDT", ob.ID, ob.TradingPair)
+	}
+	if err := ob.UpdatePrice(decimal.MustParse("3000"), "binance"); err != nil {
+		t.Fatalf("UpdatePrice: %v", err)
+	}
+	if err := r.Save(ctx, ob); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// The saved book is loaded, not created again
+	again, err := r.LoadOrCreate(ctx, "ETH/USDT")
+	if err != nil {
+		t.Fatalf("LoadOrCreate: %v", err)
+	}
+	if len(again.GetChanges()) != 0 || !again.LastPrice.Equal(decimal.MustParse("3000")) {
+		t.Errorf("reloaded book: %d pending events, last price %s; want 0 and 3000", len(again.GetChanges()), again.LastPrice)
+	}
+
+	books, err := r.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(books) != 1 || books[0].TradingPair != "ETH/USDT" {
+		t.Errorf("List = %d books, want only ETH/USDT", len(books))
+	}
+}
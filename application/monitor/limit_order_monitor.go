@@ -0,0 +1,166 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/domain/orderbook"
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// consumerName - own queue (consumer group on Kafka) for PriceUpdated
+const consumerName = "limit-order-monitor"
+
+// LimitOrderMonitor triggers resting limit orders when the market price reaches their limit
+// A triggered order leaves the order book and continues as a market order:
+// PriceQuoted → STEP 2 of the saga (position → swap → complete)
+type LimitOrderMonitor struct {
+	aggregateStore  *aggregates.AggregateStore
+	processedEvents idempotency.ProcessedEventStore
+	messageBus      messaging.MessageBus
+
+	Logger *slog.Logger
+}
+
+func NewLimitOrderMonitor(
+	aggregateStore *aggregates.AggregateStore,
+	processedEvents idempotency.ProcessedEventStore,
+	messageBus messaging.MessageBus,
+) *LimitOrderMonitor {
+	return &LimitOrderMonitor{
+		aggregateStore:  aggregateStore,
+		processedEvents: processedEvents,
+		messageBus:      messageBus,
+		Logger:          slog.Default(),
+	}
+}
+
+// Start subscribes to PriceUpdated events of the order books
+func (m *LimitOrderMonitor) Start(ctx context.Context) error {
+	if err := m.messageBus.SubscribeAs(ctx, consumerName, "PriceUpdated", m.handlePriceUpdated); err != nil {
+		return err
+	}
+
+	m.Logger.Info("Limit Order Monitor started, listening for price updates")
+
+	<-ctx.Done()
+
+	// Return only after in-flight triggers finished and were acked
+	<-m.messageBus.Drained()
+	m.Logger.Info("Limit Order Monitor stopped")
+	return nil
+}
+
+// LimitTriggered reports whether the market price reached a limit order
+// Buy triggers at price ≤ limit, sell at price ≥ limit
+func LimitTriggered(side string, limitPrice, marketPrice decimal.Decimal) bool {
+	switch side {
+	case "buy":
+		return marketPrice.Cmp(limitPrice) <= 0
+	case "sell":
+		return marketPrice.Cmp(limitPrice) >= 0
+	default:
+		return false
+	}
+}
+
+// handlePriceUpdated triggers every resting order of the book whose limit is reached
+func (m *LimitOrderMonitor) handlePriceUpdated(ctx context.Context, eventData []byte) (err error) {
+	var evt orderbook.PriceUpdated
+	if err = json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	logger := m.Logger.With(logging.EventID(evt.EventID), "order_book_id", evt.AggregateID, "price", evt.NewPrice)
+
+	// processed_events is shared with the saga - use a monitor-specific key
+	claimKey := pkguuid.NewFromName(consumerName + ":" + evt.EventID)
+	claimed, err := m.processedEvents.ClaimEvent(ctx, claimKey, evt.AggregateID, evt.EventType, consumerName)
+	if err != nil || !claimed {
+		return err
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		// Let the redelivery run the trigger again
+		if releaseErr := m.processedEvents.ReleaseEvent(ctx, claimKey); releaseErr != nil {
+			logger.Error("Failed to release claim", logging.Err(releaseErr))
+		}
+	}()
+
+	ob, err := m.aggregateStore.LoadOrderBookAggregate(ctx, evt.AggregateID)
+	if err != nil {
+		return err
+	}
+
+	var triggered []orderbook.LimitOrder
+	for _, resting := range append(append([]orderbook.LimitOrder(nil), ob.BuyOrders...), ob.SellOrders...) {
+		if LimitTriggered(resting.Side, resting.Price, evt.NewPrice) {
+			triggered = append(triggered, resting)
+		}
+	}
+
+	if len(triggered) == 0 {
+		return nil
+	}
+
+	logger.Info("Limit orders triggered", "count", len(triggered))
+
+	// Orders first: a retry sees them already quoted and only finishes the book cleanup
+	for _, resting := range triggered {
+		if err := m.triggerOrder(ctx, logger, resting, evt.NewPrice); err != nil {
+			return fmt.Errorf("failed to trigger order %s: %w", resting.OrderID, err)
+		}
+	}
+
+	// Triggered orders execute at market: they must not be matched in the book anymore
+	for _, resting := range triggered {
+		if err := ob.CancelLimitOrder(resting.OrderID, resting.Side, orderbook.CancelReasonTriggered); err != nil {
+			return err
+		}
+	}
+
+	return m.aggregateStore.SaveOrderBookAggregate(ctx, ob)
+}
+
+// triggerOrder quotes a resting order at the market price (generates PriceQuoted event)
+// PriceQuoted starts the execution saga from STEP 2
+func (m *LimitOrderMonitor) triggerOrder(ctx context.Context, logger *slog.Logger, resting orderbook.LimitOrder, marketPrice decimal.Decimal) error {
+	o, err := m.aggregateStore.LoadOrderAggregate(ctx, resting.OrderID)
+	if err != nil {
+		return err
+	}
+
+	// Already quoted by a previous attempt, or being filled by matching
+	if o.Status != order.OrderStatusPending || o.ToAmount.IsPositive() {
+		logger.Info("Limit order already executing, skipping trigger", logging.OrderID(o.ID), "status", o.Status)
+		return nil
+	}
+
+	// Buyer spends quote and receives base, seller the other way round
+	price := marketPrice
+	toAmount := o.FromAmount.Mul(price)
+	if resting.Side == "buy" {
+		toAmount = o.FromAmount.Div(price)
+	}
+
+	if err := o.QuotePrice(price, toAmount); err != nil {
+		return err
+	}
+
+	if err := m.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
+		return err
+	}
+
+	logger.Info("Limit order triggered", logging.OrderID(o.ID), "side", resting.Side, "limit_price", resting.Price)
+	return nil
+}
@@ -0,0 +1,150 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/domain/orderbook"
+	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+func TestLimitTriggered(t *testing.T) {
+	limit := decimal.MustParse("50000")
+	tests := []struct {
+		side  string
+		price string
+		want  bool
+	}{
+		{"buy", "49999", true},
+		{"buy", "50000", true},
+		{"buy", "50001", false},
+		{"sell", "49999", false},
+		{"sell", "50000", true},
+		{"sell", "50001", true},
+		{"unknown", "50000", false},
+	}
+	for _, tt := range tests {
+		if got := LimitTriggered(tt.side, limit, decimal.MustParse(tt.price)); got != tt.want {
+			t.Errorf("LimitTriggered(%s, 50000, %s) = %v, want %v", tt.side, tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestLimitOrderMonitorTriggersRestingOrders(t *testing.T) {
+	tests := []struct {
+		name      string
+		price     string
+		triggered string // side of the order that must be quoted
+	}{
+		{name: "buy triggers at or below its limit", price: "49000", triggered: "buy"},
+		{name: "sell triggers at or above its limit", price: "53000", triggered: "sell"},
+		{name: "price between the limits triggers nothing", price: "51000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := aggregates.NewAggregateStore(eventstore.NewMemoryEventStore())
+			m := NewLimitOrderMonitor(store, idempotency.NewMemoryProcessedEventStore(), messaging.NewMemoryBus())
+
+			// Buy 0.02 BTC at 50000 (spends 1000 USDT), sell 0.02 BTC at 52000
+			ob := orderbook.NewOrderBook()
+			bookID := pkguuid.New()
+			if err := ob.CreateOrderBook(bookID, "BTC/USDT"); err != nil {
+				t.Fatalf("CreateOrderBook: %v", err)
+			}
+			orders := map[string]string{
+				"buy":  restingOrder(t, store, ob, "buy", "1000", "USDT", "BTC", "50000"),
+				"sell": restingOrder(t, store, ob, "sell", "0.02", "BTC", "USDT", "52000"),
+			}
+
+			if err := ob.UpdatePrice(decimal.MustParse(tt.price), "binance"); err != nil {
+				t.Fatalf("UpdatePrice: %v", err)
+			}
+			changes := ob.GetChanges()
+			data, err := json.Marshal(changes[len(changes)-1])
+			if err != nil {
+				t.Fatalf("marshal PriceUpdated: %v", err)
+			}
+			if err := store.SaveOrderBookAggregate(ctx, ob); err != nil {
+				t.Fatalf("SaveOrderBookAggregate: %v", err)
+			}
+
+			if err := m.handlePriceUpdated(ctx, data); err != nil {
+				t.Fatalf("handlePriceUpdated: %v", err)
+			}
+
+			book, err := store.LoadOrderBookAggregate(ctx, bookID)
+			if err != nil {
+				t.Fatalf("LoadOrderBookAggregate: %v", err)
+			}
+			for side, orderID := range orders {
+				o, err := store.LoadOrderAggregate(ctx, orderID)
+				if err != nil {
+					t.Fatalf("LoadOrderAggregate: %v", err)
+				}
+				resting := inBook(book, orderID)
+
+				if side != tt.triggered {
+					if o.Status != order.OrderStatusPending || o.ToAmount.IsPositive() || !resting {
+						t.Errorf("%s order: status %s, to amount %s, in book %v; want untouched", side, o.Status, o.ToAmount, resting)
+					}
+					continue
+				}
+
+				if !o.ExecutedPrice.Equal(decimal.MustParse(tt.price)) {
+					t.Errorf("%s order quoted at %s, want %s", side, o.ExecutedPrice, tt.price)
+				}
+				if !o.ToAmount.IsPositive() {
+					t.Errorf("%s order: to amount = %s, want quoted amount", side, o.ToAmount)
+				}
+				if resting {
+					t.Errorf("%s order still rests in the book after triggering", side)
+				}
+			}
+		})
+	}
+}
+
+// restingOrder accepts a limit order and rests it on the given side of the book
+func restingOrder(t *testing.T, store *aggregates.AggregateStore, ob *orderbook.OrderBook, side, fromAmount, from, to, limitPrice string) string {
+	t.Helper()
+
+	o := order.NewOrder()
+	orderID := pkguuid.New()
+	price := decimal.MustParse(limitPrice)
+	err := errors.Join(
+		o.AcceptOrder(orderID, "user-1", decimal.MustParse(fromAmount), from, to, "limit", price, 0, "", time.Time{}, nil),
+		o.PlaceInOrderBook(ob.ID),
+	)
+	if err != nil {
+		t.Fatalf("order setup: %v", err)
+	}
+	if err := store.SaveOrderAggregate(context.Background(), o); err != nil {
+		t.Fatalf("SaveOrderAggregate: %v", err)
+	}
+
+	amount := decimal.MustParse("0.02")
+	if err := ob.AddLimitOrder(orderID, "user-1", price, amount, side, orderbook.TimeInForceGTC, time.Time{}); err != nil {
+		t.Fatalf("AddLimitOrder: %v", err)
+	}
+	return orderID
+}
+
+func inBook(ob *orderbook.OrderBook, orderID string) bool {
+	for _, o := range append(append([]orderbook.LimitOrder(nil), ob.BuyOrders...), ob.SellOrders...) {
+		if o.OrderID == orderID {
+			return true
+		}
+	}
+	return false
+}
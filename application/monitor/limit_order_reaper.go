@@ -0,0 +1,102 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/domain/orderbook"
+	"market_order/pkg/logging"
+)
+
+// DefaultReapInterval - how often the order books are scanned for expired GTD orders
+const DefaultReapInterval = 5 * time.Second
+
+// LimitOrderReaper removes GTD limit orders from the order book once ExpiresAt passes
+// The order is expired first (generates LimitOrderExpired event), then cancelled
+// in the book: a retry sees the order already expired and only finishes the book cleanup
+type LimitOrderReaper struct {
+	aggregateStore *aggregates.AggregateStore
+	pairs          []string // Trading pairs whose order books are scanned
+
+	// Interval - time between scans
+	Interval time.Duration
+
+	Logger *slog.Logger
+}
+
+func NewLimitOrderReaper(aggregateStore *aggregates.AggregateStore, pairs []string) *LimitOrderReaper {
+	return &LimitOrderReaper{
+		aggregateStore: aggregateStore,
+		pairs:          pairs,
+		Interval:       DefaultReapInterval,
+		Logger:         slog.Default(),
+	}
+}
+
+// Start scans the order books every Interval until ctx is cancelled
+func (r *LimitOrderReaper) Start(ctx context.Context) error {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	r.Logger.Info("Limit Order Reaper started", "interval", r.Interval.String(), "pairs", len(r.pairs))
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, pair := range r.pairs {
+				if err := r.reapOrderBook(ctx, orderbook.IDForPair(pair)); err != nil {
+					r.Logger.Error("Failed to reap order book", "trading_pair", pair, logging.Err(err))
+				}
+			}
+
+		case <-ctx.Done():
+			r.Logger.Info("Limit Order Reaper stopped")
+			return nil
+		}
+	}
+}
+
+// reapOrderBook expires and cancels every GTD order of the book past its ExpiresAt
+func (r *LimitOrderReaper) reapOrderBook(ctx context.Context, orderBookID string) error {
+	ob, err := r.aggregateStore.LoadOrderBookAggregate(ctx, orderBookID)
+	if errors.Is(err, aggregates.ErrAggregateNotFound) {
+		return nil // No limit order for this pair yet
+	}
+	if err != nil {
+		return err
+	}
+
+	expired := ob.ExpiredOrders(time.Now())
+	if len(expired) == 0 {
+		return nil
+	}
+
+	logger := r.Logger.With("order_book_id", orderBookID)
+	logger.Info("Limit orders expired", "count", len(expired))
+
+	for _, resting := range expired {
+		err := r.aggregateStore.MutateOrder(ctx, resting.OrderID, func(o *order.Order) error {
+			return o.ExpireLimitOrder(orderbook.SpentAmount(resting.Side, resting.RemainingAmount, resting.Price))
+		})
+		if errors.Is(err, order.ErrLimitOrderTriggered) {
+			// Executing at market - LimitOrderMonitor takes it out of the book
+			logger.Info("Limit order already triggered, skipping expiry", logging.OrderID(resting.OrderID))
+			continue
+		}
+		if err != nil {
+			logger.Error("Failed to expire limit order", logging.OrderID(resting.OrderID), logging.Err(err))
+			continue
+		}
+
+		if err := ob.CancelLimitOrder(resting.OrderID, resting.Side, orderbook.CancelReasonExpired); err != nil {
+			return err
+		}
+		logger.Info("Limit order expired", logging.OrderID(resting.OrderID), "expires_at", resting.ExpiresAt)
+	}
+
+	return r.aggregateStore.SaveOrderBookAggregate(ctx, ob)
+}
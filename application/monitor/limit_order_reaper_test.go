@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/domain/orderbook"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+func TestLimitOrderReaperExpiresGTDOrders(t *testing.T) {
+	ctx := context.Background()
+	store := aggregates.NewAggregateStore(eventstore.NewMemoryEventStore())
+	r := NewLimitOrderReaper(store, []string{"BTC/USDT"})
+
+	bookID := orderbook.IDForPair("BTC/USDT")
+	ob := orderbook.NewOrderBook()
+	if err := ob.CreateOrderBook(bookID, "BTC/USDT"); err != nil {
+		t.Fatalf("CreateOrderBook: %v", err)
+	}
+
+	price := decimal.MustParse("50000")
+	place := func(timeInForce string, expiresAt time.Time) string {
+		t.Helper()
+		o := order.NewOrder()
+		orderID := pkguuid.New()
+		err := errors.Join(
+			o.AcceptOrder(orderID, "user-1", decimal.MustParse("1000"), "USDT", "BTC", "limit", price, 0, timeInForce, expiresAt, nil),
+			o.PlaceInOrderBook(bookID),
+			store.SaveOrderAggregate(ctx, o),
+			ob.AddLimitOrder(orderID, "user-1", price, decimal.MustParse("0.02"), "buy", timeInForce, expiresAt),
+		)
+		if err != nil {
+			t.Fatalf("place %s order: %v", timeInForce, err)
+		}
+		return orderID
+	}
+	gtdID := place(orderbook.TimeInForceGTD, time.Now().Add(20*time.Millisecond))
+	gtcID := place(orderbook.TimeInForceGTC, time.Time{})
+	if err := store.SaveOrderBookAggregate(ctx, ob); err != nil {
+		t.Fatalf("SaveOrderBookAggregate: %v", err)
+	}
+
+	// Before ExpiresAt nothing is reaped
+	if err := r.reapOrderBook(ctx, bookID); err != nil {
+		t.Fatalf("reapOrderBook: %v", err)
+	}
+	if book, _ := store.LoadOrderBookAggregate(ctx, bookID); len(book.BuyOrders) != 2 {
+		t.Fatalf("book has %d buy orders before expiry, want 2", len(book.BuyOrders))
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := r.reapOrderBook(ctx, bookID); err != nil {
+		t.Fatalf("reapOrderBook: %v", err)
+	}
+
+	book, err := store.LoadOrderBookAggregate(ctx, bookID)
+	if err != nil {
+		t.Fatalf("LoadOrderBookAggregate: %v", err)
+	}
+	if len(book.BuyOrders) != 1 || book.BuyOrders[0].OrderID != gtcID {
+		t.Errorf("book buy orders = %v, want only the GTC order", book.BuyOrders)
+	}
+
+	gtd, err := store.LoadOrderAggregate(ctx, gtdID)
+	if err != nil {
+		t.Fatalf("LoadOrderAggregate: %v", err)
+	}
+	// Nothing was filled: the whole order expired and closed without a trade
+	if gtd.Status != order.OrderStatusFailed || !gtd.ExpiredAmount.Equal(decimal.MustParse("1000")) {
+		t.Errorf("GTD order: status %s, expired %s; want failed with 1000 expired", gtd.Status, gtd.ExpiredAmount)
+	}
+
+	// A second scan finds nothing left to expire
+	if err := r.reapOrderBook(ctx, bookID); err != nil {
+		t.Fatalf("second reapOrderBook: %v", err)
+	}
+}
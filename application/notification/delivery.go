@@ -0,0 +1,168 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/pkg/logging"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// NotificationTaskType - message type of queued notifications (consumed from queue.NotificationTask)
+const NotificationTaskType = "NotificationTask"
+
+// NotificationTask is a rendered notification waiting for delivery
+// The event handlers enqueue it and ack their event; the DeliveryWorker sends it, so a
+// failing channel is retried without loading the order or handling the domain event again
+type NotificationTask struct {
+	// TaskID is derived from the source event: re-enqueueing the same event gives the same task
+	TaskID    string    `json:"event_id"`
+	EventID   string    `json:"source_event_id"`
+	EventType string    `json:"source_event_type"`
+	OrderID   string    `json:"order_id"`
+	UserID    string    `json:"user_id"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// newNotificationTask builds the task of an event's notification
+func newNotificationTask(eventID, eventType, orderID, userID, message string) NotificationTask {
+	return NotificationTask{
+		TaskID:    pkguuid.NewFromName(eventID + ":notification"),
+		EventID:   eventID,
+		EventType: eventType,
+		OrderID:   orderID,
+		UserID:    userID,
+		Message:   message,
+		CreatedAt: time.Now().UTC(),
+	}
+}
+
+// DeliveryWorker sends queued notification tasks through the notifier
+// A failed delivery is returned to the message bus after one attempt: its retries (with
+// backoff) and dead-letter queue take over, the consumer never sleeps and the domain
+// event is not involved
+type DeliveryWorker struct {
+	processedEvents idempotency.ProcessedEventStore
+	messageBus      messaging.MessageBus
+	notifier        Notifier
+
+	// Logger - structured logger (defaults to slog.Default())
+	Logger *slog.Logger
+}
+
+func NewDeliveryWorker(
+	processedEvents idempotency.ProcessedEventStore,
+	messageBus messaging.MessageBus,
+	notifier Notifier,
+) *DeliveryWorker {
+	return &DeliveryWorker{
+		processedEvents: processedEvents,
+		messageBus:      messageBus,
+		notifier:        notifier,
+		Logger:          slog.Default(),
+	}
+}
+
+// Start begins delivering queued notifications
+func (w *DeliveryWorker) Start(ctx context.Context) error {
+	if err := w.messageBus.Subscribe(ctx, NotificationTaskType, w.handleTask); err != nil {
+		return err
+	}
+
+	w.Logger.Info("Notification delivery worker started")
+
+	<-ctx.Done()
+
+	// Return only after in-flight deliveries finished and were acked
+	<-w.messageBus.Drained()
+	w.Logger.Info("Notification delivery worker stopped")
+	return nil
+}
+
+// handleTask delivers one notification task
+func (w *DeliveryWorker) handleTask(ctx context.Context, data []byte) (err error) {
+	var task NotificationTask
+	if err = json.Unmarshal(data, &task); err != nil {
+		return err
+	}
+
+	logger := w.Logger.With(logging.OrderID(task.OrderID), logging.EventID(task.EventID), logging.EventType(task.EventType))
+
+	// Idempotency: a task enqueued twice (redelivered event) is sent once
+	claimed, err := w.processedEvents.ClaimEvent(ctx, task.TaskID, task.OrderID, NotificationTaskType, "notification-delivery")
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		logger.Info("Notification already delivered, skipping")
+		return nil
+	}
+	defer w.releaseOnError(ctx, task.TaskID, &err)
+
+	if err = w.notify(ctx, logger, task); err != nil {
+		logger.Error("Failed to send notification", logging.Err(err))
+		return err
+	}
+
+	logger.Info("Notification sent", "user_id", task.UserID)
+	return nil
+}
+
+// notify sends the task's message to the user
+// With a NotifierRegistry every channel is claimed separately: when one channel fails,
+// only the channels not yet delivered are retried
+func (w *DeliveryWorker) notify(ctx context.Context, logger *slog.Logger, task NotificationTask) error {
+	registry, ok := w.notifier.(*NotifierRegistry)
+	if !ok {
+		return w.notifier.SendMessage(ctx, task.UserID, task.Message)
+	}
+
+	deliveries, err := registry.Resolve(ctx, task.UserID)
+	if err != nil {
+		return err
+	}
+	if len(deliveries) == 0 {
+		logger.Info("No notification channels for user", "user_id", task.UserID)
+		return nil
+	}
+
+	var errs []error
+	for _, d := range deliveries {
+		if err := w.deliver(ctx, task, d); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.Channel, err))
+			continue
+		}
+		logger.Debug("Notification delivered", "channel", d.Channel)
+	}
+	return errors.Join(errs...)
+}
+
+// deliver sends one channel's notification at most once per event
+// The claim key is derived from the event, channel and address (like the saga's fill keys)
+func (w *DeliveryWorker) deliver(ctx context.Context, task NotificationTask, d Delivery) (err error) {
+	deliveryKey := pkguuid.NewFromName(task.EventID + ":" + d.Channel + ":" + d.Address)
+	claimed, err := w.processedEvents.ClaimEvent(ctx, deliveryKey, task.OrderID, task.EventType, "notification-"+d.Channel)
+	if err != nil || !claimed {
+		return err
+	}
+	defer w.releaseOnError(ctx, deliveryKey, &err)
+
+	return d.Send(ctx, task.UserID, task.Message)
+}
+
+// releaseOnError drops the claim if the delivery failed, so the retry sends it again
+func (w *DeliveryWorker) releaseOnError(ctx context.Context, key string, err *error) {
+	if *err == nil {
+		return
+	}
+	if releaseErr := w.processedEvents.ReleaseEvent(ctx, key); releaseErr != nil {
+		w.Logger.Error("Failed to release delivery claim", logging.EventID(key), logging.Err(releaseErr))
+	}
+}
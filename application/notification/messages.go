@@ -0,0 +1,154 @@
+package notification
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"market_order/domain/order"
+)
+
+// Message template names
+const (
+	MessageOrderCompleted       = "order_completed"
+	MessageOrderFailed          = "order_failed"
+	MessageOrderPartiallyFilled = "order_partially_filled"
+)
+
+// DefaultLocale - locale of users without one, and of templates missing in the user's locale
+const DefaultLocale = "en"
+
+// builtinTemplates - templates/<locale>.tmpl, each with a {{define}} per message
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// MessageBuilder renders notification messages from per-locale text/template sets
+//
+// Every locale is one template set with a {{define "name"}} per message. A user's locale
+// comes from UserLocales; a locale or message that is not defined falls back to Fallback
+type MessageBuilder struct {
+	locales map[string]*template.Template // locale → template set
+
+	// Fallback - locale used when the user's locale has no such message
+	Fallback string
+	// UserLocales - user_id → locale (NOTIFY_LOCALES); users not listed get Fallback
+	UserLocales map[string]string
+}
+
+// MessageDetails - event data a message shows besides the order
+type MessageDetails struct {
+	Reason string                      // order_failed
+	Fill   *order.OrderPartiallyFilled // order_partially_filled
+}
+
+// messageView is the template data: {{.Order.ID}}, {{.Reason}}, {{.Fill.SpentAmount}}
+type messageView struct {
+	Order *order.Order
+	MessageDetails
+}
+
+// NewMessageBuilder returns a builder with the built-in templates (en, ru)
+func NewMessageBuilder() *MessageBuilder {
+	b := &MessageBuilder{
+		locales:     make(map[string]*template.Template),
+		Fallback:    DefaultLocale,
+		UserLocales: make(map[string]string),
+	}
+
+	files, err := builtinTemplates.ReadDir("templates")
+	if err != nil {
+		panic(err) // Embedded at build time
+	}
+	for _, f := range files {
+		locale := strings.TrimSuffix(f.Name(), ".tmpl")
+		b.locales[locale] = template.Must(template.New(locale).ParseFS(builtinTemplates, "templates/"+f.Name()))
+	}
+	return b
+}
+
+// LoadTemplates adds or replaces locales from dir/<locale>.tmpl (NOTIFY_TEMPLATES_DIR)
+// A replaced locale loses the built-in messages it does not define: they fall back to Fallback
+func (b *MessageBuilder) LoadTemplates(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no *.tmpl templates in %s", dir)
+	}
+
+	for _, path := range paths {
+		locale := strings.TrimSuffix(filepath.Base(path), ".tmpl")
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(locale).Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("invalid template %s: %w", path, err)
+		}
+		b.locales[locale] = tmpl
+	}
+	return nil
+}
+
+// Locale returns the locale of the user's messages
+func (b *MessageBuilder) Locale(userID string) string {
+	if locale, ok := b.UserLocales[userID]; ok {
+		return locale
+	}
+	return b.Fallback
+}
+
+// Build renders message name for the order's user in their locale
+func (b *MessageBuilder) Build(name string, o *order.Order, details MessageDetails) (string, error) {
+	return b.BuildLocale(name, b.Locale(o.UserID), o, details)
+}
+
+// BuildLocale renders message name in locale, falling back to Fallback
+func (b *MessageBuilder) BuildLocale(name, locale string, o *order.Order, details MessageDetails) (string, error) {
+	tmpl := b.lookup(locale, name)
+	if tmpl == nil {
+		tmpl = b.lookup(b.Fallback, name)
+	}
+	if tmpl == nil {
+		return "", fmt.Errorf("no %q message template for locale %q or %q", name, locale, b.Fallback)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, messageView{Order: o, MessageDetails: details}); err != nil {
+		return "", fmt.Errorf("failed to render %s/%s: %w", locale, name, err)
+	}
+	return sb.String(), nil
+}
+
+// lookup returns the named template of a locale, or nil
+func (b *MessageBuilder) lookup(locale, name string) *template.Template {
+	set, ok := b.locales[locale]
+	if !ok {
+		return nil
+	}
+	return set.Lookup(name)
+}
+
+// ParseUserLocales parses NOTIFY_LOCALES: "user-1=ru,user-2=en"
+func ParseUserLocales(s string) (map[string]string, error) {
+	locales := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		userID, locale, ok := strings.Cut(entry, "=")
+		if !ok || userID == "" || locale == "" {
+			return nil, fmt.Errorf("invalid locale entry %q: want user_id=locale", entry)
+		}
+		locales[userID] = locale
+	}
+	return locales, nil
+}
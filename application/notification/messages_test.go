@@ -0,0 +1,98 @@
+package notification
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
+)
+
+func completedOrder(t *testing.T) *order.Order {
+	t.Helper()
+
+	o := order.NewOrder()
+	err := errors.Join(
+		o.AcceptOrder("order-1", "user-1", decimal.MustParse("100"), "USDT", "ETH", "market", decimal.Zero, 0, "", time.Time{}, nil),
+		o.QuotePrice(decimal.MustParse("0.0005"), decimal.MustParse("0.05")),
+		o.StartSwapExecution("swap-key"),
+		o.RecordSwapExecution("position-1", "0xabc", decimal.MustParse("100"), decimal.MustParse("0.05"), decimal.MustParse("0.0005"), decimal.Zero, 0),
+		o.CompleteOrder(decimal.Zero, decimal.Zero),
+	)
+	if err != nil {
+		t.Fatalf("order setup: %v", err)
+	}
+	return o
+}
+
+func TestMessageBuilderRendersLocales(t *testing.T) {
+	b := NewMessageBuilder()
+	b.UserLocales = map[string]string{"user-1": "ru"}
+	o := completedOrder(t)
+
+	tests := []struct {
+		name    string
+		locale  string
+		message string
+		details MessageDetails
+		want    []string
+	}{
+		{name: "completed en", locale: "en", message: MessageOrderCompleted, want: []string{"Order Completed", "From: 100 USDT", "To: 0.05 ETH"}},
+		{name: "completed ru", locale: "ru", message: MessageOrderCompleted, want: []string{"Ордер исполнен", "Отдано: 100 USDT", "Получено: 0.05 ETH"}},
+		{name: "failed en", locale: "en", message: MessageOrderFailed, details: MessageDetails{Reason: "swap_failed"}, want: []string{"Order Failed", "Reason: swap_failed"}},
+		{name: "failed ru", locale: "ru", message: MessageOrderFailed, details: MessageDetails{Reason: "swap_failed"}, want: []string{"Ордер не исполнен", "Причина: swap_failed"}},
+		// Unknown locales fall back to English
+		{name: "fallback locale", locale: "de", message: MessageOrderFailed, details: MessageDetails{Reason: "expired"}, want: []string{"Order Failed", "Reason: expired"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := b.BuildLocale(tt.message, tt.locale, o, tt.details)
+			if err != nil {
+				t.Fatalf("BuildLocale: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(msg, want) {
+					t.Errorf("message %q does not contain %q", msg, want)
+				}
+			}
+		})
+	}
+
+	// Build picks the user's locale
+	if msg, err := b.Build(MessageOrderCompleted, o, MessageDetails{}); err != nil || !strings.Contains(msg, "Ордер исполнен") {
+		t.Errorf("Build for a ru user = %q, %v", msg, err)
+	}
+	if _, err := b.BuildLocale("unknown_message", "en", o, MessageDetails{}); err == nil {
+		t.Error("unknown message: expected an error")
+	}
+}
+
+func TestMessageBuilderLoadTemplatesFallsBackPerMessage(t *testing.T) {
+	dir := t.TempDir()
+	custom := `{{define "order_completed"}}Готово: {{.Order.ID}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "ru.tmpl"), []byte(custom), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewMessageBuilder()
+	if err := b.LoadTemplates(dir); err != nil {
+		t.Fatalf("LoadTemplates: %v", err)
+	}
+	o := completedOrder(t)
+
+	if msg, err := b.BuildLocale(MessageOrderCompleted, "ru", o, MessageDetails{}); err != nil || msg != "Готово: order-1" {
+		t.Errorf("custom ru message = %q, %v", msg, err)
+	}
+	// The replaced locale has no order_failed: English is used
+	if msg, err := b.BuildLocale(MessageOrderFailed, "ru", o, MessageDetails{Reason: "x"}); err != nil || !strings.Contains(msg, "Order Failed") {
+		t.Errorf("ru order_failed = %q, %v; want the English fallback", msg, err)
+	}
+
+	if err := b.LoadTemplates(t.TempDir()); err == nil {
+		t.Error("LoadTemplates of an empty dir: expected an error")
+	}
+}
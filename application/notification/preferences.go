@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Preference is one notification channel of a user
+type Preference struct {
+	Channel string // Registered channel name: "email", "telegram", "webhook"
+	Address string // Where to deliver in that channel: e-mail, Telegram chat_id, webhook URL
+}
+
+// PreferenceStore resolves the notification channels of a user
+type PreferenceStore interface {
+	// Preferences returns the user's channels; none - the registry's Fallback applies
+	Preferences(ctx context.Context, userID string) ([]Preference, error)
+}
+
+// StaticPreferences - channel preferences from configuration (NOTIFY_PREFERENCES)
+type StaticPreferences map[string][]Preference
+
+// Preferences implements PreferenceStore
+func (p StaticPreferences) Preferences(ctx context.Context, userID string) ([]Preference, error) {
+	return p[userID], nil
+}
+
+// ParsePreferences parses "user-1=email:alice@example.com|telegram:123456,user-2=webhook:https://bot.example.com/hook"
+// Users are separated by ",", a user's channels by "|"; the address is everything after the first ":"
+func ParsePreferences(s string) (StaticPreferences, error) {
+	prefs := make(StaticPreferences)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		userID, channels, ok := strings.Cut(entry, "=")
+		if !ok || userID == "" || channels == "" {
+			return nil, fmt.Errorf("invalid preference entry %q: want user_id=channel:address", entry)
+		}
+
+		for _, ch := range strings.Split(channels, "|") {
+			channel, address, ok := strings.Cut(strings.TrimSpace(ch), ":")
+			if !ok || channel == "" || address == "" {
+				return nil, fmt.Errorf("invalid channel %q of user %s: want channel:address", ch, userID)
+			}
+			prefs[userID] = append(prefs[userID], Preference{Channel: channel, Address: address})
+		}
+	}
+	return prefs, nil
+}
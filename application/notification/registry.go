@@ -0,0 +1,95 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"market_order/infrastructure/notification"
+)
+
+// NotifierRegistry sends a user's notifications to the channels they chose
+//
+// Channels (email, telegram, webhook, ...) are Notifiers registered by name; the user's
+// preferences pick the channels and the address in each. NotifierRegistry is itself a
+// Notifier. NotificationService recognizes it and claims every channel separately,
+// so a failed channel is retried without re-sending the ones that succeeded
+type NotifierRegistry struct {
+	channels    map[string]Notifier
+	preferences PreferenceStore
+
+	// Fallback - channel for users without preferences, sent without an address ("" - not notified)
+	Fallback string
+
+	// Logger - structured logger (defaults to slog.Default())
+	Logger *slog.Logger
+}
+
+// Delivery is one channel a notification goes to
+type Delivery struct {
+	Channel  string
+	Address  string
+	notifier Notifier
+}
+
+// Send delivers the message through the channel's notifier to the delivery's address
+func (d Delivery) Send(ctx context.Context, userID, message string) error {
+	if d.Address != "" {
+		ctx = notification.WithRecipient(ctx, d.Address)
+	}
+	return d.notifier.SendMessage(ctx, userID, message)
+}
+
+func NewNotifierRegistry(preferences PreferenceStore) *NotifierRegistry {
+	return &NotifierRegistry{
+		channels:    make(map[string]Notifier),
+		preferences: preferences,
+		Logger:      slog.Default(),
+	}
+}
+
+// Register makes a channel available to user preferences
+func (r *NotifierRegistry) Register(channel string, notifier Notifier) {
+	r.channels[channel] = notifier
+}
+
+// Resolve returns the deliveries of a user's notification
+// Preferences naming an unregistered channel are skipped (logged): they can't succeed on retry either
+func (r *NotifierRegistry) Resolve(ctx context.Context, userID string) ([]Delivery, error) {
+	prefs, err := r.preferences.Preferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences of %s: %w", userID, err)
+	}
+	if len(prefs) == 0 && r.Fallback != "" {
+		prefs = []Preference{{Channel: r.Fallback}}
+	}
+
+	deliveries := make([]Delivery, 0, len(prefs))
+	for _, pref := range prefs {
+		notifier, ok := r.channels[pref.Channel]
+		if !ok {
+			r.Logger.Warn("Notification channel not configured, skipping", "user_id", userID, "channel", pref.Channel)
+			continue
+		}
+		deliveries = append(deliveries, Delivery{Channel: pref.Channel, Address: pref.Address, notifier: notifier})
+	}
+	return deliveries, nil
+}
+
+// SendMessage implements Notifier: the message goes to every channel of the user
+// A failing channel does not stop the others; the failures are returned together
+func (r *NotifierRegistry) SendMessage(ctx context.Context, userID, message string) error {
+	deliveries, err := r.Resolve(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, d := range deliveries {
+		if err := d.Send(ctx, userID, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.Channel, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,120 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/notification"
+)
+
+// recordingNotifier records the addresses it delivered to and fails while failing is set
+type recordingNotifier struct {
+	mu        sync.Mutex
+	failing   bool
+	delivered []string
+}
+
+func (n *recordingNotifier) SendMessage(ctx context.Context, userID, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.failing {
+		return errors.New("channel down")
+	}
+	address, _ := notification.RecipientFromContext(ctx)
+	n.delivered = append(n.delivered, address)
+	return nil
+}
+
+func TestParsePreferences(t *testing.T) {
+	prefs, err := ParsePreferences("user-1=email:alice@example.com|telegram:123456, user-2=webhook:https://bot.example.com/hook")
+	if err != nil {
+		t.Fatalf("ParsePreferences: %v", err)
+	}
+	if got := prefs["user-1"]; len(got) != 2 || got[1] != (Preference{Channel: "telegram", Address: "123456"}) {
+		t.Errorf("user-1 = %+v, want email and telegram", got)
+	}
+	// The address keeps its own ":"
+	if got := prefs["user-2"]; len(got) != 1 || got[0].Address != "https://bot.example.com/hook" {
+		t.Errorf("user-2 = %+v, want the webhook URL", got)
+	}
+
+	for _, bad := range []string{"user-1", "user-1=email", "=email:a@b.c", "user-1=email:"} {
+		if _, err := ParsePreferences(bad); err == nil {
+			t.Errorf("ParsePreferences(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestNotifierRegistryResolve(t *testing.T) {
+	r := NewNotifierRegistry(StaticPreferences{
+		"user-1": {{Channel: "email", Address: "alice@example.com"}, {Channel: "sms", Address: "+100"}},
+	})
+	r.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	r.Register("email", &recordingNotifier{})
+	r.Register("telegram", &recordingNotifier{})
+
+	// Unregistered channels are skipped
+	deliveries, err := r.Resolve(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Channel != "email" || deliveries[0].Address != "alice@example.com" {
+		t.Errorf("deliveries = %+v, want only email", deliveries)
+	}
+
+	// Users without preferences get the fallback channel, or nothing
+	if deliveries, _ := r.Resolve(context.Background(), "user-2"); len(deliveries) != 0 {
+		t.Errorf("no preferences, no fallback: %d deliveries, want 0", len(deliveries))
+	}
+	r.Fallback = "telegram"
+	if deliveries, _ := r.Resolve(context.Background(), "user-2"); len(deliveries) != 1 || deliveries[0].Channel != "telegram" {
+		t.Errorf("fallback deliveries = %+v, want telegram", deliveries)
+	}
+}
+
+// A failing channel fails the task; the retry sends only to that channel
+func TestDeliveryWorkerRetriesOnlyFailedChannel(t *testing.T) {
+	email := &recordingNotifier{}
+	telegram := &recordingNotifier{failing: true}
+	registry := NewNotifierRegistry(StaticPreferences{
+		"user-1": {{Channel: "email", Address: "alice@example.com"}, {Channel: "telegram", Address: "123456"}},
+	})
+	registry.Register("email", email)
+	registry.Register("telegram", telegram)
+
+	w := NewDeliveryWorker(idempotency.NewMemoryProcessedEventStore(), messaging.NewMemoryBus(), registry)
+	w.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	task, err := json.Marshal(newNotificationTask("event-1", "OrderCompleted", "order-1", "user-1", "Order completed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.handleTask(context.Background(), task); err == nil {
+		t.Fatal("telegram down: expected the task to fail")
+	}
+
+	telegram.failing = false
+	if err := w.handleTask(context.Background(), task); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	// A redelivery after success sends nothing
+	if err := w.handleTask(context.Background(), task); err != nil {
+		t.Fatalf("redelivery: %v", err)
+	}
+
+	if len(email.delivered) != 1 || email.delivered[0] != "alice@example.com" {
+		t.Errorf("email deliveries = %v, want one to alice@example.com", email.delivered)
+	}
+	if len(telegram.delivered) != 1 || telegram.delivered[0] != "123456" {
+		t.Errorf("telegram deliveries = %v, want one to 123456", telegram.delivered)
+	}
+}
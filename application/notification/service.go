@@ -3,23 +3,28 @@ package notification
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"log"
+	"log/slog"
 
 	"market_order/domain/order"
 	"market_order/infrastructure/idempotency"
 	"market_order/infrastructure/messaging"
 	"market_order/infrastructure/repository"
+	"market_order/pkg/logging"
 )
 
-// NotificationService listens to domain events and sends notifications
+// NotificationService listens to domain events and enqueues their notifications
 // Reads data from EventStore (source of truth) - NO projections!
+// Sending is left to the DeliveryWorker: the event is acked once its NotificationTask is queued
 type NotificationService struct {
 	orderRepo       *repository.OrderRepository    // EventStore
 	positionRepo    *repository.PositionRepository // EventStore
-	processedEvents *idempotency.ProcessedEventsRepository
-	messageBus      *messaging.RabbitMQ
-	notifier        Notifier
+	processedEvents idempotency.ProcessedEventStore
+	messageBus      messaging.MessageBus
+
+	// Messages - templated, localized notification texts
+	Messages *MessageBuilder
+	// Logger - structured logger (defaults to slog.Default())
+	Logger *slog.Logger
 }
 
 // Notifier interface for sending notifications (Telegram, Email, etc.)
@@ -30,157 +35,219 @@ type Notifier interface {
 func NewNotificationService(
 	orderRepo *repository.OrderRepository,
 	positionRepo *repository.PositionRepository,
-	processedEvents *idempotency.ProcessedEventsRepository,
-	messageBus *messaging.RabbitMQ,
-	notifier Notifier,
+	processedEvents idempotency.ProcessedEventStore,
+	messageBus messaging.MessageBus,
 ) *NotificationService {
 	return &NotificationService{
 		orderRepo:       orderRepo,
 		positionRepo:    positionRepo,
 		processedEvents: processedEvents,
 		messageBus:      messageBus,
-		notifier:        notifier,
+		Messages:        NewMessageBuilder(),
+		Logger:          slog.Default(),
 	}
 }
 
 // Start begins listening to events
 func (ns *NotificationService) Start(ctx context.Context) error {
 	// Subscribe to OrderCompleted events
-	if err := ns.messageBus.Subscribe("OrderCompleted", ns.handleOrderCompleted); err != nil {
+	if err := ns.messageBus.Subscribe(ctx, "OrderCompleted", ns.handleOrderCompleted); err != nil {
 		return err
 	}
 
 	// Subscribe to OrderFailed events
-	if err := ns.messageBus.Subscribe("OrderFailed", ns.handleOrderFailed); err != nil {
+	if err := ns.messageBus.Subscribe(ctx, "OrderFailed", ns.handleOrderFailed); err != nil {
+		return err
+	}
+
+	// Subscribe to OrderPartiallyFilled events (limit order fills)
+	if err := ns.messageBus.Subscribe(ctx, "OrderPartiallyFilled", ns.handleOrderPartiallyFilled); err != nil {
 		return err
 	}
 
-	log.Println("✅ Notification Service started, listening for events...")
+	ns.Logger.Info("Notification Service started, listening for events")
 
 	<-ctx.Done()
+
+	// Return only after in-flight notifications finished and were acked
+	<-ns.messageBus.Drained()
+	ns.Logger.Info("Notification Service stopped")
 	return nil
 }
 
 // handleOrderCompleted processes OrderCompleted events
-func (ns *NotificationService) handleOrderCompleted(ctx context.Context, eventData []byte) error {
-	log.Println("📨 NotificationService: Received OrderCompleted event")
-
+func (ns *NotificationService) handleOrderCompleted(ctx context.Context, eventData []byte) (err error) {
 	var evt order.OrderCompleted
-	if err := json.Unmarshal(eventData, &evt); err != nil {
+	if err = json.Unmarshal(eventData, &evt); err != nil {
 		return err
 	}
 
-	// Idempotency check
-	processed, err := ns.processedEvents.IsProcessed(ctx, evt.EventID)
+	logger := ns.eventLogger(evt.AggregateID, evt.EventID, evt.EventType)
+	logger.Info("Received OrderCompleted event")
+
+	// Idempotency: claim the event so a redelivery can't enqueue the notification twice
+	claimed, err := ns.processedEvents.ClaimEvent(ctx, evt.EventID, evt.AggregateID, evt.EventType, "notification-service")
 	if err != nil {
 		return err
 	}
-	if processed {
-		log.Printf("⏭️  Event %s already processed, skipping notification", evt.EventID)
+	if !claimed {
+		logger.Info("Event already processed, skipping notification")
 		return nil
 	}
+	defer ns.releaseOnError(ctx, evt.EventID, &err)
 
 	// Load order for details
 	o, err := ns.orderRepo.Get(ctx, evt.AggregateID)
 	if err != nil {
-		log.Printf("⚠️  Failed to load order: %v", err)
+		logger.Error("Failed to load order", logging.Err(err))
 		return err
 	}
 
-	// Format notification message
-	message := fmt.Sprintf(
-		"✅ Order Completed!\n\n"+
-			"Order ID: %s\n"+
-			"From: %.2f %s\n"+
-			"To: %.8f %s\n"+
-			"Price: %.2f %s/%s\n"+
-			"Status: %s",
-		o.ID,
-		o.FromAmount, o.FromCurrency,
-		o.ToAmount, o.ToCurrency,
-		o.ExecutedPrice, o.FromCurrency, o.ToCurrency,
-		o.Status,
-	)
+	// Format notification message in the user's locale
+	message, err := ns.Messages.Build(MessageOrderCompleted, o, MessageDetails{})
+	if err != nil {
+		logger.Error("Failed to build notification message", logging.Err(err))
+		return err
+	}
 
-	// Send notification
-	if err := ns.notifier.SendMessage(ctx, o.UserID, message); err != nil {
-		log.Printf("⚠️  Failed to send notification: %v", err)
+	// Queue notification for the delivery worker
+	if err := ns.enqueue(evt.EventID, evt.EventType, o.ID, o.UserID, message); err != nil {
+		logger.Error("Failed to enqueue notification", logging.Err(err))
 		return err
 	}
 
-	log.Printf("📤 Notification sent to user %s", o.UserID)
+	logger.Info("Notification enqueued", "user_id", o.UserID)
 
-	// Mark as processed
-	return ns.processedEvents.MarkAsProcessed(
-		ctx,
-		evt.EventID,
-		evt.AggregateID,
-		evt.EventType,
-		"notification-service",
-	)
+	return nil
 }
 
 // handleOrderFailed processes OrderFailed events
-func (ns *NotificationService) handleOrderFailed(ctx context.Context, eventData []byte) error {
-	log.Println("📨 NotificationService: Received OrderFailed event")
-
+func (ns *NotificationService) handleOrderFailed(ctx context.Context, eventData []byte) (err error) {
 	var evt order.OrderFailed
-	if err := json.Unmarshal(eventData, &evt); err != nil {
+	if err = json.Unmarshal(eventData, &evt); err != nil {
 		return err
 	}
 
-	// Idempotency check
-	processed, err := ns.processedEvents.IsProcessed(ctx, evt.EventID)
+	logger := ns.eventLogger(evt.AggregateID, evt.EventID, evt.EventType)
+	logger.Info("Received OrderFailed event")
+
+	// Idempotency: claim the event so a redelivery can't enqueue the notification twice
+	claimed, err := ns.processedEvents.ClaimEvent(ctx, evt.EventID, evt.AggregateID, evt.EventType, "notification-service")
 	if err != nil {
 		return err
 	}
-	if processed {
-		log.Printf("⏭️  Event %s already processed, skipping notification", evt.EventID)
+	if !claimed {
+		logger.Info("Event already processed, skipping notification")
 		return nil
 	}
+	defer ns.releaseOnError(ctx, evt.EventID, &err)
 
 	// Load order for details
 	o, err := ns.orderRepo.Get(ctx, evt.AggregateID)
 	if err != nil {
-		log.Printf("⚠️  Failed to load order: %v", err)
+		logger.Error("Failed to load order", logging.Err(err))
+		return err
+	}
+
+	// Format notification message in the user's locale
+	message, err := ns.Messages.Build(MessageOrderFailed, o, MessageDetails{Reason: evt.Reason})
+	if err != nil {
+		logger.Error("Failed to build notification message", logging.Err(err))
+		return err
+	}
+
+	// Queue notification for the delivery worker
+	if err := ns.enqueue(evt.EventID, evt.EventType, o.ID, o.UserID, message); err != nil {
+		logger.Error("Failed to enqueue notification", logging.Err(err))
+		return err
+	}
+
+	logger.Info("Failure notification enqueued", "user_id", o.UserID)
+
+	return nil
+}
+
+// handleOrderPartiallyFilled processes OrderPartiallyFilled events of limit orders
+// The final fill is left to the OrderCompleted notification that follows it
+func (ns *NotificationService) handleOrderPartiallyFilled(ctx context.Context, eventData []byte) (err error) {
+	var evt order.OrderPartiallyFilled
+	if err = json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	logger := ns.eventLogger(evt.AggregateID, evt.EventID, evt.EventType)
+	logger.Info("Received OrderPartiallyFilled event")
+
+	// Idempotency: claim the event so a redelivery can't enqueue the notification twice
+	claimed, err := ns.processedEvents.ClaimEvent(ctx, evt.EventID, evt.AggregateID, evt.EventType, "notification-service")
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		logger.Info("Event already processed, skipping notification")
+		return nil
+	}
+	defer ns.releaseOnError(ctx, evt.EventID, &err)
+
+	// Order as of this fill: later fills must not show up in its cumulative amounts
+	o, err := ns.orderRepo.GetAtVersion(ctx, evt.AggregateID, evt.Version)
+	if err != nil {
+		logger.Error("Failed to load order", logging.Err(err))
+		return err
+	}
+
+	if o.RemainingToFill().IsZero() {
+		logger.Info("Final fill, leaving it to the completion notification")
+		return nil
+	}
+
+	// Format notification message in the user's locale
+	message, err := ns.Messages.Build(MessageOrderPartiallyFilled, o, MessageDetails{Fill: &evt})
+	if err != nil {
+		logger.Error("Failed to build notification message", logging.Err(err))
 		return err
 	}
 
-	// Format notification message
-	message := fmt.Sprintf(
-		"❌ Order Failed\n\n"+
-			"Order ID: %s\n"+
-			"Amount: %.2f %s\n"+
-			"Reason: %s\n"+
-			"Status: %s",
-		o.ID,
-		o.FromAmount, o.FromCurrency,
-		evt.Reason,
-		o.Status,
-	)
+	// Queue notification for the delivery worker
+	if err := ns.enqueue(evt.EventID, evt.EventType, o.ID, o.UserID, message); err != nil {
+		logger.Error("Failed to enqueue notification", logging.Err(err))
+		return err
+	}
+
+	logger.Info("Fill notification enqueued", "user_id", o.UserID)
+
+	return nil
+}
 
-	// Send notification
-	if err := ns.notifier.SendMessage(ctx, o.UserID, message); err != nil {
-		log.Printf("⚠️  Failed to send notification: %v", err)
+// enqueue publishes the notification of an event as a NotificationTask
+func (ns *NotificationService) enqueue(eventID, eventType, orderID, userID, message string) error {
+	task := newNotificationTask(eventID, eventType, orderID, userID, message)
+	data, err := json.Marshal(task)
+	if err != nil {
 		return err
 	}
+	return ns.messageBus.PublishEvent(NotificationTaskType, task.TaskID, data)
+}
 
-	log.Printf("📤 Failure notification sent to user %s", o.UserID)
+// releaseOnError drops the claim if the notification was not queued, so the redelivered event is retried
+func (ns *NotificationService) releaseOnError(ctx context.Context, eventID string, err *error) {
+	if *err == nil {
+		return
+	}
+	if releaseErr := ns.processedEvents.ReleaseEvent(ctx, eventID); releaseErr != nil {
+		ns.Logger.Error("Failed to release event claim", logging.EventID(eventID), logging.Err(releaseErr))
+	}
+}
 
-	// Mark as processed
-	return ns.processedEvents.MarkAsProcessed(
-		ctx,
-		evt.EventID,
-		evt.AggregateID,
-		evt.EventType,
-		"notification-service",
-	)
+// eventLogger returns a logger carrying the order and event fields
+func (ns *NotificationService) eventLogger(orderID, eventID, eventType string) *slog.Logger {
+	return ns.Logger.With(logging.OrderID(orderID), logging.EventID(eventID), logging.EventType(eventType))
 }
 
-// MockNotifier is a simple console notifier for testing
+// MockNotifier is a simple console notifier for testing (also the "log" channel)
 type MockNotifier struct{}
 
 func (m *MockNotifier) SendMessage(ctx context.Context, userID, message string) error {
-	log.Printf("📱 [MOCK NOTIFICATION] To: %s\n%s\n", userID, message)
+	slog.Info("Mock notification", "user_id", userID, "message", message)
 	return nil
 }
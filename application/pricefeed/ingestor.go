@@ -0,0 +1,233 @@
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+	"market_order/pkg/websocket"
+)
+
+// Defaults for the price feed connection and PriceUpdated throttling
+const (
+	DefaultSource             = "binance"
+	DefaultThrottle           = 500 * time.Millisecond
+	DefaultReadTimeout        = 60 * time.Second
+	DefaultReconnectBaseDelay = 1 * time.Second
+	maxReconnectDelay         = 30 * time.Second
+	dialTimeout               = 10 * time.Second
+)
+
+// PriceFeedIngestor streams ticks from a Binance-style WebSocket feed into the order books
+//
+// Ticks only update the latest price per pair; every Throttle the changed prices are
+// persisted as one PriceUpdated per pair (UpdatePrice on the order book aggregate).
+// PriceUpdated reaches the LimitOrderMonitor through the outbox and triggers limit orders
+type PriceFeedIngestor struct {
+	orderBooks *aggregates.OrderBookRegistry
+	url        string
+	pairs      map[string]string // Feed symbol ("BTCUSDT") → trading pair ("BTC/USDT")
+
+	mu        sync.Mutex
+	latest    map[string]decimal.Decimal // Last tick per pair
+	persisted map[string]decimal.Decimal // Last PriceUpdated per pair
+
+	// Source - PriceUpdated.Source of the persisted prices
+	Source string
+	// Throttle - at most one PriceUpdated per pair per Throttle
+	Throttle time.Duration
+	// ReadTimeout - a silent feed connection is dropped and re-dialed after this long
+	ReadTimeout time.Duration
+	// ReconnectBaseDelay - first reconnect backoff, doubled up to 30s
+	ReconnectBaseDelay time.Duration
+
+	Logger *slog.Logger
+}
+
+// NewPriceFeedIngestor streams the pairs registered in orderBooks
+func NewPriceFeedIngestor(orderBooks *aggregates.OrderBookRegistry, url string) *PriceFeedIngestor {
+	pairs := orderBooks.Pairs()
+	symbols := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		symbols[strings.ReplaceAll(pair, "/", "")] = pair
+	}
+
+	return &PriceFeedIngestor{
+		orderBooks:         orderBooks,
+		url:                url,
+		pairs:              symbols,
+		latest:             make(map[string]decimal.Decimal),
+		persisted:          make(map[string]decimal.Decimal),
+		Source:             DefaultSource,
+		Throttle:           DefaultThrottle,
+		ReadTimeout:        DefaultReadTimeout,
+		ReconnectBaseDelay: DefaultReconnectBaseDelay,
+		Logger:             slog.Default(),
+	}
+}
+
+// Start consumes the feed and persists throttled prices until ctx is cancelled
+// The feed is re-dialed with exponential backoff whenever the connection drops
+func (i *PriceFeedIngestor) Start(ctx context.Context) error {
+	i.Logger.Info("Price Feed Ingestor started", "url", i.url, "pairs", len(i.pairs), "throttle", i.Throttle.String())
+
+	go i.consumeFeed(ctx)
+
+	ticker := time.NewTicker(i.Throttle)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			i.flush(ctx)
+
+		case <-ctx.Done():
+			i.Logger.Info("Price Feed Ingestor stopped")
+			return nil
+		}
+	}
+}
+
+// consumeFeed keeps a feed connection open and records ticks
+func (i *PriceFeedIngestor) consumeFeed(ctx context.Context) {
+	delay := i.ReconnectBaseDelay
+
+	for ctx.Err() == nil {
+		connected, err := i.readFeed(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if connected {
+			delay = i.ReconnectBaseDelay // The connection worked - start the backoff over
+		}
+		i.Logger.Warn("Price feed disconnected, reconnecting", "delay", delay.String(), logging.Err(err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// readFeed dials the feed and reads ticks until the connection fails
+// connected reports whether the handshake succeeded
+func (i *PriceFeedIngestor) readFeed(ctx context.Context) (connected bool, err error) {
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	conn, err := websocket.Dial(dialCtx, i.url)
+	cancel()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage on shutdown
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	i.Logger.Info("Price feed connected")
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(i.ReadTimeout))
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+
+		pair, price, ok := i.parseTick(message)
+		if !ok {
+			continue // Subscription acks, other symbols, unknown payloads
+		}
+
+		i.mu.Lock()
+		i.latest[pair] = price
+		i.mu.Unlock()
+	}
+}
+
+// tick - the fields of a Binance ticker ("c") or trade ("p") message
+type tick struct {
+	Symbol    string `json:"s"`
+	LastPrice string `json:"c"`
+	Price     string `json:"p"`
+}
+
+// parseTick extracts the trading pair and price of a raw or combined-stream message
+// ({"stream": "btcusdt@ticker", "data": {...}})
+func (i *PriceFeedIngestor) parseTick(message []byte) (string, decimal.Decimal, bool) {
+	var combined struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(message, &combined); err == nil && len(combined.Data) > 0 {
+		message = combined.Data
+	}
+
+	var t tick
+	if err := json.Unmarshal(message, &t); err != nil {
+		return "", decimal.Zero, false
+	}
+
+	pair, ok := i.pairs[strings.ToUpper(t.Symbol)]
+	if !ok {
+		return "", decimal.Zero, false
+	}
+
+	raw := t.LastPrice
+	if raw == "" {
+		raw = t.Price
+	}
+	price, err := decimal.Parse(raw)
+	if err != nil || !price.IsPositive() {
+		return "", decimal.Zero, false
+	}
+
+	return pair, price, true
+}
+
+// flush persists the prices that changed since the last PriceUpdated
+func (i *PriceFeedIngestor) flush(ctx context.Context) {
+	i.mu.Lock()
+	changed := make(map[string]decimal.Decimal)
+	for pair, price := range i.latest {
+		if !i.persisted[pair].Equal(price) {
+			changed[pair] = price
+		}
+	}
+	i.mu.Unlock()
+
+	for pair, price := range changed {
+		if err := i.updatePrice(ctx, pair, price); err != nil {
+			// Not marked as persisted: retried on the next flush
+			i.Logger.Error("Failed to update order book price", "trading_pair", pair, "price", price, logging.Err(err))
+			continue
+		}
+
+		i.mu.Lock()
+		i.persisted[pair] = price
+		i.mu.Unlock()
+	}
+}
+
+// updatePrice emits PriceUpdated on the pair's order book, creating the book if needed
+func (i *PriceFeedIngestor) updatePrice(ctx context.Context, pair string, price decimal.Decimal) error {
+	ob, err := i.orderBooks.LoadOrCreate(ctx, pair)
+	if err != nil {
+		return err
+	}
+
+	if err := ob.UpdatePrice(price, i.Source); err != nil {
+		return err
+	}
+	if err := i.orderBooks.Save(ctx, ob); err != nil {
+		return fmt.Errorf("failed to save order book %s: %w", ob.ID, err)
+	}
+	return nil
+}
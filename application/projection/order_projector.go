@@ -0,0 +1,204 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/repository"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// consumerName - own queues (consumer groups on Kafka), so the projector does not compete
+// with the saga/notification service for the same events
+const consumerName = "order-projection"
+
+// rebuildBatchSize - events read per page during Rebuild
+const rebuildBatchSize = 500
+
+// projectedEvents maps order events to the projected status ("" = no status change)
+var projectedEvents = map[string]string{
+	"OrderAccepted":  string(order.OrderStatusPending),
+	"SwapExecuting":  string(order.OrderStatusExecuting),
+	"OrderCompleted": string(order.OrderStatusCompleted),
+	"OrderFailed":    string(order.OrderStatusFailed),
+	"OrderCancelled": string(order.OrderStatusFailed), // Aggregate treats cancel as failed
+
+	"LimitOrderExpired": "", // Status taken from the event: set only when closed without fills
+}
+
+// OrderProjector maintains the order_projection read model (orders per user)
+// EventStore stays the source of truth - the projection can always be rebuilt from it
+type OrderProjector struct {
+	projectionRepo  *repository.OrderProjectionRepository
+	processedEvents idempotency.ProcessedEventStore
+	messageBus      messaging.MessageBus
+	eventStore      eventstore.EventStore // For Rebuild
+}
+
+func NewOrderProjector(
+	projectionRepo *repository.OrderProjectionRepository,
+	processedEvents idempotency.ProcessedEventStore,
+	messageBus messaging.MessageBus,
+	eventStore eventstore.EventStore,
+) *OrderProjector {
+	return &OrderProjector{
+		projectionRepo:  projectionRepo,
+		processedEvents: processedEvents,
+		messageBus:      messageBus,
+		eventStore:      eventStore,
+	}
+}
+
+// Start subscribes to order events and keeps the projection up to date
+func (p *OrderProjector) Start(ctx context.Context) error {
+	for eventType := range projectedEvents {
+		if err := p.messageBus.SubscribeAs(ctx, consumerName, eventType, p.handleEvent); err != nil {
+			return err
+		}
+	}
+
+	log.Println("✅ Order Projector started, listening for events...")
+
+	<-ctx.Done()
+
+	// Return only after in-flight projections finished and were acked
+	<-p.messageBus.Drained()
+	log.Println("✅ Order Projector stopped")
+	return nil
+}
+
+// handleEvent applies one delivered event exactly once
+func (p *OrderProjector) handleEvent(ctx context.Context, eventData []byte) error {
+	var evt order.BaseEvent
+	if err := json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	// processed_events is shared with the saga - use a projector-specific key
+	processedKey := pkguuid.NewFromName(consumerName + ":" + evt.EventID)
+
+	processed, err := p.processedEvents.IsProcessed(ctx, processedKey)
+	if err != nil {
+		return err
+	}
+	if processed {
+		log.Printf("⏭️  Event %s already projected, skipping", evt.EventID)
+		return nil
+	}
+
+	if err := p.apply(ctx, evt, eventData); err != nil {
+		return err
+	}
+
+	return p.processedEvents.MarkAsProcessed(ctx, processedKey, evt.AggregateID, evt.EventType, consumerName)
+}
+
+// apply updates the projection row for a single event
+// Safe to repeat: updates are guarded by the event version
+func (p *OrderProjector) apply(ctx context.Context, evt order.BaseEvent, eventData []byte) error {
+	status, ok := projectedEvents[evt.EventType]
+	if !ok {
+		return nil
+	}
+
+	if evt.EventType == "LimitOrderExpired" {
+		var expired order.LimitOrderExpired
+		if err := json.Unmarshal(eventData, &expired); err != nil {
+			return err
+		}
+		status = expired.Status
+	}
+	if status == "" {
+		return nil
+	}
+
+	if evt.EventType != "OrderAccepted" {
+		return p.projectionRepo.UpdateStatus(ctx, evt.AggregateID, status, evt.Version, evt.Timestamp)
+	}
+
+	var accepted order.OrderAccepted
+	if err := json.Unmarshal(eventData, &accepted); err != nil {
+		return err
+	}
+
+	return p.projectionRepo.Insert(ctx, repository.OrderProjection{
+		OrderID:       accepted.AggregateID,
+		UserID:        accepted.UserID,
+		Status:        status,
+		OrderType:     accepted.OrderType,
+		FromAmount:    accepted.FromAmount.Float64(),
+		FromCurrency:  accepted.FromCurrency,
+		ToCurrency:    accepted.ToCurrency,
+		ClientOrderID: order.ClientMetadataFrom(accepted.Metadata)[order.ClientOrderIDKey],
+		Version:       accepted.Version,
+		CreatedAt:     accepted.Timestamp,
+		UpdatedAt:     accepted.Timestamp,
+	})
+}
+
+// Rebuild replays the full event store into an empty projection
+// Replayed events are marked as processed page by page (one INSERT per page)
+// Run while the projector is stopped; live events are picked up again on Start
+func (p *OrderProjector) Rebuild(ctx context.Context) error {
+	log.Println("🔁 Rebuilding order projection from Event Store...")
+
+	if err := p.projectionRepo.Truncate(ctx); err != nil {
+		return err
+	}
+
+	var (
+		fromSeq int64
+		applied int
+	)
+
+	for {
+		events, err := p.eventStore.LoadAll(ctx, fromSeq, rebuildBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		var replayed []idempotency.ProcessedEvent
+		for _, stored := range events {
+			if stored.AggregateType != "Order" {
+				continue
+			}
+
+			var evt order.BaseEvent
+			if err := json.Unmarshal(stored.EventData, &evt); err != nil {
+				return err
+			}
+
+			if err := p.apply(ctx, evt, stored.EventData); err != nil {
+				return err
+			}
+			applied++
+
+			if _, ok := projectedEvents[evt.EventType]; ok {
+				replayed = append(replayed, idempotency.ProcessedEvent{
+					EventID:     pkguuid.NewFromName(consumerName + ":" + evt.EventID),
+					AggregateID: evt.AggregateID,
+					EventType:   evt.EventType,
+					ProcessedBy: consumerName,
+				})
+			}
+		}
+
+		// Redelivered copies of replayed events are skipped by handleEvent
+		if err := p.processedEvents.MarkManyAsProcessed(ctx, replayed); err != nil {
+			return err
+		}
+
+		fromSeq = events[len(events)-1].GlobalSequence + 1
+	}
+
+	log.Printf("✅ Order projection rebuilt: %d events replayed", applied)
+	return nil
+}
@@ -0,0 +1,133 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+)
+
+// reportBatchSize - events read per page while building a report
+const reportBatchSize = 500
+
+// OrderReport summarizes the orders closed since a point in time
+type OrderReport struct {
+	Since     time.Time
+	Completed int
+	Failed    int
+
+	// Volumes - traded volume of completed orders per currency
+	Volumes map[string]*CurrencyVolume
+}
+
+// CurrencyVolume is the traded volume of one currency
+type CurrencyVolume struct {
+	Sold         decimal.Decimal // Sum of from_amount of orders selling the currency
+	Bought       decimal.Decimal // Sum of to_amount of orders buying the currency
+	PlatformFees decimal.Decimal // Sum of platform_fee (charged in the sold currency)
+}
+
+// volume returns the currency's entry, creating it on first use
+func (r *OrderReport) volume(currency string) *CurrencyVolume {
+	v, ok := r.Volumes[currency]
+	if !ok {
+		v = &CurrencyVolume{}
+		r.Volumes[currency] = v
+	}
+	return v
+}
+
+// OrderReporter builds order reports straight from the event store
+// Reads OrderCompleted/OrderFailed by type, without loading each order stream
+type OrderReporter struct {
+	eventStore eventstore.EventStore
+}
+
+func NewOrderReporter(eventStore eventstore.EventStore) *OrderReporter {
+	return &OrderReporter{eventStore: eventStore}
+}
+
+// Report counts completed vs failed orders and sums the traded volume per currency since since
+func (r *OrderReporter) Report(ctx context.Context, since time.Time) (*OrderReport, error) {
+	report := &OrderReport{Since: since, Volumes: make(map[string]*CurrencyVolume)}
+
+	err := r.forEachEvent(ctx, "OrderCompleted", since, func(stored eventstore.Event) error {
+		var evt order.OrderCompleted
+		if err := json.Unmarshal(stored.EventData, &evt); err != nil {
+			return err
+		}
+		if evt.FromCurrency == "" {
+			if err := r.loadCurrencies(ctx, &evt); err != nil {
+				return err
+			}
+		}
+
+		report.Completed++
+		sold := report.volume(evt.FromCurrency)
+		sold.Sold = sold.Sold.Add(evt.FromAmount)
+		sold.PlatformFees = sold.PlatformFees.Add(evt.PlatformFee)
+		bought := report.volume(evt.ToCurrency)
+		bought.Bought = bought.Bought.Add(evt.ToAmount)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.forEachEvent(ctx, "OrderFailed", since, func(eventstore.Event) error {
+		report.Failed++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// loadCurrencies fills the pair of an OrderCompleted written before it carried the currencies
+// Reads the order stream up to the event: only such legacy events cost a stream load
+func (r *OrderReporter) loadCurrencies(ctx context.Context, evt *order.OrderCompleted) error {
+	events, err := r.eventStore.LoadRangeByAggregateType(ctx, evt.AggregateID, "Order", 1, evt.Version)
+	if err != nil {
+		return err
+	}
+	for _, stored := range events {
+		if stored.EventType != "OrderAccepted" {
+			continue
+		}
+		var accepted order.OrderAccepted
+		if err := json.Unmarshal(stored.EventData, &accepted); err != nil {
+			return err
+		}
+		evt.FromCurrency, evt.ToCurrency = accepted.FromCurrency, accepted.ToCurrency
+		return nil
+	}
+	return fmt.Errorf("order %s: OrderAccepted not found", evt.AggregateID)
+}
+
+// forEachEvent pages through the events of eventType, using global_sequence as the cursor
+func (r *OrderReporter) forEachEvent(ctx context.Context, eventType string, since time.Time, fn func(eventstore.Event) error) error {
+	var fromSeq int64
+	for {
+		events, err := r.eventStore.LoadByType(ctx, eventType, since, fromSeq, reportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, stored := range events {
+			if err := fn(stored); err != nil {
+				return err
+			}
+		}
+
+		if len(events) < reportBatchSize {
+			return nil
+		}
+		fromSeq = events[len(events)-1].GlobalSequence + 1
+	}
+}
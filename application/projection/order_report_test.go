@@ -0,0 +1,90 @@
+package projection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// completeOrder stores the stream of a market order completed with the given amounts
+// legacy strips the currencies from OrderCompleted, as in events written before they existed
+func completeOrder(t *testing.T, es eventstore.EventStore, from, to string, fromAmount, toAmount, platformFee string, legacy bool) {
+	t.Helper()
+
+	o := order.NewOrder()
+	if err := o.AcceptOrder(pkguuid.New(), "user-1", decimal.MustParse(fromAmount), from, to, "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := o.StartSwapExecution("swap-" + o.ID); err != nil {
+		t.Fatalf("StartSwapExecution: %v", err)
+	}
+	price := decimal.MustParse(toAmount).Div(decimal.MustParse(fromAmount))
+	if err := o.RecordSwapExecution(pkguuid.New(), "0xtx", decimal.MustParse(fromAmount), decimal.MustParse(toAmount), price, decimal.Zero, 0); err != nil {
+		t.Fatalf("RecordSwapExecution: %v", err)
+	}
+	if err := o.CompleteOrder(decimal.Zero, decimal.MustParse(platformFee)); err != nil {
+		t.Fatalf("CompleteOrder: %v", err)
+	}
+
+	changes := o.GetChanges()
+	if legacy {
+		completed := changes[len(changes)-1].(order.OrderCompleted)
+		completed.FromCurrency, completed.ToCurrency = "", ""
+		changes[len(changes)-1] = completed
+	}
+	if err := es.Save(context.Background(), changes); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+}
+
+func TestOrderReporterVolumesPerCurrency(t *testing.T) {
+	es := eventstore.NewMemoryEventStore()
+	completeOrder(t, es, "USDT", "BTC", "1000", "0.02", "1", false)
+	completeOrder(t, es, "USDT", "ETH", "500", "0.2", "0.5", false)
+	completeOrder(t, es, "BTC", "USDT", "0.01", "510", "0.00001", true)
+
+	failed := order.NewOrder()
+	if err := failed.AcceptOrder(pkguuid.New(), "user-1", decimal.MustParse("10"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := failed.FailOrder("no liquidity"); err != nil {
+		t.Fatalf("FailOrder: %v", err)
+	}
+	if err := es.Save(context.Background(), failed.GetChanges()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	report, err := NewOrderReporter(es).Report(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+
+	if report.Completed != 3 || report.Failed != 1 {
+		t.Errorf("completed/failed = %d/%d, want 3/1", report.Completed, report.Failed)
+	}
+
+	want := map[string]CurrencyVolume{
+		"USDT": {Sold: decimal.MustParse("1500"), Bought: decimal.MustParse("510"), PlatformFees: decimal.MustParse("1.5")},
+		"BTC":  {Sold: decimal.MustParse("0.01"), Bought: decimal.MustParse("0.02"), PlatformFees: decimal.MustParse("0.00001")},
+		"ETH":  {Bought: decimal.MustParse("0.2")},
+	}
+	if len(report.Volumes) != len(want) {
+		t.Fatalf("volumes of %d currencies, want %d", len(report.Volumes), len(want))
+	}
+	for currency, w := range want {
+		got, ok := report.Volumes[currency]
+		if !ok {
+			t.Errorf("%s: no volume", currency)
+			continue
+		}
+		if !got.Sold.Equal(w.Sold) || !got.Bought.Equal(w.Bought) || !got.PlatformFees.Equal(w.PlatformFees) {
+			t.Errorf("%s: sold/bought/fees = %s/%s/%s, want %s/%s/%s",
+				currency, got.Sold, got.Bought, got.PlatformFees, w.Sold, w.Bought, w.PlatformFees)
+		}
+	}
+}
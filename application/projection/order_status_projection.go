@@ -0,0 +1,93 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/repository"
+)
+
+// statusViewConsumerName - own queue (consumer group on Kafka) for the order_status_view projection
+const statusViewConsumerName = "order-status-view"
+
+// statusViewPattern - every event of the Order aggregate, through a single queue
+const statusViewPattern = "Order.#"
+
+// OrderStatusProjection maintains order_status_view: the latest state of every order,
+// so hot orders can be read without replaying their events
+//
+// Each event upserts the order state rebuilt by the AggregateStore (snapshots keep it cheap).
+// Amounts are accumulated by the aggregate, not by the projection, so the row stays correct
+// even when events are skipped: a row is only replaced by a newer version, which makes
+// redelivered and out-of-order events no-ops
+type OrderStatusProjection struct {
+	viewRepo       *repository.OrderStatusViewRepository
+	aggregateStore *aggregates.AggregateStore
+	messageBus     messaging.MessageBus
+}
+
+func NewOrderStatusProjection(
+	viewRepo *repository.OrderStatusViewRepository,
+	aggregateStore *aggregates.AggregateStore,
+	messageBus messaging.MessageBus,
+) *OrderStatusProjection {
+	return &OrderStatusProjection{
+		viewRepo:       viewRepo,
+		aggregateStore: aggregateStore,
+		messageBus:     messageBus,
+	}
+}
+
+// Start subscribes to order events and keeps order_status_view up to date
+func (p *OrderStatusProjection) Start(ctx context.Context) error {
+	if err := p.messageBus.SubscribePattern(ctx, statusViewConsumerName, statusViewPattern, p.handleEvent); err != nil {
+		return err
+	}
+
+	log.Println("✅ Order Status Projection started, listening for events...")
+
+	<-ctx.Done()
+
+	// Return only after in-flight upserts finished and were acked
+	<-p.messageBus.Drained()
+	log.Println("✅ Order Status Projection stopped")
+	return nil
+}
+
+// handleEvent upserts the order's current state
+func (p *OrderStatusProjection) handleEvent(ctx context.Context, eventData []byte) error {
+	var evt order.BaseEvent
+	if err := json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	o, err := p.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
+	if errors.Is(err, aggregates.ErrAggregateNotFound) {
+		// Published events are committed first - nothing to project
+		log.Printf("⚠️  Order %s of event %s not found, skipping", evt.AggregateID, evt.EventID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return p.viewRepo.Upsert(ctx, repository.OrderStatusView{
+		OrderID:       o.ID,
+		UserID:        o.UserID,
+		Status:        string(o.Status),
+		OrderType:     o.OrderType,
+		FromAmount:    o.FromAmount.Float64(),
+		FromCurrency:  o.FromCurrency,
+		ToAmount:      o.ToAmount.Float64(),
+		ToCurrency:    o.ToCurrency,
+		ExecutedPrice: o.ExecutedPrice.Float64(),
+		Version:       o.Version,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	})
+}
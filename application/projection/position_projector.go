@@ -0,0 +1,135 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"market_order/domain/position"
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/repository"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// positionConsumerName - own queues (consumer groups on Kafka) for the position_projection read model
+const positionConsumerName = "position-projection"
+
+// positionEvents - every event of the Position aggregate
+var positionEvents = []string{
+	"PositionCreated",
+	"PositionUpdated",
+	"PositionClosed",
+}
+
+// PositionProjector maintains the position_projection read model (positions per user)
+type PositionProjector struct {
+	projectionRepo  *repository.PositionProjectionRepository
+	processedEvents idempotency.ProcessedEventStore
+	messageBus      messaging.MessageBus
+}
+
+func NewPositionProjector(
+	projectionRepo *repository.PositionProjectionRepository,
+	processedEvents idempotency.ProcessedEventStore,
+	messageBus messaging.MessageBus,
+) *PositionProjector {
+	return &PositionProjector{
+		projectionRepo:  projectionRepo,
+		processedEvents: processedEvents,
+		messageBus:      messageBus,
+	}
+}
+
+// Start subscribes to position events and keeps the projection up to date
+func (p *PositionProjector) Start(ctx context.Context) error {
+	for _, eventType := range positionEvents {
+		if err := p.messageBus.SubscribeAs(ctx, positionConsumerName, eventType, p.handleEvent); err != nil {
+			return err
+		}
+	}
+
+	log.Println("✅ Position Projector started, listening for events...")
+
+	<-ctx.Done()
+
+	// Return only after in-flight projections finished and were acked
+	<-p.messageBus.Drained()
+	log.Println("✅ Position Projector stopped")
+	return nil
+}
+
+// handleEvent applies one delivered event exactly once
+func (p *PositionProjector) handleEvent(ctx context.Context, eventData []byte) error {
+	var evt position.BaseEvent
+	if err := json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	// processed_events is shared with the saga - use a projector-specific key
+	processedKey := pkguuid.NewFromName(positionConsumerName + ":" + evt.EventID)
+
+	processed, err := p.processedEvents.IsProcessed(ctx, processedKey)
+	if err != nil {
+		return err
+	}
+	if processed {
+		log.Printf("⏭️  Event %s already projected, skipping", evt.EventID)
+		return nil
+	}
+
+	if err := p.apply(ctx, evt, eventData); err != nil {
+		return err
+	}
+
+	return p.processedEvents.MarkAsProcessed(ctx, processedKey, evt.AggregateID, evt.EventType, positionConsumerName)
+}
+
+// apply updates the projection row for a single event
+// Safe to repeat: updates are guarded by the event version
+func (p *PositionProjector) apply(ctx context.Context, evt position.BaseEvent, eventData []byte) error {
+	switch evt.EventType {
+	case "PositionCreated":
+		var e position.PositionCreated
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return err
+		}
+		return p.projectionRepo.Insert(ctx, repository.PositionProjection{
+			PositionID:      e.AggregateID,
+			UserID:          e.UserID,
+			Currency:        e.Currency,
+			RemainingAmount: e.RemainingAmount.Float64(),
+			Status:          e.Status,
+			Version:         e.Version,
+			CreatedAt:       e.Timestamp,
+			UpdatedAt:       e.Timestamp,
+		})
+
+	case "PositionUpdated":
+		var e position.PositionUpdated
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return err
+		}
+		return p.projectionRepo.UpdateAmounts(ctx, repository.PositionProjection{
+			PositionID:      e.AggregateID,
+			RemainingAmount: e.RemainingAmount.Float64(),
+			TotalValue:      e.TotalValue.Float64(),
+			Cost:            e.Cost.Float64(),
+			RealizedPnL:     e.RealizedPnL.Float64(),
+			UnrealizedPnL:   e.UnrealizedPnL.Float64(),
+			PnL:             e.PnL.Float64(),
+			Version:         e.Version,
+			UpdatedAt:       e.Timestamp,
+		})
+
+	case "PositionClosed":
+		var e position.PositionClosed
+		if err := json.Unmarshal(eventData, &e); err != nil {
+			return err
+		}
+		return p.projectionRepo.Close(ctx, e.AggregateID, e.ClosingPrice.Float64(), e.RealizedPnL.Float64(), e.Version, e.Timestamp)
+
+	default:
+		return nil
+	}
+}
@@ -3,69 +3,147 @@ package saga
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 
 	"market_order/domain/order"
+	pricefeed "market_order/infrastructure/price"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+	"market_order/pkg/tracing"
 )
 
 // ===============================================
 // STEP 1: OrderAccepted → Get Price → Publish PriceQuoted
 // ===============================================
 
-// handleOrderAccepted processes OrderAccepted event
+// handleMarketOrderAccepted processes OrderAccepted events of market orders (OrderAccepted.market)
 // Responsibilities:
+// - Check the user's balance (balance.go) and open exposure (exposure.go)
+// - Expire market orders past their TTL (expiry.go)
 // - Get market price from price service
 // - Load order aggregate from EventStore (source of truth)
 // - Update order with quoted price (generates PriceQuoted event)
 // - Save events to EventStore
 // - Events are automatically published via Outbox pattern
-func (s *OrderSagaRefactored) handleOrderAccepted(ctx context.Context, eventData []byte) error {
-	log.Println("📨 [STEP 1] Saga: Received OrderAccepted event")
+func (s *OrderSagaRefactored) handleMarketOrderAccepted(ctx context.Context, eventData []byte) error {
+	return s.acceptOrder(ctx, eventData, s.priceMarketOrder)
+}
+
+// handleLimitOrderAccepted processes OrderAccepted events of limit orders (OrderAccepted.limit)
+// Checks the balance and places the order in the order book (limit.go)
+func (s *OrderSagaRefactored) handleLimitOrderAccepted(ctx context.Context, eventData []byte) error {
+	return s.acceptOrder(ctx, eventData, s.placeLimitOrder)
+}
 
+// acceptOrder runs the common part of STEP 1 (idempotency, balance and exposure checks), then next
+// Both order types claim the event as order-saga-step1: an event is accepted once
+// whichever subscription delivers it
+func (s *OrderSagaRefactored) acceptOrder(ctx context.Context, eventData []byte, next func(context.Context, *slog.Logger, order.OrderAccepted) error) (err error) {
 	var evt order.OrderAccepted
-	if err := json.Unmarshal(eventData, &evt); err != nil {
+	if err = json.Unmarshal(eventData, &evt); err != nil {
 		return err
 	}
 
-	// Idempotency check
-	if processed, _ := s.processedEvents.IsProcessed(ctx, evt.EventID); processed {
-		log.Printf("⏭️  Event %s already processed, skipping", evt.EventID)
-		return nil
-	}
+	logger := s.stepLogger("accept", evt.AggregateID, evt.EventID).With("order_type", evt.OrderType)
+	logger.Info("Received OrderAccepted event")
 
-	// Get market price
-	log.Printf("📊 Getting market price for %s/%s", evt.FromCurrency, evt.ToCurrency)
-	price, err := s.priceService.GetMarketPrice(ctx, evt.FromCurrency, evt.ToCurrency)
-	if err != nil {
-		log.Printf("❌ Failed to get price: %v", err)
-		return s.compensateOrderFailed(ctx, evt.AggregateID, "price_unavailable")
+	// Idempotency: claim the event before any side effects
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step1")
+	if err != nil || !claimed {
+		return err
 	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
 
-	toAmount := evt.FromAmount / price
-	log.Printf("✅ Price quoted: 1 %s = %.2f %s, toAmount = %.8f",
-		evt.ToCurrency, price, evt.FromCurrency, toAmount)
-
-	// ✅ Load aggregate from EventStore (source of truth!)
-	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
+	// Verify funds before pricing or placing the order
+	passed, err := s.checkBalance(ctx, logger, evt.AggregateID, evt.UserID, evt.FromCurrency)
 	if err != nil {
 		return err
 	}
+	if !passed {
+		return nil
+	}
 
-	// Generate PriceQuoted event
-	if err := o.QuotePrice(price, toAmount); err != nil {
+	// Cap the user's open exposure in the currency the order spends
+	passed, err = s.checkExposure(ctx, logger, evt.AggregateID, evt.UserID, evt.FromCurrency)
+	if err != nil || !passed {
 		return err
 	}
 
-	// ✅ Save events to EventStore (not repository!)
-	if err := s.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
+	return next(ctx, logger, evt)
+}
+
+// priceMarketOrder quotes the market price of an accepted market order (generates PriceQuoted)
+func (s *OrderSagaRefactored) priceMarketOrder(ctx context.Context, logger *slog.Logger, evt order.OrderAccepted) error {
+	// A market order past its TTL is not priced (OrderExpired)
+	if expired, err := s.expireStaleOrder(ctx, logger, evt.AggregateID, ""); err != nil || expired {
 		return err
 	}
 
-	// Mark as processed
-	s.processedEvents.MarkAsProcessed(ctx, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step1")
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepPricing, "", repository.SagaStatusRunning)
+
+	// Get market price
+	logger.Info("Getting market price", "from_currency", evt.FromCurrency, "to_currency", evt.ToCurrency)
+	price, err := s.getMarketPrice(ctx, evt.FromCurrency, evt.ToCurrency)
+	if err != nil {
+		return s.failPricing(ctx, logger, evt.AggregateID, "", err)
+	}
+
+	// ✅ Load aggregate from EventStore, generate PriceQuoted event and save (retried on conflict)
+	// The amount is read from the aggregate: an amendment (OrderUpdated) may have changed it
+	var toAmount decimal.Decimal
+	err = s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(o *order.Order) error {
+		toAmount = quoteToAmount(o.FromAmount, price)
+		return o.QuotePrice(price, toAmount)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("Price quoted", "price", price, "to_amount", toAmount)
 
 	// PriceQuoted event will be published automatically via Outbox
 	// and trigger STEP 2
-	log.Printf("✅ [STEP 1] Completed: Price quoted for order %s", evt.AggregateID)
+	logger.Info("Step completed: price quoted")
 	return nil
 }
+
+// getMarketPrice asks the price service for from → to, bounded by PriceTimeout
+func (s *OrderSagaRefactored) getMarketPrice(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	priceCtx, cancel := context.WithTimeout(ctx, s.PriceTimeout)
+	defer cancel()
+
+	priceCtx, span := tracing.StartSpan(priceCtx, "price.GetMarketPrice")
+	defer span.End()
+
+	price, err := s.priceService.GetMarketPrice(priceCtx, from, to)
+	if err != nil {
+		span.RecordError(err)
+	}
+	return price, err
+}
+
+// failPricing compensates an order that could not be priced
+// positionID (re-quote at STEP 3) is closed by the compensation
+func (s *OrderSagaRefactored) failPricing(ctx context.Context, logger *slog.Logger, orderID, positionID string, err error) error {
+	if ctx.Err() != nil {
+		return err // Shutdown: the order is priced on redelivery
+	}
+
+	reason := "price_unavailable"
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		logger.Warn("Price request timed out", "timeout", s.PriceTimeout.String())
+		reason = "price_timeout"
+	case errors.Is(err, pricefeed.ErrPairNotSupported):
+		logger.Error("Pair not supported", logging.Err(err))
+		reason = "pair_not_supported"
+	default:
+		logger.Error("Failed to get price", logging.Err(err))
+	}
+
+	if positionID != "" {
+		return s.compensateSwapFailed(ctx, orderID, positionID, reason)
+	}
+	return s.compensateOrderFailed(ctx, orderID, reason)
+}
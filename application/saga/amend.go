@@ -0,0 +1,102 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+)
+
+// ===============================================
+// AMENDMENT: OrderUpdated → Check Balance → Re-quote (PriceQuoted, requote)
+// ===============================================
+
+// handleOrderUpdated re-prices a pending market order after PATCH /orders/{id}
+// Responsibilities:
+// - Skip orders that are no longer pending or were never priced (STEP 1 prices the new amount)
+// - Check the balance covers the amended amount (fails the order otherwise)
+// - Re-quote at the current market price (generates PriceQuoted with requote=true)
+//
+// A re-quote does not trigger STEP 2: the order keeps the position of its first quote,
+// and STEP 3 executes the swap with the amended FromAmount
+func (s *OrderSagaRefactored) handleOrderUpdated(ctx context.Context, eventData []byte) (err error) {
+	var evt order.OrderUpdated
+	if err = json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	logger := s.stepLogger("reprice", evt.AggregateID, evt.EventID)
+	logger.Info("Received OrderUpdated event")
+
+	// Idempotency: claim the event before any side effects
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-reprice")
+	if err != nil || !claimed {
+		return err
+	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
+
+	if _, ok := evt.UpdatedFields["from_amount"]; !ok {
+		return nil // Nothing that affects the price
+	}
+
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
+	if err != nil {
+		return err
+	}
+	if o.Status != order.OrderStatusPending || o.OrderType != "market" || o.ToAmount.IsZero() {
+		logger.Info("Order not awaiting execution at a quoted price, skipping re-quote",
+			"status", string(o.Status), "order_type", o.OrderType)
+		return nil
+	}
+
+	// Funds were checked for the original amount only
+	balance, err := s.balanceService.GetAvailableBalance(ctx, o.UserID, o.FromCurrency)
+	if err != nil {
+		logger.Error("Failed to get balance", logging.Err(err))
+		return err
+	}
+	if balance.LessThan(o.FromAmount) {
+		logger.Warn("Insufficient balance for amended order",
+			"required", o.FromAmount, "available", balance, "currency", o.FromCurrency)
+		return s.compensateAmendedOrder(ctx, evt.AggregateID, "insufficient_balance")
+	}
+
+	price, err := s.getMarketPrice(ctx, o.FromCurrency, o.ToCurrency)
+	if err != nil {
+		// The order was priced before: keep it and retry the re-quote on redelivery
+		logger.Warn("Failed to get price for re-quote", logging.Err(err))
+		return err
+	}
+
+	// ✅ Re-quote on the current state: the swap may have started in the meantime
+	var toAmount decimal.Decimal
+	err = s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(current *order.Order) error {
+		if current.Status != order.OrderStatusPending {
+			return nil
+		}
+		toAmount = quoteToAmount(current.FromAmount, price)
+		return current.RequotePrice(price, toAmount)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Step completed: amended order re-quoted", "price", price, "to_amount", toAmount)
+	return nil
+}
+
+// compensateAmendedOrder fails an amended order, closing its position if STEP 2 already created one
+func (s *OrderSagaRefactored) compensateAmendedOrder(ctx context.Context, orderID, reason string) error {
+	inst, err := s.sagaRepo.Get(ctx, orderID)
+	if err != nil && !errors.Is(err, repository.ErrSagaNotFound) {
+		return err
+	}
+	if inst != nil && inst.PositionID != "" {
+		return s.compensateSwapFailed(ctx, orderID, inst.PositionID, reason)
+	}
+	return s.compensateOrderFailed(ctx, orderID, reason)
+}
@@ -0,0 +1,73 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"market_order/application/usecases"
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
+)
+
+func TestAmendedOrderIsRequoted(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     string
+		wantStatus  order.OrderStatus
+		wantAmount  string
+		wantFailure string
+	}{
+		{name: "re-quoted at the market price", balance: "1000", wantStatus: order.OrderStatusPending, wantAmount: "0.003"},
+		{name: "balance does not cover the new amount", balance: "120", wantStatus: order.OrderStatusFailed, wantAmount: "0.002", wantFailure: "insufficient_balance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			h := newSagaHarness(t, fixedPrice{decimal.MustParse("50000")}, fixedBalance{decimal.MustParse(tt.balance)}, nil, nil)
+
+			// A quoted order waiting for its position: 100 USDT → 0.002 BTC
+			orderID := h.placeMarketOrder("user-1", "100", "USDT", "BTC")
+			if err := h.aggregateStore.MutateOrder(ctx, orderID, func(o *order.Order) error {
+				return o.QuotePrice(decimal.MustParse("50000"), decimal.MustParse("0.002"))
+			}); err != nil {
+				t.Fatalf("QuotePrice: %v", err)
+			}
+			h.eventStore.TakeOutbox()
+
+			amend := usecases.NewAmendOrderUseCase(h.aggregateStore)
+			if _, err := amend.Execute(ctx, orderID, "user-1", map[string]interface{}{"from_amount": "150"}); err != nil {
+				t.Fatalf("amend: %v", err)
+			}
+			outbox := h.eventStore.TakeOutbox()
+			if len(outbox) != 1 || outbox[0].EventType != "OrderUpdated" {
+				t.Fatalf("outbox after amendment = %v, want one OrderUpdated", outbox)
+			}
+
+			if err := h.saga.handleOrderUpdated(ctx, outbox[0].EventData); err != nil {
+				t.Fatalf("handleOrderUpdated: %v", err)
+			}
+
+			o := h.order(orderID)
+			if o.Status != tt.wantStatus || !o.ToAmount.Equal(decimal.MustParse(tt.wantAmount)) {
+				t.Errorf("order %s with to_amount %s, want %s with %s", o.Status, o.ToAmount, tt.wantStatus, tt.wantAmount)
+			}
+			if reason := h.failureReason(orderID); reason != tt.wantFailure {
+				t.Errorf("failure reason = %q, want %q", reason, tt.wantFailure)
+			}
+			if tt.wantFailure != "" {
+				return
+			}
+
+			events, err := h.eventStore.Load(ctx, orderID)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			var requote order.PriceQuoted
+			if last := events[len(events)-1]; last.EventType != "PriceQuoted" || json.Unmarshal(last.EventData, &requote) != nil || !requote.Requote {
+				t.Errorf("last event = %s %s, want PriceQuoted with requote", last.EventType, last.EventData)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package saga
+
+import (
+	"context"
+	"log/slog"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+)
+
+// ===============================================
+// STEP 0: OrderAccepted → Check Balance → BalanceCheckPassed / BalanceCheckFailed
+// ===============================================
+
+// checkBalance verifies the user can fund the order before pricing
+// Runs inside handleOrderAccepted, so a failed check never reaches pricing or position creation
+// Responsibilities:
+// - Get available balance from balance service
+// - Load order aggregate from EventStore
+// - Check balance (generates BalanceCheckPassed or BalanceCheckFailed event)
+// - Save events to EventStore
+// - Compensate (fail order) on insufficient balance
+//
+// Returns false when the order was failed and the saga must stop
+func (s *OrderSagaRefactored) checkBalance(ctx context.Context, logger *slog.Logger, orderID, userID, currency string) (bool, error) {
+	s.trackStep(ctx, orderID, repository.SagaStepCheckingBalance, "", repository.SagaStatusRunning)
+
+	// Balance service errors are transient - return error so the message is retried
+	balance, err := s.balanceService.GetAvailableBalance(ctx, userID, currency)
+	if err != nil {
+		logger.Error("Failed to get balance", logging.Err(err))
+		return false, err
+	}
+
+	// ✅ Generate BalanceCheckPassed / BalanceCheckFailed event on the current state
+	// (the current FromAmount: an amendment may have changed it since OrderAccepted)
+	var required decimal.Decimal
+	err = s.aggregateStore.MutateOrder(ctx, orderID, func(o *order.Order) error {
+		required = o.FromAmount
+		return o.CheckBalances(balance)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if balance.LessThan(required) {
+		logger.Warn("Insufficient balance",
+			"required", required, "available", balance, "currency", currency)
+
+		// No position exists yet - failing the order is the whole compensation
+		if err := s.compensateOrderFailed(ctx, orderID, "insufficient_balance"); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	logger.Info("Balance check passed", "available", balance, "currency", currency)
+	return true, nil
+}
@@ -3,12 +3,18 @@ package saga
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math/rand/v2"
+	"time"
 
 	"market_order/application/usecases"
 	"market_order/domain/order"
-	pkguuid "market_order/pkg/uuid"
+	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/logging"
+	"market_order/pkg/tracing"
 )
 
 // ===============================================
@@ -19,69 +25,186 @@ import (
 // Responsibilities:
 // - Extract position_id from event metadata
 // - Atomically complete order and update position
-// - Publish PositionLinkedToOrder event
 //
 // CRITICAL: This step must be idempotent and retryable
 // The swap has already been executed on blockchain, so we CANNOT compensate
 // If this fails, we must retry until success or alert for manual intervention
-func (s *OrderSagaRefactored) handleSwapExecuted(ctx context.Context, eventData []byte) error {
-	log.Println("📨 [STEP 4] Saga: Received SwapExecuted event")
-
+func (s *OrderSagaRefactored) handleSwapExecuted(ctx context.Context, eventData []byte) (err error) {
 	var evt order.SwapExecuted
-	if err := json.Unmarshal(eventData, &evt); err != nil {
+	if err = json.Unmarshal(eventData, &evt); err != nil {
 		return err
 	}
 
-	// Idempotency check
-	if processed, _ := s.processedEvents.IsProcessed(ctx, evt.EventID); processed {
-		log.Printf("⏭️  Event %s already processed, skipping", evt.EventID)
-		return nil
+	logger := s.stepLogger("complete", evt.AggregateID, evt.EventID)
+	logger.Info("Received SwapExecuted event")
+
+	// Idempotency: claim the event before any side effects
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step4")
+	if err != nil || !claimed {
+		return err
 	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
 
 	// Get position ID from event metadata (passed from STEP 3)
-	positionID, ok := evt.Metadata["position_id"].(string)
-	if !ok {
-		log.Printf("❌ Position ID not found in event metadata")
-		return fmt.Errorf("position_id not found in event metadata")
+	positionID, err := s.positionIDForSwap(ctx, logger, evt)
+	if err != nil {
+		return err
 	}
 
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepCompleting, positionID, repository.SagaStatusRunning)
+
 	// Complete order and update position atomically
-	log.Printf("✅ Completing order and updating position (atomic transaction)")
+	logger.Info("Completing order and updating position (atomic transaction)", "position_id", positionID)
 
-	if err := s.completeOrderUC.Execute(ctx, evt.AggregateID, positionID, usecases.SwapResult{
+	completeCtx, span := tracing.StartSpan(ctx, "complete.OrderAndPosition")
+	err = s.completeWithRetry(completeCtx, logger, evt.AggregateID, positionID, usecases.SwapResult{
 		TransactionHash: evt.TransactionHash,
 		FromAmount:      evt.FromAmount,
 		ToAmount:        evt.ToAmount,
 		ExecutedPrice:   evt.ExecutedPrice,
 		Fees:            evt.Fees,
 		Slippage:        evt.Slippage,
-	}); err != nil {
-		log.Printf("❌ Failed to complete order: %v", err)
+	})
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+
+	if err != nil {
+		logger.Error("Failed to complete order", logging.Err(err))
 		// CRITICAL: Do NOT compensate here! Swap already executed.
-		// Must retry or alert for manual intervention
-		return err
+		// Retried via redelivery, then handed over for manual intervention
+		return s.retryOrRequireReview(ctx, logger, evt, positionID, err)
+	}
+
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepDone, "", repository.SagaStatusCompleted)
+
+	logger.Info("Step completed: order fully completed")
+	return nil
+}
+
+// completeWithRetry runs the completion use case, retrying a failure up to CompletionRetries
+// times with jittered exponential backoff: transient failures (lock contention, concurrency
+// conflicts) are absorbed here instead of going straight back to the message bus
+// Safe to repeat: the use case generates no new events for an already completed order/position
+func (s *OrderSagaRefactored) completeWithRetry(ctx context.Context, logger *slog.Logger, orderID, positionID string, result usecases.SwapResult) error {
+	for attempt := 0; ; attempt++ {
+		err := s.completeOrderUC.Execute(ctx, orderID, positionID, result)
+		if err == nil || attempt >= s.CompletionRetries || ctx.Err() != nil {
+			return err
+		}
+
+		delay := jitter(s.CompletionRetryBackoff << attempt)
+		logger.Warn("Completion failed, retrying",
+			"retry", attempt+1, "max_retries", s.CompletionRetries, "delay", delay.String(), logging.Err(err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return err
+		}
 	}
+}
 
-	// Publish PositionLinkedToOrder event
-	linkedEvt := order.PositionLinkedToOrder{
-		BaseEvent: order.BaseEvent{
-			EventID:       pkguuid.New(),
-			AggregateID:   evt.AggregateID,
-			AggregateType: "Order",
-			EventType:     "PositionLinkedToOrder",
-			Version:       evt.Version + 1,
-			Timestamp:     evt.Timestamp,
-		},
-		PositionID: positionID,
-		OrderID:    evt.AggregateID,
+// jitter spreads d over [d/2, 3d/2) so concurrent retries do not collide again
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
 	}
+	return d/2 + rand.N(d)
+}
 
-	eventBytes, _ := json.Marshal(linkedEvt)
-	s.messageBus.Publish("PositionLinkedToOrder", eventBytes)
+// positionIDForSwap returns the position of the swap: from the event metadata,
+// else from saga_instances (recorded by STEP 2)
+// Without either no redelivery can complete the order: it goes to manual review
+// and ErrNonRetryable dead-letters the message instead of requeueing it forever
+func (s *OrderSagaRefactored) positionIDForSwap(ctx context.Context, logger *slog.Logger, evt order.SwapExecuted) (string, error) {
+	if positionID, ok := evt.Metadata["position_id"].(string); ok && positionID != "" {
+		return positionID, nil
+	}
 
-	// Mark as processed
-	s.processedEvents.MarkAsProcessed(ctx, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step4")
+	inst, err := s.sagaRepo.Get(ctx, evt.AggregateID)
+	if err != nil && !errors.Is(err, repository.ErrSagaNotFound) {
+		return "", err
+	}
+	if inst != nil && inst.PositionID != "" {
+		logger.Warn("Position ID missing in event metadata, recovered from saga state", "position_id", inst.PositionID)
+		return inst.PositionID, nil
+	}
+
+	cause := errors.New("position_id not found in event metadata nor saga state")
+	logger.Error("Swap executed without a known position, order needs manual review", logging.Err(cause))
 
-	log.Printf("🎉 ✅ [STEP 4] Completed: Order %s fully completed!", evt.AggregateID)
+	err = s.manualReviews.Add(ctx, repository.ManualReview{
+		OrderID:         evt.AggregateID,
+		Reason:          cause.Error(),
+		Attempts:        1,
+		TransactionHash: evt.TransactionHash,
+		FromAmount:      evt.FromAmount,
+		ToAmount:        evt.ToAmount,
+		ExecutedPrice:   evt.ExecutedPrice,
+		Fees:            evt.Fees,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.recordNeedsManualReview(ctx, evt, cause, 1); err != nil {
+		logger.Warn("Failed to record OrderNeedsManualReview event", logging.Err(err))
+	}
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepCompleting, "", repository.SagaStatusNeedsReview)
+
+	return "", fmt.Errorf("%w: %w", messaging.ErrNonRetryable, cause)
+}
+
+// retryOrRequireReview counts a failed completion attempt
+// Below MaxCompletionAttempts the error is returned so the message bus redelivers the event;
+// after that the order is put into the manual_review queue and the event is acked
+func (s *OrderSagaRefactored) retryOrRequireReview(ctx context.Context, logger *slog.Logger, evt order.SwapExecuted, positionID string, cause error) error {
+	attempts, err := s.sagaRepo.IncrementAttempts(ctx, evt.AggregateID)
+	if err != nil {
+		logger.Warn("Failed to count completion attempt", logging.Err(err))
+		return cause
+	}
+
+	if attempts < s.MaxCompletionAttempts {
+		logger.Warn("Completion will be retried", "attempt", attempts, "max_attempts", s.MaxCompletionAttempts)
+		return cause
+	}
+
+	logger.Error("Completion attempts exhausted, order needs manual review", "attempts", attempts, logging.Err(cause))
+
+	// The queue entry is what the operator works from: keep retrying until it is stored
+	err = s.manualReviews.Add(ctx, repository.ManualReview{
+		OrderID:         evt.AggregateID,
+		PositionID:      positionID,
+		Reason:          cause.Error(),
+		Attempts:        attempts,
+		TransactionHash: evt.TransactionHash,
+		FromAmount:      evt.FromAmount,
+		ToAmount:        evt.ToAmount,
+		ExecutedPrice:   evt.ExecutedPrice,
+		Fees:            evt.Fees,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Best effort: the order aggregate itself may be what keeps failing
+	if err := s.recordNeedsManualReview(ctx, evt, cause, attempts); err != nil {
+		logger.Warn("Failed to record OrderNeedsManualReview event", logging.Err(err))
+	}
+
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepCompleting, positionID, repository.SagaStatusNeedsReview)
+
+	// Stop requeueing: the order now waits for an operator
 	return nil
 }
+
+// recordNeedsManualReview emits OrderNeedsManualReview on the order stream
+func (s *OrderSagaRefactored) recordNeedsManualReview(ctx context.Context, evt order.SwapExecuted, cause error, attempts int) error {
+	// Generate OrderNeedsManualReview event (published via Outbox)
+	return s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(o *order.Order) error {
+		return o.RequireManualReview(cause.Error(), attempts, evt.TransactionHash)
+	})
+}
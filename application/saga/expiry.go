@@ -0,0 +1,43 @@
+package saga
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"market_order/domain/order"
+)
+
+// ===============================================
+// MARKET ORDER TTL: expired before execution → OrderExpired → OrderFailed ("expired")
+// ===============================================
+
+// expireStaleOrder expires a pending market order whose ExpiresAt has passed
+// Checked before pricing (STEP 1) and before the swap (STEP 3): a quote taken before
+// a price feed outage must not be executed once the feed recovers
+//
+// Returns true when the order expired; the caller stops the step.
+// positionID (STEP 3) is closed by the compensation
+func (s *OrderSagaRefactored) expireStaleOrder(ctx context.Context, logger *slog.Logger, orderID, positionID string) (bool, error) {
+	var (
+		expired   bool
+		expiresAt time.Time
+	)
+	err := s.aggregateStore.MutateOrder(ctx, orderID, func(o *order.Order) error {
+		expired = o.Status == order.OrderStatusPending && o.IsExpired(time.Now())
+		if !expired {
+			return nil
+		}
+		expiresAt = o.ExpiresAt
+		return o.ExpireOrder() // No-op on redelivery: OrderExpired already recorded
+	})
+	if err != nil || !expired {
+		return false, err
+	}
+
+	logger.Warn("Market order expired before execution", "expires_at", expiresAt.Format(time.RFC3339))
+	if positionID != "" {
+		return true, s.compensateSwapFailed(ctx, orderID, positionID, "expired")
+	}
+	return true, s.compensateOrderFailed(ctx, orderID, "expired")
+}
@@ -0,0 +1,88 @@
+package saga
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// slowPrice quotes after a delay, like a price feed that is recovering
+type slowPrice struct {
+	fixedPrice
+	delay time.Duration
+}
+
+func (p slowPrice) GetMarketPrice(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	time.Sleep(p.delay)
+	return p.price, nil
+}
+
+func TestExpiredMarketOrderIsNotExecuted(t *testing.T) {
+	const ttl = 20 * time.Millisecond
+
+	tests := []struct {
+		name          string
+		priceDelay    time.Duration
+		waitBeforeRun time.Duration
+		wantPosition  bool // Expired after STEP 2 created the position
+	}{
+		{name: "expired before pricing", waitBeforeRun: 2 * ttl},
+		{name: "expired before the swap", priceDelay: 2 * ttl, wantPosition: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var swaps int
+			worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+				swaps++
+				return &SwapResponse{TransactionHash: "0xabc", ToAmount: decimal.MustParse("0.05"), ExecutedPrice: decimal.MustParse("0.0005")}, nil
+			})
+			prices := slowPrice{fixedPrice{decimal.MustParse("0.0005")}, tt.priceDelay}
+			h := newSagaHarness(t, prices, fixedBalance{decimal.MustParse("1000")}, worker, nil)
+
+			o := order.NewOrder()
+			orderID := pkguuid.New()
+			if err := o.AcceptOrder(orderID, "user-1", decimal.MustParse("100"), "USDT", "ETH", "market", decimal.Zero, 0, "", time.Now().Add(ttl), nil); err != nil {
+				t.Fatalf("AcceptOrder: %v", err)
+			}
+			if err := h.aggregateStore.SaveOrderAggregate(context.Background(), o); err != nil {
+				t.Fatalf("SaveOrderAggregate: %v", err)
+			}
+
+			time.Sleep(tt.waitBeforeRun)
+			if err := h.run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			o = h.order(orderID)
+			if o.Status != order.OrderStatusFailed || h.failureReason(orderID) != "expired" {
+				t.Errorf("order %s (%q), want failed (expired)", o.Status, h.failureReason(orderID))
+			}
+			if types := h.eventTypes(orderID); !slices.Contains(types, "OrderExpired") {
+				t.Errorf("order events %v: missing OrderExpired", types)
+			}
+			if swaps != 0 {
+				t.Errorf("%d swaps executed for an expired order", swaps)
+			}
+
+			if (o.PositionID != "") != tt.wantPosition {
+				t.Fatalf("position %q, want one: %v", o.PositionID, tt.wantPosition)
+			}
+			if tt.wantPosition {
+				p, err := h.aggregateStore.LoadPositionAggregate(context.Background(), o.PositionID)
+				if err != nil {
+					t.Fatalf("LoadPositionAggregate: %v", err)
+				}
+				if p.Status != position.PositionStatusClosed {
+					t.Errorf("position status = %s, want closed", p.Status)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+)
+
+// ===============================================
+// STEP 0b: OrderAccepted → Check Exposure → OrderFailed ("exposure_limit_exceeded")
+// ===============================================
+
+// DefaultExposureTier - tier of users without one in ExposureLimits.UserTiers
+const DefaultExposureTier = "default"
+
+// ExposureReader sums a user's open positions valued in a currency (position projection)
+type ExposureReader interface {
+	OpenExposure(ctx context.Context, userID, currency string) (decimal.Decimal, error)
+}
+
+// ExposureLimits caps a user's total open exposure per currency, by user tier
+//
+// Exposure is the value of the user's open positions in the currency the orders spent
+// (position_projection, eventually consistent) plus the new order's FromAmount.
+// A currency without a limit in the user's tier is not capped
+type ExposureLimits struct {
+	Reader ExposureReader
+	// Limits - tier → currency → max open exposure (EXPOSURE_LIMITS)
+	Limits map[string]map[string]decimal.Decimal
+	// UserTiers - user_id → tier (USER_TIERS); other users are in DefaultExposureTier
+	UserTiers map[string]string
+}
+
+// Limit returns the user's exposure limit in currency; false when it is not capped
+func (l *ExposureLimits) Limit(userID, currency string) (decimal.Decimal, bool) {
+	tier, ok := l.UserTiers[userID]
+	if !ok {
+		tier = DefaultExposureTier
+	}
+	limit, ok := l.Limits[tier][currency]
+	return limit, ok
+}
+
+// checkExposure fails an order that would take the user's open exposure over the limit
+// Runs after the balance check, before pricing or order book placement
+//
+// Returns false when the order was failed and the saga must stop
+func (s *OrderSagaRefactored) checkExposure(ctx context.Context, logger *slog.Logger, orderID, userID, currency string) (bool, error) {
+	if s.ExposureLimits == nil {
+		return true, nil
+	}
+	limit, ok := s.ExposureLimits.Limit(userID, currency)
+	if !ok {
+		return true, nil
+	}
+
+	// The current FromAmount: an amendment may have changed it since OrderAccepted
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+	amount := o.FromAmount
+
+	// Projection errors are transient - return error so the message is retried
+	exposure, err := s.ExposureLimits.Reader.OpenExposure(ctx, userID, currency)
+	if err != nil {
+		logger.Error("Failed to get open exposure", logging.Err(err))
+		return false, err
+	}
+
+	if total := exposure.Add(amount); total.GreaterThan(limit) {
+		logger.Warn("Exposure limit exceeded",
+			"open_exposure", exposure, "amount", amount, "limit", limit, "currency", currency)
+
+		// No position exists yet - failing the order is the whole compensation
+		if err := s.compensateOrderFailed(ctx, orderID, "exposure_limit_exceeded"); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+
+	return true, nil
+}
+
+// ParseExposureLimits parses EXPOSURE_LIMITS: "default=USDT:100000|BTC:2,vip=USDT:1000000"
+func ParseExposureLimits(s string) (map[string]map[string]decimal.Decimal, error) {
+	limits := make(map[string]map[string]decimal.Decimal)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		tier, list, ok := strings.Cut(entry, "=")
+		if !ok || tier == "" {
+			return nil, fmt.Errorf("invalid exposure limit entry %q: want tier=currency:limit|...", entry)
+		}
+
+		if limits[tier] == nil {
+			limits[tier] = make(map[string]decimal.Decimal)
+		}
+		for _, item := range strings.Split(list, "|") {
+			currency, value, ok := strings.Cut(item, ":")
+			if !ok || currency == "" {
+				return nil, fmt.Errorf("invalid exposure limit %q: want currency:limit", item)
+			}
+			limit, err := decimal.Parse(value)
+			if err != nil || !limit.IsPositive() {
+				return nil, fmt.Errorf("invalid exposure limit %q: must be a positive amount", item)
+			}
+			limits[tier][strings.ToUpper(currency)] = limit
+		}
+	}
+	return limits, nil
+}
+
+// ParseUserTiers parses USER_TIERS: "user-1=vip,user-2=pro"
+func ParseUserTiers(s string) (map[string]string, error) {
+	tiers := make(map[string]string)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		userID, tier, ok := strings.Cut(entry, "=")
+		if !ok || userID == "" || tier == "" {
+			return nil, fmt.Errorf("invalid tier entry %q: want user_id=tier", entry)
+		}
+		tiers[userID] = tier
+	}
+	return tiers, nil
+}
@@ -0,0 +1,69 @@
+package saga
+
+import (
+	"context"
+	"testing"
+
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
+)
+
+// exposureByUser serves open exposure from a map (user_id → currency → amount)
+type exposureByUser map[string]map[string]decimal.Decimal
+
+func (e exposureByUser) OpenExposure(ctx context.Context, userID, currency string) (decimal.Decimal, error) {
+	return e[userID][currency], nil
+}
+
+func TestExposureLimitFailsOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		userID     string
+		fromAmount string
+		wantStatus order.OrderStatus
+		wantReason string
+	}{
+		{name: "under limit", userID: "user-1", fromAmount: "300", wantStatus: order.OrderStatusCompleted},
+		{name: "exactly at limit", userID: "user-1", fromAmount: "400", wantStatus: order.OrderStatusCompleted},
+		{name: "over limit", userID: "user-1", fromAmount: "401", wantStatus: order.OrderStatusFailed, wantReason: "exposure_limit_exceeded"},
+		{name: "higher tier", userID: "vip-1", fromAmount: "401", wantStatus: order.OrderStatusCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var swaps int
+			worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+				swaps++
+				return &SwapResponse{TransactionHash: "0xabc", ToAmount: req.FromAmount.Mul(decimal.MustParse("0.0005")), ExecutedPrice: decimal.MustParse("0.0005")}, nil
+			})
+			h := newSagaHarness(t, fixedPrice{decimal.MustParse("0.0005")}, fixedBalance{decimal.MustParse("10000")}, worker, func(s *OrderSagaRefactored) {
+				s.ExposureLimits = &ExposureLimits{
+					Reader: exposureByUser{
+						"user-1": {"USDT": decimal.MustParse("600")},
+						"vip-1":  {"USDT": decimal.MustParse("600")},
+					},
+					Limits: map[string]map[string]decimal.Decimal{
+						DefaultExposureTier: {"USDT": decimal.MustParse("1000")},
+						"vip":               {"USDT": decimal.MustParse("5000")},
+					},
+					UserTiers: map[string]string{"vip-1": "vip"},
+				}
+			})
+
+			orderID := h.placeMarketOrder(tt.userID, tt.fromAmount, "USDT", "ETH")
+			if err := h.run(); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+
+			if o := h.order(orderID); o.Status != tt.wantStatus {
+				t.Fatalf("order status = %s, want %s", o.Status, tt.wantStatus)
+			}
+			if reason := h.failureReason(orderID); reason != tt.wantReason {
+				t.Errorf("failure reason = %q, want %q", reason, tt.wantReason)
+			}
+			if tt.wantReason != "" && swaps != 0 {
+				t.Errorf("ExecuteSwap called %d times for a rejected order", swaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/application/usecases"
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/idempotency"
+	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// sagaHarness wires OrderSagaRefactored to in-memory stores and bus
+// run() publishes the outbox and delivers until no message is left, so a whole order runs synchronously
+type sagaHarness struct {
+	t               *testing.T
+	eventStore      *eventstore.MemoryEventStore
+	aggregateStore  *aggregates.AggregateStore
+	bus             *messaging.MemoryBus
+	processedEvents *idempotency.MemoryProcessedEventStore
+	sagas           *repository.MemorySagaStore
+	manualReviews   *repository.MemoryManualReviewStore
+	saga            *OrderSagaRefactored
+}
+
+// newSagaHarness builds the harness; configure changes saga settings before it starts
+func newSagaHarness(t *testing.T, prices PriceService, balances BalanceService, worker TradeWorker, configure func(*OrderSagaRefactored)) *sagaHarness {
+	t.Helper()
+
+	h := &sagaHarness{
+		t:               t,
+		eventStore:      eventstore.NewMemoryEventStore(),
+		bus:             messaging.NewMemoryBus(),
+		processedEvents: idempotency.NewMemoryProcessedEventStore(),
+		sagas:           repository.NewMemorySagaStore(),
+		manualReviews:   repository.NewMemoryManualReviewStore(),
+	}
+	h.aggregateStore = aggregates.NewAggregateStore(h.eventStore)
+	h.saga = NewOrderSagaRefactored(
+		h.aggregateStore, h.processedEvents, h.sagas, h.manualReviews,
+		usecases.NewCompleteOrderAndUpdatePositionUseCase(h.aggregateStore),
+		h.bus, prices, balances, worker,
+	)
+	h.saga.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	h.saga.RecoveryStuckAfter = time.Hour
+	if configure != nil {
+		configure(h.saga)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- h.saga.Start(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		h.bus.Shutdown(context.Background())
+		if err := <-done; err != nil {
+			t.Errorf("saga Start: %v", err)
+		}
+	})
+
+	// Start subscribes before it blocks
+	deadline := time.Now().Add(5 * time.Second)
+	for h.bus.Subscriptions() < sagaSubscriptions {
+		if time.Now().After(deadline) {
+			t.Fatal("saga did not subscribe")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return h
+}
+
+// sagaSubscriptions - subscriptions made by OrderSagaRefactored.Start
+const sagaSubscriptions = 8
+
+// run publishes the outbox and delivers messages until both are empty
+// Returns the handler errors of all deliveries
+func (h *sagaHarness) run() error {
+	h.t.Helper()
+
+	var errs []error
+	for {
+		for _, e := range h.eventStore.TakeOutbox() {
+			if err := h.bus.PublishEvent(e.EventType, e.EventID, e.EventData); err != nil {
+				h.t.Fatalf("publish %s: %v", e.EventType, err)
+			}
+		}
+		if h.bus.Pending() == 0 {
+			if len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		}
+		if err := h.bus.Deliver(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+}
+
+// placeMarketOrder accepts a market order, as CreateOrderUseCase does
+func (h *sagaHarness) placeMarketOrder(userID, fromAmount, from, to string) string {
+	h.t.Helper()
+
+	o := order.NewOrder()
+	orderID := pkguuid.New()
+	if err := o.AcceptOrder(orderID, userID, decimal.MustParse(fromAmount), from, to, "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+		h.t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := h.aggregateStore.SaveOrderAggregate(context.Background(), o); err != nil {
+		h.t.Fatalf("SaveOrderAggregate: %v", err)
+	}
+	return orderID
+}
+
+// quoteAndLinkPosition brings a placed order to where STEP 2 leaves it: quoted, with a linked
+// position. The outbox is drained; the returned PositionCreatedForOrder is for the caller to deliver
+func (h *sagaHarness) quoteAndLinkPosition(orderID, price, toAmount string) []byte {
+	h.t.Helper()
+
+	o := h.order(orderID)
+	p := position.NewPosition()
+	positionID := pkguuid.New()
+	err := errors.Join(
+		o.QuotePrice(decimal.MustParse(price), decimal.MustParse(toAmount)),
+		p.CreatePosition(positionID, o.UserID, o.FromCurrency),
+		o.LinkPosition(positionID),
+		h.aggregateStore.SaveInTx(context.Background(), aggregates.OrderBatch(o), aggregates.PositionBatch(p)),
+	)
+	if err != nil {
+		h.t.Fatalf("quote and link position: %v", err)
+	}
+	h.eventStore.TakeOutbox()
+
+	positionCreated, err := json.Marshal(order.PositionCreatedForOrder{
+		BaseEvent: order.BaseEvent{
+			EventID:       pkguuid.New(),
+			AggregateID:   orderID,
+			AggregateType: "Order",
+			EventType:     "PositionCreatedForOrder",
+			Version:       o.Version,
+			Timestamp:     time.Now(),
+		},
+		PositionID: positionID,
+		UserID:     o.UserID,
+	})
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return positionCreated
+}
+
+// order loads the current state of an order
+func (h *sagaHarness) order(orderID string) *order.Order {
+	h.t.Helper()
+
+	o, err := h.aggregateStore.LoadOrderAggregate(context.Background(), orderID)
+	if err != nil {
+		h.t.Fatalf("LoadOrderAggregate: %v", err)
+	}
+	return o
+}
+
+// eventTypes returns the event types of an aggregate's stream, in order
+func (h *sagaHarness) eventTypes(aggregateID string) []string {
+	h.t.Helper()
+
+	events, err := h.eventStore.Load(context.Background(), aggregateID)
+	if err != nil {
+		h.t.Fatalf("Load: %v", err)
+	}
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.EventType)
+	}
+	return types
+}
+
+// failureReason returns the reason of the order's OrderFailed event, "" if it did not fail
+func (h *sagaHarness) failureReason(orderID string) string {
+	h.t.Helper()
+
+	events, err := h.eventStore.Load(context.Background(), orderID)
+	if err != nil {
+		h.t.Fatalf("Load: %v", err)
+	}
+	for _, e := range events {
+		if e.EventType != "OrderFailed" {
+			continue
+		}
+		var failed order.OrderFailed
+		if err := json.Unmarshal(e.EventData, &failed); err != nil {
+			h.t.Fatalf("decode OrderFailed: %v", err)
+		}
+		return failed.Reason
+	}
+	return ""
+}
+
+// fixedPrice quotes every pair at one price
+type fixedPrice struct{ price decimal.Decimal }
+
+func (p fixedPrice) GetMarketPrice(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	return p.price, nil
+}
+
+// fixedBalance gives every user the same available balance
+type fixedBalance struct{ balance decimal.Decimal }
+
+func (b fixedBalance) GetAvailableBalance(ctx context.Context, userID, currency string) (decimal.Decimal, error) {
+	return b.balance, nil
+}
+
+// tradeWorkerFunc adapts a function to TradeWorker
+type tradeWorkerFunc func(ctx context.Context, req SwapRequest) (*SwapResponse, error)
+
+func (f tradeWorkerFunc) ExecuteSwap(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+	return f(ctx, req)
+}
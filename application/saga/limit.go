@@ -0,0 +1,320 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/domain/orderbook"
+	"market_order/domain/position"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// quoteCurrencies - currencies that act as the quote side of a trading pair
+// Spending a quote currency is a buy, spending anything else is a sell
+var quoteCurrencies = map[string]bool{
+	"USDT": true,
+	"USDC": true,
+	"USD":  true,
+}
+
+// ===============================================
+// LIMIT STEP 1: OrderAccepted (limit) → Place in OrderBook → OrdersMatched
+// ===============================================
+
+// placeLimitOrder places a limit order into the order book of its trading pair
+// Responsibilities:
+// - Record the limit price on the order (generates LimitPriceSet event)
+// - Mark the order as placed (generates OrderPlacedInBook event)
+// - Add the order to the OrderBook and run matching (generates OrdersMatched events)
+// - Expire the unmatched remainder of an IOC order (generates LimitOrderExpired event)
+// - Save events to EventStore
+//
+// No market price is quoted: the order waits in the book until it is matched
+func (s *OrderSagaRefactored) placeLimitOrder(ctx context.Context, logger *slog.Logger, evt order.OrderAccepted) error {
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepInOrderBook, "", repository.SagaStatusRunning)
+
+	pair, side, amount := limitOrderPlacement(evt)
+	orderBookID := s.OrderBooks.BookID(pair)
+	logger.Info("Placing limit order in order book",
+		"side", side, "trading_pair", pair, "amount", amount, "limit_price", evt.LimitPrice)
+
+	// ✅ Load aggregate from EventStore (source of truth!)
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
+	if err != nil {
+		return err
+	}
+
+	if err := o.SetLimitPrice(evt.LimitPrice); err != nil {
+		return s.compensateOrderFailed(ctx, evt.AggregateID, "invalid_limit_price")
+	}
+
+	if err := o.PlaceInOrderBook(orderBookID); err != nil {
+		return err
+	}
+
+	if err := s.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
+		return err
+	}
+
+	// Order book per trading pair is created lazily on the first order
+	ob, err := s.OrderBooks.LoadOrCreate(ctx, pair)
+	if err != nil {
+		return err
+	}
+
+	var expiresAt time.Time
+	if evt.ExpiresAt != nil {
+		expiresAt = *evt.ExpiresAt
+	}
+
+	if err := ob.AddLimitOrder(evt.AggregateID, evt.UserID, evt.LimitPrice, amount, side, evt.TimeInForce, expiresAt); err != nil {
+		return s.compensateOrderFailed(ctx, evt.AggregateID, "order_book_rejected")
+	}
+
+	// Each fill becomes an OrdersMatched event → handled in handleOrdersMatched
+	// An IOC order that did not fully match is cancelled in the book right away
+	if err := ob.MatchOrders(); err != nil {
+		return err
+	}
+	iocUnfilled := cancelledRemainder(ob, evt.AggregateID)
+
+	if err := s.OrderBooks.Save(ctx, ob); err != nil {
+		return err
+	}
+
+	if iocUnfilled.IsPositive() {
+		err := s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(o *order.Order) error {
+			return o.ExpireLimitOrder(orderbook.SpentAmount(side, iocUnfilled, o.LimitPrice))
+		})
+		if err != nil {
+			return err
+		}
+		logger.Info("IOC remainder cancelled", "unfilled", iocUnfilled)
+	}
+
+	logger.Info("Step completed: order placed in order book", "order_book_id", orderBookID)
+	return nil
+}
+
+// limitOrderPlacement derives trading pair, side and base amount of a limit order
+// Buy:  spend quote (USDT → BTC), amount = FromAmount / LimitPrice
+// Sell: spend base  (BTC → USDT), amount = FromAmount
+func limitOrderPlacement(evt order.OrderAccepted) (pair, side string, amount decimal.Decimal) {
+	if quoteCurrencies[evt.FromCurrency] {
+		return evt.ToCurrency + "/" + evt.FromCurrency, "buy", evt.FromAmount.Div(evt.LimitPrice)
+	}
+	return evt.FromCurrency + "/" + evt.ToCurrency, "sell", evt.FromAmount
+}
+
+// cancelledRemainder returns the unfilled amount of an order cancelled by the pending book changes
+func cancelledRemainder(ob *orderbook.OrderBook, orderID string) decimal.Decimal {
+	for _, change := range ob.GetChanges() {
+		if cancelled, ok := change.(orderbook.LimitOrderCancelled); ok && cancelled.OrderID == orderID {
+			return cancelled.RemainingAmount
+		}
+	}
+	return decimal.Zero
+}
+
+// ===============================================
+// LIMIT STEP 2: OrdersMatched → PartiallyFill / FillComplete
+// ===============================================
+
+// handleOrdersMatched fills both orders of a match
+// Responsibilities:
+// - Move each order to executing on its first fill (generates SwapExecuting event)
+// - Record the fill (generates OrderPartiallyFilled event)
+// - Add the fill to the order's position (generates PositionCreated on the first fill, PositionUpdated)
+// - Complete the order once fills cover its FromAmount (generates OrderCompleted event)
+// - Flag an over-fill for manual review (generates OrderNeedsManualReview event)
+func (s *OrderSagaRefactored) handleOrdersMatched(ctx context.Context, eventData []byte) (err error) {
+	var evt orderbook.OrdersMatched
+	if err = json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	logger := s.Logger.With(logging.SagaStep("match"), logging.EventID(evt.EventID), "order_book_id", evt.AggregateID)
+	logger.Info("Received OrdersMatched event")
+
+	// Idempotency: claim the event before any side effects
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-match")
+	if err != nil || !claimed {
+		return err
+	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
+
+	if err := s.fillMatchedOrder(ctx, logger, evt, evt.BuyOrderID, "buy"); err != nil {
+		return err
+	}
+
+	if err := s.fillMatchedOrder(ctx, logger, evt, evt.SellOrderID, "sell"); err != nil {
+		return err
+	}
+
+	logger.Info("Step completed: match processed", "matched_amount", evt.MatchedAmount, "matched_price", evt.MatchedPrice)
+	return nil
+}
+
+// fillMatchedOrder applies one side of a match to its order
+// Each side has its own idempotency key so a retry after a partial failure
+// does not fill the first order twice
+// Whether the order is done is decided by the aggregate (RemainingToFill), not the book
+func (s *OrderSagaRefactored) fillMatchedOrder(ctx context.Context, logger *slog.Logger, evt orderbook.OrdersMatched, orderID, side string) (err error) {
+	fillKey := pkguuid.NewFromName(evt.EventID + ":" + orderID)
+	claimed, err := s.processedEvents.ClaimEvent(ctx, fillKey, orderID, evt.EventType, "order-saga-match")
+	if err != nil || !claimed {
+		return err
+	}
+	defer s.releaseOnError(ctx, fillKey, &err)
+
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to load %s order %s: %w", side, orderID, err)
+	}
+
+	// First fill starts the execution
+	if o.Status == order.OrderStatusPending {
+		if err := o.StartSwapExecution(fillKey); err != nil {
+			return err
+		}
+	}
+
+	spentAmount, filledAmount := matchedFillAmounts(evt, side, o.LimitPrice)
+	matchedPrice := evt.MatchedPrice
+	txHash := "match-" + evt.EventID
+
+	err = o.PartiallyFill(spentAmount, filledAmount, matchedPrice, txHash)
+	if errors.Is(err, order.ErrOverfill) {
+		// The book matched more than the order holds - retrying won't help
+		logger.Error("Limit order over-filled, flagging for manual review", logging.OrderID(orderID), logging.Err(err))
+		if err = o.RequireManualReview(err.Error(), 0, txHash); err != nil {
+			return err
+		}
+		if err = s.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
+			return err
+		}
+		s.trackStep(ctx, orderID, repository.SagaStepInOrderBook, "", repository.SagaStatusNeedsReview)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Each fill enters the position's weighted average entry price at its own price
+	p, err := s.positionForFill(ctx, logger, o)
+	if err != nil {
+		return err
+	}
+	if err := p.AddFill(orderID, filledAmount, matchedPrice, spentAmount); err != nil {
+		return fmt.Errorf("failed to add fill to position %s: %w", o.PositionID, err)
+	}
+
+	remaining := o.RemainingToFill()
+	if remaining.IsZero() {
+		if err := o.FillComplete(); err != nil {
+			return err
+		}
+	}
+
+	// Fill and position update in ONE transaction: neither is counted without the other
+	if err := s.aggregateStore.SaveInTx(ctx, aggregates.OrderBatch(o), aggregates.PositionBatch(p)); err != nil {
+		return err
+	}
+	o.ClearChanges()
+	p.ClearChanges()
+
+	if remaining.IsZero() {
+		s.trackStep(ctx, orderID, repository.SagaStepDone, o.PositionID, repository.SagaStatusCompleted)
+		logger.Info("Limit order fully filled", logging.OrderID(orderID))
+	} else {
+		s.trackStep(ctx, orderID, repository.SagaStepInOrderBook, o.PositionID, repository.SagaStatusRunning)
+		logger.Info("Limit order partially filled", logging.OrderID(orderID), "remaining", remaining)
+	}
+
+	return nil
+}
+
+// positionForFill returns the order's position, creating and linking one on the first fill
+// Limit orders skip the market STEP 2, so their position appears with the first match
+// The new position and the link are saved together with the fill
+func (s *OrderSagaRefactored) positionForFill(ctx context.Context, logger *slog.Logger, o *order.Order) (*position.Position, error) {
+	if o.PositionID != "" {
+		p, err := s.aggregateStore.LoadPositionAggregate(ctx, o.PositionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load position %s: %w", o.PositionID, err)
+		}
+		return p, nil
+	}
+
+	positionID := pkguuid.New()
+	p := position.NewPosition()
+	if err := p.CreatePosition(positionID, o.UserID, o.FromCurrency); err != nil {
+		return nil, err
+	}
+	if err := o.LinkPosition(positionID); err != nil {
+		return nil, err
+	}
+
+	logger.Info("Position created for limit order", logging.OrderID(o.ID), "position_id", positionID)
+	return p, nil
+}
+
+// matchedFillAmounts converts a match into what one side spent and received
+// Buyer spends quote reserved at its limit price (price improvement is not refunded here)
+// and receives base; seller spends base and receives quote
+func matchedFillAmounts(evt orderbook.OrdersMatched, side string, limitPrice decimal.Decimal) (spent, filled decimal.Decimal) {
+	if side == "buy" {
+		return evt.MatchedAmount.Mul(limitPrice), evt.MatchedAmount
+	}
+	return evt.MatchedAmount, evt.MatchedAmount.Mul(evt.MatchedPrice)
+}
+
+// ===============================================
+// LIMIT STEP 3: LimitOrderExpired → finish saga tracking
+// ===============================================
+
+// handleLimitOrderExpired closes the saga of an order whose remainder left the book (GTD/IOC)
+// The order itself was already updated by whoever expired it (reaper or IOC matching);
+// with matched fills still in flight the saga stays in the book step until the last fill
+func (s *OrderSagaRefactored) handleLimitOrderExpired(ctx context.Context, eventData []byte) (err error) {
+	var evt order.LimitOrderExpired
+	if err = json.Unmarshal(eventData, &evt); err != nil {
+		return err
+	}
+
+	logger := s.stepLogger("expire", evt.AggregateID, evt.EventID)
+	logger.Info("Received LimitOrderExpired event", "time_in_force", evt.TimeInForce, "expired_amount", evt.ExpiredAmount)
+
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-expire")
+	if err != nil || !claimed {
+		return err
+	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
+
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
+	if err != nil {
+		return err
+	}
+
+	switch o.Status {
+	case order.OrderStatusFailed:
+		s.trackStep(ctx, evt.AggregateID, repository.SagaStepDone, "", repository.SagaStatusFailed)
+		logger.Info("Step completed: limit order expired unfilled")
+	case order.OrderStatusCompleted:
+		s.trackStep(ctx, evt.AggregateID, repository.SagaStepDone, "", repository.SagaStatusCompleted)
+		logger.Info("Step completed: limit order expired after partial fills")
+	default:
+		logger.Info("Limit order expired, waiting for matched fills", "remaining", o.RemainingToFill())
+	}
+
+	return nil
+}
@@ -2,12 +2,45 @@ package saga
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"time"
 
 	"market_order/application/aggregates"
 	"market_order/application/usecases"
+	"market_order/domain/order"
+	"market_order/domain/position"
 	"market_order/infrastructure/idempotency"
 	"market_order/infrastructure/messaging"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/circuitbreaker"
+	"market_order/pkg/decimal"
+	"market_order/pkg/logging"
+	"market_order/pkg/metrics"
+	"market_order/pkg/tracing"
+)
+
+// Default saga step timeouts
+const (
+	DefaultPriceTimeout = 5 * time.Second
+	DefaultSwapTimeout  = 30 * time.Second
+
+	// DefaultRecoveryStuckAfter - running sagas idle this long are resumed on startup
+	DefaultRecoveryStuckAfter = 1 * time.Minute
+
+	// DefaultMaxCompletionAttempts - failed STEP 4 attempts before the order goes to manual review
+	// Kept below messaging.DefaultMaxAttempts so the event is not dead-lettered first
+	DefaultMaxCompletionAttempts = 3
+
+	// DefaultCompletionRetries - in-handler STEP 4 retries before the event goes back to the message bus
+	DefaultCompletionRetries = 3
+	// DefaultCompletionRetryBackoff - first in-handler retry delay, doubled per retry and jittered
+	DefaultCompletionRetryBackoff = 100 * time.Millisecond
+
+	// DefaultSwapConcurrency - swaps executed in parallel by one saga instance
+	DefaultSwapConcurrency = 4
+
+	// DefaultQuoteMaxAge - a market quote older than this is refreshed before the swap
+	DefaultQuoteMaxAge = 30 * time.Second
 )
 
 // OrderSagaRefactored orchestrates order execution with granular steps
@@ -20,69 +53,156 @@ import (
 // - Uses EventStore as source of truth (NOT repositories!)
 //
 // Flow:
-// OrderAccepted → [accept.go] → PriceQuoted
+// OrderAccepted → [balance.go] → BalanceCheckPassed (same handler, before pricing)
+//
+//	→ [exposure.go] open exposure within the user's limit (same handler)
 //
-//	→ [price.go] → PositionCreatedForOrder
+//	→ [accept.go] → PriceQuoted
+//	→ [price.go] → PositionLinkedToOrder, PositionCreatedForOrder
 //	→ [swap.go] → SwapExecuted
-//	→ [complete.go] → PositionLinkedToOrder
+//	→ [complete.go] → OrderCompleted
+//
+// Limit orders:
+// OrderAccepted (limit) → [limit.go] → OrderPlacedInBook, OrdersMatched
+//
+//	→ [limit.go] → OrderPartiallyFilled → OrderCompleted
 type OrderSagaRefactored struct {
 	aggregateStore  *aggregates.AggregateStore // ✅ Source of truth
-	processedEvents *idempotency.ProcessedEventsRepository
+	processedEvents idempotency.ProcessedEventStore
+	sagaRepo        repository.SagaStore // Saga progress (survives restart)
+	manualReviews   repository.ManualReviewStore
 	completeOrderUC *usecases.CompleteOrderAndUpdatePositionUseCase
-	messageBus      *messaging.RabbitMQ
+	messageBus      messaging.MessageBus
 	priceService    PriceService
+	balanceService  BalanceService
 	tradeWorker     TradeWorker
+
+	// PriceTimeout bounds priceService.GetMarketPrice (STEP 1)
+	PriceTimeout time.Duration
+	// SwapTimeout bounds tradeWorker.ExecuteSwap (STEP 3)
+	SwapTimeout time.Duration
+	// QuoteMaxAge - STEP 3 re-quotes a market order whose quote is older (QuoteRefreshed); 0 disables
+	QuoteMaxAge time.Duration
+	// RecoveryStuckAfter - idle time after which a running saga is resumed on startup
+	RecoveryStuckAfter time.Duration
+	// MaxCompletionAttempts - failed STEP 4 attempts before the order goes to manual review
+	MaxCompletionAttempts int
+	// CompletionRetries - in-handler retries of a failed completion (each delivery), 0 disables
+	CompletionRetries int
+	// CompletionRetryBackoff - delay before the first in-handler retry, doubled per retry (±50% jitter)
+	CompletionRetryBackoff time.Duration
+	// SwapConcurrency - parallel STEP 3 workers; a slow swap no longer blocks the others
+	SwapConcurrency int
+	// SwapBreaker - while open, new swaps fail with "swap_unavailable" without calling the TradeWorker
+	SwapBreaker *circuitbreaker.Breaker
+	// OrderBooks - order book per trading pair for limit orders
+	OrderBooks *aggregates.OrderBookRegistry
+	// ExposureLimits - open exposure caps per user tier (STEP 0b); nil disables the check
+	ExposureLimits *ExposureLimits
+	// Logger - structured logger; every step adds saga_step, order_id and event_id
+	Logger *slog.Logger
 }
 
 func NewOrderSagaRefactored(
 	aggregateStore *aggregates.AggregateStore,
-	processedEvents *idempotency.ProcessedEventsRepository,
+	processedEvents idempotency.ProcessedEventStore,
+	sagaRepo repository.SagaStore,
+	manualReviews repository.ManualReviewStore,
 	completeOrderUC *usecases.CompleteOrderAndUpdatePositionUseCase,
-	messageBus *messaging.RabbitMQ,
+	messageBus messaging.MessageBus,
 	priceService PriceService,
+	balanceService BalanceService,
 	tradeWorker TradeWorker,
 ) *OrderSagaRefactored {
 	return &OrderSagaRefactored{
-		aggregateStore:  aggregateStore,
-		processedEvents: processedEvents,
-		completeOrderUC: completeOrderUC,
-		messageBus:      messageBus,
-		priceService:    priceService,
-		tradeWorker:     tradeWorker,
+		aggregateStore:         aggregateStore,
+		processedEvents:        processedEvents,
+		sagaRepo:               sagaRepo,
+		manualReviews:          manualReviews,
+		completeOrderUC:        completeOrderUC,
+		messageBus:             messageBus,
+		priceService:           priceService,
+		balanceService:         balanceService,
+		tradeWorker:            tradeWorker,
+		PriceTimeout:           DefaultPriceTimeout,
+		SwapTimeout:            DefaultSwapTimeout,
+		QuoteMaxAge:            DefaultQuoteMaxAge,
+		RecoveryStuckAfter:     DefaultRecoveryStuckAfter,
+		MaxCompletionAttempts:  DefaultMaxCompletionAttempts,
+		CompletionRetries:      DefaultCompletionRetries,
+		CompletionRetryBackoff: DefaultCompletionRetryBackoff,
+		SwapConcurrency:        DefaultSwapConcurrency,
+		SwapBreaker:            circuitbreaker.New("trade_worker"),
+		OrderBooks:             aggregates.NewOrderBookRegistry(aggregateStore, nil),
+		Logger:                 slog.Default(),
 	}
 }
 
 // Start запускает Saga orchestrator (слушает события)
 //
 // Subscribes to 4 events (one per step):
-// 1. OrderAccepted      → handled in accept.go
+// 1. OrderAccepted      → handled in accept.go (OrderAccepted.market, OrderAccepted.limit)
 // 2. PriceQuoted        → handled in price.go
 // 3. PositionCreatedForOrder → handled in swap.go
 // 4. SwapExecuted       → handled in complete.go
+//
+// Plus OrdersMatched and LimitOrderExpired (limit orders) → handled in limit.go
+// and OrderUpdated (amended orders) → handled in amend.go
 func (s *OrderSagaRefactored) Start(ctx context.Context) error {
-	// STEP 1: Price quotation
-	if err := s.messageBus.Subscribe("OrderAccepted", s.handleOrderAccepted); err != nil {
+	// STEP 1: Price quotation (market orders), order book placement (limit orders)
+	// Routed by order type, so each flow has its own queue and workers
+	if err := s.messageBus.Subscribe(ctx, "OrderAccepted.market", instrument("accept", s.handleMarketOrderAccepted)); err != nil {
+		return err
+	}
+	if err := s.messageBus.Subscribe(ctx, "OrderAccepted.limit", instrument("place", s.handleLimitOrderAccepted)); err != nil {
 		return err
 	}
 
 	// STEP 2: Position creation
-	if err := s.messageBus.Subscribe("PriceQuoted", s.handlePriceQuoted); err != nil {
+	if err := s.messageBus.Subscribe(ctx, "PriceQuoted", instrument("price", s.handlePriceQuoted)); err != nil {
 		return err
 	}
 
-	// STEP 3: Swap execution
-	if err := s.messageBus.Subscribe("PositionCreatedForOrder", s.handlePositionCreated); err != nil {
+	// STEP 3: Swap execution (slow - SwapConcurrency swaps at a time)
+	// Out-of-order processing is safe: an order has a single PositionCreatedForOrder
+	err := s.messageBus.SubscribeWithOptions(ctx, "PositionCreatedForOrder", instrument("swap", s.handlePositionCreated),
+		messaging.SubscribeOptions{Prefetch: s.SwapConcurrency, Concurrency: s.SwapConcurrency})
+	if err != nil {
 		return err
 	}
 
-	// STEP 4: Order completion
-	if err := s.messageBus.Subscribe("SwapExecuted", s.handleSwapExecuted); err != nil {
+	// STEP 4: Order completion - one at a time for safety (order + position saved together)
+	err = s.messageBus.SubscribeWithOptions(ctx, "SwapExecuted", instrument("complete", s.handleSwapExecuted),
+		messaging.SubscribeOptions{Concurrency: 1})
+	if err != nil {
+		return err
+	}
+
+	// Limit orders: fills from the order book
+	if err := s.messageBus.Subscribe(ctx, "OrdersMatched", instrument("match", s.handleOrdersMatched)); err != nil {
+		return err
+	}
+
+	// Limit orders: remainder expired (GTD reaper, IOC)
+	if err := s.messageBus.Subscribe(ctx, "LimitOrderExpired", instrument("expire", s.handleLimitOrderExpired)); err != nil {
 		return err
 	}
 
-	log.Println("✅ Order Saga (Refactored) started with granular steps...")
+	// Re-pricing of amended orders (PATCH /orders/{id})
+	if err := s.messageBus.Subscribe(ctx, "OrderUpdated", instrument("reprice", s.handleOrderUpdated)); err != nil {
+		return err
+	}
+
+	s.Logger.Info("Order Saga (Refactored) started with granular steps")
+
+	// Resume sagas interrupted by a previous shutdown/crash
+	go s.recoverStuckSagas(ctx)
 
 	<-ctx.Done()
+
+	// Return only after in-flight saga steps finished and were acked
+	<-s.messageBus.Drained()
+	s.Logger.Info("Order Saga stopped")
 	return nil
 }
 
@@ -93,44 +213,105 @@ func (s *OrderSagaRefactored) Start(ctx context.Context) error {
 // compensateOrderFailed marks order as failed
 // Used when early steps fail (price unavailable, validation errors)
 func (s *OrderSagaRefactored) compensateOrderFailed(ctx context.Context, orderID, reason string) error {
-	log.Printf("🔙 COMPENSATION: Failing order %s, reason: %s", orderID, reason)
+	s.Logger.Warn("Compensation: failing order", logging.OrderID(orderID), "reason", reason)
+	metrics.SagaCompensationsTotal.Inc("order_failed", reason)
 
-	// Load aggregate from EventStore (source of truth)
-	o, err := s.aggregateStore.LoadOrderAggregate(ctx, orderID)
+	// Generate OrderFailed event (reloaded and retried if another step wrote first)
+	err := s.aggregateStore.MutateOrder(ctx, orderID, func(o *order.Order) error {
+		return o.FailOrder(reason)
+	})
 	if err != nil {
 		return err
 	}
 
-	// Generate FailOrder event
-	if err := o.FailOrder(reason); err != nil {
-		return err
-	}
-
-	// Save events to EventStore
-	return s.aggregateStore.SaveOrderAggregate(ctx, o)
+	s.trackStep(ctx, orderID, repository.SagaStepDone, "", repository.SagaStatusFailed)
+	return nil
 }
 
 // compensateSwapFailed rolls back order and position when swap fails
 // Used when swap execution fails (blockchain error, insufficient liquidity, etc.)
 func (s *OrderSagaRefactored) compensateSwapFailed(ctx context.Context, orderID, positionID, reason string) error {
-	log.Printf("🔙 COMPENSATION: Swap failed for order %s", orderID)
+	s.Logger.Warn("Compensation: swap failed", logging.OrderID(orderID), "position_id", positionID, "reason", reason)
+	metrics.SagaCompensationsTotal.Inc("swap_failed", reason)
 
 	// Fail order
 	if err := s.compensateOrderFailed(ctx, orderID, reason); err != nil {
 		return err
 	}
 
-	// Load position from EventStore
-	p, err := s.aggregateStore.LoadPositionAggregate(ctx, positionID)
+	// Generate PositionClosed event (no swap - nothing to realize, PnL stays zero)
+	return s.aggregateStore.MutatePosition(ctx, positionID, func(p *position.Position) error {
+		return p.ClosePosition("order_failed", decimal.Zero)
+	})
+}
+
+// ===============================================
+// SAGA STATE
+// ===============================================
+
+// trackStep persists saga progress
+// Failures are logged only: saga state is for recovery/visibility and must not block the step
+func (s *OrderSagaRefactored) trackStep(ctx context.Context, orderID, step, positionID, status string) {
+	if err := s.sagaRepo.SaveStep(ctx, orderID, step, positionID, status); err != nil {
+		s.Logger.Warn("Failed to persist saga step", logging.OrderID(orderID), "step", step, logging.Err(err))
+	}
+}
+
+// ===============================================
+// IDEMPOTENCY
+// ===============================================
+
+// claimEvent atomically claims an event for a step: only the first delivery gets true
+// Handlers defer releaseOnError so a failed step is retried on redelivery
+func (s *OrderSagaRefactored) claimEvent(ctx context.Context, logger *slog.Logger, eventID, aggregateID, eventType, processedBy string) (bool, error) {
+	claimed, err := s.processedEvents.ClaimEvent(ctx, eventID, aggregateID, eventType, processedBy)
 	if err != nil {
-		return err
+		return false, err
+	}
+	if !claimed {
+		logger.Info("Event already processed, skipping")
 	}
+	return claimed, nil
+}
 
-	// Generate ClosePosition event
-	if err := p.ClosePosition("order_failed"); err != nil {
-		return err
+// releaseOnError drops the claim if the step failed, so the redelivered event is processed again
+func (s *OrderSagaRefactored) releaseOnError(ctx context.Context, eventID string, err *error) {
+	if *err == nil {
+		return
 	}
+	// Released even when the handler was cancelled by shutdown, so the redelivery is not skipped
+	if releaseErr := s.processedEvents.ReleaseEvent(context.WithoutCancel(ctx), eventID); releaseErr != nil {
+		s.Logger.Error("Failed to release event claim", logging.EventID(eventID), logging.Err(releaseErr))
+	}
+}
+
+// stepLogger returns a logger carrying saga_step, order_id and event_id
+func (s *OrderSagaRefactored) stepLogger(step, orderID, eventID string) *slog.Logger {
+	return s.Logger.With(logging.SagaStep(step), logging.OrderID(orderID), logging.EventID(eventID))
+}
+
+// ===============================================
+// METRICS
+// ===============================================
+
+// instrument wraps a step handler with saga_step_total and saga_step_duration_seconds
+// and a "saga.{step}" span continuing the trace carried in the event metadata
+func instrument(step string, handler messaging.EventHandler) messaging.EventHandler {
+	return func(ctx context.Context, eventData []byte) error {
+		ctx, span := tracing.StartSpan(tracing.ExtractFromEvent(ctx, eventData), "saga."+step)
+		defer span.End()
+
+		start := time.Now()
+		err := handler(ctx, eventData)
 
-	// Save events to EventStore
-	return s.aggregateStore.SavePositionAggregate(ctx, p)
+		outcome := "success"
+		if err != nil {
+			outcome = "error"
+			span.RecordError(err)
+		}
+		metrics.SagaStepTotal.Inc(step, outcome)
+		metrics.SagaStepDuration.Observe(time.Since(start).Seconds(), step)
+
+		return err
+	}
 }
@@ -3,10 +3,13 @@ package saga
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"time"
 
+	"market_order/application/aggregates"
 	"market_order/domain/order"
 	"market_order/domain/position"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/tracing"
 	pkguuid "market_order/pkg/uuid"
 )
 
@@ -16,71 +19,105 @@ import (
 
 // handlePriceQuoted processes PriceQuoted event
 // Responsibilities:
-// - Create new position aggregate
-// - Save position events to EventStore
+// - Create new position aggregate and link it to the order (PositionLinkedToOrder)
+// - Save order and position events to EventStore in one transaction
 // - Publish PositionCreatedForOrder event with position_id (triggers STEP 3)
 // - NO repository usage - EventStore only!
-func (s *OrderSagaRefactored) handlePriceQuoted(ctx context.Context, eventData []byte) error {
-	log.Println("📨 [STEP 2] Saga: Received PriceQuoted event")
-
+func (s *OrderSagaRefactored) handlePriceQuoted(ctx context.Context, eventData []byte) (err error) {
 	var evt order.PriceQuoted
-	if err := json.Unmarshal(eventData, &evt); err != nil {
+	if err = json.Unmarshal(eventData, &evt); err != nil {
 		return err
 	}
 
-	// Idempotency check
-	if processed, _ := s.processedEvents.IsProcessed(ctx, evt.EventID); processed {
-		log.Printf("⏭️  Event %s already processed, skipping", evt.EventID)
+	logger := s.stepLogger("price", evt.AggregateID, evt.EventID)
+	logger.Info("Received PriceQuoted event")
+
+	// Re-quote of an amended order (amend.go): the first quote already created the position
+	if evt.Requote {
+		logger.Info("Re-quote, position unchanged")
 		return nil
 	}
 
+	// Idempotency: claim the event before any side effects
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step2")
+	if err != nil || !claimed {
+		return err
+	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
+
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepCreatingPosition, "", repository.SagaStatusRunning)
+
 	// ✅ Load order aggregate from EventStore to get user info
 	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
 	if err != nil {
 		return err
 	}
 
-	// Create position
-	log.Printf("📦 Creating position for user %s", o.UserID)
-	positionID := pkguuid.New()
+	// Redelivery after the position was created and linked: only the publish is missing
+	positionID := o.PositionID
+	if positionID == "" {
+		logger.Info("Creating position", "user_id", o.UserID)
+		positionID = pkguuid.New()
 
-	// Create new position aggregate
-	p := position.NewPosition()
-	if err := p.CreatePosition(positionID, o.UserID); err != nil {
-		return err
+		// Create new position aggregate
+		p := position.NewPosition()
+		if err := p.CreatePosition(positionID, o.UserID, o.FromCurrency); err != nil {
+			return err
+		}
+
+		// Link it to the order (generates PositionLinkedToOrder event)
+		if err := o.LinkPosition(positionID); err != nil {
+			return err
+		}
+
+		// ✅ Save position and link in ONE transaction (not repository!):
+		// the order never points to a missing position, nor a position to nobody
+		if err := s.aggregateStore.SaveInTx(ctx, aggregates.OrderBatch(o), aggregates.PositionBatch(p)); err != nil {
+			return err
+		}
+		o.ClearChanges()
+		p.ClearChanges()
+
+		logger.Info("Position created", "position_id", positionID)
+	} else {
+		logger.Info("Position already linked, resuming", "position_id", positionID)
 	}
 
-	// ✅ Save position events to EventStore (not repository!)
-	if err := s.aggregateStore.SavePositionAggregate(ctx, p); err != nil {
+	// Persist position link first: recovery must not create a second position
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepCreatingPosition, positionID, repository.SagaStatusRunning)
+
+	// Publish PositionCreatedForOrder event to trigger STEP 3
+	if err := s.publishPositionCreated(ctx, evt.AggregateID, positionID, o.UserID, evt.Version+1, evt.Timestamp); err != nil {
 		return err
 	}
 
-	log.Printf("✅ Position created: %s", positionID)
+	logger.Info("Step completed: position created and linked to order")
+	return nil
+}
 
-	// Publish PositionCreatedForOrder event to trigger STEP 3
-	// This is a saga coordination event (not an aggregate event)
+// publishPositionCreated publishes PositionCreatedForOrder with position_id in metadata
+// This is a saga coordination event (not an aggregate event)
+func (s *OrderSagaRefactored) publishPositionCreated(ctx context.Context, orderID, positionID, userID string, version int, timestamp time.Time) error {
 	positionCreatedEvt := order.PositionCreatedForOrder{
 		BaseEvent: order.BaseEvent{
 			EventID:       pkguuid.New(),
-			AggregateID:   evt.AggregateID, // order ID
+			AggregateID:   orderID,
 			AggregateType: "Order",
 			EventType:     "PositionCreatedForOrder",
-			Version:       evt.Version + 1,
-			Timestamp:     evt.Timestamp,
-			Metadata: map[string]interface{}{
+			Version:       version,
+			Timestamp:     timestamp,
+			Metadata: tracing.Inject(ctx, map[string]interface{}{
 				"position_id": positionID, // Pass position ID for next steps
-			},
+			}),
 		},
 		PositionID: positionID,
-		UserID:     o.UserID,
+		UserID:     userID,
 	}
 
-	eventBytes, _ := json.Marshal(positionCreatedEvt)
-	s.messageBus.Publish("PositionCreatedForOrder", eventBytes)
-
-	// Mark as processed
-	s.processedEvents.MarkAsProcessed(ctx, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step2")
+	eventBytes, err := json.Marshal(positionCreatedEvt)
+	if err != nil {
+		return err
+	}
 
-	log.Printf("✅ [STEP 2] Completed: Position created and linked to order %s", evt.AggregateID)
-	return nil
+	return s.messageBus.Publish("PositionCreatedForOrder", eventBytes)
 }
@@ -0,0 +1,96 @@
+package saga
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"market_order/application/usecases"
+	"market_order/pkg/decimal"
+)
+
+// DefaultQuoteTTL - how long a quote is considered fresh by the client
+const DefaultQuoteTTL = 10 * time.Second
+
+// DefaultEstimatedFeeRate - estimated swap fees as a fraction of the received amount (0.1%)
+var DefaultEstimatedFeeRate = decimal.MustParse("0.001")
+
+// Quote - estimated execution of a market order at the current price
+type Quote struct {
+	FromCurrency  string
+	ToCurrency    string
+	FromAmount    decimal.Decimal
+	Price         decimal.Decimal
+	GrossToAmount decimal.Decimal // from_amount / price, what STEP 1 quotes
+	EstimatedFees decimal.Decimal // In to_currency
+	ToAmount      decimal.Decimal // GrossToAmount - EstimatedFees
+	QuotedAt      time.Time
+	ExpiresAt     time.Time
+}
+
+// quoteToAmount - amount received for fromAmount at price (shared by STEP 1 and PriceQuoter)
+func quoteToAmount(fromAmount, price decimal.Decimal) decimal.Decimal {
+	return fromAmount.Div(price)
+}
+
+// PriceQuoter prices market orders without executing them (POST /orders/quote)
+// Uses the saga's PriceService and pricing formula; no events are emitted
+type PriceQuoter struct {
+	priceService PriceService
+	currencies   *usecases.CurrencyRegistry
+
+	// PriceTimeout bounds priceService.GetMarketPrice
+	PriceTimeout time.Duration
+	// FeeRate - estimated fees as a fraction of the received amount
+	FeeRate decimal.Decimal
+	// TTL - quote validity, returned to the client as ExpiresAt
+	TTL time.Duration
+}
+
+func NewPriceQuoter(priceService PriceService, currencies *usecases.CurrencyRegistry) *PriceQuoter {
+	return &PriceQuoter{
+		priceService: priceService,
+		currencies:   currencies,
+		PriceTimeout: DefaultPriceTimeout,
+		FeeRate:      DefaultEstimatedFeeRate,
+		TTL:          DefaultQuoteTTL,
+	}
+}
+
+// Quote prices fromAmount of from → to at the current market price
+// Unsupported pairs return usecases.ErrUnsupportedPair (registry) or
+// price.ErrPairNotSupported (price feed); order size violations order.ValidationErrors
+func (q *PriceQuoter) Quote(ctx context.Context, from, to string, fromAmount decimal.Decimal) (*Quote, error) {
+	if q.currencies != nil {
+		if err := q.currencies.Validate(from, to, fromAmount); err != nil {
+			return nil, err
+		}
+	}
+
+	priceCtx, cancel := context.WithTimeout(ctx, q.PriceTimeout)
+	defer cancel()
+
+	price, err := q.priceService.GetMarketPrice(priceCtx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !price.IsPositive() {
+		return nil, fmt.Errorf("invalid market price %v for %s/%s", price, from, to)
+	}
+
+	gross := quoteToAmount(fromAmount, price)
+	fees := gross.Mul(q.FeeRate)
+	now := time.Now().UTC()
+
+	return &Quote{
+		FromCurrency:  from,
+		ToCurrency:    to,
+		FromAmount:    fromAmount,
+		Price:         price,
+		GrossToAmount: gross,
+		EstimatedFees: fees,
+		ToAmount:      gross.Sub(fees),
+		QuotedAt:      now,
+		ExpiresAt:     now.Add(q.TTL),
+	}, nil
+}
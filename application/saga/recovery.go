@@ -0,0 +1,178 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/logging"
+)
+
+// ===============================================
+// RECOVERY: resume sagas interrupted by a restart
+// ===============================================
+
+// recoverStuckSagas finds running sagas that stopped advancing and resumes them
+// The next step is derived from the order's event stream (source of truth),
+// saga_instances only supplies the position_id that never reaches the event store
+func (s *OrderSagaRefactored) recoverStuckSagas(ctx context.Context) {
+	stuck, err := s.sagaRepo.FindStuck(ctx, s.RecoveryStuckAfter)
+	if err != nil {
+		s.Logger.Error("Saga recovery: failed to find stuck sagas", logging.Err(err))
+		return
+	}
+
+	if len(stuck) == 0 {
+		return
+	}
+
+	s.Logger.Info("Saga recovery: found stuck sagas", "count", len(stuck))
+
+	for _, inst := range stuck {
+		if err := s.resumeSaga(ctx, inst); err != nil {
+			s.Logger.Error("Saga recovery: failed to resume order", logging.OrderID(inst.OrderID), logging.Err(err))
+		}
+	}
+}
+
+// resumeSaga re-publishes the event that triggers the saga's next step
+func (s *OrderSagaRefactored) resumeSaga(ctx context.Context, inst repository.SagaInstance) error {
+	events, err := s.aggregateStore.LoadEvents(ctx, inst.OrderID)
+	if err != nil {
+		return err
+	}
+
+	last := lastStepEvent(events)
+	logger := s.stepLogger("recovery", inst.OrderID, last.EventID)
+	logger.Info("Resuming order", "current_step", inst.CurrentStep, logging.EventType(last.EventType))
+
+	switch last.EventType {
+	case "OrderAccepted":
+		// STEP 1 never finished
+		return s.republish(ctx, last)
+
+	case "BalanceCheckPassed":
+		// STEP 1 checked funds but never priced the order - rerun it from OrderAccepted
+		return s.republish(ctx, events[0])
+
+	case "BalanceCheckFailed":
+		// Compensation never finished
+		return s.compensateOrderFailed(ctx, inst.OrderID, "insufficient_balance")
+
+	case "PriceQuoted":
+		if inst.PositionID == "" {
+			// STEP 2 never created a position
+			return s.republish(ctx, last)
+		}
+
+		// Position exists but PositionCreatedForOrder was lost
+		o, err := s.aggregateStore.LoadOrderAggregate(ctx, inst.OrderID)
+		if err != nil {
+			return err
+		}
+		return s.publishPositionCreated(ctx, inst.OrderID, inst.PositionID, o.UserID, o.Version+1, o.UpdatedAt)
+
+	case "PositionLinkedToOrder":
+		// STEP 2 linked the position but PositionCreatedForOrder was lost
+		var linked order.PositionLinkedToOrder
+		if err := json.Unmarshal(last.EventData, &linked); err != nil {
+			return err
+		}
+		o, err := s.aggregateStore.LoadOrderAggregate(ctx, inst.OrderID)
+		if err != nil {
+			return err
+		}
+		s.trackStep(ctx, inst.OrderID, repository.SagaStepCreatingPosition, linked.PositionID, repository.SagaStatusRunning)
+		return s.publishPositionCreated(ctx, inst.OrderID, linked.PositionID, o.UserID, o.Version+1, o.UpdatedAt)
+
+	case "OrderNeedsManualReview":
+		// Already handed over to an operator
+		s.trackStep(ctx, inst.OrderID, inst.CurrentStep, "", repository.SagaStatusNeedsReview)
+		return nil
+
+	case "SwapRejectedSlippage":
+		// Compensation never finished
+		return s.compensateSwapFailed(ctx, inst.OrderID, inst.PositionID, "slippage_exceeded")
+
+	case "OrderExpired":
+		// Compensation never finished (the position exists if the order expired at STEP 3)
+		if inst.PositionID != "" {
+			return s.compensateSwapFailed(ctx, inst.OrderID, inst.PositionID, "expired")
+		}
+		return s.compensateOrderFailed(ctx, inst.OrderID, "expired")
+
+	case "SwapExecuting", "SwapTimedOut":
+		// Swap outcome unknown - never re-execute automatically
+		logger.Warn("Order has a swap in flight, flagging for manual review")
+		s.trackStep(ctx, inst.OrderID, inst.CurrentStep, "", repository.SagaStatusNeedsReview)
+		return nil
+
+	case "SwapExecuted":
+		// STEP 4 never finished - re-attach position_id lost with the message
+		if inst.PositionID == "" {
+			logger.Warn("Order executed a swap without a known position, flagging for manual review")
+			s.trackStep(ctx, inst.OrderID, inst.CurrentStep, "", repository.SagaStatusNeedsReview)
+			return nil
+		}
+
+		var evt order.SwapExecuted
+		if err := json.Unmarshal(last.EventData, &evt); err != nil {
+			return err
+		}
+		evt.Metadata = map[string]interface{}{
+			"position_id": inst.PositionID,
+		}
+
+		eventBytes, err := json.Marshal(evt)
+		if err != nil {
+			return err
+		}
+		if err := s.processedEvents.ReleaseEvent(ctx, evt.EventID); err != nil {
+			return err
+		}
+		return s.messageBus.Publish("SwapExecuted", eventBytes)
+
+	case "OrderCompleted":
+		s.trackStep(ctx, inst.OrderID, repository.SagaStepDone, "", repository.SagaStatusCompleted)
+		return nil
+
+	case "OrderFailed", "OrderCancelled":
+		s.trackStep(ctx, inst.OrderID, repository.SagaStepDone, "", repository.SagaStatusFailed)
+		return nil
+
+	default:
+		logger.Info("No recovery action", logging.EventType(last.EventType))
+		return nil
+	}
+}
+
+// lastStepEvent returns the last event that advanced the saga
+// Amendments (OrderUpdated and its re-quote) and stale-quote refreshes (QuoteRefreshed) do not:
+// the saga resumes from the step before them
+func lastStepEvent(events []eventstore.Event) eventstore.Event {
+	for i := len(events) - 1; i > 0; i-- {
+		e := events[i]
+		switch e.EventType {
+		case "OrderUpdated", "QuoteRefreshed":
+			continue
+		case "PriceQuoted":
+			var quoted order.PriceQuoted
+			if err := json.Unmarshal(e.EventData, &quoted); err == nil && quoted.Requote {
+				continue
+			}
+		}
+		return e
+	}
+	return events[0]
+}
+
+// republish re-sends a stored event to rerun its step
+// The step's claim is dropped first: a handler interrupted by the crash left it claimed
+func (s *OrderSagaRefactored) republish(ctx context.Context, e eventstore.Event) error {
+	if err := s.processedEvents.ReleaseEvent(ctx, e.EventID); err != nil {
+		return err
+	}
+	return s.messageBus.PublishEvent(e.EventType, e.EventID, e.EventData)
+}
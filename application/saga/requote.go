@@ -0,0 +1,61 @@
+package saga
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
+)
+
+// ===============================================
+// STALE QUOTE: STEP 3 delayed past QuoteMaxAge → QuoteRefreshed → swap
+// ===============================================
+
+// reQuoteStalePrice refreshes the quote of a market order that waited too long for STEP 3
+// ToAmount and ExecutedPrice are what the user was quoted and what slippage is reported
+// against: a delayed swap must not run on a price the market left long ago.
+// Called before the swap starts
+//
+// Returns true when the order failed because it could not be priced; the caller stops the step
+func (s *OrderSagaRefactored) reQuoteStalePrice(ctx context.Context, logger *slog.Logger, orderID, positionID string) (bool, error) {
+	if s.QuoteMaxAge <= 0 {
+		return false, nil
+	}
+
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, orderID)
+	if err != nil {
+		return false, err
+	}
+	if !o.IsQuoteStale(time.Now(), s.QuoteMaxAge) {
+		return false, nil
+	}
+
+	quotedAt := o.QuotedAt
+	logger.Info("Quote is stale, re-quoting before swap",
+		"quoted_at", quotedAt.Format(time.RFC3339), "max_age", s.QuoteMaxAge.String())
+
+	price, err := s.getMarketPrice(ctx, o.FromCurrency, o.ToCurrency)
+	if err != nil {
+		return true, s.failPricing(ctx, logger, orderID, positionID, err)
+	}
+
+	// ✅ Re-quote on the current state (generates QuoteRefreshed), retried on conflict
+	// Skipped when another delivery refreshed the quote or the swap started meanwhile
+	var toAmount decimal.Decimal
+	err = s.aggregateStore.MutateOrder(ctx, orderID, func(current *order.Order) error {
+		toAmount = decimal.Zero
+		if !current.IsQuoteStale(time.Now(), s.QuoteMaxAge) {
+			return nil
+		}
+		toAmount = quoteToAmount(current.FromAmount, price)
+		return current.RefreshStaleQuote(price, toAmount)
+	})
+	if err != nil || toAmount.IsZero() {
+		return false, err
+	}
+
+	logger.Info("Price re-quoted", "price", price, "to_amount", toAmount, "previous_price", o.ExecutedPrice)
+	return false, nil
+}
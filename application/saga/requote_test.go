@@ -0,0 +1,62 @@
+package saga
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+func TestStaleQuoteIsRefreshedBeforeSwap(t *testing.T) {
+	const maxAge = 20 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		wait         time.Duration
+		wantToAmount string
+		wantRequote  bool
+	}{
+		{name: "fresh quote is used as is", wantToAmount: "200000"},
+		// 100 USDT at the current 0.0004
+		{name: "stale quote is re-quoted", wait: 2 * maxAge, wantToAmount: "250000", wantRequote: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				h            *sagaHarness
+				orderID      string
+				quotedAtSwap decimal.Decimal
+			)
+			worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+				quotedAtSwap = h.order(orderID).ToAmount
+				return &SwapResponse{TransactionHash: "0xabc", ToAmount: quotedAtSwap, ExecutedPrice: decimal.MustParse("0.0004")}, nil
+			})
+			h = newSagaHarness(t, fixedPrice{decimal.MustParse("0.0004")}, fixedBalance{decimal.MustParse("1000")}, worker, func(s *OrderSagaRefactored) {
+				s.QuoteMaxAge = maxAge
+			})
+
+			orderID = h.placeMarketOrder("user-1", "100", "USDT", "ETH")
+			positionCreated := h.quoteAndLinkPosition(orderID, "0.0005", "200000")
+
+			time.Sleep(tt.wait)
+			if err := h.saga.handlePositionCreated(context.Background(), positionCreated); err != nil {
+				t.Fatalf("handlePositionCreated: %v", err)
+			}
+
+			if !quotedAtSwap.Equal(decimal.MustParse(tt.wantToAmount)) {
+				t.Errorf("to_amount at swap = %s, want %s", quotedAtSwap, tt.wantToAmount)
+			}
+			types := h.eventTypes(orderID)
+			requoted := slices.Index(types, "QuoteRefreshed")
+			if (requoted >= 0) != tt.wantRequote {
+				t.Fatalf("order events %v: QuoteRefreshed present = %v, want %v", types, requoted >= 0, tt.wantRequote)
+			}
+			if tt.wantRequote && requoted > slices.Index(types, "SwapExecuting") {
+				t.Errorf("order events %v: re-quote after the swap started", types)
+			}
+		})
+	}
+}
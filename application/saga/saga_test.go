@@ -0,0 +1,106 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/decimal"
+)
+
+func TestMarketOrderHappyPath(t *testing.T) {
+	var swaps []SwapRequest
+	worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+		swaps = append(swaps, req)
+		return &SwapResponse{
+			TransactionHash: "0xabc",
+			ToAmount:        decimal.MustParse("0.05"),
+			ExecutedPrice:   decimal.MustParse("0.0005"),
+			Fees:            decimal.MustParse("1"),
+		}, nil
+	})
+	h := newSagaHarness(t, fixedPrice{decimal.MustParse("0.0005")}, fixedBalance{decimal.MustParse("1000")}, worker, nil)
+
+	orderID := h.placeMarketOrder("user-1", "100", "USDT", "ETH")
+	if err := h.run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	o := h.order(orderID)
+	if o.Status != order.OrderStatusCompleted {
+		t.Fatalf("order status = %s, want completed", o.Status)
+	}
+	if o.TransactionHash != "0xabc" || !o.ToAmount.Equal(decimal.MustParse("0.05")) {
+		t.Errorf("order swap = %s %s, want 0xabc 0.05", o.TransactionHash, o.ToAmount)
+	}
+	if len(swaps) != 1 || !swaps[0].FromAmount.Equal(decimal.MustParse("100")) {
+		t.Fatalf("swaps = %+v, want one swap of 100", swaps)
+	}
+
+	types := h.eventTypes(orderID)
+	for _, want := range []string{"OrderAccepted", "PriceQuoted", "PositionLinkedToOrder", "SwapExecuting", "SwapExecuted", "OrderCompleted"} {
+		if !slices.Contains(types, want) {
+			t.Errorf("order events %v: missing %s", types, want)
+		}
+	}
+
+	p, err := h.aggregateStore.LoadPositionAggregate(context.Background(), o.PositionID)
+	if err != nil {
+		t.Fatalf("LoadPositionAggregate: %v", err)
+	}
+	if !p.RemainingAmount.Equal(decimal.MustParse("0.05")) {
+		t.Errorf("position amount = %s, want 0.05", p.RemainingAmount)
+	}
+
+	saga, err := h.sagas.Get(context.Background(), orderID)
+	if err != nil {
+		t.Fatalf("saga Get: %v", err)
+	}
+	if saga.CurrentStep != repository.SagaStepDone || saga.Status != repository.SagaStatusCompleted {
+		t.Errorf("saga = %s/%s, want done/completed", saga.CurrentStep, saga.Status)
+	}
+}
+
+func TestSwapFailureCompensates(t *testing.T) {
+	worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+		return nil, errors.New("insufficient liquidity")
+	})
+	h := newSagaHarness(t, fixedPrice{decimal.MustParse("0.0005")}, fixedBalance{decimal.MustParse("1000")}, worker, nil)
+
+	orderID := h.placeMarketOrder("user-1", "100", "USDT", "ETH")
+	if err := h.run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	o := h.order(orderID)
+	if o.Status != order.OrderStatusFailed {
+		t.Fatalf("order status = %s, want failed", o.Status)
+	}
+	if types := h.eventTypes(orderID); slices.Contains(types, "SwapExecuted") || !slices.Contains(types, "OrderFailed") {
+		t.Errorf("order events = %v, want OrderFailed and no SwapExecuted", types)
+	}
+
+	// compensateSwapFailed closes the position with zero PnL
+	p, err := h.aggregateStore.LoadPositionAggregate(context.Background(), o.PositionID)
+	if err != nil {
+		t.Fatalf("LoadPositionAggregate: %v", err)
+	}
+	if p.Status != position.PositionStatusClosed {
+		t.Errorf("position status = %s, want closed", p.Status)
+	}
+	if !p.RealizedPnL.IsZero() {
+		t.Errorf("position realized PnL = %s, want 0", p.RealizedPnL)
+	}
+
+	saga, err := h.sagas.Get(context.Background(), orderID)
+	if err != nil {
+		t.Fatalf("saga Get: %v", err)
+	}
+	if saga.Status != repository.SagaStatusFailed {
+		t.Errorf("saga status = %s, want failed", saga.Status)
+	}
+}
@@ -3,10 +3,13 @@ package saga
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"log/slog"
 
 	"market_order/domain/order"
-	pkguuid "market_order/pkg/uuid"
+	"market_order/infrastructure/repository"
+	"market_order/pkg/logging"
+	"market_order/pkg/tracing"
 )
 
 // ===============================================
@@ -15,7 +18,10 @@ import (
 
 // handlePositionCreated processes PositionCreatedForOrder event
 // Responsibilities:
+// - Serialize the step per order (aggregate lock: two saves around the TradeWorker call)
 // - Load order aggregate from EventStore
+// - Expire market orders past their TTL (expiry.go)
+// - Re-quote market orders whose quote is older than QuoteMaxAge (requote.go)
 // - Execute blockchain swap via TradeWorker
 // - Record swap execution result (generates SwapExecuted event)
 // - Save events to EventStore
@@ -24,103 +30,182 @@ import (
 // This is the SLOWEST step (~5s) due to blockchain interaction
 // Can be scaled independently with multiple workers
 // NO repository usage - EventStore only!
-func (s *OrderSagaRefactored) handlePositionCreated(ctx context.Context, eventData []byte) error {
-	log.Println("📨 [STEP 3] Saga: Received PositionCreatedForOrder event")
-
+func (s *OrderSagaRefactored) handlePositionCreated(ctx context.Context, eventData []byte) (err error) {
 	var evt order.PositionCreatedForOrder
-	if err := json.Unmarshal(eventData, &evt); err != nil {
+	if err = json.Unmarshal(eventData, &evt); err != nil {
 		return err
 	}
 
-	// Idempotency check
-	if processed, _ := s.processedEvents.IsProcessed(ctx, evt.EventID); processed {
-		log.Printf("⏭️  Event %s already processed, skipping", evt.EventID)
-		return nil
+	logger := s.stepLogger("swap", evt.AggregateID, evt.EventID)
+	logger.Info("Received PositionCreatedForOrder event")
+
+	// Idempotency: claim the event before any side effects
+	claimed, err := s.claimEvent(ctx, logger, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step3")
+	if err != nil || !claimed {
+		return err
 	}
+	defer s.releaseOnError(ctx, evt.EventID, &err)
+
+	// The step saves the order twice around the TradeWorker call: serialize it per order,
+	// so a duplicate PositionCreatedForOrder (e.g. republished by recovery) waits here
+	// instead of interleaving its saves with this one
+	return s.aggregateStore.WithAggregateLock(ctx, evt.AggregateID, func(ctx context.Context) error {
+		return s.executeSwapStep(ctx, logger, evt)
+	})
+}
 
-	// ✅ Load order aggregate from EventStore
-	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
-	if err != nil {
+// executeSwapStep runs STEP 3 for one order; called with the order's aggregate lock held
+func (s *OrderSagaRefactored) executeSwapStep(ctx context.Context, logger *slog.Logger, evt order.PositionCreatedForOrder) error {
+	// A market order past its TTL is not executed at its stale quote (OrderExpired)
+	if expired, err := s.expireStaleOrder(ctx, logger, evt.AggregateID, evt.PositionID); err != nil || expired {
 		return err
 	}
 
+	// A quote older than QuoteMaxAge is refreshed before it is used for the slippage check
+	if failed, err := s.reQuoteStalePrice(ctx, logger, evt.AggregateID, evt.PositionID); err != nil || failed {
+		return err
+	}
+
+	// Circuit breaker: while the TradeWorker keeps failing, new swaps fail fast without reaching it
+	if !s.SwapBreaker.Allow() {
+		if rejected, err := s.rejectSwapUnavailable(ctx, logger, evt); err != nil || rejected {
+			return err
+		}
+	}
+
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepExecutingSwap, evt.PositionID, repository.SagaStatusRunning)
+
 	// Execute swap
-	log.Printf("🔄 Executing swap for order %s", evt.AggregateID)
+	logger.Info("Executing swap")
 
 	idempotencyKey := generateIdempotencyKey(evt.AggregateID)
 
-	// Mark as executing (generates SwapExecuting event)
-	if err := o.StartSwapExecution(idempotencyKey); err != nil {
+	// ✅ Mark as executing (generates SwapExecuting event), retried on conflict
+	// No-op if this order's swap was already started with the same key
+	var o *order.Order
+	err := s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(current *order.Order) error {
+		o = current
+		return o.StartSwapExecution(idempotencyKey)
+	})
+	if err != nil {
 		return err
 	}
 
-	// ✅ Save events to EventStore
-	if err := s.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
-		return err
+	// Redelivery after the swap was recorded or the order finished: nothing left to execute
+	if o.TransactionHash != "" || o.Status != order.OrderStatusExecuting {
+		logger.Info("Swap already recorded, skipping", "status", string(o.Status), "tx_hash", o.TransactionHash)
+		return nil
 	}
 
+	// Redelivery of a started swap reuses the same idempotency key:
+	// the TradeWorker dedups it instead of swapping twice on-chain
 	swapReq := SwapRequest{
 		IdempotencyKey: idempotencyKey,
 		FromCurrency:   o.FromCurrency,
 		ToCurrency:     o.ToCurrency,
 		FromAmount:     o.FromAmount,
-		Slippage:       0.5, // 0.5%
+		Slippage:       o.MaxSlippage, // %
 	}
 
-	swapResp, err := s.tradeWorker.ExecuteSwap(ctx, swapReq)
+	swapCtx, cancel := context.WithTimeout(ctx, s.SwapTimeout)
+	defer cancel()
+
+	swapCtx, span := tracing.StartSpan(swapCtx, "tradeWorker.ExecuteSwap")
+	swapResp, err := s.tradeWorker.ExecuteSwap(swapCtx, swapReq)
 	if err != nil {
-		log.Printf("❌ Swap execution failed: %v", err)
+		span.RecordError(err)
+	}
+	span.End()
+
+	if err != nil {
+		if ctx.Err() != nil {
+			// Shutdown, not a swap failure: the redelivery retries with the same idempotency key
+			return err
+		}
+		s.SwapBreaker.Failure()
+		if errors.Is(err, context.DeadlineExceeded) {
+			// Do NOT compensate: swap may have partially executed on-chain
+			logger.Warn("Swap timed out, flagging order for manual review", "timeout", s.SwapTimeout.String())
+			return s.recordSwapTimeout(ctx, evt, idempotencyKey)
+		}
+		logger.Error("Swap execution failed", logging.Err(err))
 		return s.compensateSwapFailed(ctx, evt.AggregateID, evt.PositionID, err.Error())
 	}
 
-	log.Printf("✅ Swap executed: txHash=%s", swapResp.TransactionHash)
+	s.SwapBreaker.Success()
+	logger.Info("Swap executed", "tx_hash", swapResp.TransactionHash)
 
-	// ✅ Reload aggregate and record swap execution
-	o, _ = s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
-	o.RecordSwapExecution(
-		swapResp.TransactionHash,
-		o.FromAmount,
-		swapResp.ToAmount,
-		swapResp.ExecutedPrice,
-		swapResp.Fees,
-		swapResp.Slippage,
-	)
+	// Slippage protection: the user must not receive far less than quoted
+	if swapResp.Slippage > o.MaxSlippage {
+		logger.Warn("Swap slippage exceeds tolerance, rejecting",
+			"slippage", swapResp.Slippage, "max_slippage", o.MaxSlippage,
+			"quoted_to_amount", o.ToAmount, "executed_to_amount", swapResp.ToAmount)
+		return s.rejectSwapSlippage(ctx, evt, swapResp)
+	}
 
-	// ✅ Save events to EventStore
-	if err := s.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
+	// ✅ Reload aggregate, record swap execution (generates SwapExecuted event) and save
+	// position_id is stored in the event metadata: the outbox delivers it to STEP 4
+	err = s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(current *order.Order) error {
+		o = current
+		return o.RecordSwapExecution(
+			evt.PositionID,
+			swapResp.TransactionHash,
+			o.FromAmount,
+			swapResp.ToAmount,
+			swapResp.ExecutedPrice,
+			swapResp.Fees,
+			swapResp.Slippage,
+		)
+	})
+	if err != nil {
 		return err
 	}
 
-	// Publish SwapExecuted with position_id in metadata
-	// This will be published automatically by EventStore via Outbox
-	// But we also manually publish for saga coordination
-	swapExecutedEvt := order.SwapExecuted{
-		BaseEvent: order.BaseEvent{
-			EventID:       pkguuid.New(),
-			AggregateID:   evt.AggregateID,
-			AggregateType: "Order",
-			EventType:     "SwapExecuted",
-			Version:       o.Version,
-			Timestamp:     o.UpdatedAt,
-			Metadata: map[string]interface{}{
-				"position_id": evt.PositionID, // Pass position ID to STEP 4
-			},
-		},
-		TransactionHash: swapResp.TransactionHash,
-		FromAmount:      o.FromAmount,
-		ToAmount:        swapResp.ToAmount,
-		ExecutedPrice:   swapResp.ExecutedPrice,
-		Fees:            swapResp.Fees,
-		Slippage:        swapResp.Slippage,
-	}
-
-	eventBytes, _ := json.Marshal(swapExecutedEvt)
-	s.messageBus.Publish("SwapExecuted", eventBytes)
-
-	// Mark as processed
-	s.processedEvents.MarkAsProcessed(ctx, evt.EventID, evt.AggregateID, evt.EventType, "order-saga-step3")
-
-	// SwapExecuted event will trigger STEP 4
-	log.Printf("✅ [STEP 3] Completed: Swap executed for order %s", evt.AggregateID)
+	// SwapExecuted event (published via Outbox) will trigger STEP 4
+	logger.Info("Step completed: swap executed")
+	return nil
+}
+
+// rejectSwapUnavailable fails an order whose swap has not started while the TradeWorker circuit is open
+// Returns false for a started swap: it may have reached the worker before,
+// so it is retried with its idempotency key regardless of the breaker
+func (s *OrderSagaRefactored) rejectSwapUnavailable(ctx context.Context, logger *slog.Logger, evt order.PositionCreatedForOrder) (bool, error) {
+	o, err := s.aggregateStore.LoadOrderAggregate(ctx, evt.AggregateID)
+	if err != nil {
+		return false, err
+	}
+	if o.Status != order.OrderStatusPending {
+		return false, nil
+	}
+
+	logger.Warn("TradeWorker circuit open, failing order without calling it", "breaker_state", string(s.SwapBreaker.State()))
+	return true, s.compensateSwapFailed(ctx, evt.AggregateID, evt.PositionID, "swap_unavailable")
+}
+
+// rejectSwapSlippage emits SwapRejectedSlippage and runs the swap-failed compensation
+func (s *OrderSagaRefactored) rejectSwapSlippage(ctx context.Context, evt order.PositionCreatedForOrder, swapResp *SwapResponse) error {
+	// Generate SwapRejectedSlippage event (published via Outbox)
+	err := s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(o *order.Order) error {
+		return o.RejectSwapSlippage(swapResp.TransactionHash, swapResp.ToAmount, swapResp.ExecutedPrice, swapResp.Slippage)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.compensateSwapFailed(ctx, evt.AggregateID, evt.PositionID, "slippage_exceeded")
+}
+
+// recordSwapTimeout emits SwapTimedOut for manual review instead of compensating
+func (s *OrderSagaRefactored) recordSwapTimeout(ctx context.Context, evt order.PositionCreatedForOrder, idempotencyKey string) error {
+	// Generate SwapTimedOut event (published via Outbox)
+	err := s.aggregateStore.MutateOrder(ctx, evt.AggregateID, func(o *order.Order) error {
+		return o.RecordSwapTimeout(idempotencyKey, s.SwapTimeout)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.trackStep(ctx, evt.AggregateID, repository.SagaStepExecutingSwap, "", repository.SagaStatusNeedsReview)
+
 	return nil
 }
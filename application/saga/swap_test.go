@@ -0,0 +1,208 @@
+package saga
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/pkg/circuitbreaker"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// A duplicate PositionCreatedForOrder (new event ID, same order) waits for the aggregate
+// lock while the first swap runs, then finds the swap recorded and skips it
+func TestConcurrentPositionCreatedSwapsOnce(t *testing.T) {
+	var calls atomic.Int32
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+		if calls.Add(1) == 1 {
+			close(entered)
+			<-release
+		}
+		return &SwapResponse{
+			TransactionHash: "0xabc",
+			ToAmount:        decimal.MustParse("0.05"),
+			ExecutedPrice:   decimal.MustParse("0.0005"),
+		}, nil
+	})
+	h := newSagaHarness(t, fixedPrice{decimal.MustParse("0.0005")}, fixedBalance{decimal.MustParse("1000")}, worker, nil)
+
+	orderID := h.placeMarketOrder("user-1", "100", "USDT", "ETH")
+	runDone := make(chan error, 1)
+	go func() { runDone <- h.run() }()
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("swap was not started")
+	}
+
+	o := h.order(orderID)
+	duplicate, err := json.Marshal(order.PositionCreatedForOrder{
+		BaseEvent: order.BaseEvent{
+			EventID:       pkguuid.New(),
+			AggregateID:   orderID,
+			AggregateType: "Order",
+			EventType:     "PositionCreatedForOrder",
+			Version:       o.Version,
+			Timestamp:     time.Now(),
+		},
+		PositionID: o.PositionID,
+		UserID:     o.UserID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	duplicateDone := make(chan error, 1)
+	go func() { duplicateDone <- h.saga.handlePositionCreated(context.Background(), duplicate) }()
+
+	select {
+	case err := <-duplicateDone:
+		t.Fatalf("duplicate handled while the first swap held the lock: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-duplicateDone; err != nil {
+		t.Fatalf("duplicate handlePositionCreated: %v", err)
+	}
+	if err := <-runDone; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("ExecuteSwap called %d times, want 1", n)
+	}
+	if o := h.order(orderID); o.Status != order.OrderStatusCompleted {
+		t.Errorf("order status = %s, want completed", o.Status)
+	}
+}
+
+// The first PositionCreatedForOrder delivery starts the swap, then the consumer shuts down
+// before the TradeWorker answers. The redelivery must not start the swap again and must
+// hand the worker the same idempotency key, so the on-chain swap runs once
+func TestRedeliveredPositionCreatedReusesIdempotencyKey(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		keys     []string
+		executed = make(map[string]*SwapResponse) // On-chain swaps by idempotency key
+	)
+	ctx, shutdown := context.WithCancel(context.Background())
+	worker := tradeWorkerFunc(func(_ context.Context, req SwapRequest) (*SwapResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		keys = append(keys, req.IdempotencyKey)
+		resp, ok := executed[req.IdempotencyKey]
+		if !ok {
+			resp = &SwapResponse{
+				TransactionHash: "0xabc",
+				ToAmount:        decimal.MustParse("0.05"),
+				ExecutedPrice:   decimal.MustParse("0.0005"),
+			}
+			executed[req.IdempotencyKey] = resp
+		}
+		if len(keys) == 1 {
+			// The swap went through, but the answer is lost in the shutdown
+			shutdown()
+			return nil, context.Canceled
+		}
+		return resp, nil
+	})
+	h := newSagaHarness(t, fixedPrice{decimal.MustParse("0.0005")}, fixedBalance{decimal.MustParse("1000")}, worker, nil)
+	orderID := h.placeMarketOrder("user-1", "100", "USDT", "ETH")
+
+	positionCreated := h.quoteAndLinkPosition(orderID, "0.0005", "0.05")
+
+	if err := h.saga.handlePositionCreated(ctx, positionCreated); err == nil {
+		t.Fatal("delivery interrupted by shutdown: expected an error")
+	}
+	if o := h.order(orderID); o.Status != order.OrderStatusExecuting {
+		t.Fatalf("order status after the interrupted swap = %s, want executing", o.Status)
+	}
+
+	// Redelivery of the same message
+	if err := h.saga.handlePositionCreated(context.Background(), positionCreated); err != nil {
+		t.Fatalf("redelivered handlePositionCreated: %v", err)
+	}
+	if err := h.run(); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(keys) != 2 || keys[0] != keys[1] {
+		t.Errorf("idempotency keys = %v, want the same key twice", keys)
+	}
+	if len(executed) != 1 {
+		t.Errorf("%d on-chain swaps, want 1", len(executed))
+	}
+	types := h.eventTypes(orderID)
+	if n := len(slices.DeleteFunc(slices.Clone(types), func(t string) bool { return t != "SwapExecuting" })); n != 1 {
+		t.Errorf("%d SwapExecuting events, want 1: %v", n, types)
+	}
+	if o := h.order(orderID); o.Status != order.OrderStatusCompleted {
+		t.Errorf("order status = %s, want completed", o.Status)
+	}
+}
+
+// Once the TradeWorker failed FailureThreshold times, orders fail with swap_unavailable
+// without reaching it; after the cooldown a trial swap closes the breaker again
+func TestOpenSwapBreakerFailsFastWithoutCallingWorker(t *testing.T) {
+	var calls atomic.Int32
+	var down atomic.Bool
+	down.Store(true)
+	worker := tradeWorkerFunc(func(ctx context.Context, req SwapRequest) (*SwapResponse, error) {
+		calls.Add(1)
+		if down.Load() {
+			return nil, errors.New("worker unavailable")
+		}
+		return &SwapResponse{TransactionHash: "0xabc", ToAmount: decimal.MustParse("0.05"), ExecutedPrice: decimal.MustParse("0.0005")}, nil
+	})
+	h := newSagaHarness(t, fixedPrice{decimal.MustParse("0.0005")}, fixedBalance{decimal.MustParse("1000")}, worker, func(s *OrderSagaRefactored) {
+		s.SwapBreaker.FailureThreshold = 2
+		s.SwapBreaker.Cooldown = 50 * time.Millisecond
+	})
+
+	place := func() string {
+		orderID := h.placeMarketOrder("user-1", "100", "USDT", "ETH")
+		if err := h.run(); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return orderID
+	}
+
+	for i := 0; i < 2; i++ {
+		if id := place(); h.failureReason(id) != "worker unavailable" {
+			t.Fatalf("order %d failure reason = %q, want the worker error", i+1, h.failureReason(id))
+		}
+	}
+
+	rejected := place()
+	if reason := h.failureReason(rejected); reason != "swap_unavailable" {
+		t.Errorf("failure reason with open breaker = %q, want swap_unavailable", reason)
+	}
+	if n := calls.Load(); n != 2 {
+		t.Errorf("ExecuteSwap called %d times, want 2 (not for the short-circuited order)", n)
+	}
+	if p, err := h.aggregateStore.LoadPositionAggregate(context.Background(), h.order(rejected).PositionID); err != nil || p.Status != position.PositionStatusClosed {
+		t.Errorf("position of the rejected order: %v, err %v; want closed", p, err)
+	}
+
+	// The worker recovers: the trial swap after the cooldown goes through
+	down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if o := h.order(place()); o.Status != order.OrderStatusCompleted {
+		t.Errorf("order after cooldown = %s, want completed", o.Status)
+	}
+	if state := h.saga.SwapBreaker.State(); state != circuitbreaker.StateClosed {
+		t.Errorf("breaker state = %s, want closed", state)
+	}
+}
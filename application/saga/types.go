@@ -3,6 +3,8 @@ package saga
 import (
 	"context"
 	"fmt"
+
+	"market_order/pkg/decimal"
 )
 
 // ===============================================
@@ -11,7 +13,12 @@ import (
 
 // PriceService интерфейс для получения цен
 type PriceService interface {
-	GetMarketPrice(ctx context.Context, from, to string) (float64, error)
+	GetMarketPrice(ctx context.Context, from, to string) (decimal.Decimal, error)
+}
+
+// BalanceService интерфейс для проверки баланса пользователя
+type BalanceService interface {
+	GetAvailableBalance(ctx context.Context, userID, currency string) (decimal.Decimal, error)
 }
 
 // TradeWorker интерфейс для исполнения swap
@@ -24,17 +31,17 @@ type SwapRequest struct {
 	IdempotencyKey string
 	FromCurrency   string
 	ToCurrency     string
-	FromAmount     float64
-	Slippage       float64
+	FromAmount     decimal.Decimal
+	Slippage       float64 // %
 }
 
 // SwapResponse represents the result of a blockchain swap
 type SwapResponse struct {
 	TransactionHash string
-	ToAmount        float64
-	ExecutedPrice   float64
-	Fees            float64
-	Slippage        float64
+	ToAmount        decimal.Decimal
+	ExecutedPrice   decimal.Decimal
+	Fees            decimal.Decimal
+	Slippage        float64 // %
 }
 
 // ===============================================
@@ -0,0 +1,171 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/messaging"
+	"market_order/pkg/logging"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// subscriberBuffer - events buffered per stream before a slow client is dropped
+const subscriberBuffer = 32
+
+// streamedEvents - order aggregate events pushed to clients
+// Saga coordination events (PositionCreatedForOrder) are not part of the order's timeline
+var streamedEvents = []string{
+	"OrderAccepted",
+	"BalanceCheckPassed",
+	"BalanceCheckFailed",
+	"PriceQuoted",
+	"QuoteRefreshed",
+	"PositionLinkedToOrder",
+	"LimitPriceSet",
+	"OrderUpdated",
+	"OrderPlacedInBook",
+	"SwapExecuting",
+	"SwapExecuted",
+	"SwapRejectedSlippage",
+	"SwapTimedOut",
+	"OrderPartiallyFilled",
+	"LimitOrderExpired",
+	"OrderExpired",
+	"OrderNeedsManualReview",
+	"OrderCompleted",
+	"OrderFailed",
+	"OrderCancelled",
+}
+
+// OrderEvent - persisted order event delivered to a stream
+type OrderEvent struct {
+	EventType string
+	Version   int
+	Timestamp time.Time
+	Data      []byte
+}
+
+// OrderEventHub is an in-process pub/sub of order events keyed by order ID
+// Fed by a per-instance queue, so every instance sees every event
+// and serves the streams of its own clients
+type OrderEventHub struct {
+	messageBus messaging.MessageBus
+	consumer   string // Unique per instance: transient queues must not be shared
+
+	mu          sync.Mutex
+	subscribers map[string]map[chan OrderEvent]struct{}
+	closed      bool
+
+	Logger *slog.Logger
+}
+
+func NewOrderEventHub(messageBus messaging.MessageBus) *OrderEventHub {
+	return &OrderEventHub{
+		messageBus:  messageBus,
+		consumer:    "order-stream." + pkguuid.New(),
+		subscribers: make(map[string]map[chan OrderEvent]struct{}),
+		Logger:      slog.Default(),
+	}
+}
+
+// Start consumes order events until ctx is cancelled, then closes every stream
+func (h *OrderEventHub) Start(ctx context.Context) error {
+	for _, eventType := range streamedEvents {
+		if err := h.messageBus.SubscribeTransient(ctx, h.consumer, eventType, h.handleEvent); err != nil {
+			return err
+		}
+	}
+
+	h.Logger.Info("Order Event Hub started, streaming order events")
+
+	<-ctx.Done()
+
+	<-h.messageBus.Drained()
+	h.close()
+	h.Logger.Info("Order Event Hub stopped")
+	return nil
+}
+
+// Subscribe returns a channel of the order's events and a function to unsubscribe
+// The channel is closed on unsubscribe, on shutdown, or when the client falls behind
+func (h *OrderEventHub) Subscribe(orderID string) (<-chan OrderEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan OrderEvent, subscriberBuffer)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+
+	if h.subscribers[orderID] == nil {
+		h.subscribers[orderID] = make(map[chan OrderEvent]struct{})
+	}
+	h.subscribers[orderID][ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.remove(orderID, ch)
+	}
+}
+
+// handleEvent fans an event out to the streams of its order
+// Never fails: a stream is best effort and must not requeue the event
+func (h *OrderEventHub) handleEvent(ctx context.Context, eventData []byte) error {
+	var evt order.BaseEvent
+	if err := json.Unmarshal(eventData, &evt); err != nil {
+		h.Logger.Warn("Order Event Hub: malformed event", logging.Err(err))
+		return nil
+	}
+
+	if evt.AggregateType != "Order" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[evt.AggregateID] {
+		select {
+		case ch <- OrderEvent{EventType: evt.EventType, Version: evt.Version, Timestamp: evt.Timestamp, Data: eventData}:
+		default:
+			// Slow client: drop the stream rather than block the consumer
+			h.Logger.Warn("Order Event Hub: stream too slow, closing", logging.OrderID(evt.AggregateID))
+			h.remove(evt.AggregateID, ch)
+		}
+	}
+
+	return nil
+}
+
+// remove closes a subscriber channel (h.mu held)
+func (h *OrderEventHub) remove(orderID string, ch chan OrderEvent) {
+	subs := h.subscribers[orderID]
+	if _, ok := subs[ch]; !ok {
+		return
+	}
+
+	delete(subs, ch)
+	close(ch)
+	if len(subs) == 0 {
+		delete(h.subscribers, orderID)
+	}
+}
+
+// close ends every stream on shutdown
+func (h *OrderEventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for orderID, subs := range h.subscribers {
+		for ch := range subs {
+			h.remove(orderID, ch)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+)
+
+// ErrOrderNotAmendable is returned when the order is already executing, closed, or rests in the order book
+var ErrOrderNotAmendable = errors.New("order cannot be amended")
+
+// ErrNotOrderOwner is returned when the order belongs to another user
+var ErrNotOrderOwner = errors.New("order belongs to another user")
+
+// AmendableOrderFields - fields of a pending order a client may change
+// to_amount is not amendable: the saga re-quotes it from the new from_amount
+var AmendableOrderFields = map[string]bool{
+	"from_amount": true,
+}
+
+// AmendOrderUseCase changes a pending market order (PATCH /orders/{id})
+//
+// IMPORTANT:
+// - Uses aggregateStore (NOT repository!)
+// - Guard rails live in the Order aggregate (UpdateOrder)
+// - Generates OrderUpdated event; the saga re-prices the order on it
+type AmendOrderUseCase struct {
+	aggregateStore *aggregates.AggregateStore // ✅ Source of truth
+
+	// Currencies - order size limits the amended amount must respect
+	Currencies *CurrencyRegistry
+}
+
+func NewAmendOrderUseCase(aggregateStore *aggregates.AggregateStore) *AmendOrderUseCase {
+	return &AmendOrderUseCase{aggregateStore: aggregateStore}
+}
+
+// Execute applies fields to userID's order and returns its new state
+// Unknown fields and invalid values are returned as order.ValidationErrors
+func (uc *AmendOrderUseCase) Execute(ctx context.Context, orderID, userID string, fields map[string]interface{}) (*order.Order, error) {
+	if err := validateAmendment(fields); err != nil {
+		return nil, err
+	}
+
+	// ✅ Load, update (generates OrderUpdated event) and save, retried on conflict
+	var amended *order.Order
+	err := uc.aggregateStore.MutateOrder(ctx, orderID, func(o *order.Order) error {
+		amended = o
+
+		if o.UserID != userID {
+			return ErrNotOrderOwner
+		}
+
+		// The order book holds the original amount of a resting limit order
+		if o.OrderType == "limit" {
+			return fmt.Errorf("%w: limit orders must be cancelled and placed again", ErrOrderNotAmendable)
+		}
+
+		if amount, ok := order.ParseAmount(fields["from_amount"]); ok && uc.Currencies != nil {
+			if err := uc.Currencies.Validate(o.FromCurrency, o.ToCurrency, amount); err != nil {
+				return err
+			}
+		}
+
+		if err := o.UpdateOrder(fields); err != nil {
+			var validationErrs order.ValidationErrors
+			if !errors.As(err, &validationErrs) && o.Status != order.OrderStatusPending {
+				return fmt.Errorf("%w: %v", ErrOrderNotAmendable, err)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return amended, nil
+}
+
+// validateAmendment rejects empty amendments and fields outside AmendableOrderFields
+func validateAmendment(fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return order.ValidationErrors{{Field: "body", Message: "no fields to amend"}}
+	}
+
+	var violations order.ValidationErrors
+	for field := range fields {
+		if !AmendableOrderFields[field] {
+			violations = append(violations, order.ValidationError{Field: field, Message: "cannot be amended"})
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool { return violations[i].Field < violations[j].Field })
+
+	if len(violations) > 0 {
+		return violations
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+)
+
+// ErrOrderNotCancellable is returned when the order is already executing or completed
+var ErrOrderNotCancellable = errors.New("order cannot be cancelled")
+
+// CancelOrderUseCase cancels a pending order on user request
+//
+// IMPORTANT:
+// - Uses aggregateStore (NOT repository!)
+// - Guard rails live in the Order aggregate (CancelOrder)
+// - Generates OrderCancelled event
+type CancelOrderUseCase struct {
+	aggregateStore *aggregates.AggregateStore // ✅ Source of truth
+}
+
+func NewCancelOrderUseCase(aggregateStore *aggregates.AggregateStore) *CancelOrderUseCase {
+	return &CancelOrderUseCase{aggregateStore: aggregateStore}
+}
+
+// Execute cancels userID's order and returns its final state
+func (uc *CancelOrderUseCase) Execute(ctx context.Context, orderID, userID, reason string) (*order.Order, error) {
+	// ✅ Load Order from EventStore (source of truth)
+	o, err := uc.aggregateStore.LoadOrderAggregate(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.UserID != userID {
+		return nil, ErrNotOrderOwner
+	}
+
+	// ✅ Execute command (generates OrderCancelled event)
+	if err := o.CancelOrder(reason); err != nil {
+		if o.Status == order.OrderStatusExecuting || o.Status == order.OrderStatusCompleted {
+			return nil, fmt.Errorf("%w: %v", ErrOrderNotCancellable, err)
+		}
+		return nil, err
+	}
+
+	// ✅ Save events to EventStore
+	if err := uc.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
+		return nil, fmt.Errorf("failed to save order events: %w", err)
+	}
+
+	return o, nil
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
 )
 
 // CompleteOrderAndUpdatePositionUseCase completes order and updates position
@@ -16,6 +18,9 @@ import (
 // - NO direct database access
 type CompleteOrderAndUpdatePositionUseCase struct {
 	aggregateStore *aggregates.AggregateStore // ✅ Source of truth
+
+	// Fees - platform fee recorded in OrderCompleted (PlatformFee)
+	Fees FeeCalculator
 }
 
 func NewCompleteOrderAndUpdatePositionUseCase(
@@ -23,20 +28,22 @@ func NewCompleteOrderAndUpdatePositionUseCase(
 ) *CompleteOrderAndUpdatePositionUseCase {
 	return &CompleteOrderAndUpdatePositionUseCase{
 		aggregateStore: aggregateStore,
+		Fees:           NewPercentageFeeCalculator(DefaultFeeSchedules),
 	}
 }
 
 type SwapResult struct {
 	TransactionHash string
-	FromAmount      float64
-	ToAmount        float64
-	ExecutedPrice   float64
-	Fees            float64
+	FromAmount      decimal.Decimal
+	ToAmount        decimal.Decimal
+	ExecutedPrice   decimal.Decimal
+	Fees            decimal.Decimal
 	Slippage        float64
 }
 
 // Execute completes order and updates position atomically
 // This is CRITICAL for consistency - both aggregates must be updated in single transaction
+// Idempotent: calling it again for the same order/position generates no new events
 func (uc *CompleteOrderAndUpdatePositionUseCase) Execute(
 	ctx context.Context,
 	orderID, positionID string,
@@ -48,8 +55,20 @@ func (uc *CompleteOrderAndUpdatePositionUseCase) Execute(
 		return fmt.Errorf("failed to load order aggregate: %w", err)
 	}
 
+	// Orders priced before STEP 2 linked positions learn theirs here (no-op if already linked)
+	if o.Status == order.OrderStatusExecuting {
+		if err := o.LinkPosition(positionID); err != nil {
+			return fmt.Errorf("failed to link position: %w", err)
+		}
+	}
+
 	// ✅ 2. Complete Order (generates OrderCompleted event)
-	if err := o.CompleteOrder(); err != nil {
+	// Network fees come from the TradeWorker, the platform fee from the fee model
+	var platformFee decimal.Decimal
+	if uc.Fees != nil {
+		platformFee = uc.Fees.Calculate(o.OrderType, o.FromAmount)
+	}
+	if err := o.CompleteOrder(swapResult.Fees, platformFee); err != nil {
 		return fmt.Errorf("failed to complete order: %w", err)
 	}
 
@@ -60,22 +79,21 @@ func (uc *CompleteOrderAndUpdatePositionUseCase) Execute(
 	}
 
 	// ✅ 4. Update Position (generates events)
-	totalValue := swapResult.FromAmount
-	pnl := 0.0 // For first order
-
-	if err := p.AddOrder(orderID, swapResult.ToAmount, totalValue, pnl); err != nil {
-		return fmt.Errorf("failed to update position: %w", err)
-	}
-
-	// ✅ 5. Save Order events to EventStore
-	if err := uc.aggregateStore.SaveOrderAggregate(ctx, o); err != nil {
-		return fmt.Errorf("failed to save order events: %w", err)
+	// Position computes average entry price and PnL from quantity and executed price
+	// A retried completion must not count ToAmount twice: skip orders already in the position
+	if !p.HasOrder(orderID) {
+		if err := p.AddOrder(orderID, swapResult.ToAmount, swapResult.ExecutedPrice, swapResult.FromAmount); err != nil {
+			return fmt.Errorf("failed to update position: %w", err)
+		}
 	}
 
-	// ✅ 6. Save Position events to EventStore
-	if err := uc.aggregateStore.SavePositionAggregate(ctx, p); err != nil {
-		return fmt.Errorf("failed to save position events: %w", err)
+	// ✅ 5. Save Order and Position events in ONE transaction
+	// A crash can no longer complete the order without updating the position
+	if err := uc.aggregateStore.SaveInTx(ctx, aggregates.OrderBatch(o), aggregates.PositionBatch(p)); err != nil {
+		return fmt.Errorf("failed to save order and position events: %w", err)
 	}
+	o.ClearChanges()
+	p.ClearChanges()
 
 	// Events are automatically published via Outbox pattern
 	// Projections will update database independently
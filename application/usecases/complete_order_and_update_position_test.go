@@ -0,0 +1,106 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"market_order/application/aggregates"
+	"market_order/domain/order"
+	"market_order/domain/position"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+// executingOrder saves a market order for 1000 USDT whose swap has started, and an open position
+func executingOrder(t *testing.T, store *aggregates.AggregateStore) (orderID, positionID string) {
+	t.Helper()
+	ctx := context.Background()
+
+	o := order.NewOrder()
+	orderID = pkguuid.New()
+	err := errors.Join(
+		o.AcceptOrder(orderID, "user-1", decimal.MustParse("1000"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, nil),
+		o.QuotePrice(decimal.MustParse("50000"), decimal.MustParse("0.02")),
+		o.StartSwapExecution("swap-key"),
+		store.SaveOrderAggregate(ctx, o),
+	)
+	if err != nil {
+		t.Fatalf("order setup: %v", err)
+	}
+
+	p := position.NewPosition()
+	positionID = pkguuid.New()
+	if err := errors.Join(p.CreatePosition(positionID, "user-1", "USDT"), store.SavePositionAggregate(ctx, p)); err != nil {
+		t.Fatalf("position setup: %v", err)
+	}
+	return orderID, positionID
+}
+
+var testSwapResult = SwapResult{
+	TransactionHash: "0xswap",
+	FromAmount:      decimal.MustParse("1000"),
+	ToAmount:        decimal.MustParse("0.02"),
+	ExecutedPrice:   decimal.MustParse("50000"),
+}
+
+func TestCompleteOrderExecuteTwiceCountsPositionOnce(t *testing.T) {
+	ctx := context.Background()
+	store := aggregates.NewAggregateStore(eventstore.NewMemoryEventStore())
+	uc := NewCompleteOrderAndUpdatePositionUseCase(store)
+	orderID, positionID := executingOrder(t, store)
+
+	for i := 0; i < 2; i++ {
+		if err := uc.Execute(ctx, orderID, positionID, testSwapResult); err != nil {
+			t.Fatalf("Execute #%d: %v", i+1, err)
+		}
+	}
+
+	o, err := store.LoadOrderAggregate(ctx, orderID)
+	if err != nil {
+		t.Fatalf("LoadOrderAggregate: %v", err)
+	}
+	if o.Status != order.OrderStatusCompleted {
+		t.Errorf("order status = %s, want %s", o.Status, order.OrderStatusCompleted)
+	}
+
+	p, err := store.LoadPositionAggregate(ctx, positionID)
+	if err != nil {
+		t.Fatalf("LoadPositionAggregate: %v", err)
+	}
+	if !p.RemainingAmount.Equal(testSwapResult.ToAmount) || !p.Cost.Equal(testSwapResult.FromAmount) {
+		t.Errorf("position holds %s for %s, want %s for %s", p.RemainingAmount, p.Cost, testSwapResult.ToAmount, testSwapResult.FromAmount)
+	}
+	if p.Version != 2 {
+		t.Errorf("position version = %d, want 2 (created + one update)", p.Version)
+	}
+}
+
+func TestCompleteOrderSkipsOrderAlreadyInPosition(t *testing.T) {
+	ctx := context.Background()
+	store := aggregates.NewAggregateStore(eventstore.NewMemoryEventStore())
+	uc := NewCompleteOrderAndUpdatePositionUseCase(store)
+	orderID, positionID := executingOrder(t, store)
+
+	// An earlier attempt updated the position but never completed the order
+	err := store.MutatePosition(ctx, positionID, func(p *position.Position) error {
+		return p.AddOrder(orderID, testSwapResult.ToAmount, testSwapResult.ExecutedPrice, testSwapResult.FromAmount)
+	})
+	if err != nil {
+		t.Fatalf("MutatePosition: %v", err)
+	}
+
+	if err := uc.Execute(ctx, orderID, positionID, testSwapResult); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	p, err := store.LoadPositionAggregate(ctx, positionID)
+	if err != nil {
+		t.Fatalf("LoadPositionAggregate: %v", err)
+	}
+	if !p.RemainingAmount.Equal(testSwapResult.ToAmount) {
+		t.Errorf("position remaining = %s, want %s", p.RemainingAmount, testSwapResult.ToAmount)
+	}
+}
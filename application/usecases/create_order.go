@@ -2,12 +2,30 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"market_order/application/aggregates"
 	"market_order/domain/order"
+	"market_order/infrastructure/idempotency"
+	"market_order/pkg/decimal"
 )
 
+// ErrRequestInProgress is returned when another request with the same Idempotency-Key is still being processed
+var ErrRequestInProgress = errors.New("request with this idempotency key is in progress")
+
+// ErrOrderExists is returned when the new order's ID already has events (ID collision or re-create)
+var ErrOrderExists = errors.New("order already exists")
+
+// DefaultInFlightTimeout - an in-progress key older than this is considered abandoned
+// (the first request crashed before saving the order) and may be taken over
+const DefaultInFlightTimeout = 30 * time.Second
+
+// DefaultMarketOrderTTL - a market order not executed within this is expired by the saga
+// instead of executing at a stale price (e.g. after a price feed outage)
+const DefaultMarketOrderTTL = 30 * time.Second
+
 // CreateOrderUseCase creates a new order
 //
 // IMPORTANT:
@@ -17,34 +35,176 @@ import (
 // - Saves to EventStore
 // - NO direct database access
 type CreateOrderUseCase struct {
-	aggregateStore *aggregates.AggregateStore // ✅ Source of truth
+	aggregateStore *aggregates.AggregateStore        // ✅ Source of truth
+	requestKeys    *idempotency.RequestKeyRepository // Idempotency-Key → order_id
+
+	// InFlightTimeout - after this an in-progress key without an order is taken over
+	InFlightTimeout time.Duration
+
+	// MarketOrderTTL - expires_at of market orders that do not set one (0 - no TTL)
+	MarketOrderTTL time.Duration
+
+	// Currencies - tradeable pairs and per-currency order size limits
+	Currencies *CurrencyRegistry
 }
 
-func NewCreateOrderUseCase(aggregateStore *aggregates.AggregateStore) *CreateOrderUseCase {
-	return &CreateOrderUseCase{aggregateStore: aggregateStore}
+func NewCreateOrderUseCase(aggregateStore *aggregates.AggregateStore, requestKeys *idempotency.RequestKeyRepository) *CreateOrderUseCase {
+	return &CreateOrderUseCase{
+		aggregateStore:  aggregateStore,
+		requestKeys:     requestKeys,
+		InFlightTimeout: DefaultInFlightTimeout,
+		MarketOrderTTL:  DefaultMarketOrderTTL,
+		Currencies:      DefaultCurrencyRegistry(),
+	}
 }
 
 type CreateOrderRequest struct {
 	OrderID      string
 	UserID       string
-	FromAmount   float64
+	FromAmount   decimal.Decimal
 	FromCurrency string
 	ToCurrency   string
 	OrderType    string
+	LimitPrice   decimal.Decimal // Required for "limit" orders
+	MaxSlippage  float64         // Swap slippage tolerance in %, 0 means order.DefaultMaxSlippage
+	TimeInForce  string          // Limit orders only: "GTC" (default), "IOC" or "GTD"
+	ExpiresAt    time.Time       // Required for "GTD" orders; market orders default to now + MarketOrderTTL
+
+	// Metadata - optional client data (client_order_id, tags), stored in OrderAccepted metadata
+	Metadata map[string]string
+
+	// IdempotencyKey - optional client key; a repeated key returns the original order
+	IdempotencyKey string
 }
 
-func (uc *CreateOrderUseCase) Execute(ctx context.Context, req CreateOrderRequest) error {
+// CreateOrderResult is the order created (or found) for a request
+type CreateOrderResult struct {
+	OrderID  string
+	Replayed bool // true if the order was created by an earlier request with the same key
+}
+
+func (uc *CreateOrderUseCase) Execute(ctx context.Context, req CreateOrderRequest) (*CreateOrderResult, error) {
+	// Reject unsupported pairs before OrderAccepted: the saga would only fail at pricing
+	if err := uc.Currencies.Validate(req.FromCurrency, req.ToCurrency, req.FromAmount); err != nil {
+		return nil, err
+	}
+
+	if req.IdempotencyKey == "" {
+		if err := uc.createOrder(ctx, req); err != nil {
+			return nil, err
+		}
+		return &CreateOrderResult{OrderID: req.OrderID}, nil
+	}
+
+	// Claim the key before creating the order, so a concurrent retry can't create a second one
+	reserved, err := uc.requestKeys.Reserve(ctx, req.UserID, req.IdempotencyKey, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !reserved {
+		existing, err := uc.resolveExistingKey(ctx, req)
+		if err != nil || existing != nil {
+			return existing, err
+		}
+
+		// Abandoned key was released: claim it again
+		reserved, err = uc.requestKeys.Reserve(ctx, req.UserID, req.IdempotencyKey, req.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if !reserved {
+			return nil, ErrRequestInProgress
+		}
+	}
+
+	if err := uc.createOrder(ctx, req); err != nil {
+		// Let the client retry with the same key
+		if releaseErr := uc.requestKeys.Release(ctx, req.UserID, req.IdempotencyKey, req.OrderID); releaseErr != nil {
+			return nil, errors.Join(err, releaseErr)
+		}
+		return nil, err
+	}
+
+	if err := uc.requestKeys.Complete(ctx, req.UserID, req.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
+	return &CreateOrderResult{OrderID: req.OrderID}, nil
+}
+
+// resolveExistingKey handles a key that is already taken:
+// - completed → the original order
+// - in progress, order already saved (crash before Complete) → the original order
+// - in progress and still young → ErrRequestInProgress
+// - in progress and abandoned → released, returns nil so the caller can claim it
+func (uc *CreateOrderUseCase) resolveExistingKey(ctx context.Context, req CreateOrderRequest) (*CreateOrderResult, error) {
+	rk, err := uc.requestKeys.Get(ctx, req.UserID, req.IdempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	if rk == nil {
+		return nil, nil // Released in the meantime
+	}
+
+	if rk.Status == idempotency.RequestKeyCompleted {
+		return &CreateOrderResult{OrderID: rk.OrderID, Replayed: true}, nil
+	}
+
+	_, err = uc.aggregateStore.LoadOrderAggregate(ctx, rk.OrderID)
+	if err == nil {
+		if err := uc.requestKeys.Complete(ctx, req.UserID, req.IdempotencyKey); err != nil {
+			return nil, err
+		}
+		return &CreateOrderResult{OrderID: rk.OrderID, Replayed: true}, nil
+	}
+	if !errors.Is(err, aggregates.ErrAggregateNotFound) {
+		return nil, err
+	}
+
+	if time.Since(rk.CreatedAt) < uc.InFlightTimeout {
+		return nil, ErrRequestInProgress
+	}
+
+	if err := uc.requestKeys.Release(ctx, req.UserID, req.IdempotencyKey, rk.OrderID); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// createOrder creates the aggregate and saves its OrderAccepted event
+func (uc *CreateOrderUseCase) createOrder(ctx context.Context, req CreateOrderRequest) error {
+	// OrderAccepted is version 1: saving it for an existing ID would only fail
+	// with a concurrency conflict, so report the collision as such
+	exists, err := uc.aggregateStore.Exists(ctx, req.OrderID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("%w: %s", ErrOrderExists, req.OrderID)
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.OrderType == "market" && expiresAt.IsZero() && uc.MarketOrderTTL > 0 {
+		expiresAt = time.Now().Add(uc.MarketOrderTTL)
+	}
+
 	// ✅ Create new aggregate
 	o := order.NewOrder()
 
 	// ✅ Execute command (generates OrderAccepted event)
-	err := o.AcceptOrder(
+	err = o.AcceptOrder(
 		req.OrderID,
 		req.UserID,
 		req.FromAmount,
 		req.FromCurrency,
 		req.ToCurrency,
 		req.OrderType,
+		req.LimitPrice,
+		req.MaxSlippage,
+		req.TimeInForce,
+		expiresAt,
+		req.Metadata,
 	)
 	if err != nil {
 		return err
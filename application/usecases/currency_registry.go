@@ -0,0 +1,133 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
+)
+
+// ErrUnsupportedPair is returned when the currency pair is not tradeable
+var ErrUnsupportedPair = errors.New("unsupported currency pair")
+
+// CurrencyLimits - order size limits in units of the spent (from) currency
+type CurrencyLimits struct {
+	MinOrderAmount decimal.Decimal
+	MaxOrderAmount decimal.Decimal // 0 = no upper limit
+}
+
+// CurrencyRegistry is the allow-list of tradeable pairs and per-currency order size limits
+// Pairs are "BASE/QUOTE" and can be traded in both directions (buy and sell)
+type CurrencyRegistry struct {
+	pairs  map[string]bool
+	limits map[string]CurrencyLimits
+}
+
+func NewCurrencyRegistry(pairs []string, limits map[string]CurrencyLimits) *CurrencyRegistry {
+	r := &CurrencyRegistry{
+		pairs:  make(map[string]bool, len(pairs)),
+		limits: limits,
+	}
+	for _, pair := range pairs {
+		r.pairs[strings.ToUpper(strings.TrimSpace(pair))] = true
+	}
+	return r
+}
+
+// DefaultCurrencyPairs - tradeable pairs unless configured otherwise (SUPPORTED_PAIRS)
+var DefaultCurrencyPairs = []string{"BTC/USDT", "ETH/USDT", "BTC/USDC", "ETH/USDC"}
+
+// DefaultCurrencyLimits - order size limits per spent currency
+var DefaultCurrencyLimits = map[string]CurrencyLimits{
+	"USDT": {MinOrderAmount: decimal.NewFromInt(10), MaxOrderAmount: decimal.NewFromInt(1_000_000)},
+	"USDC": {MinOrderAmount: decimal.NewFromInt(10), MaxOrderAmount: decimal.NewFromInt(1_000_000)},
+	"BTC":  {MinOrderAmount: decimal.MustParse("0.0001"), MaxOrderAmount: decimal.NewFromInt(100)},
+	"ETH":  {MinOrderAmount: decimal.MustParse("0.001"), MaxOrderAmount: decimal.NewFromInt(1_000)},
+}
+
+// ParseCurrencyLimits parses "USDT:10:1000000,BTC:0.0001" (the ORDER_LIMITS format):
+// currency:min[:max], max 0 or omitted = no upper limit
+// Listed currencies replace their entry in base, the others keep base limits
+func ParseCurrencyLimits(s string, base map[string]CurrencyLimits) (map[string]CurrencyLimits, error) {
+	limits := make(map[string]CurrencyLimits, len(base))
+	for currency, l := range base {
+		limits[currency] = l
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid order limit %q: want currency:min[:max]", entry)
+		}
+
+		var l CurrencyLimits
+		var err error
+		if l.MinOrderAmount, err = decimal.Parse(parts[1]); err != nil || l.MinOrderAmount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid minimum in order limit %q", entry)
+		}
+		if len(parts) == 3 {
+			l.MaxOrderAmount, err = decimal.Parse(parts[2])
+			if err != nil || l.MaxOrderAmount.Sign() < 0 || (l.MaxOrderAmount.IsPositive() && l.MaxOrderAmount.LessThan(l.MinOrderAmount)) {
+				return nil, fmt.Errorf("invalid maximum in order limit %q", entry)
+			}
+		}
+		limits[strings.ToUpper(parts[0])] = l
+	}
+	return limits, nil
+}
+
+// DefaultCurrencyRegistry - registry of the default pairs and limits
+func DefaultCurrencyRegistry() *CurrencyRegistry {
+	return NewCurrencyRegistry(DefaultCurrencyPairs, DefaultCurrencyLimits)
+}
+
+// Pairs returns the tradeable pairs in alphabetical order
+func (r *CurrencyRegistry) Pairs() []string {
+	pairs := make([]string, 0, len(r.pairs))
+	for pair := range r.pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// IsSupported reports whether from → to is a tradeable pair (either direction)
+func (r *CurrencyRegistry) IsSupported(from, to string) bool {
+	return r.pairs[from+"/"+to] || r.pairs[to+"/"+from]
+}
+
+// Validate checks the pair and the order size for the spent currency
+// Size violations are returned as order.ValidationErrors (field from_amount)
+func (r *CurrencyRegistry) Validate(from, to string, amount decimal.Decimal) error {
+	if !r.IsSupported(from, to) {
+		return fmt.Errorf("%w: %s/%s", ErrUnsupportedPair, from, to)
+	}
+
+	limits, ok := r.limits[from]
+	if !ok {
+		return nil
+	}
+
+	if amount.LessThan(limits.MinOrderAmount) {
+		return order.ValidationErrors{{
+			Field:   "from_amount",
+			Message: fmt.Sprintf("minimum order amount for %s is %s", from, limits.MinOrderAmount),
+		}}
+	}
+	if limits.MaxOrderAmount.IsPositive() && amount.GreaterThan(limits.MaxOrderAmount) {
+		return order.ValidationErrors{{
+			Field:   "from_amount",
+			Message: fmt.Sprintf("maximum order amount for %s is %s", from, limits.MaxOrderAmount),
+		}}
+	}
+
+	return nil
+}
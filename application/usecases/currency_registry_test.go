@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"errors"
+	"testing"
+
+	"market_order/domain/order"
+	"market_order/pkg/decimal"
+)
+
+func TestCurrencyRegistryEnforcesPerCurrencyMinimum(t *testing.T) {
+	r := DefaultCurrencyRegistry()
+
+	tests := []struct {
+		from, to string
+		amount   string
+		wantErr  bool
+	}{
+		{"USDT", "BTC", "10", false},
+		{"USDT", "BTC", "9.99", true},
+		// The BTC minimum is far below the old universal 10
+		{"BTC", "USDT", "0.0001", false},
+		{"BTC", "USDT", "0.00005", true},
+		{"ETH", "USDT", "0.001", false},
+		{"ETH", "USDT", "1001", true}, // above the ETH maximum
+	}
+	for _, tt := range tests {
+		err := r.Validate(tt.from, tt.to, decimal.MustParse(tt.amount))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%s→%s, %s) error = %v, wantErr %v", tt.from, tt.to, tt.amount, err, tt.wantErr)
+			continue
+		}
+		var violations order.ValidationErrors
+		if tt.wantErr && (!errors.As(err, &violations) || violations[0].Field != "from_amount") {
+			t.Errorf("Validate(%s→%s, %s) error = %v, want a from_amount violation", tt.from, tt.to, tt.amount, err)
+		}
+	}
+
+	if err := r.Validate("USDT", "DOGE", decimal.NewFromInt(100)); !errors.Is(err, ErrUnsupportedPair) {
+		t.Errorf("unsupported pair error = %v, want ErrUnsupportedPair", err)
+	}
+}
+
+func TestParseCurrencyLimits(t *testing.T) {
+	limits, err := ParseCurrencyLimits("usdt:25:50000, BTC:0.001", DefaultCurrencyLimits)
+	if err != nil {
+		t.Fatalf("ParseCurrencyLimits: %v", err)
+	}
+
+	if l := limits["USDT"]; !l.MinOrderAmount.Equal(decimal.NewFromInt(25)) || !l.MaxOrderAmount.Equal(decimal.NewFromInt(50000)) {
+		t.Errorf("USDT limits = %s..%s, want 25..50000", l.MinOrderAmount, l.MaxOrderAmount)
+	}
+	// Max omitted: no upper limit
+	if l := limits["BTC"]; !l.MinOrderAmount.Equal(decimal.MustParse("0.001")) || !l.MaxOrderAmount.IsZero() {
+		t.Errorf("BTC limits = %s..%s, want 0.001..0", l.MinOrderAmount, l.MaxOrderAmount)
+	}
+	// Unlisted currencies keep the base limits, and the base is not modified
+	if l := limits["ETH"]; !l.MinOrderAmount.Equal(DefaultCurrencyLimits["ETH"].MinOrderAmount) {
+		t.Errorf("ETH min = %s, want the default", l.MinOrderAmount)
+	}
+	if !DefaultCurrencyLimits["USDT"].MinOrderAmount.Equal(decimal.NewFromInt(10)) {
+		t.Error("ParseCurrencyLimits modified the base limits")
+	}
+
+	r := NewCurrencyRegistry(DefaultCurrencyPairs, limits)
+	if err := r.Validate("USDT", "BTC", decimal.NewFromInt(20)); err == nil {
+		t.Error("20 USDT with a configured minimum of 25: expected a violation")
+	}
+
+	for _, bad := range []string{"USDT", "USDT:abc", "USDT:10:5", "USDT:-1", ":10", "USDT:1:2:3"} {
+		if _, err := ParseCurrencyLimits(bad, nil); err == nil {
+			t.Errorf("ParseCurrencyLimits(%q): expected an error", bad)
+		}
+	}
+}
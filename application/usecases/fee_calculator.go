@@ -0,0 +1,85 @@
+package usecases
+
+import (
+	"fmt"
+	"strings"
+
+	"market_order/pkg/decimal"
+)
+
+// FeeCalculator computes the platform fee of an order, in units of the spent (from) currency
+// Distinct from the network fees reported by the TradeWorker
+type FeeCalculator interface {
+	Calculate(orderType string, fromAmount decimal.Decimal) decimal.Decimal
+}
+
+// FeeSchedule - percentage fee with a floor
+type FeeSchedule struct {
+	Rate    decimal.Decimal // Fraction of from_amount, e.g. 0.001 = 0.1%
+	Minimum decimal.Decimal // Charged when Rate * from_amount is lower
+}
+
+// DefaultFeeSchedules - platform fees per order type: market orders take liquidity, limit orders make it
+var DefaultFeeSchedules = map[string]FeeSchedule{
+	"market": {Rate: decimal.MustParse("0.001")},
+	"limit":  {Rate: decimal.MustParse("0.0005")},
+}
+
+// PercentageFeeCalculator charges Rate * from_amount, at least Minimum, per order type
+// Order types without a schedule are not charged
+type PercentageFeeCalculator struct {
+	schedules map[string]FeeSchedule
+}
+
+func NewPercentageFeeCalculator(schedules map[string]FeeSchedule) *PercentageFeeCalculator {
+	return &PercentageFeeCalculator{schedules: schedules}
+}
+
+// Calculate returns the fee for fromAmount, never more than fromAmount itself
+func (c *PercentageFeeCalculator) Calculate(orderType string, fromAmount decimal.Decimal) decimal.Decimal {
+	schedule, ok := c.schedules[orderType]
+	if !ok || !fromAmount.IsPositive() {
+		return decimal.Zero
+	}
+
+	fee := fromAmount.Mul(schedule.Rate)
+	if fee.LessThan(schedule.Minimum) {
+		fee = schedule.Minimum
+	}
+	return decimal.Min(fee, fromAmount)
+}
+
+// ParseFeeSchedules parses "market:0.001:0.5,limit:0.0005" (the PLATFORM_FEES format):
+// order_type:rate[:minimum], minimum omitted = 0
+// Listed order types replace their entry in base, the others keep base schedules
+func ParseFeeSchedules(s string, base map[string]FeeSchedule) (map[string]FeeSchedule, error) {
+	schedules := make(map[string]FeeSchedule, len(base))
+	for orderType, f := range base {
+		schedules[orderType] = f
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid platform fee %q: want order_type:rate[:minimum]", entry)
+		}
+
+		var f FeeSchedule
+		var err error
+		if f.Rate, err = decimal.Parse(parts[1]); err != nil || f.Rate.Sign() < 0 || f.Rate.Cmp(decimal.NewFromInt(1)) >= 0 {
+			return nil, fmt.Errorf("invalid rate in platform fee %q", entry)
+		}
+		if len(parts) == 3 {
+			if f.Minimum, err = decimal.Parse(parts[2]); err != nil || f.Minimum.Sign() < 0 {
+				return nil, fmt.Errorf("invalid minimum in platform fee %q", entry)
+			}
+		}
+		schedules[strings.ToLower(parts[0])] = f
+	}
+	return schedules, nil
+}
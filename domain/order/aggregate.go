@@ -1,9 +1,12 @@
 package order
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
+
+	"market_order/pkg/decimal"
 )
 
 // OrderStatus представляет статус заказа
@@ -16,21 +19,61 @@ const (
 	OrderStatusFailed    OrderStatus = "failed"
 )
 
+// ErrOverfill - fill превышает остаток ордера (FromAmount - FilledAmount)
+var ErrOverfill = errors.New("fill exceeds remaining order amount")
+
+// ErrLimitOrderTriggered - лимитный ордер уже исполняется по рынку (LimitOrderMonitor), истечь не может
+var ErrLimitOrderTriggered = errors.New("limit order already triggered at market price")
+
+// Time in force лимитного ордера
+const (
+	TimeInForceGTC = "GTC" // Good-Til-Cancelled: лежит в книге до исполнения или отмены
+	TimeInForceIOC = "IOC" // Immediate-Or-Cancel: неисполненный при размещении остаток снимается
+	TimeInForceGTD = "GTD" // Good-Til-Date: снимается reaper'ом после ExpiresAt
+)
+
+// Допустимое проскальзывание swap, в процентах
+const (
+	// DefaultMaxSlippage - если max_slippage не задан
+	DefaultMaxSlippage = 0.5
+	// MaxSlippageLimit - верхняя граница max_slippage, которую может выбрать пользователь
+	MaxSlippageLimit = 5.0
+)
+
+// fillTolerance - относительная погрешность при сравнении суммы fill'ов с FromAmount
+// Покупка размещается в книге в base: FromAmount / LimitPrice округляется до decimal.Scale знаков,
+// поэтому сумма fill'ов (количество * LimitPrice) может разойтись с FromAmount в последних знаках
+var fillTolerance = decimal.MustParse("0.000000001")
+
 // Order - агрегат заказа
 type Order struct {
 	// Состояние
-	ID            string
-	UserID        string
-	FromAmount    float64
-	FromCurrency  string
-	ToCurrency    string
-	ToAmount      float64
-	ExecutedPrice float64
-	OrderType     string // "market" или "limit"
-	Status        OrderStatus
-	Version       int
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	ID                 string
+	UserID             string
+	FromAmount         decimal.Decimal
+	FromCurrency       string
+	ToCurrency         string
+	ToAmount           decimal.Decimal
+	ExecutedPrice      decimal.Decimal
+	QuotedAt           time.Time         // Время последней котировки (PriceQuoted, QuoteRefreshed)
+	FilledAmount       decimal.Decimal   // Исполнено частичными fill'ами, в FromCurrency
+	LimitPrice         decimal.Decimal   // Только для "limit"
+	OrderType          string            // "market" или "limit"
+	MaxSlippage        float64           // Допустимое проскальзывание swap, %
+	TimeInForce        string            // Только для "limit": GTC, IOC или GTD
+	ExpiresAt          time.Time         // GTD: снятие с книги; market: TTL исполнения
+	ExpiredAmount      decimal.Decimal   // Снято с книги по time in force, в FromCurrency
+	SwapIdempotencyKey string            // Idempotency key запущенного swap (SwapExecuting)
+	TransactionHash    string            // Хеш транзакции записанного swap (SwapExecuted)
+	PositionID         string            // Позиция ордера (PositionLinkedToOrder)
+	Fees               decimal.Decimal   // Сетевые комиссии swap (OrderCompleted)
+	PlatformFee        decimal.Decimal   // Комиссия платформы, в FromCurrency (OrderCompleted)
+	ClientMetadata     map[string]string // Метаданные клиента из OrderAccepted (client_order_id, теги)
+	ExpiredAt          time.Time         // Market ордер истёк до исполнения (OrderExpired)
+	Status             OrderStatus
+	Version            int
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
 
 	// Несохранённые события
 	Changes []interface{}
@@ -54,6 +97,13 @@ func (o *Order) When(event interface{}) error {
 		o.FromCurrency = e.FromCurrency
 		o.ToCurrency = e.ToCurrency
 		o.OrderType = e.OrderType
+		o.LimitPrice = e.LimitPrice
+		o.MaxSlippage = e.MaxSlippage
+		o.TimeInForce = e.TimeInForce
+		if e.ExpiresAt != nil {
+			o.ExpiresAt = *e.ExpiresAt
+		}
+		o.ClientMetadata = ClientMetadataFrom(e.Metadata)
 		o.Status = OrderStatusPending
 		o.Version = e.Version
 		o.CreatedAt = e.Timestamp
@@ -62,15 +112,25 @@ func (o *Order) When(event interface{}) error {
 	case PriceQuoted:
 		o.ToAmount = e.ToAmount
 		o.ExecutedPrice = e.Price
+		o.QuotedAt = e.QuoteTimestamp
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
+	case QuoteRefreshed:
+		o.ToAmount = e.ToAmount
+		o.ExecutedPrice = e.Price
+		o.QuotedAt = e.QuoteTimestamp
 		o.Version = e.Version
 		o.UpdatedAt = e.Timestamp
 
 	case SwapExecuting:
 		o.Status = OrderStatusExecuting
+		o.SwapIdempotencyKey = e.IdempotencyKey
 		o.Version = e.Version
 		o.UpdatedAt = e.Timestamp
 
 	case SwapExecuted:
+		o.TransactionHash = e.TransactionHash
 		o.ToAmount = e.ToAmount
 		o.ExecutedPrice = e.ExecutedPrice
 		o.Version = e.Version
@@ -81,6 +141,8 @@ func (o *Order) When(event interface{}) error {
 		o.FromAmount = e.FromAmount
 		o.ToAmount = e.ToAmount
 		o.ExecutedPrice = e.ExecutedPrice
+		o.Fees = e.Fees
+		o.PlatformFee = e.PlatformFee
 		o.Version = e.Version
 		o.UpdatedAt = e.Timestamp
 
@@ -95,6 +157,7 @@ func (o *Order) When(event interface{}) error {
 
 	case LimitPriceSet:
 		o.ExecutedPrice = e.LimitPrice
+		o.LimitPrice = e.LimitPrice
 		o.Version = e.Version
 		o.UpdatedAt = e.Timestamp
 
@@ -102,11 +165,11 @@ func (o *Order) When(event interface{}) error {
 		for key, value := range e.UpdatedFields {
 			switch key {
 			case "from_amount":
-				if v, ok := value.(float64); ok {
+				if v, ok := ParseAmount(value); ok {
 					o.FromAmount = v
 				}
 			case "to_amount":
-				if v, ok := value.(float64); ok {
+				if v, ok := ParseAmount(value); ok {
 					o.ToAmount = v
 				}
 			}
@@ -114,6 +177,11 @@ func (o *Order) When(event interface{}) error {
 		o.Version = e.Version
 		o.UpdatedAt = e.Timestamp
 
+	case PositionLinkedToOrder:
+		o.PositionID = e.PositionID
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
 	case OrderCancelled:
 		o.Status = OrderStatusFailed
 		o.Version = e.Version
@@ -132,11 +200,42 @@ func (o *Order) When(event interface{}) error {
 		o.UpdatedAt = e.Timestamp
 
 	case OrderPartiallyFilled:
-		o.ToAmount += e.FilledAmount
+		o.ToAmount = o.ToAmount.Add(e.FilledAmount)
+		o.FilledAmount = o.FilledAmount.Add(e.SpentAmount)
 		o.ExecutedPrice = e.ExecutedPrice
 		o.Version = e.Version
 		o.UpdatedAt = e.Timestamp
 
+	case LimitOrderExpired:
+		o.ExpiredAmount = o.ExpiredAmount.Add(e.ExpiredAmount)
+		// Без Status ордер ждёт fill'ов сматченной части (OrderCompleted по последнему)
+		if e.Status != "" {
+			o.Status = OrderStatus(e.Status)
+		}
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
+	case OrderExpired:
+		// Статус меняет компенсация (OrderFailed)
+		o.ExpiredAt = e.ExpiredAt
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
+	case SwapRejectedSlippage:
+		// Статус меняет компенсация (OrderFailed)
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
+	case SwapTimedOut:
+		// Статус не меняется: swap мог частично исполниться
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
+	case OrderNeedsManualReview:
+		// Статус не меняется: оператор решает, как завершить ордер
+		o.Version = e.Version
+		o.UpdatedAt = e.Timestamp
+
 	default:
 		return fmt.Errorf("unknown event type: %T", event)
 	}
@@ -154,24 +253,89 @@ func (o *Order) Apply(event interface{}) error {
 	return nil
 }
 
+// GetChanges возвращает несохранённые события
+func (o *Order) GetChanges() []interface{} {
+	return o.Changes
+}
+
+// ClearChanges очищает Changes после сохранения
+func (o *Order) ClearChanges() {
+	o.Changes = nil
+}
+
 // AcceptOrder - команда: принять заказ
 func (o *Order) AcceptOrder(
 	orderID, userID string,
-	fromAmount float64,
+	fromAmount decimal.Decimal,
 	fromCurrency, toCurrency string,
 	orderType string,
+	limitPrice decimal.Decimal,
+	maxSlippage float64, // 0 - DefaultMaxSlippage
+	timeInForce string, // "" - GTC для limit
+	expiresAt time.Time, // GTD; для market - TTL исполнения
+	clientMetadata map[string]string, // Опционально: client_order_id и теги клиента
 ) error {
-	// Бизнес-валидация
-	if fromAmount <= 0 {
-		return errors.New("from_amount must be positive")
-	}
+	// Бизнес-валидация (все нарушения сразу, чтобы клиент исправил их за один запрос)
+	var violations ValidationErrors
 
-	if fromAmount < 10.0 {
-		return errors.New("minimum order amount is 10")
+	// Минимальный/максимальный размер зависит от валюты (usecases.CurrencyRegistry)
+	if !fromAmount.IsPositive() {
+		violations = append(violations, ValidationError{Field: "from_amount", Message: "must be positive"})
 	}
 
 	if orderType != "market" && orderType != "limit" {
-		return errors.New("order_type must be 'market' or 'limit'")
+		violations = append(violations, ValidationError{Field: "order_type", Message: "must be 'market' or 'limit'"})
+	}
+
+	if orderType == "limit" && !limitPrice.IsPositive() {
+		violations = append(violations, ValidationError{Field: "limit_price", Message: "must be positive for limit orders"})
+	}
+
+	if maxSlippage == 0 {
+		maxSlippage = DefaultMaxSlippage
+	}
+	if maxSlippage < 0 || maxSlippage > MaxSlippageLimit {
+		violations = append(violations, ValidationError{Field: "max_slippage", Message: fmt.Sprintf("must be between 0 and %g percent", MaxSlippageLimit)})
+	}
+
+	if orderType == "limit" && timeInForce == "" {
+		timeInForce = TimeInForceGTC
+	}
+	switch {
+	case orderType != "limit" && timeInForce != "":
+		violations = append(violations, ValidationError{Field: "time_in_force", Message: "only applies to limit orders"})
+	case timeInForce != "" && timeInForce != TimeInForceGTC && timeInForce != TimeInForceIOC && timeInForce != TimeInForceGTD:
+		violations = append(violations, ValidationError{Field: "time_in_force", Message: "must be 'GTC', 'IOC' or 'GTD'"})
+	}
+
+	var expires *time.Time
+	switch {
+	case timeInForce == TimeInForceGTD:
+		if !expiresAt.After(time.Now()) {
+			violations = append(violations, ValidationError{Field: "expires_at", Message: "must be in the future for GTD orders"})
+		}
+		expires = &expiresAt
+	case orderType == "market" && !expiresAt.IsZero():
+		// TTL market ордера: после него saga не исполняет ордер по устаревшей цене
+		if !expiresAt.After(time.Now()) {
+			violations = append(violations, ValidationError{Field: "expires_at", Message: "must be in the future"})
+		}
+		expires = &expiresAt
+	case !expiresAt.IsZero():
+		violations = append(violations, ValidationError{Field: "expires_at", Message: "only applies to GTD and market orders"})
+	}
+
+	violations = append(violations, validateClientMetadata(clientMetadata)...)
+
+	if len(violations) > 0 {
+		return violations
+	}
+
+	metadata := map[string]interface{}{
+		"user_agent": "api-v1",
+	}
+	if len(clientMetadata) > 0 {
+		metadata[clientMetadataKey] = clientMetadata
 	}
 
 	// Генерируем событие
@@ -183,28 +347,40 @@ func (o *Order) AcceptOrder(
 			EventType:     "OrderAccepted",
 			Version:       1,
 			Timestamp:     time.Now(),
-			Metadata: map[string]interface{}{
-				"user_agent": "api-v1",
-			},
+			Metadata:      metadata,
 		},
 		UserID:       userID,
 		FromAmount:   fromAmount,
 		FromCurrency: fromCurrency,
 		ToCurrency:   toCurrency,
 		OrderType:    orderType,
+		LimitPrice:   limitPrice,
+		MaxSlippage:  maxSlippage,
+		TimeInForce:  timeInForce,
+		ExpiresAt:    expires,
 	}
 
 	return o.Apply(event)
 }
 
 // QuotePrice - команда: установить котировку
-func (o *Order) QuotePrice(price, toAmount float64) error {
+func (o *Order) QuotePrice(price, toAmount decimal.Decimal) error {
+	return o.quotePrice(price, toAmount, false)
+}
+
+// RequotePrice - команда: повторная котировка изменённого ордера (OrderUpdated)
+// PriceQuoted с Requote не запускает создание позиции: у ордера уже есть первая котировка
+func (o *Order) RequotePrice(price, toAmount decimal.Decimal) error {
+	return o.quotePrice(price, toAmount, true)
+}
+
+func (o *Order) quotePrice(price, toAmount decimal.Decimal, requote bool) error {
 	// Бизнес-правила
 	if o.Status != OrderStatusPending {
 		return fmt.Errorf("cannot quote price: order status is %s", o.Status)
 	}
 
-	if price <= 0 || toAmount <= 0 {
+	if !price.IsPositive() || !toAmount.IsPositive() {
 		return errors.New("price and toAmount must be positive")
 	}
 
@@ -220,13 +396,61 @@ func (o *Order) QuotePrice(price, toAmount float64) error {
 		Price:          price,
 		ToAmount:       toAmount,
 		QuoteTimestamp: time.Now(),
+		Requote:        requote,
+	}
+
+	return o.Apply(event)
+}
+
+// RefreshStaleQuote - команда: обновить устаревшую котировку перед swap
+// В отличие от RequotePrice (изменённый ордер) сумма не менялась: устарела только цена,
+// поэтому событие отдельное (QuoteRefreshed) и хранит заменённую котировку
+func (o *Order) RefreshStaleQuote(price, toAmount decimal.Decimal) error {
+	if o.Status != OrderStatusPending {
+		return fmt.Errorf("cannot re-quote price: order status is %s", o.Status)
+	}
+
+	if o.QuotedAt.IsZero() {
+		return errors.New("cannot re-quote price: order was never quoted")
+	}
+
+	if !price.IsPositive() || !toAmount.IsPositive() {
+		return errors.New("price and toAmount must be positive")
+	}
+
+	event := QuoteRefreshed{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "QuoteRefreshed",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		Price:          price,
+		ToAmount:       toAmount,
+		PreviousPrice:  o.ExecutedPrice,
+		PreviousQuote:  o.QuotedAt,
+		QuoteTimestamp: time.Now(),
 	}
 
 	return o.Apply(event)
 }
 
+// IsQuoteStale - котировка market ордера старше maxAge, а swap ещё не запущен
+// Ордера без QuotedAt (не оценённые) не устаревают
+func (o *Order) IsQuoteStale(now time.Time, maxAge time.Duration) bool {
+	return o.OrderType == "market" && o.Status == OrderStatusPending &&
+		!o.QuotedAt.IsZero() && now.Sub(o.QuotedAt) > maxAge
+}
+
 // StartSwapExecution - команда: начать исполнение
+// Повтор с тем же ключом (redelivery) - no-op: swap уже запущен
 func (o *Order) StartSwapExecution(idempotencyKey string) error {
+	if o.SwapIdempotencyKey != "" && o.SwapIdempotencyKey == idempotencyKey {
+		return nil
+	}
+
 	if o.Status != OrderStatusPending {
 		return fmt.Errorf("cannot start execution: order status is %s", o.Status)
 	}
@@ -247,9 +471,11 @@ func (o *Order) StartSwapExecution(idempotencyKey string) error {
 }
 
 // RecordSwapExecution - команда: записать результат swap
+// positionID попадает в metadata события: STEP 4 берёт его из сохранённого SwapExecuted
 func (o *Order) RecordSwapExecution(
-	txHash string,
-	fromAmount, toAmount, executedPrice, fees, slippage float64,
+	positionID, txHash string,
+	fromAmount, toAmount, executedPrice, fees decimal.Decimal,
+	slippage float64,
 ) error {
 	if o.Status != OrderStatusExecuting {
 		return fmt.Errorf("cannot record execution: order status is %s", o.Status)
@@ -263,6 +489,9 @@ func (o *Order) RecordSwapExecution(
 			EventType:     "SwapExecuted",
 			Version:       o.Version + 1,
 			Timestamp:     time.Now(),
+			Metadata: map[string]interface{}{
+				"position_id": positionID,
+			},
 		},
 		TransactionHash: txHash,
 		FromAmount:      fromAmount,
@@ -276,7 +505,8 @@ func (o *Order) RecordSwapExecution(
 }
 
 // CompleteOrder - команда: завершить заказ
-func (o *Order) CompleteOrder() error {
+// fees - сетевые комиссии swap, platformFee - комиссия платформы в FromCurrency
+func (o *Order) CompleteOrder(fees, platformFee decimal.Decimal) error {
 	// Идемпотентность на бизнес-уровне
 	if o.Status == OrderStatusCompleted {
 		return nil // Уже завершён, ничего не делаем
@@ -298,7 +528,11 @@ func (o *Order) CompleteOrder() error {
 		FromAmount:    o.FromAmount,
 		ToAmount:      o.ToAmount,
 		ExecutedPrice: o.ExecutedPrice,
+		Fees:          fees,
+		PlatformFee:   platformFee,
 		Status:        "completed",
+		FromCurrency:  o.FromCurrency,
+		ToCurrency:    o.ToCurrency,
 	}
 
 	return o.Apply(event)
@@ -356,7 +590,7 @@ func (o *Order) InitializeOrder() error {
 }
 
 // SetLimitPrice - команда: установка лимитной цены
-func (o *Order) SetLimitPrice(limitPrice float64) error {
+func (o *Order) SetLimitPrice(limitPrice decimal.Decimal) error {
 	if o.OrderType != "limit" {
 		return errors.New("cannot set limit price: order is not a limit order")
 	}
@@ -365,7 +599,7 @@ func (o *Order) SetLimitPrice(limitPrice float64) error {
 		return fmt.Errorf("cannot set limit price: order status is %s", o.Status)
 	}
 
-	if limitPrice <= 0 {
+	if !limitPrice.IsPositive() {
 		return errors.New("limit price must be positive")
 	}
 
@@ -384,6 +618,41 @@ func (o *Order) SetLimitPrice(limitPrice float64) error {
 	return o.Apply(event)
 }
 
+// LinkPosition - команда: привязать позицию, созданную saga для ордера
+// Повтор с той же позицией - no-op; другая позиция - ошибка (у ордера одна позиция)
+func (o *Order) LinkPosition(positionID string) error {
+	if positionID == "" {
+		return errors.New("position ID is required")
+	}
+
+	if o.PositionID == positionID {
+		return nil
+	}
+
+	if o.PositionID != "" {
+		return fmt.Errorf("order already linked to position %s", o.PositionID)
+	}
+
+	if o.Status == OrderStatusCompleted || o.Status == OrderStatusFailed {
+		return fmt.Errorf("cannot link position: order status is %s", o.Status)
+	}
+
+	event := PositionLinkedToOrder{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "PositionLinkedToOrder",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		PositionID: positionID,
+		OrderID:    o.ID,
+	}
+
+	return o.Apply(event)
+}
+
 // UpdateOrder - команда: обновление параметров ордера
 func (o *Order) UpdateOrder(params map[string]interface{}) error {
 	if o.Status == OrderStatusCompleted {
@@ -394,6 +663,24 @@ func (o *Order) UpdateOrder(params map[string]interface{}) error {
 		return errors.New("cannot update failed order")
 	}
 
+	// Swap уже запущен с текущими параметрами
+	if o.Status == OrderStatusExecuting {
+		return errors.New("cannot update executing order")
+	}
+
+	// Суммы сохраняются как decimal (строкой в JSON), а не в том виде, как пришли
+	fields := make(map[string]interface{}, len(params))
+	for key, value := range params {
+		fields[key] = value
+	}
+	if v, ok := params["from_amount"]; ok {
+		amount, ok := ParseAmount(v)
+		if !ok || !amount.IsPositive() {
+			return ValidationErrors{{Field: "from_amount", Message: "must be positive"}}
+		}
+		fields["from_amount"] = amount
+	}
+
 	event := OrderUpdated{
 		BaseEvent: BaseEvent{
 			EventID:       generateUUID(),
@@ -403,7 +690,7 @@ func (o *Order) UpdateOrder(params map[string]interface{}) error {
 			Version:       o.Version + 1,
 			Timestamp:     time.Now(),
 		},
-		UpdatedFields: params,
+		UpdatedFields: fields,
 	}
 
 	return o.Apply(event)
@@ -441,12 +728,12 @@ func (o *Order) CancelOrder(reason string) error {
 }
 
 // CheckBalances - команда: проверка достаточности средств
-func (o *Order) CheckBalances(availableBalance float64) error {
+func (o *Order) CheckBalances(availableBalance decimal.Decimal) error {
 	if o.Status != OrderStatusPending {
 		return fmt.Errorf("cannot check balances: order status is %s", o.Status)
 	}
 
-	if availableBalance < o.FromAmount {
+	if availableBalance.LessThan(o.FromAmount) {
 		// Insufficient balance
 		event := BalanceCheckFailed{
 			BaseEvent: BaseEvent{
@@ -507,16 +794,32 @@ func (o *Order) PlaceInOrderBook(orderBookID string) error {
 	return o.Apply(event)
 }
 
+// RemainingToFill возвращает неисполненную часть ордера в FromCurrency
+// Истёкший по time in force остаток исполнять уже не нужно
+func (o *Order) RemainingToFill() decimal.Decimal {
+	remaining := o.FromAmount.Sub(o.FilledAmount).Sub(o.ExpiredAmount)
+	if remaining.Cmp(o.FromAmount.Mul(fillTolerance)) <= 0 {
+		return decimal.Zero
+	}
+	return remaining
+}
+
 // PartiallyFill - команда: частичное исполнение (для лимитных ордеров)
-func (o *Order) PartiallyFill(filledAmount, executedPrice float64, transactionHash string) error {
+// spentAmount - списано в FromCurrency, filledAmount - получено в ToCurrency
+func (o *Order) PartiallyFill(spentAmount, filledAmount, executedPrice decimal.Decimal, transactionHash string) error {
 	if o.Status != OrderStatusExecuting {
 		return fmt.Errorf("cannot partially fill: order status is %s", o.Status)
 	}
 
-	if filledAmount <= 0 || filledAmount > o.FromAmount {
+	if !spentAmount.IsPositive() || !filledAmount.IsPositive() {
 		return errors.New("invalid filled amount")
 	}
 
+	// Нельзя исполнить больше, чем осталось от FromAmount
+	if remaining := o.RemainingToFill(); spentAmount.GreaterThan(remaining.Add(o.FromAmount.Mul(fillTolerance))) {
+		return fmt.Errorf("%w: fill %s, remaining %s", ErrOverfill, spentAmount, remaining)
+	}
+
 	event := OrderPartiallyFilled{
 		BaseEvent: BaseEvent{
 			EventID:       generateUUID(),
@@ -526,6 +829,7 @@ func (o *Order) PartiallyFill(filledAmount, executedPrice float64, transactionHa
 			Version:       o.Version + 1,
 			Timestamp:     time.Now(),
 		},
+		SpentAmount:     spentAmount,
 		FilledAmount:    filledAmount,
 		ExecutedPrice:   executedPrice,
 		TransactionHash: transactionHash,
@@ -534,3 +838,217 @@ func (o *Order) PartiallyFill(filledAmount, executedPrice float64, transactionHa
 
 	return o.Apply(event)
 }
+
+// FillComplete - команда: завершить лимитный ордер, когда fill'ы покрыли весь FromAmount
+func (o *Order) FillComplete() error {
+	// Идемпотентность
+	if o.Status == OrderStatusCompleted {
+		return nil
+	}
+
+	if o.Status != OrderStatusExecuting {
+		return fmt.Errorf("cannot complete fill: order status is %s", o.Status)
+	}
+
+	if remaining := o.RemainingToFill(); remaining.IsPositive() {
+		return fmt.Errorf("cannot complete fill: %s %s still unfilled", remaining, o.FromCurrency)
+	}
+
+	// Лимитные ордера завершаются без use case: платформенная комиссия пока не начисляется
+	return o.CompleteOrder(decimal.Zero, decimal.Zero)
+}
+
+// ExpireLimitOrder - команда: снять неисполненный остаток лимитного ордера (GTD/IOC)
+// expiredAmount - остаток, снятый с книги, в FromCurrency (сматченное, но ещё не
+// исполненное в книге уже нет, поэтому остаток считает книга, а не ордер)
+// Если fill'ы уже покрыли остальное - ордер завершается (OrderCompleted)
+func (o *Order) ExpireLimitOrder(expiredAmount decimal.Decimal) error {
+	if o.OrderType != "limit" {
+		return errors.New("only limit orders can expire")
+	}
+
+	// Идемпотентность: уже истёк или закрыт (отменён пользователем, исполнен)
+	if o.ExpiredAmount.IsPositive() || o.Status == OrderStatusCompleted || o.Status == OrderStatusFailed {
+		return nil
+	}
+
+	// Сработал по рынку: ордер снимет с книги LimitOrderMonitor
+	if o.Status == OrderStatusPending && o.ToAmount.IsPositive() {
+		return ErrLimitOrderTriggered
+	}
+
+	if !expiredAmount.IsPositive() {
+		return errors.New("invalid expired amount")
+	}
+	expiredAmount = decimal.Min(expiredAmount, o.RemainingToFill())
+
+	// Ничего не исполнено и не ждёт исполнения - ордер закрыт без сделки
+	var status string
+	if o.FilledAmount.IsZero() && o.RemainingToFill().Sub(expiredAmount).Cmp(o.FromAmount.Mul(fillTolerance)) <= 0 {
+		status = string(OrderStatusFailed)
+	}
+
+	event := LimitOrderExpired{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "LimitOrderExpired",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		TimeInForce:   o.TimeInForce,
+		ExpiredAmount: expiredAmount,
+		FilledAmount:  o.FilledAmount,
+		Status:        status,
+		ExpiredAt:     time.Now(),
+	}
+
+	if err := o.Apply(event); err != nil {
+		return err
+	}
+
+	if o.Status == OrderStatusExecuting && o.RemainingToFill().IsZero() {
+		return o.FillComplete()
+	}
+	return nil
+}
+
+// RejectSwapSlippage - команда: swap исполнен с проскальзыванием выше MaxSlippage
+// После события saga запускает компенсацию swap-failed
+func (o *Order) RejectSwapSlippage(transactionHash string, executedToAmount, executedPrice decimal.Decimal, slippage float64) error {
+	if o.Status != OrderStatusExecuting {
+		return fmt.Errorf("cannot reject swap: order status is %s", o.Status)
+	}
+
+	if slippage <= o.MaxSlippage {
+		return fmt.Errorf("cannot reject swap: slippage %.4f%% is within %.4f%%", slippage, o.MaxSlippage)
+	}
+
+	event := SwapRejectedSlippage{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "SwapRejectedSlippage",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		TransactionHash:  transactionHash,
+		QuotedToAmount:   o.ToAmount,
+		ExecutedToAmount: executedToAmount,
+		ExecutedPrice:    executedPrice,
+		Slippage:         slippage,
+		MaxSlippage:      o.MaxSlippage,
+		RejectedAt:       time.Now(),
+	}
+
+	return o.Apply(event)
+}
+
+// ParseAmount читает сумму из нетипизированных данных: параметры UpdateOrder,
+// OrderUpdated.UpdatedFields, JSON события как map
+// decimal.Decimal - до сохранения, строка - после replay, json.Number - тело запроса,
+// float64 - события, записанные до decimal
+func ParseAmount(value interface{}) (decimal.Decimal, bool) {
+	switch v := value.(type) {
+	case decimal.Decimal:
+		return v, true
+	case json.Number:
+		amount, err := decimal.Parse(v.String())
+		return amount, err == nil
+	case string:
+		amount, err := decimal.Parse(v)
+		return amount, err == nil
+	case float64:
+		return decimal.NewFromFloat(v), true
+	}
+	return decimal.Zero, false
+}
+
+// IsExpired - market ордер не исполнен до ExpiresAt: котировка устарела
+// Ордера без ExpiresAt (созданные до TTL) не истекают
+func (o *Order) IsExpired(now time.Time) bool {
+	return o.OrderType == "market" && !o.ExpiresAt.IsZero() && now.After(o.ExpiresAt)
+}
+
+// ExpireOrder - команда: market ордер истёк до исполнения swap
+// Статус меняет компенсация saga (OrderFailed "expired")
+func (o *Order) ExpireOrder() error {
+	// Идемпотентность
+	if !o.ExpiredAt.IsZero() {
+		return nil
+	}
+
+	if o.Status != OrderStatusPending {
+		return fmt.Errorf("cannot expire order: order status is %s", o.Status)
+	}
+
+	if !o.IsExpired(time.Now()) {
+		return fmt.Errorf("cannot expire order: expires at %s", o.ExpiresAt.Format(time.RFC3339))
+	}
+
+	event := OrderExpired{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "OrderExpired",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		ExpiresAt: o.ExpiresAt,
+		ExpiredAt: time.Now(),
+	}
+
+	return o.Apply(event)
+}
+
+// RecordSwapTimeout - команда: зафиксировать таймаут swap (для ручной проверки)
+// Не компенсирует ордер: swap мог частично исполниться в блокчейне
+func (o *Order) RecordSwapTimeout(idempotencyKey string, timeout time.Duration) error {
+	if o.Status != OrderStatusExecuting {
+		return fmt.Errorf("cannot record swap timeout: order status is %s", o.Status)
+	}
+
+	event := SwapTimedOut{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "SwapTimedOut",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		IdempotencyKey: idempotencyKey,
+		Timeout:        timeout.String(),
+		TimedOutAt:     time.Now(),
+	}
+
+	return o.Apply(event)
+}
+
+// RequireManualReview - команда: передать ордер оператору после исчерпания попыток завершения
+// Не компенсирует ордер: swap уже исполнен в блокчейне
+func (o *Order) RequireManualReview(reason string, attempts int, transactionHash string) error {
+	if o.Status == OrderStatusCompleted || o.Status == OrderStatusFailed {
+		return fmt.Errorf("cannot require manual review: order status is %s", o.Status)
+	}
+
+	event := OrderNeedsManualReview{
+		BaseEvent: BaseEvent{
+			EventID:       generateUUID(),
+			AggregateID:   o.ID,
+			AggregateType: "Order",
+			EventType:     "OrderNeedsManualReview",
+			Version:       o.Version + 1,
+			Timestamp:     time.Now(),
+		},
+		Reason:          reason,
+		Attempts:        attempts,
+		TransactionHash: transactionHash,
+		FlaggedAt:       time.Now(),
+	}
+
+	return o.Apply(event)
+}
@@ -0,0 +1,181 @@
+package order
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+// newExecutingLimitOrder returns a 1000 USDT limit order placed in the book and executing
+func newExecutingLimitOrder(t *testing.T) *Order {
+	t.Helper()
+
+	o := NewOrder()
+	if err := o.AcceptOrder(generateUUID(), "user-1", decimal.MustParse("1000"), "USDT", "BTC", "limit", decimal.MustParse("50000"), 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	err := errors.Join(
+		o.InitializeOrder(),
+		o.CheckBalances(decimal.MustParse("5000")),
+		o.PlaceInOrderBook("book-1"),
+		o.StartSwapExecution("fill-key"),
+	)
+	if err != nil {
+		t.Fatalf("setup: %v", err)
+	}
+	return o
+}
+
+func TestPartiallyFillRejectsOverfill(t *testing.T) {
+	o := newExecutingLimitOrder(t)
+
+	if err := o.PartiallyFill(decimal.MustParse("600"), decimal.MustParse("0.012"), decimal.MustParse("50000"), "0xfill-1"); err != nil {
+		t.Fatalf("PartiallyFill: %v", err)
+	}
+	if want := decimal.MustParse("400"); !o.RemainingToFill().Equal(want) {
+		t.Errorf("remaining = %s, want %s", o.RemainingToFill(), want)
+	}
+
+	// 500 > 400 left: rejected without changing the order
+	version := o.Version
+	err := o.PartiallyFill(decimal.MustParse("500"), decimal.MustParse("0.01"), decimal.MustParse("50000"), "0xfill-2")
+	if !errors.Is(err, ErrOverfill) {
+		t.Fatalf("overfill error = %v, want ErrOverfill", err)
+	}
+	if o.Version != version || !o.FilledAmount.Equal(decimal.MustParse("600")) {
+		t.Errorf("overfill changed the order: version %d -> %d, filled %s", version, o.Version, o.FilledAmount)
+	}
+
+	// A partially filled order cannot be completed yet
+	if err := o.FillComplete(); err == nil {
+		t.Error("FillComplete with 400 unfilled: expected an error")
+	}
+	if o.Status != OrderStatusExecuting {
+		t.Errorf("status = %s, want %s", o.Status, OrderStatusExecuting)
+	}
+}
+
+func TestExactFillCompletesOrder(t *testing.T) {
+	o := newExecutingLimitOrder(t)
+
+	fills := []struct{ spent, filled string }{
+		{"250", "0.005"},
+		{"750", "0.015"},
+	}
+	for i, f := range fills {
+		if err := o.PartiallyFill(decimal.MustParse(f.spent), decimal.MustParse(f.filled), decimal.MustParse("50000"), "0xfill"); err != nil {
+			t.Fatalf("PartiallyFill #%d: %v", i+1, err)
+		}
+	}
+
+	if !o.RemainingToFill().IsZero() {
+		t.Fatalf("remaining = %s, want 0", o.RemainingToFill())
+	}
+	if err := o.FillComplete(); err != nil {
+		t.Fatalf("FillComplete: %v", err)
+	}
+	if o.Status != OrderStatusCompleted {
+		t.Errorf("status = %s, want %s", o.Status, OrderStatusCompleted)
+	}
+	if want := decimal.MustParse("0.02"); !o.ToAmount.Equal(want) {
+		t.Errorf("to amount = %s, want %s", o.ToAmount, want)
+	}
+
+	// Repeated completion (redelivered OrdersMatched) is a no-op
+	version := o.Version
+	if err := o.FillComplete(); err != nil || o.Version != version {
+		t.Errorf("second FillComplete: err = %v, version %d -> %d", err, version, o.Version)
+	}
+}
+
+func TestClientMetadataSurvivesReplay(t *testing.T) {
+	metadata := map[string]string{ClientOrderIDKey: "client-42", "desk": "otc"}
+
+	o := NewOrder()
+	if err := o.AcceptOrder(generateUUID(), "user-1", decimal.MustParse("100"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, metadata); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if o.ClientMetadata[ClientOrderIDKey] != "client-42" {
+		t.Errorf("client metadata = %v, want client_order_id client-42", o.ClientMetadata)
+	}
+
+	// Replay from the stored JSON, as the event store does
+	data, err := json.Marshal(o.GetChanges()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accepted OrderAccepted
+	if err := json.Unmarshal(data, &accepted); err != nil {
+		t.Fatal(err)
+	}
+	replayed := NewOrder()
+	if err := replayed.When(accepted); err != nil {
+		t.Fatalf("When: %v", err)
+	}
+	if len(replayed.ClientMetadata) != 2 || replayed.ClientMetadata["desk"] != "otc" || replayed.ClientMetadata[ClientOrderIDKey] != "client-42" {
+		t.Errorf("replayed client metadata = %v, want %v", replayed.ClientMetadata, metadata)
+	}
+}
+
+func TestAcceptOrderValidatesClientMetadata(t *testing.T) {
+	tooMany := make(map[string]string, MaxClientMetadataEntries+1)
+	for i := 0; i <= MaxClientMetadataEntries; i++ {
+		tooMany[generateUUID()] = "x"
+	}
+
+	tests := []struct {
+		name      string
+		metadata  map[string]string
+		wantField string
+	}{
+		{name: "too many entries", metadata: tooMany, wantField: "metadata"},
+		{name: "empty key", metadata: map[string]string{"": "x"}, wantField: "metadata"},
+		{name: "value too long", metadata: map[string]string{"note": strings.Repeat("x", 257)}, wantField: "metadata.note"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewOrder().AcceptOrder(generateUUID(), "user-1", decimal.MustParse("100"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, tt.metadata)
+			var violations ValidationErrors
+			if !errors.As(err, &violations) || violations[0].Field != tt.wantField {
+				t.Errorf("error = %v, want a %s violation", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestRefreshStaleQuote(t *testing.T) {
+	o := NewOrder()
+	if err := o.AcceptOrder(generateUUID(), "user-1", decimal.MustParse("100"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+		t.Fatalf("AcceptOrder: %v", err)
+	}
+	if err := o.RefreshStaleQuote(decimal.MustParse("0.0004"), decimal.MustParse("250000")); err == nil {
+		t.Fatal("RefreshStaleQuote of a never quoted order succeeded")
+	}
+
+	if err := o.QuotePrice(decimal.MustParse("0.0005"), decimal.MustParse("200000")); err != nil {
+		t.Fatalf("QuotePrice: %v", err)
+	}
+	quotedAt := o.QuotedAt
+	if !o.IsQuoteStale(quotedAt.Add(time.Minute), 30*time.Second) || o.IsQuoteStale(quotedAt.Add(time.Second), 30*time.Second) {
+		t.Fatal("IsQuoteStale does not compare the quote age with maxAge")
+	}
+
+	if err := o.RefreshStaleQuote(decimal.MustParse("0.0004"), decimal.MustParse("250000")); err != nil {
+		t.Fatalf("RefreshStaleQuote: %v", err)
+	}
+	last := o.GetChanges()[len(o.GetChanges())-1]
+	refreshed, ok := last.(QuoteRefreshed)
+	if !ok {
+		t.Fatalf("last event = %T, want QuoteRefreshed", last)
+	}
+	if !refreshed.PreviousPrice.Equal(decimal.MustParse("0.0005")) || !refreshed.PreviousQuote.Equal(quotedAt) {
+		t.Errorf("previous quote = %s at %s, want 0.0005 at %s", refreshed.PreviousPrice, refreshed.PreviousQuote, quotedAt)
+	}
+	if !o.ExecutedPrice.Equal(decimal.MustParse("0.0004")) || !o.ToAmount.Equal(decimal.MustParse("250000")) || o.QuotedAt.Before(quotedAt) {
+		t.Errorf("order quote = %s / %s at %s, want the refreshed one", o.ExecutedPrice, o.ToAmount, o.QuotedAt)
+	}
+}
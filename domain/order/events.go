@@ -3,6 +3,7 @@ package order
 import (
 	"time"
 	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
 )
 
 // BaseEvent содержит общие поля для всех событий
@@ -14,6 +15,7 @@ type BaseEvent struct {
 	Version       int                    `json:"version"`
 	Timestamp     time.Time              `json:"timestamp"`
 	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	SchemaVersion int                    `json:"schema_version,omitempty"` // Версия схемы события (см. upcasters.go)
 }
 
 // GetBaseFields extracts base fields from BaseEvent
@@ -46,11 +48,15 @@ func (b BaseEvent) GetVersion() int {
 // OrderAccepted - событие: заказ принят
 type OrderAccepted struct {
 	BaseEvent
-	UserID       string  `json:"user_id"`
-	FromAmount   float64 `json:"from_amount"`
-	FromCurrency string  `json:"from_currency"`
-	ToCurrency   string  `json:"to_currency"`
-	OrderType    string  `json:"order_type"` // "market" или "limit"
+	UserID       string          `json:"user_id"`
+	FromAmount   decimal.Decimal `json:"from_amount"`
+	FromCurrency string          `json:"from_currency"`
+	ToCurrency   string          `json:"to_currency"`
+	OrderType    string          `json:"order_type"`              // "market" или "limit"
+	LimitPrice   decimal.Decimal `json:"limit_price"`             // Только для "limit"
+	MaxSlippage  float64         `json:"max_slippage"`            // Допустимое проскальзывание swap, %
+	TimeInForce  string          `json:"time_in_force,omitempty"` // Только для "limit": GTC, IOC или GTD
+	ExpiresAt    *time.Time      `json:"expires_at,omitempty"`    // Только для GTD
 }
 
 // GetBaseEvent implements BaseFieldsProvider
@@ -61,15 +67,30 @@ func (e OrderAccepted) GetBaseEvent() eventstore.BaseFields {
 // PriceQuoted - событие: получена котировка
 type PriceQuoted struct {
 	BaseEvent
-	Price          float64   `json:"price"`
-	ToAmount       float64   `json:"to_amount"`
-	QuoteTimestamp time.Time `json:"quote_timestamp"`
+	Price          decimal.Decimal `json:"price"`
+	ToAmount       decimal.Decimal `json:"to_amount"`
+	QuoteTimestamp time.Time       `json:"quote_timestamp"`
+	Requote        bool            `json:"requote,omitempty"` // Повторная котировка после изменения ордера (OrderUpdated)
 }
 
 func (e PriceQuoted) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
+// QuoteRefreshed - событие: устаревшая котировка обновлена перед swap
+type QuoteRefreshed struct {
+	BaseEvent
+	Price          decimal.Decimal `json:"price"`
+	ToAmount       decimal.Decimal `json:"to_amount"`
+	PreviousPrice  decimal.Decimal `json:"previous_price"`
+	PreviousQuote  time.Time       `json:"previous_quote_timestamp"` // Время заменённой котировки
+	QuoteTimestamp time.Time       `json:"quote_timestamp"`
+}
+
+func (e QuoteRefreshed) GetBaseEvent() eventstore.BaseFields {
+	return e.BaseEvent.GetBaseFields()
+}
+
 // SwapExecuting - событие: начало исполнения swap
 type SwapExecuting struct {
 	BaseEvent
@@ -83,12 +104,12 @@ func (e SwapExecuting) GetBaseEvent() eventstore.BaseFields {
 // SwapExecuted - событие: swap исполнен
 type SwapExecuted struct {
 	BaseEvent
-	TransactionHash string  `json:"transaction_hash"`
-	FromAmount      float64 `json:"from_amount"`
-	ToAmount        float64 `json:"to_amount"`
-	ExecutedPrice   float64 `json:"executed_price"`
-	Fees            float64 `json:"fees"`
-	Slippage        float64 `json:"slippage"`
+	TransactionHash string          `json:"transaction_hash"`
+	FromAmount      decimal.Decimal `json:"from_amount"`
+	ToAmount        decimal.Decimal `json:"to_amount"`
+	ExecutedPrice   decimal.Decimal `json:"executed_price"`
+	Fees            decimal.Decimal `json:"fees"`
+	Slippage        float64         `json:"slippage"`
 }
 
 func (e SwapExecuted) GetBaseEvent() eventstore.BaseFields {
@@ -98,10 +119,17 @@ func (e SwapExecuted) GetBaseEvent() eventstore.BaseFields {
 // OrderCompleted - событие: заказ завершён
 type OrderCompleted struct {
 	BaseEvent
-	FromAmount    float64 `json:"from_amount"`
-	ToAmount      float64 `json:"to_amount"`
-	ExecutedPrice float64 `json:"executed_price"`
-	Status        string  `json:"status"` // "completed"
+	FromAmount    decimal.Decimal `json:"from_amount"`
+	ToAmount      decimal.Decimal `json:"to_amount"`
+	ExecutedPrice decimal.Decimal `json:"executed_price"`
+	Fees          decimal.Decimal `json:"fees"`         // Сетевые комиссии swap (TradeWorker)
+	PlatformFee   decimal.Decimal `json:"platform_fee"` // Комиссия платформы, в FromCurrency
+	Status        string          `json:"status"`       // "completed"
+
+	// Валюты пары: отчёты суммируют объёмы по валютам без загрузки потока ордера
+	// В событиях, записанных до их появления, пусты (валюты есть в OrderAccepted)
+	FromCurrency string `json:"from_currency,omitempty"`
+	ToCurrency   string `json:"to_currency,omitempty"`
 }
 
 func (e OrderCompleted) GetBaseEvent() eventstore.BaseFields {
@@ -135,7 +163,7 @@ func (e OrderInitialized) GetBaseEvent() eventstore.BaseFields {
 // LimitPriceSet - событие: установлена лимитная цена
 type LimitPriceSet struct {
 	BaseEvent
-	LimitPrice float64 `json:"limit_price"`
+	LimitPrice decimal.Decimal `json:"limit_price"`
 }
 
 func (e LimitPriceSet) GetBaseEvent() eventstore.BaseFields {
@@ -166,8 +194,8 @@ func (e OrderCancelled) GetBaseEvent() eventstore.BaseFields {
 // BalanceCheckPassed - событие: проверка баланса пройдена
 type BalanceCheckPassed struct {
 	BaseEvent
-	AvailableAmount float64 `json:"available_amount"`
-	Currency        string  `json:"currency"`
+	AvailableAmount decimal.Decimal `json:"available_amount"`
+	Currency        string          `json:"currency"`
 }
 
 func (e BalanceCheckPassed) GetBaseEvent() eventstore.BaseFields {
@@ -177,9 +205,9 @@ func (e BalanceCheckPassed) GetBaseEvent() eventstore.BaseFields {
 // BalanceCheckFailed - событие: проверка баланса не пройдена
 type BalanceCheckFailed struct {
 	BaseEvent
-	RequiredAmount  float64 `json:"required_amount"`
-	AvailableAmount float64 `json:"available_amount"`
-	Currency        string  `json:"currency"`
+	RequiredAmount  decimal.Decimal `json:"required_amount"`
+	AvailableAmount decimal.Decimal `json:"available_amount"`
+	Currency        string          `json:"currency"`
 }
 
 func (e BalanceCheckFailed) GetBaseEvent() eventstore.BaseFields {
@@ -198,18 +226,88 @@ func (e OrderPlacedInBook) GetBaseEvent() eventstore.BaseFields {
 }
 
 // OrderPartiallyFilled - событие: ордер частично исполнен
+// SpentAmount нет в событиях, записанных до учёта остатка - они остаток не уменьшают
 type OrderPartiallyFilled struct {
 	BaseEvent
-	FilledAmount    float64   `json:"filled_amount"`
-	ExecutedPrice   float64   `json:"executed_price"`
-	TransactionHash string    `json:"transaction_hash"`
-	FilledAt        time.Time `json:"filled_at"`
+	SpentAmount     decimal.Decimal `json:"spent_amount"`  // В FromCurrency
+	FilledAmount    decimal.Decimal `json:"filled_amount"` // В ToCurrency
+	ExecutedPrice   decimal.Decimal `json:"executed_price"`
+	TransactionHash string          `json:"transaction_hash"`
+	FilledAt        time.Time       `json:"filled_at"`
 }
 
 func (e OrderPartiallyFilled) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
+// LimitOrderExpired - событие: неисполненный остаток лимитного ордера снят с книги
+// GTD - по ExpiresAt (reaper), IOC - сразу после матчинга
+type LimitOrderExpired struct {
+	BaseEvent
+	TimeInForce   string          `json:"time_in_force"`
+	ExpiredAmount decimal.Decimal `json:"expired_amount"`   // Снятый остаток, в FromCurrency
+	FilledAmount  decimal.Decimal `json:"filled_amount"`    // Исполнено к моменту истечения, в FromCurrency
+	Status        string          `json:"status,omitempty"` // "failed" - ордер закрыт без сделки
+	ExpiredAt     time.Time       `json:"expired_at"`
+}
+
+func (e LimitOrderExpired) GetBaseEvent() eventstore.BaseFields {
+	return e.BaseEvent.GetBaseFields()
+}
+
+// OrderExpired - событие: market ордер не исполнен до ExpiresAt (например, недоступен price feed)
+type OrderExpired struct {
+	BaseEvent
+	ExpiresAt time.Time `json:"expires_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+func (e OrderExpired) GetBaseEvent() eventstore.BaseFields {
+	return e.BaseEvent.GetBaseFields()
+}
+
+// SwapRejectedSlippage - событие: swap отклонён, проскальзывание превысило MaxSlippage
+type SwapRejectedSlippage struct {
+	BaseEvent
+	TransactionHash  string          `json:"transaction_hash"`
+	QuotedToAmount   decimal.Decimal `json:"quoted_to_amount"`   // Из PriceQuoted
+	ExecutedToAmount decimal.Decimal `json:"executed_to_amount"` // Фактически получено
+	ExecutedPrice    decimal.Decimal `json:"executed_price"`
+	Slippage         float64         `json:"slippage"`     // %
+	MaxSlippage      float64         `json:"max_slippage"` // %
+	RejectedAt       time.Time       `json:"rejected_at"`
+}
+
+func (e SwapRejectedSlippage) GetBaseEvent() eventstore.BaseFields {
+	return e.BaseEvent.GetBaseFields()
+}
+
+// SwapTimedOut - событие: swap не ответил вовремя (требует ручной проверки)
+type SwapTimedOut struct {
+	BaseEvent
+	IdempotencyKey string    `json:"idempotency_key"`
+	Timeout        string    `json:"timeout"`
+	TimedOutAt     time.Time `json:"timed_out_at"`
+}
+
+func (e SwapTimedOut) GetBaseEvent() eventstore.BaseFields {
+	return e.BaseEvent.GetBaseFields()
+}
+
+// OrderNeedsManualReview - событие: завершение после swap не удаётся, нужен оператор
+// Деньги уже перемещены в блокчейне, поэтому компенсация невозможна
+type OrderNeedsManualReview struct {
+	BaseEvent
+	Reason          string    `json:"reason"`
+	Attempts        int       `json:"attempts"`
+	TransactionHash string    `json:"transaction_hash"`
+	FlaggedAt       time.Time `json:"flagged_at"`
+}
+
+func (e OrderNeedsManualReview) GetBaseEvent() eventstore.BaseFields {
+	return e.BaseEvent.GetBaseFields()
+}
+
 // ===============================================
 // Saga Step Events
 // ===============================================
@@ -225,7 +323,7 @@ func (e PositionCreatedForOrder) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
-// PositionLinkedToOrder - событие: позиция привязана к ордеру (saga step)
+// PositionLinkedToOrder - событие: позиция привязана к ордеру (сохраняется в EventStore)
 type PositionLinkedToOrder struct {
 	BaseEvent
 	PositionID string `json:"position_id"`
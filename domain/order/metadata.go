@@ -0,0 +1,71 @@
+package order
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ClientOrderIDKey - ключ метаданных клиента с его собственным ID ордера (сверка, поиск)
+const ClientOrderIDKey = "client_order_id"
+
+// clientMetadataKey - метаданные клиента хранятся в BaseEvent.Metadata под этим ключом,
+// отдельно от служебных (user_agent, traceparent)
+const clientMetadataKey = "client"
+
+// Ограничения метаданных клиента: попадают в каждое OrderAccepted и в read model
+const (
+	MaxClientMetadataEntries  = 16
+	maxClientMetadataKeyLen   = 64
+	maxClientMetadataValueLen = 256
+)
+
+// validateClientMetadata проверяет метаданные клиента (поле "metadata" запроса)
+func validateClientMetadata(metadata map[string]string) ValidationErrors {
+	var violations ValidationErrors
+	if len(metadata) > MaxClientMetadataEntries {
+		violations = append(violations, ValidationError{
+			Field:   "metadata",
+			Message: fmt.Sprintf("must have at most %d entries", MaxClientMetadataEntries),
+		})
+	}
+
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch {
+		case key == "" || len(key) > maxClientMetadataKeyLen:
+			violations = append(violations, ValidationError{
+				Field:   "metadata",
+				Message: fmt.Sprintf("keys must be 1-%d characters", maxClientMetadataKeyLen),
+			})
+		case len(metadata[key]) > maxClientMetadataValueLen:
+			violations = append(violations, ValidationError{
+				Field:   "metadata." + key,
+				Message: fmt.Sprintf("must be at most %d characters", maxClientMetadataValueLen),
+			})
+		}
+	}
+	return violations
+}
+
+// ClientMetadataFrom извлекает метаданные клиента из BaseEvent.Metadata
+// После replay из JSON значение - map[string]interface{}, до сохранения - map[string]string
+func ClientMetadataFrom(eventMetadata map[string]interface{}) map[string]string {
+	switch m := eventMetadata[clientMetadataKey].(type) {
+	case map[string]string:
+		return m
+	case map[string]interface{}:
+		metadata := make(map[string]string, len(m))
+		for key, value := range m {
+			if s, ok := value.(string); ok {
+				metadata[key] = s
+			}
+		}
+		return metadata
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package order
+
+import "market_order/infrastructure/messaging"
+
+// Routing key OrderAccepted - с типом ордера (OrderAccepted.market, OrderAccepted.limit):
+// рыночные и limit-ордера обрабатываются разными подписками saga и масштабируются отдельно.
+// События без order_type (до limit-ордеров) - рыночные, как и в upcaster'е v1 → v2
+func init() {
+	messaging.RegisterRoutingField("OrderAccepted", "order_type", "market")
+}
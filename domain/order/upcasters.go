@@ -0,0 +1,36 @@
+package order
+
+import "market_order/infrastructure/eventstore"
+
+// Upcasters приводят сохранённые события старых схем к текущей
+// Новый шаг регистрируется при каждом несовместимом изменении события:
+// старые потоки продолжают корректно восстанавливаться при replay
+func init() {
+	// OrderAccepted v1 → v2: order_type появился вместе с limit-ордерами,
+	// до этого все ордера были рыночными
+	eventstore.RegisterUpcaster("OrderAccepted", 1, func(fields map[string]interface{}) error {
+		if orderType, _ := fields["order_type"].(string); orderType == "" {
+			fields["order_type"] = "market"
+		}
+		return nil
+	})
+
+	// OrderAccepted v2 → v3: max_slippage задаётся при создании ордера,
+	// старые ордера saga исполняла с зашитым допуском 0.5% (= DefaultMaxSlippage)
+	eventstore.RegisterUpcaster("OrderAccepted", 2, func(fields map[string]interface{}) error {
+		if maxSlippage, _ := fields["max_slippage"].(float64); maxSlippage == 0 {
+			fields["max_slippage"] = DefaultMaxSlippage
+		}
+		return nil
+	})
+
+	// OrderAccepted v3 → v4: time_in_force у limit-ордеров,
+	// до этого они лежали в книге до исполнения или отмены (GTC)
+	eventstore.RegisterUpcaster("OrderAccepted", 3, func(fields map[string]interface{}) error {
+		orderType, _ := fields["order_type"].(string)
+		if tif, _ := fields["time_in_force"].(string); orderType == "limit" && tif == "" {
+			fields["time_in_force"] = TimeInForceGTC
+		}
+		return nil
+	})
+}
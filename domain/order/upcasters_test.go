@@ -0,0 +1,40 @@
+package order
+
+import (
+	"encoding/json"
+	"testing"
+
+	"market_order/infrastructure/eventstore"
+)
+
+func TestUpcastLegacyOrderAcceptedMaxSlippage(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want float64
+	}{
+		// Before max_slippage the saga swapped every order with a hardcoded 0.5%
+		{name: "v1 event", data: `{"event_type": "OrderAccepted", "order_type": "market"}`, want: DefaultMaxSlippage},
+		{name: "v2 event", data: `{"event_type": "OrderAccepted", "order_type": "market", "schema_version": 2}`, want: DefaultMaxSlippage},
+		{name: "v3 event keeps its tolerance", data: `{"event_type": "OrderAccepted", "order_type": "market", "max_slippage": 2, "schema_version": 3}`, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := eventstore.Upcast("OrderAccepted", []byte(tt.data))
+			if err != nil {
+				t.Fatalf("Upcast: %v", err)
+			}
+
+			var fields struct {
+				MaxSlippage float64 `json:"max_slippage"`
+			}
+			if err := json.Unmarshal(data, &fields); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if fields.MaxSlippage != tt.want {
+				t.Errorf("max_slippage = %v, want %v", fields.MaxSlippage, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+func TestEventIDsAreUnique(t *testing.T) {
+	const n = 100_000
+
+	seen := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		o := NewOrder()
+		if err := o.AcceptOrder(generateUUID(), "user-1", decimal.MustParse("100"), "USDT", "BTC", "market", decimal.Zero, 0, "", time.Time{}, nil); err != nil {
+			t.Fatalf("AcceptOrder: %v", err)
+		}
+		id := o.GetChanges()[0].(OrderAccepted).EventID
+		if seen[id] {
+			t.Fatalf("duplicate event ID %s after %d events", id, i)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,24 @@
+package order
+
+import "strings"
+
+// ValidationError - нарушение правила в конкретном поле команды (ошибка клиента, не инфраструктуры)
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors - все нарушения, найденные при проверке команды
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
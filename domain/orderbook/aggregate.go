@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sort"
 	"time"
+
+	"market_order/pkg/decimal"
 )
 
 // OrderBookStatus представляет статус книги заявок
@@ -17,23 +19,40 @@ const (
 )
 
 // LimitOrder представляет лимитный ордер в книге
+// Цены и количества - decimal: матчинг точен, события float64-эпохи (JSON-числа) читаются как есть
 type LimitOrder struct {
 	OrderID       string
 	UserID        string
-	Price         float64
-	Amount        float64
+	Price         decimal.Decimal
+	Amount        decimal.Decimal
 	Side          string // "buy" или "sell"
 	PlacedAt      time.Time
-	RemainingAmount float64
+	RemainingAmount decimal.Decimal
+	TimeInForce   string    // "GTC", "IOC" или "GTD"
+	ExpiresAt     time.Time // Только для GTD (zero - бессрочный)
 }
 
+// Time in force ордеров в книге (значения order.TimeInForce*)
+const (
+	TimeInForceGTC = "GTC"
+	TimeInForceIOC = "IOC"
+	TimeInForceGTD = "GTD"
+)
+
+// Причины LimitOrderCancelled
+const (
+	CancelReasonTriggered   = "triggered"    // Сработал по рынку (LimitOrderMonitor)
+	CancelReasonExpired     = "expired"      // GTD: истёк ExpiresAt
+	CancelReasonIOCUnfilled = "ioc_unfilled" // IOC: не сматчился сразу при размещении
+)
+
 // OrderBook - агрегат книги заявок (matching engine)
 type OrderBook struct {
 	ID            string
 	TradingPair   string // например "BTC/USDT"
 	BuyOrders     []LimitOrder
 	SellOrders    []LimitOrder
-	LastPrice     float64
+	LastPrice     decimal.Decimal
 	Status        OrderBookStatus
 	Version       int
 	CreatedAt     time.Time
@@ -72,19 +91,33 @@ func (ob *OrderBook) When(event interface{}) error {
 			Side:            e.Side,
 			PlacedAt:        e.PlacedAt,
 			RemainingAmount: e.Amount,
+			TimeInForce:     e.TimeInForce,
+		}
+		if order.TimeInForce == "" {
+			order.TimeInForce = TimeInForceGTC
+		}
+		if e.ExpiresAt != nil {
+			order.ExpiresAt = *e.ExpiresAt
 		}
 
+		// Price-time priority: best price first, earliest PlacedAt among equal prices
 		if e.Side == "buy" {
 			ob.BuyOrders = append(ob.BuyOrders, order)
 			// Sort buy orders: highest price first
-			sort.Slice(ob.BuyOrders, func(i, j int) bool {
-				return ob.BuyOrders[i].Price > ob.BuyOrders[j].Price
+			sort.SliceStable(ob.BuyOrders, func(i, j int) bool {
+				if !ob.BuyOrders[i].Price.Equal(ob.BuyOrders[j].Price) {
+					return ob.BuyOrders[i].Price.GreaterThan(ob.BuyOrders[j].Price)
+				}
+				return ob.BuyOrders[i].PlacedAt.Before(ob.BuyOrders[j].PlacedAt)
 			})
 		} else {
 			ob.SellOrders = append(ob.SellOrders, order)
 			// Sort sell orders: lowest price first
-			sort.Slice(ob.SellOrders, func(i, j int) bool {
-				return ob.SellOrders[i].Price < ob.SellOrders[j].Price
+			sort.SliceStable(ob.SellOrders, func(i, j int) bool {
+				if !ob.SellOrders[i].Price.Equal(ob.SellOrders[j].Price) {
+					return ob.SellOrders[i].Price.LessThan(ob.SellOrders[j].Price)
+				}
+				return ob.SellOrders[i].PlacedAt.Before(ob.SellOrders[j].PlacedAt)
 			})
 		}
 		ob.Version = e.Version
@@ -123,6 +156,14 @@ func (ob *OrderBook) Apply(event interface{}) error {
 	return nil
 }
 
+func (ob *OrderBook) GetChanges() []interface{} {
+	return ob.Changes
+}
+
+func (ob *OrderBook) ClearChanges() {
+	ob.Changes = nil
+}
+
 // ===============================================
 // Commands
 // ===============================================
@@ -145,7 +186,8 @@ func (ob *OrderBook) CreateOrderBook(orderBookID, tradingPair string) error {
 }
 
 // AddLimitOrder - команда: добавить лимитный ордер
-func (ob *OrderBook) AddLimitOrder(orderID, userID string, price, amount float64, side string) error {
+// expiresAt задаётся только для GTD
+func (ob *OrderBook) AddLimitOrder(orderID, userID string, price, amount decimal.Decimal, side, timeInForce string, expiresAt time.Time) error {
 	if ob.Status != OrderBookStatusActive {
 		return fmt.Errorf("order book is %s", ob.Status)
 	}
@@ -154,10 +196,22 @@ func (ob *OrderBook) AddLimitOrder(orderID, userID string, price, amount float64
 		return errors.New("side must be 'buy' or 'sell'")
 	}
 
-	if price <= 0 || amount <= 0 {
+	if !price.IsPositive() || !amount.IsPositive() {
 		return errors.New("price and amount must be positive")
 	}
 
+	var expires *time.Time
+	switch timeInForce {
+	case TimeInForceGTC, TimeInForceIOC:
+	case TimeInForceGTD:
+		if expiresAt.IsZero() {
+			return errors.New("GTD order requires expiry time")
+		}
+		expires = &expiresAt
+	default:
+		return fmt.Errorf("unknown time in force: %q", timeInForce)
+	}
+
 	event := LimitOrderAdded{
 		BaseEvent: BaseEvent{
 			EventID:       generateUUID(),
@@ -173,29 +227,34 @@ func (ob *OrderBook) AddLimitOrder(orderID, userID string, price, amount float64
 		Amount:   amount,
 		Side:     side,
 		PlacedAt: time.Now(),
+
+		TimeInForce: timeInForce,
+		ExpiresAt:   expires,
 	}
 
 	return ob.Apply(event)
 }
 
 // MatchOrders - команда: провести матчинг ордеров
+// Матчит, пока книга пересекается (best buy >= best sell) и обе стороны не пусты;
+// каждое исполнение генерирует отдельное событие OrdersMatched
+// Неисполненный остаток IOC ордеров после матчинга снимается (LimitOrderCancelled)
 func (ob *OrderBook) MatchOrders() error {
 	if ob.Status != OrderBookStatusActive {
 		return fmt.Errorf("order book is %s", ob.Status)
 	}
 
-	// Simple matching algorithm: check if best buy >= best sell
-	if len(ob.BuyOrders) == 0 || len(ob.SellOrders) == 0 {
-		return nil // Nothing to match
-	}
+	for len(ob.BuyOrders) > 0 && len(ob.SellOrders) > 0 {
+		bestBuy := ob.BuyOrders[0]
+		bestSell := ob.SellOrders[0]
 
-	bestBuy := ob.BuyOrders[0]
-	bestSell := ob.SellOrders[0]
+		if bestBuy.Price.LessThan(bestSell.Price) {
+			break // Книга больше не пересекается
+		}
 
-	if bestBuy.Price >= bestSell.Price {
 		// Match found!
-		matchedAmount := min(bestBuy.RemainingAmount, bestSell.RemainingAmount)
-		matchedPrice := (bestBuy.Price + bestSell.Price) / 2.0
+		matchedAmount := decimal.Min(bestBuy.RemainingAmount, bestSell.RemainingAmount)
+		matchedPrice := bestBuy.Price.Add(bestSell.Price).Div(decimal.NewFromInt(2))
 
 		event := OrdersMatched{
 			BaseEvent: BaseEvent{
@@ -210,27 +269,45 @@ func (ob *OrderBook) MatchOrders() error {
 			SellOrderID:   bestSell.OrderID,
 			MatchedPrice:  matchedPrice,
 			MatchedAmount: matchedAmount,
+			BuyRemaining:  bestBuy.RemainingAmount.Sub(matchedAmount),
+			SellRemaining: bestSell.RemainingAmount.Sub(matchedAmount),
 			MatchedAt:     time.Now(),
 		}
 
-		return ob.Apply(event)
+		// Apply обновляет книгу: исполненный ордер удаляется или уменьшается
+		if err := ob.Apply(event); err != nil {
+			return err
+		}
+	}
+
+	// IOC не остаётся в книге: всё, что не сматчилось сейчас, отменяется
+	for _, order := range ob.restingOrders() {
+		if order.TimeInForce != TimeInForceIOC {
+			continue
+		}
+		if err := ob.CancelLimitOrder(order.OrderID, order.Side, CancelReasonIOCUnfilled); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 // CancelLimitOrder - команда: отменить лимитный ордер
-func (ob *OrderBook) CancelLimitOrder(orderID, side string) error {
+// reason - одна из CancelReason*
+func (ob *OrderBook) CancelLimitOrder(orderID, side, reason string) error {
 	if ob.Status != OrderBookStatusActive {
 		return fmt.Errorf("order book is %s", ob.Status)
 	}
 
 	// Check if order exists
 	found := false
+	var remaining decimal.Decimal
 	if side == "buy" {
 		for _, order := range ob.BuyOrders {
 			if order.OrderID == orderID {
 				found = true
+				remaining = order.RemainingAmount
 				break
 			}
 		}
@@ -238,6 +315,7 @@ func (ob *OrderBook) CancelLimitOrder(orderID, side string) error {
 		for _, order := range ob.SellOrders {
 			if order.OrderID == orderID {
 				found = true
+				remaining = order.RemainingAmount
 				break
 			}
 		}
@@ -259,14 +337,17 @@ func (ob *OrderBook) CancelLimitOrder(orderID, side string) error {
 		OrderID:     orderID,
 		Side:        side,
 		CancelledAt: time.Now(),
+
+		Reason:          reason,
+		RemainingAmount: remaining,
 	}
 
 	return ob.Apply(event)
 }
 
 // UpdatePrice - команда: обновить текущую цену (из WebSocket feed)
-func (ob *OrderBook) UpdatePrice(newPrice float64, source string) error {
-	if newPrice <= 0 {
+func (ob *OrderBook) UpdatePrice(newPrice decimal.Decimal, source string) error {
+	if !newPrice.IsPositive() {
 		return errors.New("price must be positive")
 	}
 
@@ -288,16 +369,86 @@ func (ob *OrderBook) UpdatePrice(newPrice float64, source string) error {
 	return ob.Apply(event)
 }
 
+// ===============================================
+// Queries
+// ===============================================
+
+// PriceLevel - агрегированный уровень цены в стакане
+type PriceLevel struct {
+	Price  decimal.Decimal `json:"price"`
+	Amount decimal.Decimal `json:"amount"` // Сумма RemainingAmount на уровне
+	Orders int             `json:"orders"`
+}
+
+// Depth возвращает top-N уровней bid и ask (лучшая цена первой)
+// Для пустой книги возвращает пустые (не nil) срезы
+func (ob *OrderBook) Depth(levels int) ([]PriceLevel, []PriceLevel) {
+	return aggregateLevels(ob.BuyOrders, levels), aggregateLevels(ob.SellOrders, levels)
+}
+
+// SpentAmount переводит количество base в валюту, которую тратит владелец ордера
+// buy тратит quote по своей лимитной цене, sell - сам base
+func SpentAmount(side string, amount, price decimal.Decimal) decimal.Decimal {
+	if side == "buy" {
+		return amount.Mul(price)
+	}
+	return amount
+}
+
+// ExpiredOrders возвращает GTD ордера, у которых ExpiresAt наступил к моменту now
+func (ob *OrderBook) ExpiredOrders(now time.Time) []LimitOrder {
+	var expired []LimitOrder
+	for _, order := range ob.restingOrders() {
+		if order.TimeInForce == TimeInForceGTD && !order.ExpiresAt.IsZero() && !order.ExpiresAt.After(now) {
+			expired = append(expired, order)
+		}
+	}
+	return expired
+}
+
+// aggregateLevels схлопывает отсортированные ордера в уровни цены
+func aggregateLevels(orders []LimitOrder, levels int) []PriceLevel {
+	result := make([]PriceLevel, 0, levels)
+
+	for _, order := range orders {
+		if n := len(result); n > 0 && result[n-1].Price.Equal(order.Price) {
+			result[n-1].Amount = result[n-1].Amount.Add(order.RemainingAmount)
+			result[n-1].Orders++
+			continue
+		}
+
+		if len(result) == levels {
+			break
+		}
+
+		result = append(result, PriceLevel{
+			Price:  order.Price,
+			Amount: order.RemainingAmount,
+			Orders: 1,
+		})
+	}
+
+	return result
+}
+
 // ===============================================
 // Helper methods
 // ===============================================
 
-func (ob *OrderBook) removeOrUpdateOrder(orderID string, matchedAmount float64, side string) {
+// restingOrders - копия всех ордеров книги (buy, затем sell)
+// Копия, потому что команды над результатом меняют BuyOrders/SellOrders
+func (ob *OrderBook) restingOrders() []LimitOrder {
+	orders := make([]LimitOrder, 0, len(ob.BuyOrders)+len(ob.SellOrders))
+	orders = append(orders, ob.BuyOrders...)
+	return append(orders, ob.SellOrders...)
+}
+
+func (ob *OrderBook) removeOrUpdateOrder(orderID string, matchedAmount decimal.Decimal, side string) {
 	if side == "buy" {
 		for i, order := range ob.BuyOrders {
 			if order.OrderID == orderID {
-				order.RemainingAmount -= matchedAmount
-				if order.RemainingAmount <= 0 {
+				order.RemainingAmount = order.RemainingAmount.Sub(matchedAmount)
+				if order.RemainingAmount.Sign() <= 0 {
 					// Remove order
 					ob.BuyOrders = append(ob.BuyOrders[:i], ob.BuyOrders[i+1:]...)
 				} else {
@@ -309,8 +460,8 @@ func (ob *OrderBook) removeOrUpdateOrder(orderID string, matchedAmount float64,
 	} else {
 		for i, order := range ob.SellOrders {
 			if order.OrderID == orderID {
-				order.RemainingAmount -= matchedAmount
-				if order.RemainingAmount <= 0 {
+				order.RemainingAmount = order.RemainingAmount.Sub(matchedAmount)
+				if order.RemainingAmount.Sign() <= 0 {
 					// Remove order
 					ob.SellOrders = append(ob.SellOrders[:i], ob.SellOrders[i+1:]...)
 				} else {
@@ -339,14 +490,3 @@ func (ob *OrderBook) removeOrder(orderID, side string) {
 		}
 	}
 }
-
-func min(a, b float64) float64 {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func generateUUID() string {
-	return fmt.Sprintf("uuid-%d", time.Now().UnixNano())
-}
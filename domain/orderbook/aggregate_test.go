@@ -0,0 +1,220 @@
+package orderbook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+func newActiveBook(t *testing.T) *OrderBook {
+	t.Helper()
+	ob := NewOrderBook()
+	if err := ob.CreateOrderBook("book-1", "BTC/USDT"); err != nil {
+		t.Fatalf("CreateOrderBook: %v", err)
+	}
+	return ob
+}
+
+func addOrder(t *testing.T, ob *OrderBook, orderID, side, price, amount string) {
+	t.Helper()
+	if err := ob.AddLimitOrder(orderID, "user-"+orderID, decimal.MustParse(price), decimal.MustParse(amount), side, TimeInForceGTC, time.Time{}); err != nil {
+		t.Fatalf("AddLimitOrder(%s): %v", orderID, err)
+	}
+}
+
+func TestMatchOrdersIsExact(t *testing.T) {
+	ob := newActiveBook(t)
+
+	// In float64 0.3 - 0.1 leaves 0.19999999999999998, and the second buy kept a dust remainder
+	addOrder(t, ob, "sell-1", "sell", "100", "0.3")
+	addOrder(t, ob, "buy-1", "buy", "100", "0.1")
+	if err := ob.MatchOrders(); err != nil {
+		t.Fatalf("MatchOrders: %v", err)
+	}
+	addOrder(t, ob, "buy-2", "buy", "100", "0.2")
+	if err := ob.MatchOrders(); err != nil {
+		t.Fatalf("MatchOrders: %v", err)
+	}
+
+	if len(ob.BuyOrders) != 0 || len(ob.SellOrders) != 0 {
+		t.Fatalf("book not empty: %d bids, %d asks", len(ob.BuyOrders), len(ob.SellOrders))
+	}
+
+	var matched []decimal.Decimal
+	for _, change := range ob.GetChanges() {
+		if m, ok := change.(OrdersMatched); ok {
+			matched = append(matched, m.MatchedAmount)
+		}
+	}
+	if len(matched) != 2 || !matched[0].Equal(decimal.MustParse("0.1")) || !matched[1].Equal(decimal.MustParse("0.2")) {
+		t.Errorf("matched amounts = %v, want [0.1 0.2]", matched)
+	}
+	if sum := matched[0].Add(matched[1]); !sum.Equal(decimal.MustParse("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", sum)
+	}
+}
+
+func TestReplayFloatEraEvents(t *testing.T) {
+	// Events written while the order book used float64: amounts and prices are JSON numbers
+	stream := []struct {
+		event interface{}
+		data  string
+	}{
+		{&OrderBookCreated{}, `{"aggregate_id":"book-1","event_type":"OrderBookCreated","version":1,"trading_pair":"BTC/USDT"}`},
+		{&LimitOrderAdded{}, `{"aggregate_id":"book-1","event_type":"LimitOrderAdded","version":2,"order_id":"sell-1","user_id":"u1","price":100.1,"amount":0.3,"side":"sell"}`},
+		{&LimitOrderAdded{}, `{"aggregate_id":"book-1","event_type":"LimitOrderAdded","version":3,"order_id":"buy-1","user_id":"u2","price":100.2,"amount":0.1,"side":"buy"}`},
+		{&OrdersMatched{}, `{"aggregate_id":"book-1","event_type":"OrdersMatched","version":4,"buy_order_id":"buy-1","sell_order_id":"sell-1","matched_price":100.15000000000001,"matched_amount":0.1,"buy_remaining":0,"sell_remaining":0.19999999999999998}`},
+		{&PriceUpdated{}, `{"aggregate_id":"book-1","event_type":"PriceUpdated","version":5,"new_price":100.3,"old_price":100.15000000000001,"source":"binance"}`},
+	}
+
+	ob := NewOrderBook()
+	for _, s := range stream {
+		if err := json.Unmarshal([]byte(s.data), s.event); err != nil {
+			t.Fatalf("unmarshal %s: %v", s.data, err)
+		}
+		var err error
+		switch e := s.event.(type) {
+		case *OrderBookCreated:
+			err = ob.When(*e)
+		case *LimitOrderAdded:
+			err = ob.When(*e)
+		case *OrdersMatched:
+			err = ob.When(*e)
+		case *PriceUpdated:
+			err = ob.When(*e)
+		}
+		if err != nil {
+			t.Fatalf("When: %v", err)
+		}
+	}
+
+	if ob.Version != 5 || len(ob.BuyOrders) != 0 || len(ob.SellOrders) != 1 {
+		t.Fatalf("version %d, %d bids, %d asks; want 5, 0, 1", ob.Version, len(ob.BuyOrders), len(ob.SellOrders))
+	}
+	sell := ob.SellOrders[0]
+	if !sell.Price.Equal(decimal.MustParse("100.1")) || !sell.RemainingAmount.Equal(decimal.MustParse("0.2")) {
+		t.Errorf("sell price/remaining = %s/%s, want 100.1/0.2", sell.Price, sell.RemainingAmount)
+	}
+	if !ob.LastPrice.Equal(decimal.MustParse("100.3")) {
+		t.Errorf("last price = %s, want 100.3", ob.LastPrice)
+	}
+
+	// The replayed book keeps matching in decimal: the remainder fills completely
+	addOrder(t, ob, "buy-2", "buy", "100.2", "0.2")
+	if err := ob.MatchOrders(); err != nil {
+		t.Fatalf("MatchOrders: %v", err)
+	}
+	if len(ob.BuyOrders) != 0 || len(ob.SellOrders) != 0 {
+		t.Errorf("book not empty after matching the remainder: %d bids, %d asks", len(ob.BuyOrders), len(ob.SellOrders))
+	}
+	if want := decimal.MustParse("100.15"); !ob.LastPrice.Equal(want) {
+		t.Errorf("matched price = %s, want %s", ob.LastPrice, want)
+	}
+}
+
+func TestMatchOrdersDrainsBookWithPriceTimePriority(t *testing.T) {
+	ob := newActiveBook(t)
+
+	addOrder(t, ob, "buy-1", "buy", "101", "1")
+	addOrder(t, ob, "buy-2", "buy", "102", "1")
+	addOrder(t, ob, "buy-3", "buy", "101", "1") // Same price as buy-1, placed later
+	addOrder(t, ob, "sell-1", "sell", "100", "1.5")
+	addOrder(t, ob, "sell-2", "sell", "101", "1")
+	ob.ClearChanges()
+
+	if err := ob.MatchOrders(); err != nil {
+		t.Fatalf("MatchOrders: %v", err)
+	}
+
+	want := []struct{ buy, sell, price, amount string }{
+		{"buy-2", "sell-1", "101", "1"},
+		{"buy-1", "sell-1", "100.5", "0.5"},
+		{"buy-1", "sell-2", "101", "0.5"},
+		{"buy-3", "sell-2", "101", "0.5"},
+	}
+	var got []OrdersMatched
+	for _, change := range ob.GetChanges() {
+		if m, ok := change.(OrdersMatched); ok {
+			got = append(got, m)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%d matches, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		m := got[i]
+		if m.BuyOrderID != w.buy || m.SellOrderID != w.sell ||
+			!m.MatchedPrice.Equal(decimal.MustParse(w.price)) || !m.MatchedAmount.Equal(decimal.MustParse(w.amount)) {
+			t.Errorf("match %d = %s/%s %s@%s, want %s/%s %s@%s", i,
+				m.BuyOrderID, m.SellOrderID, m.MatchedAmount, m.MatchedPrice, w.buy, w.sell, w.amount, w.price)
+		}
+	}
+
+	if len(ob.SellOrders) != 0 {
+		t.Errorf("%d asks left, want 0", len(ob.SellOrders))
+	}
+	if len(ob.BuyOrders) != 1 || ob.BuyOrders[0].OrderID != "buy-3" || !ob.BuyOrders[0].RemainingAmount.Equal(decimal.MustParse("0.5")) {
+		t.Errorf("bids = %+v, want buy-3 with 0.5 left", ob.BuyOrders)
+	}
+}
+
+func TestMatchOrdersCancelsIOCRemainder(t *testing.T) {
+	ob := newActiveBook(t)
+	addOrder(t, ob, "sell-1", "sell", "49000", "0.4")
+	if err := ob.AddLimitOrder("buy-ioc", "user-1", decimal.MustParse("50000"), decimal.MustParse("1"), "buy", TimeInForceIOC, time.Time{}); err != nil {
+		t.Fatalf("AddLimitOrder: %v", err)
+	}
+	ob.ClearChanges()
+
+	if err := ob.MatchOrders(); err != nil {
+		t.Fatalf("MatchOrders: %v", err)
+	}
+
+	changes := ob.GetChanges()
+	if len(changes) != 2 {
+		t.Fatalf("got %d events, want OrdersMatched and LimitOrderCancelled", len(changes))
+	}
+	matched, ok := changes[0].(OrdersMatched)
+	if !ok || !matched.MatchedAmount.Equal(decimal.MustParse("0.4")) {
+		t.Errorf("first event = %#v, want OrdersMatched of 0.4", changes[0])
+	}
+	cancelled, ok := changes[1].(LimitOrderCancelled)
+	if !ok {
+		t.Fatalf("second event = %T, want LimitOrderCancelled", changes[1])
+	}
+	if cancelled.OrderID != "buy-ioc" || cancelled.Reason != CancelReasonIOCUnfilled {
+		t.Errorf("cancelled %s (%s), want buy-ioc (%s)", cancelled.OrderID, cancelled.Reason, CancelReasonIOCUnfilled)
+	}
+	if want := decimal.MustParse("0.6"); !cancelled.RemainingAmount.Equal(want) {
+		t.Errorf("cancelled remaining = %s, want %s", cancelled.RemainingAmount, want)
+	}
+	if len(ob.BuyOrders) != 0 || len(ob.SellOrders) != 0 {
+		t.Errorf("book not empty: %d buys, %d sells", len(ob.BuyOrders), len(ob.SellOrders))
+	}
+}
+
+func TestExpiredOrdersReturnsOnlyPastGTD(t *testing.T) {
+	ob := newActiveBook(t)
+	now := time.Now()
+	addOrder(t, ob, "buy-gtc", "buy", "48000", "1")
+	for id, expiresAt := range map[string]time.Time{
+		"buy-expired": now.Add(-time.Minute),
+		"buy-live":    now.Add(time.Minute),
+	} {
+		if err := ob.AddLimitOrder(id, "user-1", decimal.MustParse("49000"), decimal.MustParse("1"), "buy", TimeInForceGTD, expiresAt); err != nil {
+			t.Fatalf("AddLimitOrder(%s): %v", id, err)
+		}
+	}
+
+	expired := ob.ExpiredOrders(now)
+	if len(expired) != 1 || expired[0].OrderID != "buy-expired" {
+		t.Fatalf("expired = %v, want only buy-expired", expired)
+	}
+
+	// GTD without an expiry time is rejected
+	if err := ob.AddLimitOrder("buy-bad", "user-1", decimal.MustParse("49000"), decimal.MustParse("1"), "buy", TimeInForceGTD, time.Time{}); err == nil {
+		t.Error("GTD without ExpiresAt: expected an error")
+	}
+}
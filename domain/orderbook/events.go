@@ -1,6 +1,10 @@
 package orderbook
 
-import "time"
+import (
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+	"time"
+)
 
 type BaseEvent struct {
 	EventID       string    `json:"event_id"`
@@ -11,8 +15,8 @@ type BaseEvent struct {
 	Timestamp     time.Time `json:"timestamp"`
 }
 
-func (b BaseEvent) GetBaseFields() BaseFields {
-	return BaseFields{
+func (b BaseEvent) GetBaseFields() eventstore.BaseFields {
+	return eventstore.BaseFields{
 		EventID:       b.EventID,
 		AggregateID:   b.AggregateID,
 		AggregateType: b.AggregateType,
@@ -22,15 +26,6 @@ func (b BaseEvent) GetBaseFields() BaseFields {
 	}
 }
 
-type BaseFields struct {
-	EventID       string
-	AggregateID   string
-	AggregateType string
-	EventType     string
-	Version       int
-	Timestamp     time.Time
-}
-
 // OrderBookCreated - событие: книга заявок создана
 type OrderBookCreated struct {
 	BaseEvent
@@ -40,22 +35,27 @@ type OrderBookCreated struct {
 // LimitOrderAdded - событие: лимитный ордер добавлен
 type LimitOrderAdded struct {
 	BaseEvent
-	OrderID  string    `json:"order_id"`
-	UserID   string    `json:"user_id"`
-	Price    float64   `json:"price"`
-	Amount   float64   `json:"amount"`
-	Side     string    `json:"side"` // "buy" or "sell"
-	PlacedAt time.Time `json:"placed_at"`
+	OrderID  string          `json:"order_id"`
+	UserID   string          `json:"user_id"`
+	Price    decimal.Decimal `json:"price"`
+	Amount   decimal.Decimal `json:"amount"`
+	Side     string          `json:"side"` // "buy" or "sell"
+	PlacedAt time.Time       `json:"placed_at"`
+
+	TimeInForce string     `json:"time_in_force,omitempty"` // "" = GTC (события до time in force)
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`    // Только для GTD
 }
 
 // OrdersMatched - событие: ордера сматчились
 type OrdersMatched struct {
 	BaseEvent
-	BuyOrderID    string    `json:"buy_order_id"`
-	SellOrderID   string    `json:"sell_order_id"`
-	MatchedPrice  float64   `json:"matched_price"`
-	MatchedAmount float64   `json:"matched_amount"`
-	MatchedAt     time.Time `json:"matched_at"`
+	BuyOrderID    string          `json:"buy_order_id"`
+	SellOrderID   string          `json:"sell_order_id"`
+	MatchedPrice  decimal.Decimal `json:"matched_price"`
+	MatchedAmount decimal.Decimal `json:"matched_amount"`
+	BuyRemaining  decimal.Decimal `json:"buy_remaining"`  // Остаток buy ордера после матчинга
+	SellRemaining decimal.Decimal `json:"sell_remaining"` // Остаток sell ордера после матчинга
+	MatchedAt     time.Time       `json:"matched_at"`
 }
 
 // LimitOrderCancelled - событие: лимитный ордер отменён
@@ -64,34 +64,37 @@ type LimitOrderCancelled struct {
 	OrderID     string    `json:"order_id"`
 	Side        string    `json:"side"`
 	CancelledAt time.Time `json:"cancelled_at"`
+
+	Reason          string          `json:"reason,omitempty"` // "triggered", "expired", "ioc_unfilled"
+	RemainingAmount decimal.Decimal `json:"remaining_amount"` // Неисполненный остаток на момент отмены
 }
 
 // PriceUpdated - событие: цена обновлена (от WebSocket feed)
 type PriceUpdated struct {
 	BaseEvent
-	NewPrice  float64   `json:"new_price"`
-	OldPrice  float64   `json:"old_price"`
-	Source    string    `json:"source"` // "binance", "uniswap", etc.
-	UpdatedAt time.Time `json:"updated_at"`
+	NewPrice  decimal.Decimal `json:"new_price"`
+	OldPrice  decimal.Decimal `json:"old_price"`
+	Source    string          `json:"source"` // "binance", "uniswap", etc.
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 // GetBaseEvent implementations
-func (e OrderBookCreated) GetBaseEvent() BaseFields {
+func (e OrderBookCreated) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
-func (e LimitOrderAdded) GetBaseEvent() BaseFields {
+func (e LimitOrderAdded) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
-func (e OrdersMatched) GetBaseEvent() BaseFields {
+func (e OrdersMatched) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
-func (e LimitOrderCancelled) GetBaseEvent() BaseFields {
+func (e LimitOrderCancelled) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
 
-func (e PriceUpdated) GetBaseEvent() BaseFields {
+func (e PriceUpdated) GetBaseEvent() eventstore.BaseFields {
 	return e.BaseEvent.GetBaseFields()
 }
@@ -0,0 +1,14 @@
+package orderbook
+
+import (
+	pkguuid "market_order/pkg/uuid"
+)
+
+func generateUUID() string {
+	return pkguuid.New()
+}
+
+// IDForPair - детерминированный ID книги заявок торговой пары ("BTC/USDT")
+func IDForPair(tradingPair string) string {
+	return pkguuid.NewFromName("orderbook:" + tradingPair)
+}
@@ -0,0 +1,42 @@
+package orderbook
+
+import (
+	"testing"
+
+	"market_order/pkg/decimal"
+)
+
+func TestEventIDsAreUnique(t *testing.T) {
+	const n = 100_000
+
+	ob := newActiveBook(t)
+	for i := 0; i < n; i++ {
+		if err := ob.UpdatePrice(decimal.NewFromInt(int64(i+1)), "test"); err != nil {
+			t.Fatalf("UpdatePrice: %v", err)
+		}
+	}
+
+	seen := make(map[string]bool, n)
+	for _, change := range ob.GetChanges() {
+		e, ok := change.(PriceUpdated)
+		if !ok {
+			continue
+		}
+		if seen[e.EventID] {
+			t.Fatalf("duplicate event ID %s", e.EventID)
+		}
+		seen[e.EventID] = true
+	}
+	if len(seen) != n {
+		t.Errorf("%d PriceUpdated events, want %d", len(seen), n)
+	}
+}
+
+func TestIDForPairIsStable(t *testing.T) {
+	if IDForPair("BTC/USDT") != IDForPair("BTC/USDT") {
+		t.Error("IDForPair must return the same ID for a pair")
+	}
+	if IDForPair("BTC/USDT") == IDForPair("ETH/USDT") {
+		t.Error("IDForPair must differ between pairs")
+	}
+}
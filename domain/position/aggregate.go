@@ -1,8 +1,11 @@
 package position
 
 import (
+	"errors"
 	"fmt"
 	"time"
+
+	"market_order/pkg/decimal"
 )
 
 type PositionStatus string
@@ -14,16 +17,21 @@ const (
 
 // Position - агрегат позиции
 type Position struct {
-	ID              string
-	UserID          string
-	OrderIDs        []string // Список ID заказов в позиции
-	RemainingAmount float64  // Оставшееся количество актива
-	TotalValue      float64  // Общая стоимость в USD
-	PnL             float64  // Прибыль/убыток
-	Status          PositionStatus
-	Version         int
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	ID                string
+	UserID            string
+	Currency          string          // Валюта Cost (FromCurrency заказа)
+	OrderIDs          []string        // Список ID заказов в позиции
+	RemainingAmount   decimal.Decimal // Оставшееся количество актива
+	AverageEntryPrice decimal.Decimal // Средневзвешенная цена входа
+	TotalValue        decimal.Decimal // Стоимость позиции по последней цене исполнения
+	Cost              decimal.Decimal // Потрачено на открытый остаток, в Currency (лимиты экспозиции)
+	RealizedPnL       decimal.Decimal // Зафиксированная прибыль/убыток (при уменьшении позиции)
+	UnrealizedPnL     decimal.Decimal // Нереализованная прибыль/убыток относительно средней цены
+	PnL               decimal.Decimal // Прибыль/убыток: RealizedPnL + UnrealizedPnL
+	Status            PositionStatus
+	Version           int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 
 	Changes []interface{}
 }
@@ -42,6 +50,7 @@ func (p *Position) When(event interface{}) error {
 	case PositionCreated:
 		p.ID = e.AggregateID
 		p.UserID = e.UserID
+		p.Currency = e.Currency
 		p.RemainingAmount = e.RemainingAmount
 		p.Status = PositionStatus(e.Status)
 		p.Version = e.Version
@@ -49,15 +58,29 @@ func (p *Position) When(event interface{}) error {
 		p.UpdatedAt = e.Timestamp
 
 	case PositionUpdated:
-		p.OrderIDs = append(p.OrderIDs, e.AddedOrderID)
+		// Частичные исполнения одного заказа (AddFill) учитывают его один раз
+		if !p.HasOrder(e.AddedOrderID) {
+			p.OrderIDs = append(p.OrderIDs, e.AddedOrderID)
+		}
 		p.RemainingAmount = e.RemainingAmount
+		p.AverageEntryPrice = e.AverageEntryPrice
 		p.TotalValue = e.TotalValue
+		p.Cost = e.Cost
+		p.RealizedPnL = e.RealizedPnL
+		p.UnrealizedPnL = e.UnrealizedPnL
 		p.PnL = e.PnL
 		p.Version = e.Version
 		p.UpdatedAt = e.Timestamp
 
 	case PositionClosed:
 		p.Status = PositionStatusClosed
+		// Старые события без closing_price не меняют PnL
+		if e.ClosingPrice.IsPositive() {
+			p.TotalValue = p.RemainingAmount.Mul(e.ClosingPrice)
+			p.RealizedPnL = e.RealizedPnL
+			p.UnrealizedPnL = decimal.Zero
+			p.PnL = e.RealizedPnL
+		}
 		p.Version = e.Version
 		p.UpdatedAt = e.Timestamp
 
@@ -76,8 +99,17 @@ func (p *Position) Apply(event interface{}) error {
 	return nil
 }
 
+func (p *Position) GetChanges() []interface{} {
+	return p.Changes
+}
+
+func (p *Position) ClearChanges() {
+	p.Changes = nil
+}
+
 // CreatePosition - команда: создать позицию
-func (p *Position) CreatePosition(positionID, userID string) error {
+// currency - валюта стоимости позиции: FromCurrency заказа (лимиты экспозиции считаются в ней)
+func (p *Position) CreatePosition(positionID, userID, currency string) error {
 	event := PositionCreated{
 		BaseEvent: BaseEvent{
 			EventID:       generateUUID(),
@@ -88,7 +120,8 @@ func (p *Position) CreatePosition(positionID, userID string) error {
 			Timestamp:     time.Now(),
 		},
 		UserID:          userID,
-		RemainingAmount: 0,
+		Currency:        currency,
+		RemainingAmount: decimal.Zero,
 		Status:          "open",
 	}
 
@@ -96,14 +129,62 @@ func (p *Position) CreatePosition(positionID, userID string) error {
 }
 
 // AddOrder - команда: добавить заказ в позицию
+// quantity > 0 - покупка: пересчитывается средневзвешенная цена входа
+// quantity < 0 - продажа: PnL по проданной части фиксируется по средней цене
+// Нереализованный PnL считается по executedPrice (последняя цена исполнения)
+// spent - сколько Currency заплачено за покупку (FromAmount заказа); для продажи не используется
 func (p *Position) AddOrder(
 	orderID string,
-	toAmount, totalValue, pnl float64,
+	quantity, executedPrice, spent decimal.Decimal,
 ) error {
+	return p.update(orderID, quantity, executedPrice, spent)
+}
+
+// AddFill - команда: учесть частичное исполнение заказа (limit order: каждый OrdersMatched)
+// Каждое исполнение входит в средневзвешенную цену входа со своей ценой,
+// поэтому для одного заказа команду можно вызывать многократно.
+// Защита от повторного учёта того же исполнения - на стороне вызывающего
+func (p *Position) AddFill(orderID string, quantity, price, spent decimal.Decimal) error {
+	if !quantity.IsPositive() {
+		return errors.New("fill quantity must be positive")
+	}
+	return p.update(orderID, quantity, price, spent)
+}
+
+// update пересчитывает количество, среднюю цену входа и PnL (AddOrder, AddFill)
+func (p *Position) update(orderID string, quantity, executedPrice, spent decimal.Decimal) error {
 	if p.Status != PositionStatusOpen {
 		return fmt.Errorf("cannot add order: position is %s", p.Status)
 	}
 
+	if quantity.IsZero() || !executedPrice.IsPositive() {
+		return errors.New("quantity must be non-zero and executed price positive")
+	}
+
+	if quantity.Neg().GreaterThan(p.RemainingAmount) {
+		return fmt.Errorf("cannot sell %s: position holds %s", quantity.Neg(), p.RemainingAmount)
+	}
+
+	remaining := p.RemainingAmount.Add(quantity)
+	averageEntryPrice := p.AverageEntryPrice
+	realizedPnL := p.RealizedPnL
+	cost := p.Cost
+
+	if quantity.IsPositive() {
+		if spent.Sign() < 0 {
+			return errors.New("spent amount must not be negative")
+		}
+		total := p.RemainingAmount.Mul(p.AverageEntryPrice).Add(quantity.Mul(executedPrice))
+		averageEntryPrice = total.Div(remaining)
+		cost = cost.Add(spent)
+	} else {
+		realizedPnL = realizedPnL.Add(quantity.Neg().Mul(executedPrice.Sub(p.AverageEntryPrice)))
+		// Проданная часть уходит из Cost пропорционально количеству
+		cost = cost.Mul(remaining).Div(p.RemainingAmount)
+	}
+
+	unrealizedPnL := remaining.Mul(executedPrice.Sub(averageEntryPrice))
+
 	event := PositionUpdated{
 		BaseEvent: BaseEvent{
 			EventID:       generateUUID(),
@@ -113,21 +194,48 @@ func (p *Position) AddOrder(
 			Version:       p.Version + 1,
 			Timestamp:     time.Now(),
 		},
-		AddedOrderID:    orderID,
-		RemainingAmount: p.RemainingAmount + toAmount,
-		TotalValue:      totalValue,
-		PnL:             pnl,
+		AddedOrderID:      orderID,
+		Quantity:          quantity,
+		ExecutedPrice:     executedPrice,
+		RemainingAmount:   remaining,
+		AverageEntryPrice: averageEntryPrice,
+		TotalValue:        remaining.Mul(executedPrice),
+		Cost:              cost,
+		RealizedPnL:       realizedPnL,
+		UnrealizedPnL:     unrealizedPnL,
+		PnL:               realizedPnL.Add(unrealizedPnL),
 	}
 
 	return p.Apply(event)
 }
 
-// ClosePosition - команда: закрыть позицию (компенсация)
-func (p *Position) ClosePosition(reason string) error {
+// HasOrder - заказ уже учтён в позиции (повторное AddOrder удвоило бы количество)
+func (p *Position) HasOrder(orderID string) bool {
+	for _, id := range p.OrderIDs {
+		if id == orderID {
+			return true
+		}
+	}
+	return false
+}
+
+// ClosePosition - команда: закрыть позицию
+// closingPrice > 0 - остаток фиксируется по этой цене относительно средней цены входа
+// closingPrice = 0 - принудительное закрытие (компенсация): PnL не меняется
+func (p *Position) ClosePosition(reason string, closingPrice decimal.Decimal) error {
 	if p.Status == PositionStatusClosed {
 		return nil // Идемпотентность
 	}
 
+	if closingPrice.Sign() < 0 {
+		return errors.New("closing price must not be negative")
+	}
+
+	realizedPnL := p.RealizedPnL
+	if closingPrice.IsPositive() {
+		realizedPnL = realizedPnL.Add(p.RemainingAmount.Mul(closingPrice.Sub(p.AverageEntryPrice)))
+	}
+
 	event := PositionClosed{
 		BaseEvent: BaseEvent{
 			EventID:       generateUUID(),
@@ -137,8 +245,10 @@ func (p *Position) ClosePosition(reason string) error {
 			Version:       p.Version + 1,
 			Timestamp:     time.Now(),
 		},
-		Reason:   reason,
-		ClosedAt: time.Now(),
+		Reason:       reason,
+		ClosingPrice: closingPrice,
+		RealizedPnL:  realizedPnL,
+		ClosedAt:     time.Now(),
 	}
 
 	return p.Apply(event)
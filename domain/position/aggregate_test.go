@@ -0,0 +1,180 @@
+package position
+
+import (
+	"testing"
+
+	"market_order/pkg/decimal"
+)
+
+func TestPositionCostTracksSpentCurrency(t *testing.T) {
+	p := NewPosition()
+	if err := p.CreatePosition("position-1", "user-1", "USDT"); err != nil {
+		t.Fatalf("CreatePosition: %v", err)
+	}
+
+	// Bought 0.02 BTC for 1000 USDT, then 0.01 BTC for 600 USDT
+	if err := p.AddOrder("order-1", decimal.MustParse("0.02"), decimal.MustParse("50000"), decimal.MustParse("1000")); err != nil {
+		t.Fatalf("AddOrder: %v", err)
+	}
+	if err := p.AddFill("order-2", decimal.MustParse("0.01"), decimal.MustParse("60000"), decimal.MustParse("600")); err != nil {
+		t.Fatalf("AddFill: %v", err)
+	}
+
+	// Cost is in USDT, while TotalValue is the BTC amount times the last price
+	if want := decimal.MustParse("1600"); !p.Cost.Equal(want) {
+		t.Errorf("cost = %s, want %s", p.Cost, want)
+	}
+
+	// Selling a third of the position releases a third of its cost
+	if err := p.AddOrder("order-3", decimal.MustParse("-0.01"), decimal.MustParse("55000"), decimal.Zero); err != nil {
+		t.Fatalf("AddOrder(sell): %v", err)
+	}
+	if want := decimal.MustParse("1066.66666667"); p.Cost.Sub(want).Float64() > 1e-6 || want.Sub(p.Cost).Float64() > 1e-6 {
+		t.Errorf("cost after sell = %s, want ~%s", p.Cost, want)
+	}
+
+	// Replaying the events restores the cost
+	replayed := NewPosition()
+	for _, evt := range p.GetChanges() {
+		if err := replayed.When(evt); err != nil {
+			t.Fatalf("When: %v", err)
+		}
+	}
+	if !replayed.Cost.Equal(p.Cost) {
+		t.Errorf("replayed cost = %s, want %s", replayed.Cost, p.Cost)
+	}
+}
+
+func TestPositionAverageEntryPriceAndPnL(t *testing.T) {
+	p := NewPosition()
+	if err := p.CreatePosition("position-1", "user-1", "USDT"); err != nil {
+		t.Fatalf("CreatePosition: %v", err)
+	}
+
+	// 1 @ 100 and 3 @ 200: average = (100 + 600) / 4 = 175
+	if err := p.AddOrder("order-1", decimal.NewFromInt(1), decimal.NewFromInt(100), decimal.NewFromInt(100)); err != nil {
+		t.Fatalf("AddOrder: %v", err)
+	}
+	if err := p.AddOrder("order-2", decimal.NewFromInt(3), decimal.NewFromInt(200), decimal.NewFromInt(600)); err != nil {
+		t.Fatalf("AddOrder: %v", err)
+	}
+
+	check := func(field string, got decimal.Decimal, want string) {
+		t.Helper()
+		if !got.Equal(decimal.MustParse(want)) {
+			t.Errorf("%s = %s, want %s", field, got, want)
+		}
+	}
+	check("remaining", p.RemainingAmount, "4")
+	check("average entry price", p.AverageEntryPrice, "175")
+	check("unrealized pnl", p.UnrealizedPnL, "100") // 4 * (200 - 175)
+	check("realized pnl", p.RealizedPnL, "0")
+	check("pnl", p.PnL, "100")
+
+	// Selling 2 @ 225 realizes 2 * (225 - 175); the average entry price is unchanged
+	if err := p.AddOrder("order-3", decimal.NewFromInt(-2), decimal.NewFromInt(225), decimal.Zero); err != nil {
+		t.Fatalf("AddOrder(sell): %v", err)
+	}
+	check("remaining", p.RemainingAmount, "2")
+	check("average entry price", p.AverageEntryPrice, "175")
+	check("realized pnl", p.RealizedPnL, "100")
+	check("unrealized pnl", p.UnrealizedPnL, "100") // 2 * (225 - 175)
+	check("pnl", p.PnL, "200")
+
+	// Selling more than the position holds is rejected
+	if err := p.AddOrder("order-4", decimal.NewFromInt(-3), decimal.NewFromInt(225), decimal.Zero); err == nil {
+		t.Error("overselling the position: expected an error")
+	}
+}
+
+func TestClosePositionRealizesPnL(t *testing.T) {
+	tests := []struct {
+		name         string
+		reason       string
+		closingPrice decimal.Decimal
+		wantPnL      string
+	}{
+		// 0.02 @ 50000: closing at 55000 realizes 0.02 * 5000
+		{name: "profitable close", reason: "user_closed", closingPrice: decimal.MustParse("55000"), wantPnL: "100"},
+		// Compensation of a failed order: no closing price, nothing realized
+		{name: "compensation close", reason: "order_failed", closingPrice: decimal.Zero, wantPnL: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPosition()
+			if err := p.CreatePosition("position-1", "user-1", "USDT"); err != nil {
+				t.Fatalf("CreatePosition: %v", err)
+			}
+			if err := p.AddOrder("order-1", decimal.MustParse("0.02"), decimal.MustParse("50000"), decimal.MustParse("1000")); err != nil {
+				t.Fatalf("AddOrder: %v", err)
+			}
+
+			if err := p.ClosePosition(tt.reason, tt.closingPrice); err != nil {
+				t.Fatalf("ClosePosition: %v", err)
+			}
+			if p.Status != PositionStatusClosed {
+				t.Errorf("status = %s, want %s", p.Status, PositionStatusClosed)
+			}
+
+			changes := p.GetChanges()
+			closed, ok := changes[len(changes)-1].(PositionClosed)
+			if !ok {
+				t.Fatalf("last event = %T, want PositionClosed", changes[len(changes)-1])
+			}
+			want := decimal.MustParse(tt.wantPnL)
+			if !closed.RealizedPnL.Equal(want) || !p.RealizedPnL.Equal(want) {
+				t.Errorf("realized pnl: event %s, position %s, want %s", closed.RealizedPnL, p.RealizedPnL, want)
+			}
+			if closed.Reason != tt.reason || !closed.ClosingPrice.Equal(tt.closingPrice) {
+				t.Errorf("event reason %q price %s, want %q %s", closed.Reason, closed.ClosingPrice, tt.reason, tt.closingPrice)
+			}
+
+			// Closing again is a no-op
+			if err := p.ClosePosition(tt.reason, tt.closingPrice); err != nil || len(p.GetChanges()) != len(changes) {
+				t.Errorf("second ClosePosition: err = %v, %d new events", err, len(p.GetChanges())-len(changes))
+			}
+		})
+	}
+}
+
+func TestPositionAddFillAveragesEachFill(t *testing.T) {
+	p := NewPosition()
+	if err := p.CreatePosition("position-1", "user-1", "USDT"); err != nil {
+		t.Fatalf("CreatePosition: %v", err)
+	}
+
+	// One limit order filled three times at different prices
+	fills := []struct{ quantity, price, spent string }{
+		{"0.01", "50000", "500"},
+		{"0.02", "49000", "980"},
+		{"0.01", "52000", "520"},
+	}
+	for i, f := range fills {
+		if err := p.AddFill("order-1", decimal.MustParse(f.quantity), decimal.MustParse(f.price), decimal.MustParse(f.spent)); err != nil {
+			t.Fatalf("AddFill #%d: %v", i+1, err)
+		}
+	}
+
+	// (500 + 980 + 520) / 0.04 = 50000
+	if want := decimal.MustParse("0.04"); !p.RemainingAmount.Equal(want) {
+		t.Errorf("remaining = %s, want %s", p.RemainingAmount, want)
+	}
+	if want := decimal.MustParse("50000"); !p.AverageEntryPrice.Equal(want) {
+		t.Errorf("average entry price = %s, want %s", p.AverageEntryPrice, want)
+	}
+	if want := decimal.MustParse("2000"); !p.Cost.Equal(want) {
+		t.Errorf("cost = %s, want %s", p.Cost, want)
+	}
+	// Marked at the last fill price: 0.04 * (52000 - 50000)
+	if want := decimal.MustParse("80"); !p.UnrealizedPnL.Equal(want) {
+		t.Errorf("unrealized pnl = %s, want %s", p.UnrealizedPnL, want)
+	}
+	if !p.HasOrder("order-1") || len(p.GetChanges()) != 4 {
+		t.Errorf("order-1 tracked: %v, %d events; want true and 4", p.HasOrder("order-1"), len(p.GetChanges()))
+	}
+
+	if err := p.AddFill("order-1", decimal.Zero, decimal.MustParse("50000"), decimal.Zero); err == nil {
+		t.Error("empty fill: expected an error")
+	}
+}
@@ -2,6 +2,7 @@ package position
 
 import (
 	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
 	"time"
 )
 
@@ -28,9 +29,10 @@ func (b BaseEvent) GetBaseFields() eventstore.BaseFields {
 // PositionCreated - событие: позиция создана
 type PositionCreated struct {
 	BaseEvent
-	UserID          string  `json:"user_id"`
-	RemainingAmount float64 `json:"remaining_amount"`
-	Status          string  `json:"status"` // "open"
+	UserID          string          `json:"user_id"`
+	Currency        string          `json:"currency,omitempty"` // Валюта стоимости позиции (FromCurrency заказа); нет у старых событий
+	RemainingAmount decimal.Decimal `json:"remaining_amount"`
+	Status          string          `json:"status"` // "open"
 }
 
 func (e PositionCreated) GetBaseEvent() eventstore.BaseFields {
@@ -40,10 +42,16 @@ func (e PositionCreated) GetBaseEvent() eventstore.BaseFields {
 // PositionUpdated - событие: позиция обновлена
 type PositionUpdated struct {
 	BaseEvent
-	AddedOrderID    string  `json:"added_order_id"`
-	RemainingAmount float64 `json:"remaining_amount"`
-	TotalValue      float64 `json:"total_value"`
-	PnL             float64 `json:"pnl"`
+	AddedOrderID      string          `json:"added_order_id"`
+	Quantity          decimal.Decimal `json:"quantity"`       // Изменение количества (< 0 - продажа)
+	ExecutedPrice     decimal.Decimal `json:"executed_price"` // Цена исполнения заказа
+	RemainingAmount   decimal.Decimal `json:"remaining_amount"`
+	AverageEntryPrice decimal.Decimal `json:"average_entry_price"`
+	TotalValue        decimal.Decimal `json:"total_value"`
+	Cost              decimal.Decimal `json:"cost"` // Потрачено на открытый остаток, в Currency; 0 в событиях до его появления
+	RealizedPnL       decimal.Decimal `json:"realized_pnl"`
+	UnrealizedPnL     decimal.Decimal `json:"unrealized_pnl"`
+	PnL               decimal.Decimal `json:"pnl"` // RealizedPnL + UnrealizedPnL
 }
 
 func (e PositionUpdated) GetBaseEvent() eventstore.BaseFields {
@@ -53,8 +61,10 @@ func (e PositionUpdated) GetBaseEvent() eventstore.BaseFields {
 // PositionClosed - событие: позиция закрыта
 type PositionClosed struct {
 	BaseEvent
-	Reason   string    `json:"reason"`
-	ClosedAt time.Time `json:"closed_at"`
+	Reason       string          `json:"reason"`
+	ClosingPrice decimal.Decimal `json:"closing_price"` // 0 - закрыта без цены (компенсация)
+	RealizedPnL  decimal.Decimal `json:"realized_pnl"`  // Итоговый зафиксированный PnL позиции
+	ClosedAt     time.Time       `json:"closed_at"`
 }
 
 func (e PositionClosed) GetBaseEvent() eventstore.BaseFields {
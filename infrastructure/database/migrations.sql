@@ -7,6 +7,7 @@
 -- =====================================================
 CREATE TABLE IF NOT EXISTS events (
     id BIGSERIAL PRIMARY KEY,
+    global_sequence BIGSERIAL NOT NULL,         -- Глобальный порядок событий (LoadAll, catch-up проекций)
     event_id UUID NOT NULL UNIQUE,              -- Уникальный ID события
     aggregate_id UUID NOT NULL,                 -- ID агрегата (orderID, positionID)
     aggregate_type VARCHAR(50) NOT NULL,        -- Тип агрегата: "Order", "Position"
@@ -17,6 +18,10 @@ CREATE TABLE IF NOT EXISTS events (
     created_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Таблица создана до появления global_sequence: существующие строки получают номера
+-- в порядке хранения, не записи (для catch-up проекций после миграции - пересобрать их)
+ALTER TABLE events ADD COLUMN IF NOT EXISTS global_sequence BIGSERIAL;
+
 -- IMPORTANT: Уникальность (aggregate_id, version) для Optimistic Locking
 CREATE UNIQUE INDEX IF NOT EXISTS idx_aggregate_version
     ON events(aggregate_id, version);
@@ -29,6 +34,14 @@ CREATE INDEX IF NOT EXISTS idx_events_aggregate_id
 CREATE INDEX IF NOT EXISTS idx_events_type
     ON events(event_type);
 
+-- Индекс для выборки событий одного типа за период (LoadByType, отчёты)
+CREATE INDEX IF NOT EXISTS idx_events_type_created_at
+    ON events(event_type, created_at);
+
+-- Индекс для чтения всего потока по порядку (LoadAll)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_events_global_sequence
+    ON events(global_sequence);
+
 -- Индекс для временной сортировки
 CREATE INDEX IF NOT EXISTS idx_events_created_at
     ON events(created_at DESC);
@@ -49,9 +62,15 @@ CREATE TABLE IF NOT EXISTS outbox (
     event_data JSONB NOT NULL,                  -- Данные для публикации
     published BOOLEAN DEFAULT FALSE,            -- Флаг: опубликовано ли событие
     published_at TIMESTAMP,                     -- Когда опубликовано
+    retry_count INT NOT NULL DEFAULT 0,         -- Количество неудачных попыток публикации
+    last_error TEXT,                            -- Последняя ошибка публикации
     created_at TIMESTAMP DEFAULT NOW()
 );
 
+-- Таблица создана до появления счётчика повторов
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS retry_count INT NOT NULL DEFAULT 0;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS last_error TEXT;
+
 -- Индекс для выборки непубликованных событий
 CREATE INDEX IF NOT EXISTS idx_outbox_published
     ON outbox(published, created_at)
@@ -64,6 +83,35 @@ CREATE INDEX IF NOT EXISTS idx_outbox_event_id
 COMMENT ON TABLE outbox IS 'Transactional Outbox: гарантирует публикацию событий в RabbitMQ';
 COMMENT ON COLUMN outbox.published IS 'FALSE = событие ждёт публикации, TRUE = опубликовано';
 
+-- NOTIFY order_outbox при вставке: OutboxPublisher публикует сразу, не дожидаясь опроса
+-- Одинаковые уведомления в одной транзакции Postgres схлопывает в одно
+CREATE OR REPLACE FUNCTION notify_order_outbox() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('order_outbox', '');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_outbox_notify ON outbox;
+CREATE TRIGGER trg_outbox_notify
+    AFTER INSERT ON outbox
+    FOR EACH ROW EXECUTE FUNCTION notify_order_outbox();
+
+-- События, которые не удалось опубликовать после max retries
+CREATE TABLE IF NOT EXISTS outbox_dead (
+    id BIGSERIAL PRIMARY KEY,
+    event_id UUID NOT NULL UNIQUE,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    event_data JSONB NOT NULL,
+    retry_count INT NOT NULL,                   -- Сколько попыток было сделано
+    last_error TEXT NOT NULL,                   -- Причина последней неудачи
+    created_at TIMESTAMP NOT NULL,              -- Когда событие попало в outbox
+    failed_at TIMESTAMP DEFAULT NOW()           -- Когда перенесено в outbox_dead
+);
+
+COMMENT ON TABLE outbox_dead IS 'Outbox dead letters: события для ручного разбора операторами';
+
 
 -- =====================================================
 -- 3. Processed Events Table (Idempotency)
@@ -85,9 +133,27 @@ CREATE UNIQUE INDEX IF NOT EXISTS idx_processed_events_event_id
 CREATE INDEX IF NOT EXISTS idx_processed_events_aggregate
     ON processed_events(aggregate_id, event_type);
 
+-- Индекс для очистки старых записей (DeleteOlderThan)
+CREATE INDEX IF NOT EXISTS idx_processed_events_processed_at
+    ON processed_events(processed_at);
+
 COMMENT ON TABLE processed_events IS 'Таблица для идемпотентности: предотвращает дублирование обработки событий';
 COMMENT ON COLUMN processed_events.event_id IS 'Уникальный ID события - проверяется перед обработкой';
 
+-- Request Keys: Idempotency-Key заголовка POST /orders → созданный ордер
+-- Повторный запрос клиента с тем же ключом возвращает исходный order_id
+CREATE TABLE IF NOT EXISTS request_keys (
+    user_id VARCHAR(255) NOT NULL,              -- Ключ уникален в рамках пользователя
+    idempotency_key VARCHAR(255) NOT NULL,      -- Значение заголовка Idempotency-Key
+    order_id UUID NOT NULL,                     -- Ордер, созданный первым запросом
+    status VARCHAR(20) NOT NULL,                -- "in_progress" или "completed"
+    created_at TIMESTAMP DEFAULT NOW(),
+    completed_at TIMESTAMP,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+COMMENT ON TABLE request_keys IS 'Идемпотентность HTTP: предотвращает дублирование ордеров при retry клиента';
+
 
 -- =====================================================
 -- 4. Saga State Table (Optional: для persistent saga state)
@@ -116,6 +182,47 @@ CREATE INDEX IF NOT EXISTS idx_saga_status
 
 COMMENT ON TABLE saga_state IS 'Персистентное состояние саг (optional: для recovery после рестарта)';
 
+-- Saga Instances: прогресс саги исполнения ордера (шаг + position_id)
+CREATE TABLE IF NOT EXISTS saga_instances (
+    order_id UUID PRIMARY KEY,                  -- ID ордера (одна сага на ордер)
+    current_step VARCHAR(50) NOT NULL,          -- "pricing", "creating_position", "executing_swap", "completing", "done"
+    position_id UUID,                           -- Позиция, связанная с ордером (STEP 2 → STEP 4)
+    status VARCHAR(20) NOT NULL,                -- "running", "completed", "failed", "needs_review"
+    attempts INT NOT NULL DEFAULT 0,            -- Неудачные попытки текущего шага (сбрасывается при смене шага)
+    started_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()          -- Когда сага вошла в текущий шаг
+);
+
+-- Индекс для recovery: поиск зависших саг
+CREATE INDEX IF NOT EXISTS idx_saga_instances_running
+    ON saga_instances(updated_at)
+    WHERE status = 'running';
+
+COMMENT ON TABLE saga_instances IS 'Прогресс саг: позволяет возобновить ордера после рестарта';
+
+-- Manual Review: ордера, которые нельзя ни завершить, ни компенсировать (swap уже исполнен)
+CREATE TABLE IF NOT EXISTS manual_review (
+    id BIGSERIAL PRIMARY KEY,
+    order_id UUID NOT NULL UNIQUE,
+    position_id UUID,
+    reason TEXT NOT NULL,                       -- Последняя ошибка завершения
+    attempts INT NOT NULL,                      -- Сколько попыток завершения было сделано
+    transaction_hash VARCHAR(255),              -- Детали swap для оператора
+    from_amount DECIMAL(20, 8),
+    to_amount DECIMAL(20, 8),
+    executed_price DECIMAL(20, 8),
+    fees DECIMAL(20, 8),
+    created_at TIMESTAMP DEFAULT NOW(),
+    resolved_at TIMESTAMP                       -- NULL = ждёт оператора
+);
+
+-- Индекс для очереди оператора (нерешённые)
+CREATE INDEX IF NOT EXISTS idx_manual_review_pending
+    ON manual_review(created_at)
+    WHERE resolved_at IS NULL;
+
+COMMENT ON TABLE manual_review IS 'Очередь ручного разбора: деньги перемещены, состояние ордера не завершено';
+
 
 -- =====================================================
 -- 5. Read Model Tables (CQRS - для queries)
@@ -147,6 +254,53 @@ CREATE INDEX IF NOT EXISTS idx_order_view_status
 COMMENT ON TABLE order_view IS 'Read Model для Order - обновляется из событий через проектор';
 
 
+-- Order Projection (список ордеров пользователя: GET /users/{id}/orders)
+CREATE TABLE IF NOT EXISTS order_projection (
+    order_id UUID PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL,                -- "pending", "executing", "completed", "failed"
+    order_type VARCHAR(20) NOT NULL,            -- "market", "limit"
+    from_amount DECIMAL(20, 8) NOT NULL,
+    from_currency VARCHAR(10) NOT NULL,
+    to_currency VARCHAR(10) NOT NULL,
+    client_order_id VARCHAR(256),               -- metadata.client_order_id из OrderAccepted (NULL - не задан)
+    version INT NOT NULL,                       -- Версия последнего применённого события
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_projection_user
+    ON order_projection(user_id, created_at DESC);
+
+-- Поиск ордера по ID клиента: GET /users/{id}/orders?client_order_id=
+CREATE INDEX IF NOT EXISTS idx_order_projection_client_order
+    ON order_projection(user_id, client_order_id)
+    WHERE client_order_id IS NOT NULL;
+
+COMMENT ON TABLE order_projection IS 'Проекция ордеров по пользователю - обновляется OrderProjector из RabbitMQ';
+COMMENT ON COLUMN order_projection.version IS 'События с version <= сохранённой игнорируются (out-of-order доставка)';
+
+
+-- Order Status Read Model: последнее состояние ордера для GET /orders/{id}?view=projection
+CREATE TABLE IF NOT EXISTS order_status_view (
+    order_id UUID PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    order_type VARCHAR(20) NOT NULL,
+    from_amount DECIMAL(20, 8) NOT NULL,
+    from_currency VARCHAR(10) NOT NULL,
+    to_amount DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    to_currency VARCHAR(10) NOT NULL,
+    executed_price DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    version INT NOT NULL,                       -- Версия агрегата, из которой построена строка
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+COMMENT ON TABLE order_status_view IS 'Состояние ордера без replay - обновляется OrderStatusProjection из RabbitMQ';
+COMMENT ON COLUMN order_status_view.version IS 'Строка заменяется только более новой версией (out-of-order доставка)';
+
+
 -- Position Read Model (проекция для чтения)
 CREATE TABLE IF NOT EXISTS position_view (
     position_id UUID PRIMARY KEY,
@@ -166,6 +320,38 @@ CREATE INDEX IF NOT EXISTS idx_position_view_user
 COMMENT ON TABLE position_view IS 'Read Model для Position - обновляется из событий';
 
 
+-- Position Projection: позиции пользователя для GET /users/{id}/positions
+CREATE TABLE IF NOT EXISTS position_projection (
+    position_id UUID PRIMARY KEY,
+    user_id VARCHAR(100) NOT NULL,
+    currency VARCHAR(10) NOT NULL DEFAULT '',   -- Валюта cost (FromCurrency заказа); '' у позиций до её появления
+    remaining_amount DECIMAL(20, 8) NOT NULL,
+    total_value DECIMAL(20, 8) NOT NULL DEFAULT 0,  -- В купленной валюте (ToCurrency)
+    cost DECIMAL(20, 8) NOT NULL DEFAULT 0,     -- Потрачено на открытый остаток, в currency (лимиты экспозиции)
+    realized_pnl DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    unrealized_pnl DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    pnl DECIMAL(20, 8) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL,                -- "open", "closed"
+    version INT NOT NULL,                       -- Версия последнего применённого события
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+-- Таблица создана до появления currency
+ALTER TABLE position_projection ADD COLUMN IF NOT EXISTS currency VARCHAR(10) NOT NULL DEFAULT '';
+-- ... и до появления cost
+ALTER TABLE position_projection ADD COLUMN IF NOT EXISTS cost DECIMAL(20, 8) NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_position_projection_user
+    ON position_projection(user_id, status, created_at DESC);
+
+-- Лимиты экспозиции: сумма открытых позиций пользователя в валюте
+CREATE INDEX IF NOT EXISTS idx_position_projection_exposure
+    ON position_projection(user_id, currency) WHERE status = 'open';
+
+COMMENT ON TABLE position_projection IS 'Проекция позиций по пользователю - обновляется PositionProjector из RabbitMQ';
+
+
 -- Position Orders (many-to-many)
 CREATE TABLE IF NOT EXISTS position_orders (
     position_id UUID NOT NULL,
@@ -200,6 +386,34 @@ CREATE INDEX IF NOT EXISTS idx_notification_log_sent
 COMMENT ON TABLE notification_log IS 'Лог отправленных уведомлений (для идемпотентности и аудита)';
 
 
+-- =====================================================
+-- 7. Snapshots Table (ограничивает стоимость replay)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS snapshots (
+    aggregate_id UUID NOT NULL,                 -- ID агрегата
+    aggregate_type VARCHAR(50) NOT NULL,        -- Тип агрегата: "Order"
+    version INT NOT NULL,                       -- Версия агрегата на момент снапшота
+    state JSONB NOT NULL,                       -- Сериализованное состояние агрегата
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (aggregate_id, version)
+);
+
+COMMENT ON TABLE snapshots IS 'Снапшоты агрегатов: replay начинается с последнего снапшота';
+COMMENT ON COLUMN snapshots.version IS 'Replay продолжается с событий version + 1';
+
+
+-- =====================================================
+-- 8. Aggregate Locks (EventStore.WithAggregateLock)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS aggregate_locks (
+    aggregate_id UUID PRIMARY KEY,              -- ID заблокированного агрегата
+    owner UUID NOT NULL,                        -- Владелец lease (один вызов WithAggregateLock)
+    expires_at TIMESTAMP NOT NULL               -- Lease без продления истекает и может быть перехвачен
+);
+
+COMMENT ON TABLE aggregate_locks IS 'Lease-lock''и агрегатов: не держат транзакцию и соединение на время внешних вызовов';
+
+
 -- =====================================================
 -- Example Data
 -- =====================================================
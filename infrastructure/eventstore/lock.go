@@ -0,0 +1,129 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	pkguuid "market_order/pkg/uuid"
+)
+
+// ErrAggregateLocked - lock агрегата не получен за LockWaitTimeout
+// Вызывающий повторит позже (сообщение уйдёт на retry)
+var ErrAggregateLocked = errors.New("aggregate is locked")
+
+// ErrLockLost - lease агрегата истёк или не продлён, пока fn работала
+// ctx fn отменяется: шаг прерывается, а не продолжается без защиты от параллельного
+var ErrLockLost = errors.New("aggregate lock lost")
+
+// Параметры lease-lock'а WithAggregateLock
+const (
+	DefaultLockLeaseTTL    = 30 * time.Second // Lease истекает, если владелец перестал его продлевать (упал процесс)
+	DefaultLockWaitTimeout = time.Minute      // Дольше swap'а (30s): дубликат дожидается шага, а не падает сразу
+	lockPollInterval       = 100 * time.Millisecond
+)
+
+// WithAggregateLock выполняет fn, держа lease агрегата в aggregate_locks
+// Lease - строка (aggregate_id, owner, expires_at), а не транзакция: пока fn ждёт внешний
+// вызов (TradeWorker), соединение из пула не занято, и Save внутри fn всегда получит соединение.
+// Ожидание ограничено LockWaitTimeout (ErrAggregateLocked); пока fn работает, lease
+// продлевается каждые LockLeaseTTL/3, после fn удаляется.
+// Если продлить lease не удалось, ctx fn отменяется и возвращается ErrLockLost
+func (es *PostgresEventStore) WithAggregateLock(ctx context.Context, aggregateID string, fn func(ctx context.Context) error) error {
+	owner := pkguuid.New()
+	if err := es.acquireLease(ctx, aggregateID, owner); err != nil {
+		return err
+	}
+	defer es.releaseLease(aggregateID, owner)
+
+	fnCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go es.renewLease(aggregateID, owner, stop, cancel)
+
+	err := fn(fnCtx)
+	if cause := context.Cause(fnCtx); errors.Is(cause, ErrLockLost) {
+		return cause
+	}
+	return err
+}
+
+// acquireLease ждёт, пока lease агрегата свободен или истёк, и забирает его
+func (es *PostgresEventStore) acquireLease(ctx context.Context, aggregateID, owner string) error {
+	waitCtx, cancel := context.WithTimeout(ctx, es.LockWaitTimeout)
+	defer cancel()
+
+	// Истёкший lease (владелец упал) перехватывается; время - по часам Postgres
+	query := `
+        INSERT INTO aggregate_locks (aggregate_id, owner, expires_at)
+        VALUES ($1, $2, NOW() + $3 * INTERVAL '1 millisecond')
+        ON CONFLICT (aggregate_id) DO UPDATE
+            SET owner = EXCLUDED.owner, expires_at = EXCLUDED.expires_at
+            WHERE aggregate_locks.expires_at < NOW()
+    `
+
+	for {
+		res, err := es.db.ExecContext(waitCtx, query, aggregateID, owner, es.LockLeaseTTL.Milliseconds())
+		if err == nil {
+			if n, _ := res.RowsAffected(); n == 1 {
+				return nil
+			}
+		} else if waitCtx.Err() == nil {
+			return fmt.Errorf("failed to lock aggregate %s: %w", aggregateID, err)
+		}
+
+		select {
+		case <-time.After(lockPollInterval):
+		case <-waitCtx.Done():
+			if ctx.Err() != nil {
+				return fmt.Errorf("failed to lock aggregate %s: %w", aggregateID, ctx.Err())
+			}
+			return fmt.Errorf("%w: %s, waited %s", ErrAggregateLocked, aggregateID, es.LockWaitTimeout)
+		}
+	}
+}
+
+// renewLease продлевает lease, пока не закрыт stop
+// Сбой продления или перехваченный lease отменяют ctx fn через lost
+func (es *PostgresEventStore) renewLease(aggregateID, owner string, stop <-chan struct{}, lost context.CancelCauseFunc) {
+	ticker := time.NewTicker(es.LockLeaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		res, err := es.db.Exec(
+			`UPDATE aggregate_locks SET expires_at = NOW() + $3 * INTERVAL '1 millisecond' WHERE aggregate_id = $1 AND owner = $2`,
+			aggregateID, owner, es.LockLeaseTTL.Milliseconds(),
+		)
+		if err != nil {
+			// Без продления lease может истечь до следующей попытки: fn прерывается сразу
+			log.Printf("⚠️  Failed to renew lock of aggregate %s: %v", aggregateID, err)
+			lost(fmt.Errorf("%w: %s: renewal failed: %v", ErrLockLost, aggregateID, err))
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			// Lease истёк и перехвачен: fn больше не защищена от параллельного шага
+			log.Printf("⚠️  Lock of aggregate %s expired while held", aggregateID)
+			lost(fmt.Errorf("%w: %s: lease expired", ErrLockLost, aggregateID))
+			return
+		}
+	}
+}
+
+// releaseLease удаляет свой lease; чужой (перехваченный после истечения) не трогается
+func (es *PostgresEventStore) releaseLease(aggregateID, owner string) {
+	_, err := es.db.Exec(`DELETE FROM aggregate_locks WHERE aggregate_id = $1 AND owner = $2`, aggregateID, owner)
+	if err != nil {
+		// Lease истечёт сам через LockLeaseTTL
+		log.Printf("⚠️  Failed to release lock of aggregate %s: %v", aggregateID, err)
+	}
+}
@@ -0,0 +1,59 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestWithAggregateLockCancelsFnWhenLeaseIsLost(t *testing.T) {
+	tests := []struct {
+		name  string
+		renew func(e *sqlmock.ExpectedExec)
+	}{
+		// Lease истёк и перехвачен другим worker'ом: UPDATE своего lease не нашёл строку
+		{name: "lease expired", renew: func(e *sqlmock.ExpectedExec) { e.WillReturnResult(sqlmock.NewResult(0, 0)) }},
+		{name: "renewal failed", renew: func(e *sqlmock.ExpectedExec) { e.WillReturnError(errors.New("connection reset")) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			es, mock := newTestEventStore(t)
+			es.LockLeaseTTL = 30 * time.Millisecond
+
+			mock.ExpectExec("INSERT INTO aggregate_locks").
+				WithArgs("order-1", sqlmock.AnyArg(), es.LockLeaseTTL.Milliseconds()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			tt.renew(mock.ExpectExec("UPDATE aggregate_locks SET expires_at").
+				WithArgs("order-1", sqlmock.AnyArg(), es.LockLeaseTTL.Milliseconds()))
+			mock.ExpectExec("DELETE FROM aggregate_locks").
+				WithArgs("order-1", sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 0))
+
+			// fn ждёт внешний вызов дольше lease: потеря lease должна её прервать
+			var fnErr error
+			err := es.WithAggregateLock(context.Background(), "order-1", func(ctx context.Context) error {
+				select {
+				case <-ctx.Done():
+					fnErr = ctx.Err()
+				case <-time.After(time.Second):
+					fnErr = errors.New("fn was not cancelled")
+				}
+				return fnErr
+			})
+
+			if !errors.Is(fnErr, context.Canceled) {
+				t.Errorf("fn ctx: %v, want cancelled", fnErr)
+			}
+			if !errors.Is(err, ErrLockLost) {
+				t.Errorf("WithAggregateLock() = %v, want ErrLockLost", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package eventstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryEventStore - Event Store в памяти процесса (тестовые стенды, локальные прогоны saga)
+//
+// Повторяет гарантии PostgresEventStore: optimistic locking по (aggregate_id, version),
+// global_sequence в порядке записи, атомарная запись событий и outbox.
+// Outbox читается через TakeOutbox: вызывающий сам публикует события в MessageBus
+type MemoryEventStore struct {
+	mu      sync.Mutex
+	events  []Event          // В порядке global_sequence
+	streams map[string][]int // aggregate_id → индексы в events, по версии
+	outbox  []Event          // Записанные, но ещё не отданные TakeOutbox
+
+	locks map[string]chan struct{} // aggregate_id → семафор WithAggregateLock
+}
+
+var _ EventStore = (*MemoryEventStore)(nil)
+
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{
+		streams: make(map[string][]int),
+		locks:   make(map[string]chan struct{}),
+	}
+}
+
+// Save сохраняет события атомарно: либо все, либо ни одного
+// Как и в PostgresEventStore, проверяется только уникальность версий (без ExpectedVersion)
+func (es *MemoryEventStore) Save(ctx context.Context, events []interface{}) error {
+	if len(events) == 0 {
+		return nil
+	}
+	return es.SaveInTx(ctx, []EventBatch{{Events: events, ExpectedVersion: -1}})
+}
+
+// SaveInTx сохраняет события нескольких агрегатов атомарно
+// Версия каждого агрегата должна быть ExpectedVersion, иначе ErrConcurrencyConflict;
+// больше DefaultMaxEventsPerSave событий - ErrSaveTooLarge
+func (es *MemoryEventStore) SaveInTx(ctx context.Context, batches []EventBatch) error {
+	if err := checkSaveSize(countEvents(batches), DefaultMaxEventsPerSave); err != nil {
+		return err
+	}
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	var staged []Event
+	versions := make(map[string]int) // Версия агрегата с учётом уже подготовленных событий
+	for _, batch := range batches {
+		if len(batch.Events) == 0 {
+			continue
+		}
+
+		if batch.ExpectedVersion >= 0 {
+			version := es.versionLocked(batch.AggregateID, versions)
+			if version != batch.ExpectedVersion {
+				return fmt.Errorf("%w: aggregate %s is at version %d, expected %d",
+					ErrConcurrencyConflict, batch.AggregateID, version, batch.ExpectedVersion)
+			}
+		}
+
+		for _, event := range batch.Events {
+			eventData, metadata, baseFields, err := serializeEvent(ctx, event)
+			if err != nil {
+				return fmt.Errorf("failed to serialize event: %w", err)
+			}
+
+			if baseFields.Version <= es.versionLocked(baseFields.AggregateID, versions) {
+				return fmt.Errorf("%w: aggregate %s version %d", ErrConcurrencyConflict, baseFields.AggregateID, baseFields.Version)
+			}
+			versions[baseFields.AggregateID] = baseFields.Version
+
+			staged = append(staged, Event{
+				EventID:       baseFields.EventID,
+				AggregateID:   baseFields.AggregateID,
+				AggregateType: baseFields.AggregateType,
+				EventType:     baseFields.EventType,
+				EventData:     eventData,
+				Metadata:      metadata,
+				Version:       baseFields.Version,
+				CreatedAt:     time.Now().UTC().Format(time.RFC3339Nano),
+			})
+		}
+	}
+
+	// Все проверки пройдены: "коммит"
+	for _, e := range staged {
+		e.ID = int64(len(es.events) + 1)
+		e.GlobalSequence = e.ID
+		es.streams[e.AggregateID] = append(es.streams[e.AggregateID], len(es.events))
+		es.events = append(es.events, e)
+		es.outbox = append(es.outbox, e)
+	}
+	return nil
+}
+
+// versionLocked - последняя версия агрегата, включая подготовленные в этой записи события
+func (es *MemoryEventStore) versionLocked(aggregateID string, staged map[string]int) int {
+	if version, ok := staged[aggregateID]; ok {
+		return version
+	}
+	stream := es.streams[aggregateID]
+	if len(stream) == 0 {
+		return 0
+	}
+	return es.events[stream[len(stream)-1]].Version
+}
+
+// TakeOutbox возвращает события, записанные после предыдущего вызова, в порядке записи
+func (es *MemoryEventStore) TakeOutbox() []Event {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	outbox := es.outbox
+	es.outbox = nil
+	return outbox
+}
+
+// Load загружает все события агрегата; нет событий - ErrAggregateNotFound
+func (es *MemoryEventStore) Load(ctx context.Context, aggregateID string) ([]Event, error) {
+	events, err := es.loadWhere(aggregateID, func(e Event) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAggregateNotFound, aggregateID)
+	}
+	return events, nil
+}
+
+// LoadByAggregateType загружает события агрегата только указанного типа; нет таких - ErrAggregateNotFound
+func (es *MemoryEventStore) LoadByAggregateType(ctx context.Context, aggregateID, aggregateType string) ([]Event, error) {
+	events, err := es.loadWhere(aggregateID, func(e Event) bool { return e.AggregateType == aggregateType })
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrAggregateNotFound, aggregateType, aggregateID)
+	}
+	return events, nil
+}
+
+// Exists - есть ли у агрегата хотя бы одно событие
+func (es *MemoryEventStore) Exists(ctx context.Context, aggregateID string) (bool, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	return len(es.streams[aggregateID]) > 0, nil
+}
+
+// WithAggregateLock выполняет fn, пока другие вызовы для того же агрегата ждут
+// Ожидание прерывается отменой ctx
+func (es *MemoryEventStore) WithAggregateLock(ctx context.Context, aggregateID string, fn func(ctx context.Context) error) error {
+	es.mu.Lock()
+	lock, ok := es.locks[aggregateID]
+	if !ok {
+		lock = make(chan struct{}, 1)
+		es.locks[aggregateID] = lock
+	}
+	es.mu.Unlock()
+
+	select {
+	case lock <- struct{}{}:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to lock aggregate %s: %w", aggregateID, ctx.Err())
+	}
+	defer func() { <-lock }()
+
+	return fn(ctx)
+}
+
+// LoadFromVersion загружает события начиная с версии
+func (es *MemoryEventStore) LoadFromVersion(ctx context.Context, aggregateID string, fromVersion int) ([]Event, error) {
+	return es.loadWhere(aggregateID, func(e Event) bool { return e.Version >= fromVersion })
+}
+
+// LoadUpToVersion загружает события до версии включительно
+func (es *MemoryEventStore) LoadUpToVersion(ctx context.Context, aggregateID string, version int) ([]Event, error) {
+	return es.loadWhere(aggregateID, func(e Event) bool { return e.Version <= version })
+}
+
+// LoadRange загружает события в диапазоне версий [fromVersion, toVersion] включительно
+func (es *MemoryEventStore) LoadRange(ctx context.Context, aggregateID string, fromVersion, toVersion int) ([]Event, error) {
+	return es.loadWhere(aggregateID, func(e Event) bool { return e.Version >= fromVersion && e.Version <= toVersion })
+}
+
+// LoadRangeByAggregateType загружает события агрегата указанного типа в диапазоне версий [fromVersion, toVersion]
+func (es *MemoryEventStore) LoadRangeByAggregateType(ctx context.Context, aggregateID, aggregateType string, fromVersion, toVersion int) ([]Event, error) {
+	return es.loadWhere(aggregateID, func(e Event) bool {
+		return e.AggregateType == aggregateType && e.Version >= fromVersion && e.Version <= toVersion
+	})
+}
+
+// LoadAll загружает события всех агрегатов в глобальном порядке (global_sequence >= fromGlobalSeq)
+func (es *MemoryEventStore) LoadAll(ctx context.Context, fromGlobalSeq int64, limit int) ([]Event, error) {
+	return es.scan(limit, func(e Event) bool { return e.GlobalSequence >= fromGlobalSeq })
+}
+
+// LoadByType загружает события одного типа, созданные не раньше since (global_sequence >= fromGlobalSeq)
+func (es *MemoryEventStore) LoadByType(ctx context.Context, eventType string, since time.Time, fromGlobalSeq int64, limit int) ([]Event, error) {
+	return es.scan(limit, func(e Event) bool {
+		createdAt, _ := time.Parse(time.RFC3339Nano, e.CreatedAt)
+		return e.EventType == eventType && !createdAt.Before(since) && e.GlobalSequence >= fromGlobalSeq
+	})
+}
+
+// loadWhere возвращает события агрегата по версии, прошедшие фильтр
+func (es *MemoryEventStore) loadWhere(aggregateID string, match func(Event) bool) ([]Event, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	var events []Event
+	for _, i := range es.streams[aggregateID] {
+		if match(es.events[i]) {
+			events = append(events, es.events[i])
+		}
+	}
+	return upcastEvents(events)
+}
+
+// scan возвращает до limit событий всех агрегатов в глобальном порядке
+func (es *MemoryEventStore) scan(limit int, match func(Event) bool) ([]Event, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	var events []Event
+	for _, e := range es.events {
+		if len(events) == limit {
+			break
+		}
+		if match(e) {
+			events = append(events, e)
+		}
+	}
+	return upcastEvents(events)
+}
+
+// upcastEvents приводит события к текущей схеме (как scanEvents), не меняя хранимые данные
+func upcastEvents(events []Event) ([]Event, error) {
+	for i := range events {
+		data, err := Upcast(events[i].EventType, append(json.RawMessage(nil), events[i].EventData...))
+		if err != nil {
+			return nil, err
+		}
+		events[i].EventData = data
+	}
+	return events, nil
+}
+
+// MemorySnapshotStore - Snapshot Store в памяти процесса (пара к MemoryEventStore)
+type MemorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string][]Snapshot // aggregate_id → снапшоты по возрастанию версии
+}
+
+var _ SnapshotStore = (*MemorySnapshotStore)(nil)
+
+func NewMemorySnapshotStore() *MemorySnapshotStore {
+	return &MemorySnapshotStore{snapshots: make(map[string][]Snapshot)}
+}
+
+// SaveSnapshot сохраняет снапшот (повторное сохранение той же версии игнорируется)
+func (ss *MemorySnapshotStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	stored := ss.snapshots[snapshot.AggregateID]
+	for _, s := range stored {
+		if s.Version == snapshot.Version {
+			return nil
+		}
+	}
+	snapshot.State = append(json.RawMessage(nil), snapshot.State...)
+	snapshot.CreatedAt = time.Now().UTC().Format(time.RFC3339Nano)
+	stored = append(stored, snapshot)
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Version < stored[j].Version })
+	ss.snapshots[snapshot.AggregateID] = stored
+	return nil
+}
+
+// LoadSnapshot загружает последний снапшот с версией не больше maxVersion
+// Возвращает nil, nil если снапшота нет
+func (ss *MemorySnapshotStore) LoadSnapshot(ctx context.Context, aggregateID string, maxVersion int) (*Snapshot, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	stored := ss.snapshots[aggregateID]
+	for i := len(stored) - 1; i >= 0; i-- {
+		if stored[i].Version <= maxVersion {
+			snapshot := stored[i]
+			return &snapshot, nil
+		}
+	}
+	return nil, nil
+}
@@ -6,119 +6,344 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/lib/pq"
 )
 
 // Event представляет сохранённое событие
 type Event struct {
-	ID            int64
-	EventID       string
-	AggregateID   string
-	AggregateType string
-	EventType     string
-	EventData     json.RawMessage
-	Metadata      json.RawMessage
-	Version       int
-	CreatedAt     string
+	ID             int64
+	GlobalSequence int64 // Монотонный номер события во всём Event Store (catch-up подписки)
+	EventID        string
+	AggregateID    string
+	AggregateType  string
+	EventType      string
+	EventData      json.RawMessage
+	Metadata       json.RawMessage
+	Version        int
+	CreatedAt      string
 }
 
 // EventStore интерфейс для работы с событиями
 type EventStore interface {
 	Save(ctx context.Context, events []interface{}) error
+	SaveInTx(ctx context.Context, batches []EventBatch) error
 	Load(ctx context.Context, aggregateID string) ([]Event, error)
+	// LoadByAggregateType - Load только событий с aggregate_type = aggregateType
+	// Чужие события под тем же ID (коллизия, неверный ID) не попадают в replay агрегата
+	LoadByAggregateType(ctx context.Context, aggregateID, aggregateType string) ([]Event, error)
+	// LoadRangeByAggregateType - LoadRange только событий с aggregate_type = aggregateType
+	// (replay со snapshot'а или до версии); пустой результат - не ошибка, как у LoadRange
+	LoadRangeByAggregateType(ctx context.Context, aggregateID, aggregateType string, fromVersion, toVersion int) ([]Event, error)
+	// Exists - есть ли у агрегата хотя бы одно событие (без загрузки потока)
+	Exists(ctx context.Context, aggregateID string) (bool, error)
 	LoadFromVersion(ctx context.Context, aggregateID string, fromVersion int) ([]Event, error)
+	LoadUpToVersion(ctx context.Context, aggregateID string, version int) ([]Event, error)
+	LoadRange(ctx context.Context, aggregateID string, fromVersion, toVersion int) ([]Event, error)
+	LoadAll(ctx context.Context, fromGlobalSeq int64, limit int) ([]Event, error)
+	LoadByType(ctx context.Context, eventType string, since time.Time, fromGlobalSeq int64, limit int) ([]Event, error)
+	// WithAggregateLock выполняет fn, пока никто другой не держит lock этого агрегата
+	// Для шагов из нескольких Save (с внешним вызовом между ними), которые не должны чередоваться
+	// Потерянный lock отменяет ctx fn и возвращает ErrLockLost
+	WithAggregateLock(ctx context.Context, aggregateID string, fn func(ctx context.Context) error) error
 }
 
+// ErrConcurrencyConflict - версия агрегата уже записана другим writer'ом (optimistic locking)
+// Агрегат нужно перезагрузить и повторить команду
+var ErrConcurrencyConflict = errors.New("optimistic locking conflict: version already exists")
+
+// ErrAggregateNotFound - у агрегата нет ни одного события
+// Отличает "не существует" от ошибок БД: первое - 404, второе - 500
+var ErrAggregateNotFound = errors.New("aggregate not found")
+
+// ErrSaveTooLarge - в одном Save/SaveInTx больше событий, чем MaxEventsPerSave
+// Повтор не поможет: вызывающий должен сохранять события частями
+var ErrSaveTooLarge = errors.New("too many events in a single save")
+
+// Лимиты записи событий
+const (
+	DefaultInsertBatchSize  = 500    // Событий в одном INSERT (8 параметров на событие, лимит Postgres - 65535)
+	DefaultMaxEventsPerSave = 10_000 // Больше событий в одной записи - ErrSaveTooLarge
+)
+
+// globalSequenceLockKey - ключ advisory lock, упорядочивающего запись событий (OrderedWrites)
+// Пока транзакция держит lock, никто другой не получает global_sequence, поэтому
+// номера становятся видимыми строго по возрастанию: читатель LoadAll не может
+// пропустить событие, закоммиченное позже события с большим номером
+const globalSequenceLockKey = 7_310_001
+
 // PostgresEventStore реализация Event Store на PostgreSQL
 type PostgresEventStore struct {
 	db *sql.DB
+
+	// InsertBatchSize - событий в одном многострочном INSERT (events и outbox)
+	InsertBatchSize int
+	// MaxEventsPerSave - предел событий в одном Save/SaveInTx, сверх него ErrSaveTooLarge
+	MaxEventsPerSave int
+	// OrderedWrites - писать под глобальным advisory lock (globalSequenceLockKey)
+	// Цена: все Save системы, по всем агрегатам, выполняются строго по одному.
+	// Нужно только читателям хвоста LoadAll под нагрузкой (catch-up подписки): без lock'а
+	// событие с меньшим global_sequence может закоммититься позже, и курсор его пропустит.
+	// По умолчанию выключено
+	OrderedWrites bool
+
+	// LockLeaseTTL - срок lease WithAggregateLock без продления
+	LockLeaseTTL time.Duration
+	// LockWaitTimeout - сколько WithAggregateLock ждёт занятый lease, потом ErrAggregateLocked
+	LockWaitTimeout time.Duration
 }
 
 func NewPostgresEventStore(db *sql.DB) *PostgresEventStore {
-	return &PostgresEventStore{db: db}
+	return &PostgresEventStore{
+		db:               db,
+		InsertBatchSize:  DefaultInsertBatchSize,
+		MaxEventsPerSave: DefaultMaxEventsPerSave,
+		LockLeaseTTL:     DefaultLockLeaseTTL,
+		LockWaitTimeout:  DefaultLockWaitTimeout,
+	}
+}
+
+// EventBatch - новые события одного агрегата для SaveInTx
+type EventBatch struct {
+	AggregateID     string
+	ExpectedVersion int // Версия агрегата до этих событий (0 - агрегат ещё не существует)
+	Events          []interface{}
 }
 
+// NewEventBatch собирает batch из Changes агрегата текущей версии version
+func NewEventBatch(aggregateID string, version int, changes []interface{}) EventBatch {
+	return EventBatch{
+		AggregateID:     aggregateID,
+		ExpectedVersion: version - len(changes),
+		Events:          changes,
+	}
+}
+
+// SQL запрос для вставки событий: VALUES по 8 параметров на событие (insertChunk)
+const insertEventQuery = `
+        INSERT INTO events (
+            event_id, aggregate_id, aggregate_type, event_type, 
+            event_data, metadata, version, created_at
+        ) VALUES `
+
+// SQL запрос для Outbox: VALUES по 4 параметра на событие
+const insertOutboxQuery = `
+        INSERT INTO outbox (
+            event_id, aggregate_id, event_type, event_data, published
+        ) VALUES `
+
 // Save сохраняет события в транзакции
+// Каждое событие записывается в events и outbox атомарно (insertEvents):
+// сохранённое событие всегда будет опубликовано, неудачная запись не оставляет ни того, ни другого
 func (es *PostgresEventStore) Save(ctx context.Context, events []interface{}) error {
 	if len(events) == 0 {
 		return nil
 	}
+	if err := checkSaveSize(len(events), es.MaxEventsPerSave); err != nil {
+		return err
+	}
 
+	return es.inTx(ctx, func(tx *sql.Tx) error {
+		return es.insertEvents(ctx, tx, events)
+	})
+}
+
+// SaveInTx сохраняет события нескольких агрегатов в одной транзакции
+// Либо записаны все batch'и, либо ни один. Версия каждого агрегата проверяется
+// до записи: если она не ExpectedVersion - ErrConcurrencyConflict
+func (es *PostgresEventStore) SaveInTx(ctx context.Context, batches []EventBatch) error {
+	if err := checkSaveSize(countEvents(batches), es.MaxEventsPerSave); err != nil {
+		return err
+	}
+
+	return es.inTx(ctx, func(tx *sql.Tx) error {
+		for _, batch := range batches {
+			if len(batch.Events) == 0 {
+				continue
+			}
+
+			// Параллельная запись с той же версией упрётся в idx_aggregate_version:
+			// проверка ниже - быстрый отказ, уникальный индекс - гарантия
+			var version int
+			err := tx.QueryRowContext(ctx,
+				`SELECT COALESCE(MAX(version), 0) FROM events WHERE aggregate_id = $1`,
+				batch.AggregateID,
+			).Scan(&version)
+			if err != nil {
+				return fmt.Errorf("failed to read aggregate version: %w", err)
+			}
+
+			if version != batch.ExpectedVersion {
+				return fmt.Errorf("%w: aggregate %s is at version %d, expected %d",
+					ErrConcurrencyConflict, batch.AggregateID, version, batch.ExpectedVersion)
+			}
+
+			if err := es.insertEvents(ctx, tx, batch.Events); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// inTx выполняет запись и коммитит её (события + outbox атомарно)
+// С OrderedWrites запись идёт под глобальным advisory lock
+func (es *PostgresEventStore) inTx(ctx context.Context, write func(tx *sql.Tx) error) error {
 	tx, err := es.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// SQL запрос для вставки события
-	query := `
-        INSERT INTO events (
-            event_id, aggregate_id, aggregate_type, event_type, 
-            event_data, metadata, version, created_at
-        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-    `
+	// Сериализуем запись: global_sequence выдаётся в порядке коммитов
+	if es.OrderedWrites {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, globalSequenceLockKey); err != nil {
+			return fmt.Errorf("failed to acquire event store lock: %w", err)
+		}
+	}
 
-	// SQL запрос для Outbox
-	outboxQuery := `
-        INSERT INTO outbox (
-            event_id, aggregate_id, event_type, event_data, published
-        ) VALUES ($1, $2, $3, $4, false)
-    `
+	if err := write(tx); err != nil {
+		return err
+	}
+
+	// Коммит транзакции (события + outbox атомарно)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 
+	return nil
+}
+
+// insertEvents записывает события и их outbox-записи в открытой транзакции
+// частями по InsertBatchSize: один INSERT на часть вместо двух на каждое событие
+func (es *PostgresEventStore) insertEvents(ctx context.Context, tx *sql.Tx, events []interface{}) error {
+	rows := make([]insertRow, 0, len(events))
 	for _, event := range events {
 		// Извлекаем базовые поля через рефлексию или type assertion
-		eventData, metadata, baseFields, err := serializeEvent(event)
+		eventData, metadata, baseFields, err := serializeEvent(ctx, event)
 		if err != nil {
 			return fmt.Errorf("failed to serialize event: %w", err)
 		}
+		rows = append(rows, insertRow{eventData: eventData, metadata: metadata, fields: baseFields})
+	}
+
+	batchSize := es.InsertBatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultInsertBatchSize
+	}
+	for start := 0; start < len(rows); start += batchSize {
+		end := min(start+batchSize, len(rows))
+		if err := insertChunk(ctx, tx, rows[start:end]); err != nil {
+			return err
+		}
+	}
 
-		// Сохраняем в events таблицу
-		_, err = tx.ExecContext(ctx, query,
-			baseFields.EventID,
-			baseFields.AggregateID,
-			baseFields.AggregateType,
-			baseFields.EventType,
-			eventData,
-			metadata,
-			baseFields.Version,
-			baseFields.Timestamp,
+	return nil
+}
+
+// insertRow - сериализованное событие, готовое к записи
+type insertRow struct {
+	eventData []byte
+	metadata  []byte
+	fields    BaseFields
+}
+
+// insertChunk записывает часть событий в events и outbox двумя многострочными INSERT
+func insertChunk(ctx context.Context, tx *sql.Tx, rows []insertRow) error {
+	eventArgs := make([]interface{}, 0, len(rows)*8)
+	outboxArgs := make([]interface{}, 0, len(rows)*4)
+	for _, r := range rows {
+		eventArgs = append(eventArgs,
+			r.fields.EventID,
+			r.fields.AggregateID,
+			r.fields.AggregateType,
+			r.fields.EventType,
+			r.eventData,
+			r.metadata,
+			r.fields.Version,
+			r.fields.Timestamp,
+		)
+		outboxArgs = append(outboxArgs,
+			r.fields.EventID,
+			r.fields.AggregateID,
+			r.fields.EventType,
+			r.eventData,
 		)
+	}
 
-		if err != nil {
-			// Проверяем на конфликт версий (optimistic locking)
-			if isUniqueViolation(err) {
-				return errors.New("optimistic locking conflict: version already exists")
+	// Сохраняем в events таблицу
+	_, err := tx.ExecContext(ctx, insertEventQuery+valuesList(len(rows), 8, ""), eventArgs...)
+	if err != nil {
+		// Проверяем на конфликт версий (optimistic locking)
+		if isUniqueViolation(err) {
+			first, last := rows[0].fields, rows[len(rows)-1].fields
+			if first.AggregateID == last.AggregateID {
+				return fmt.Errorf("%w: aggregate %s versions %d-%d", ErrConcurrencyConflict, first.AggregateID, first.Version, last.Version)
 			}
-			return fmt.Errorf("failed to insert event: %w", err)
+			return fmt.Errorf("%w: aggregates %s, %s", ErrConcurrencyConflict, first.AggregateID, last.AggregateID)
 		}
+		return fmt.Errorf("failed to insert events: %w", err)
+	}
 
-		// Сохраняем в outbox (для гарантированной публикации)
-		_, err = tx.ExecContext(ctx, outboxQuery,
-			baseFields.EventID,
-			baseFields.AggregateID,
-			baseFields.EventType,
-			eventData,
-		)
+	// Сохраняем в outbox (для гарантированной публикации)
+	_, err = tx.ExecContext(ctx, insertOutboxQuery+valuesList(len(rows), 4, "false"), outboxArgs...)
+	if err != nil {
+		return fmt.Errorf("failed to insert into outbox: %w", err)
+	}
 
-		if err != nil {
-			return fmt.Errorf("failed to insert into outbox: %w", err)
+	return nil
+}
+
+// valuesList строит "($1, $2), ($3, $4)" для rows строк по columns параметров
+// extra дописывается в каждую строку как литерал (published = false)
+func valuesList(rows, columns int, extra string) string {
+	var sb strings.Builder
+	n := 1
+	for r := 0; r < rows; r++ {
+		if r > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for c := 0; c < columns; c++ {
+			if c > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", n)
+			n++
+		}
+		if extra != "" {
+			sb.WriteString(", " + extra)
 		}
+		sb.WriteByte(')')
 	}
+	return sb.String()
+}
 
-	// Коммит транзакции (события + outbox атомарно)
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// checkSaveSize отклоняет запись больше max событий (max <= 0 - без предела)
+func checkSaveSize(count, max int) error {
+	if max > 0 && count > max {
+		return fmt.Errorf("%w: %d events, limit %d", ErrSaveTooLarge, count, max)
 	}
-
 	return nil
 }
 
+// countEvents - число событий во всех batch'ах
+func countEvents(batches []EventBatch) int {
+	count := 0
+	for _, batch := range batches {
+		count += len(batch.Events)
+	}
+	return count
+}
+
 // Load загружает все события для агрегата
+// Нет событий - ErrAggregateNotFound, ошибка БД возвращается как есть
 func (es *PostgresEventStore) Load(ctx context.Context, aggregateID string) ([]Event, error) {
 	query := `
         SELECT 
-            id, event_id, aggregate_id, aggregate_type, event_type,
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
             event_data, metadata, version, created_at
         FROM events
         WHERE aggregate_id = $1
@@ -131,33 +356,64 @@ func (es *PostgresEventStore) Load(ctx context.Context, aggregateID string) ([]E
 	}
 	defer rows.Close()
 
-	var events []Event
-	for rows.Next() {
-		var event Event
-		err := rows.Scan(
-			&event.ID,
-			&event.EventID,
-			&event.AggregateID,
-			&event.AggregateType,
-			&event.EventType,
-			&event.EventData,
-			&event.Metadata,
-			&event.Version,
-			&event.CreatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan event: %w", err)
-		}
-		events = append(events, event)
+	events, err := scanEvents(rows)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := rows.Err(); err != nil {
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAggregateNotFound, aggregateID)
+	}
+
+	return events, nil
+}
+
+// LoadByAggregateType загружает события агрегата только указанного типа
+// Нет таких событий - ErrAggregateNotFound (даже если под ID есть события другого типа)
+func (es *PostgresEventStore) LoadByAggregateType(ctx context.Context, aggregateID, aggregateType string) ([]Event, error) {
+	query := `
+        SELECT 
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
+            event_data, metadata, version, created_at
+        FROM events
+        WHERE aggregate_id = $1 AND aggregate_type = $2
+        ORDER BY version ASC
+    `
+
+	rows, err := es.db.QueryContext(ctx, query, aggregateID, aggregateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events, err := scanEvents(rows)
+	if err != nil {
 		return nil, err
 	}
 
+	if len(events) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrAggregateNotFound, aggregateType, aggregateID)
+	}
+
 	return events, nil
 }
 
+// Exists проверяет наличие агрегата одной строкой индекса (aggregate_id, version)
+// Дешевле Load, когда нужен только ответ "есть / 404"
+func (es *PostgresEventStore) Exists(ctx context.Context, aggregateID string) (bool, error) {
+	query := `SELECT 1 FROM events WHERE aggregate_id = $1 LIMIT 1`
+
+	var one int
+	err := es.db.QueryRowContext(ctx, query, aggregateID).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check aggregate %s: %w", aggregateID, err)
+	}
+	return true, nil
+}
+
 // LoadFromVersion загружает события начиная с версии
 func (es *PostgresEventStore) LoadFromVersion(
 	ctx context.Context,
@@ -166,7 +422,7 @@ func (es *PostgresEventStore) LoadFromVersion(
 ) ([]Event, error) {
 	query := `
         SELECT 
-            id, event_id, aggregate_id, aggregate_type, event_type,
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
             event_data, metadata, version, created_at
         FROM events
         WHERE aggregate_id = $1 AND version >= $2
@@ -179,11 +435,149 @@ func (es *PostgresEventStore) LoadFromVersion(
 	}
 	defer rows.Close()
 
+	return scanEvents(rows)
+}
+
+// LoadUpToVersion загружает события до указанной версии включительно
+// (point-in-time восстановление агрегата)
+func (es *PostgresEventStore) LoadUpToVersion(
+	ctx context.Context,
+	aggregateID string,
+	version int,
+) ([]Event, error) {
+	query := `
+        SELECT 
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
+            event_data, metadata, version, created_at
+        FROM events
+        WHERE aggregate_id = $1 AND version <= $2
+        ORDER BY version ASC
+    `
+
+	rows, err := es.db.QueryContext(ctx, query, aggregateID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// LoadRange загружает события в диапазоне версий [fromVersion, toVersion] включительно
+// (постраничное чтение длинных потоков событий)
+func (es *PostgresEventStore) LoadRange(
+	ctx context.Context,
+	aggregateID string,
+	fromVersion, toVersion int,
+) ([]Event, error) {
+	query := `
+        SELECT 
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
+            event_data, metadata, version, created_at
+        FROM events
+        WHERE aggregate_id = $1 AND version >= $2 AND version <= $3
+        ORDER BY version ASC
+    `
+
+	rows, err := es.db.QueryContext(ctx, query, aggregateID, fromVersion, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// LoadRangeByAggregateType загружает события агрегата указанного типа в диапазоне версий
+// [fromVersion, toVersion] включительно
+func (es *PostgresEventStore) LoadRangeByAggregateType(
+	ctx context.Context,
+	aggregateID, aggregateType string,
+	fromVersion, toVersion int,
+) ([]Event, error) {
+	query := `
+        SELECT 
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
+            event_data, metadata, version, created_at
+        FROM events
+        WHERE aggregate_id = $1 AND aggregate_type = $2 AND version >= $3 AND version <= $4
+        ORDER BY version ASC
+    `
+
+	rows, err := es.db.QueryContext(ctx, query, aggregateID, aggregateType, fromVersion, toVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// LoadAll загружает события всех агрегатов в глобальном порядке (global_sequence >= fromGlobalSeq)
+// Проекция хранит последний обработанный номер и догоняет поток с номера + 1.
+// Без OrderedWrites у хвоста потока возможны "дыры": номер выдан, но транзакция ещё не закоммичена
+func (es *PostgresEventStore) LoadAll(
+	ctx context.Context,
+	fromGlobalSeq int64,
+	limit int,
+) ([]Event, error) {
+	query := `
+        SELECT 
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
+            event_data, metadata, version, created_at
+        FROM events
+        WHERE global_sequence >= $1
+        ORDER BY global_sequence ASC
+        LIMIT $2
+    `
+
+	rows, err := es.db.QueryContext(ctx, query, fromGlobalSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// LoadByType загружает события одного типа всех агрегатов, созданные не раньше since,
+// в глобальном порядке (global_sequence >= fromGlobalSeq)
+// Курсор - global_sequence: следующая страница начинается с номера последнего события + 1
+func (es *PostgresEventStore) LoadByType(
+	ctx context.Context,
+	eventType string,
+	since time.Time,
+	fromGlobalSeq int64,
+	limit int,
+) ([]Event, error) {
+	// created_at - TIMESTAMP без зоны (NOW() сессии): since приводится к зоне сессии
+	query := `
+        SELECT 
+            id, global_sequence, event_id, aggregate_id, aggregate_type, event_type,
+            event_data, metadata, version, created_at
+        FROM events
+        WHERE event_type = $1 AND created_at >= $2::timestamptz::timestamp AND global_sequence >= $3
+        ORDER BY global_sequence ASC
+        LIMIT $4
+    `
+
+	rows, err := es.db.QueryContext(ctx, query, eventType, since, fromGlobalSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanEvents(rows)
+}
+
+// scanEvents читает строки events в срез Event
+func scanEvents(rows *sql.Rows) ([]Event, error) {
 	var events []Event
 	for rows.Next() {
 		var event Event
 		err := rows.Scan(
 			&event.ID,
+			&event.GlobalSequence,
 			&event.EventID,
 			&event.AggregateID,
 			&event.AggregateType,
@@ -193,11 +587,21 @@ func (es *PostgresEventStore) LoadFromVersion(
 			&event.Version,
 			&event.CreatedAt,
 		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+
+		// Старые события приводятся к текущей схеме до десериализации
+		event.EventData, err = Upcast(event.EventType, event.EventData)
 		if err != nil {
 			return nil, err
 		}
 		events = append(events, event)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return events, nil
 }
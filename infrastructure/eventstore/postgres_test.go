@@ -0,0 +1,253 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// testEvent - минимальное событие для тестов записи
+type testEvent struct {
+	base BaseFields
+}
+
+func (e testEvent) GetBaseEvent() BaseFields { return e.base }
+
+func newTestEvent(aggregateID, aggregateType string, version int) testEvent {
+	return testEvent{base: BaseFields{
+		EventID:       aggregateID + "-event",
+		AggregateID:   aggregateID,
+		AggregateType: aggregateType,
+		EventType:     aggregateType + "Updated",
+		Version:       version,
+		Timestamp:     time.Now(),
+	}}
+}
+
+func newTestEventStore(t *testing.T) (*PostgresEventStore, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewPostgresEventStore(db), mock
+}
+
+const selectVersionQuery = `SELECT COALESCE\(MAX\(version\), 0\) FROM events WHERE aggregate_id = \$1`
+
+func TestSaveInTxRollsBackEveryBatchOnFailure(t *testing.T) {
+	es, mock := newTestEventStore(t)
+	batches := []EventBatch{
+		{AggregateID: "order-1", ExpectedVersion: 0, Events: []interface{}{newTestEvent("order-1", "Order", 1)}},
+		{AggregateID: "position-1", ExpectedVersion: 0, Events: []interface{}{newTestEvent("position-1", "Position", 1)}},
+	}
+
+	// Первый batch записан в транзакции, на втором - сбой: коммита нет
+	failure := errors.New("connection reset")
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectVersionQuery).WithArgs("order-1").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(0))
+	mock.ExpectExec(`INSERT INTO events`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(selectVersionQuery).WithArgs("position-1").WillReturnError(failure)
+	mock.ExpectRollback()
+
+	if err := es.SaveInTx(context.Background(), batches); !errors.Is(err, failure) {
+		t.Fatalf("SaveInTx error = %v, want %v", err, failure)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMemorySaveInTxIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	es := NewMemoryEventStore()
+
+	if err := es.Save(ctx, []interface{}{newTestEvent("position-1", "Position", 1)}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	// Второй batch ожидает устаревшую версию: не записан ни один
+	err := es.SaveInTx(ctx, []EventBatch{
+		{AggregateID: "order-1", ExpectedVersion: 0, Events: []interface{}{newTestEvent("order-1", "Order", 1)}},
+		{AggregateID: "position-1", ExpectedVersion: 0, Events: []interface{}{newTestEvent("position-1", "Position", 1)}},
+	})
+	if !errors.Is(err, ErrConcurrencyConflict) {
+		t.Fatalf("SaveInTx error = %v, want ErrConcurrencyConflict", err)
+	}
+
+	if events, _ := es.Load(ctx, "order-1"); len(events) != 0 {
+		t.Errorf("order-1 has %d events after the failed save, want 0", len(events))
+	}
+	if outbox := es.TakeOutbox(); len(outbox) != 1 {
+		t.Errorf("outbox has %d events, want only the first save", len(outbox))
+	}
+}
+
+func TestSaveWritesOutboxRowPerEvent(t *testing.T) {
+	es, mock := newTestEventStore(t)
+	first := newTestEvent("order-1", "Order", 1)
+	second := newTestEvent("order-1", "Order", 2)
+	second.base.EventID = "order-1-event-2"
+
+	anyArg := sqlmock.AnyArg()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO events .* VALUES \(\$1, .*\$8\), \(\$9, .*\$16\)$`).
+		WithArgs("order-1-event", "order-1", "Order", "OrderUpdated", anyArg, anyArg, 1, anyArg,
+			"order-1-event-2", "order-1", "Order", "OrderUpdated", anyArg, anyArg, 2, anyArg).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	// Every event gets its outbox row, unpublished, in the same transaction
+	mock.ExpectExec(`INSERT INTO outbox .* VALUES \(\$1, \$2, \$3, \$4, false\), \(\$5, \$6, \$7, \$8, false\)$`).
+		WithArgs("order-1-event", "order-1", "OrderUpdated", anyArg,
+			"order-1-event-2", "order-1", "OrderUpdated", anyArg).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	if err := es.Save(context.Background(), []interface{}{first, second}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveRollsBackEventsWhenOutboxInsertFails(t *testing.T) {
+	es, mock := newTestEventStore(t)
+
+	failure := errors.New("outbox unavailable")
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO events`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnError(failure)
+	mock.ExpectRollback()
+
+	if err := es.Save(context.Background(), []interface{}{newTestEvent("order-1", "Order", 1)}); !errors.Is(err, failure) {
+		t.Fatalf("Save error = %v, want %v", err, failure)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveChunksLargeSaves(t *testing.T) {
+	const n = 5000
+	es, mock := newTestEventStore(t)
+
+	events := make([]interface{}, n)
+	for i := range events {
+		e := newTestEvent("order-1", "Order", i+1)
+		e.base.EventID = fmt.Sprintf("order-1-event-%d", i+1)
+		events[i] = e
+	}
+
+	// 5000 events: 10 chunks of DefaultInsertBatchSize, all in one transaction
+	mock.ExpectBegin()
+	for i := 0; i < n/DefaultInsertBatchSize; i++ {
+		last := fmt.Sprintf(`\(\$%d, \$%d, \$%d, \$%d, \$%d, \$%d, \$%d, \$%d\)$`,
+			DefaultInsertBatchSize*8-7, DefaultInsertBatchSize*8-6, DefaultInsertBatchSize*8-5, DefaultInsertBatchSize*8-4,
+			DefaultInsertBatchSize*8-3, DefaultInsertBatchSize*8-2, DefaultInsertBatchSize*8-1, DefaultInsertBatchSize*8)
+		mock.ExpectExec(`INSERT INTO events .*` + last).WillReturnResult(sqlmock.NewResult(0, DefaultInsertBatchSize))
+		mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(0, DefaultInsertBatchSize))
+	}
+	mock.ExpectCommit()
+
+	if err := es.Save(context.Background(), events); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveChunksKeepVersionOrder(t *testing.T) {
+	es, mock := newTestEventStore(t)
+	es.InsertBatchSize = 2
+
+	events := make([]interface{}, 5)
+	for i := range events {
+		e := newTestEvent("order-1", "Order", i+1)
+		e.base.EventID = fmt.Sprintf("order-1-event-%d", i+1)
+		events[i] = e
+	}
+
+	anyArg := sqlmock.AnyArg()
+	eventArgs := func(versions ...int) []driver.Value {
+		var args []driver.Value
+		for _, v := range versions {
+			args = append(args, fmt.Sprintf("order-1-event-%d", v), "order-1", "Order", "OrderUpdated", anyArg, anyArg, v, anyArg)
+		}
+		return args
+	}
+
+	mock.ExpectBegin()
+	for _, chunk := range [][]int{{1, 2}, {3, 4}, {5}} {
+		mock.ExpectExec(`INSERT INTO events`).WithArgs(eventArgs(chunk...)...).WillReturnResult(sqlmock.NewResult(0, int64(len(chunk))))
+		mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(0, int64(len(chunk))))
+	}
+	mock.ExpectCommit()
+
+	if err := es.Save(context.Background(), events); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSaveRejectsTooLargeSave(t *testing.T) {
+	es, mock := newTestEventStore(t)
+	es.MaxEventsPerSave = 3
+
+	events := make([]interface{}, 4)
+	for i := range events {
+		events[i] = newTestEvent("order-1", "Order", i+1)
+	}
+
+	// Rejected before a transaction is opened
+	if err := es.Save(context.Background(), events); !errors.Is(err, ErrSaveTooLarge) {
+		t.Fatalf("Save error = %v, want ErrSaveTooLarge", err)
+	}
+	batches := []EventBatch{{AggregateID: "order-1", Events: events}}
+	if err := es.SaveInTx(context.Background(), batches); !errors.Is(err, ErrSaveTooLarge) {
+		t.Fatalf("SaveInTx error = %v, want ErrSaveTooLarge", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMemorySaveKeepsLargeSaveInOrder(t *testing.T) {
+	const n = 5000
+	ctx := context.Background()
+	es := NewMemoryEventStore()
+
+	events := make([]interface{}, n)
+	for i := range events {
+		e := newTestEvent("order-1", "Order", i+1)
+		e.base.EventID = fmt.Sprintf("order-1-event-%d", i+1)
+		events[i] = e
+	}
+	if err := es.Save(ctx, events); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	stored, err := es.Load(ctx, "order-1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(stored) != n {
+		t.Fatalf("stored %d events, want %d", len(stored), n)
+	}
+	for i, e := range stored {
+		if e.Version != i+1 || e.EventID != fmt.Sprintf("order-1-event-%d", i+1) {
+			t.Fatalf("event %d = %s v%d, want order-1-event-%d v%d", i, e.EventID, e.Version, i+1, i+1)
+		}
+	}
+}
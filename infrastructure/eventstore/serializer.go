@@ -1,9 +1,12 @@
 package eventstore
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"time"
+
+	"market_order/pkg/tracing"
 )
 
 // BaseFieldsProvider is an interface for events that can provide base fields
@@ -22,7 +25,9 @@ type BaseFields struct {
 }
 
 // serializeEvent serializes an event and extracts base fields
-func serializeEvent(event interface{}) ([]byte, []byte, BaseFields, error) {
+// The active trace context (if any) is added to the event metadata;
+// the metadata column stores the event's whole metadata (e.g. position_id)
+func serializeEvent(ctx context.Context, event interface{}) ([]byte, []byte, BaseFields, error) {
 	// Serialize entire event to JSON
 	eventData, err := json.Marshal(event)
 	if err != nil {
@@ -37,12 +42,66 @@ func serializeEvent(event interface{}) ([]byte, []byte, BaseFields, error) {
 
 	baseFields := provider.GetBaseEvent()
 
-	// Metadata (empty for now, can be extended)
-	metadata := []byte("{}")
+	// Version of the event schema (used by upcasters when reading old events)
+	eventData, err = withSchemaVersion(eventData, baseFields.EventType)
+	if err != nil {
+		return nil, nil, BaseFields{}, err
+	}
+
+	if traceparent := tracing.Traceparent(ctx); traceparent != "" {
+		eventData, err = withMetadataValue(eventData, tracing.MetadataKey, traceparent)
+		if err != nil {
+			return nil, nil, BaseFields{}, err
+		}
+	}
+
+	metadata, err := eventMetadata(eventData)
+	if err != nil {
+		return nil, nil, BaseFields{}, err
+	}
 
 	return eventData, metadata, baseFields, nil
 }
 
+// eventMetadata returns the metadata object of serialized event JSON ("{}" if absent)
+func eventMetadata(eventData []byte) ([]byte, error) {
+	var fields struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := json.Unmarshal(eventData, &fields); err != nil {
+		return nil, err
+	}
+	if fields.Metadata == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(fields.Metadata)
+}
+
+// withMetadataValue sets metadata[key] inside serialized event JSON
+// Works for every aggregate, including events without a Metadata field
+func withMetadataValue(eventData []byte, key, value string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(eventData, &fields); err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]interface{})
+	if raw, ok := fields["metadata"]; ok {
+		if err := json.Unmarshal(raw, &metadata); err != nil || metadata == nil {
+			metadata = make(map[string]interface{})
+		}
+	}
+	metadata[key] = value
+
+	raw, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	fields["metadata"] = raw
+
+	return json.Marshal(fields)
+}
+
 // isUniqueViolation checks if error is a PostgreSQL unique constraint violation
 func isUniqueViolation(err error) bool {
 	// Check for PostgreSQL error code 23505 (unique_violation)
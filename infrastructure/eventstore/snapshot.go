@@ -0,0 +1,87 @@
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Snapshot представляет сохранённое состояние агрегата на определённой версии
+type Snapshot struct {
+	AggregateID   string
+	AggregateType string
+	Version       int
+	State         json.RawMessage
+	CreatedAt     string
+}
+
+// SnapshotStore интерфейс для работы со снапшотами агрегатов
+type SnapshotStore interface {
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+	LoadSnapshot(ctx context.Context, aggregateID string, maxVersion int) (*Snapshot, error)
+}
+
+// PostgresSnapshotStore реализация Snapshot Store на PostgreSQL
+type PostgresSnapshotStore struct {
+	db *sql.DB
+}
+
+func NewPostgresSnapshotStore(db *sql.DB) *PostgresSnapshotStore {
+	return &PostgresSnapshotStore{db: db}
+}
+
+// SaveSnapshot сохраняет снапшот (повторное сохранение той же версии игнорируется)
+func (ss *PostgresSnapshotStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	query := `
+        INSERT INTO snapshots (aggregate_id, aggregate_type, version, state)
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT (aggregate_id, version) DO NOTHING
+    `
+
+	_, err := ss.db.ExecContext(ctx, query,
+		snapshot.AggregateID,
+		snapshot.AggregateType,
+		snapshot.Version,
+		[]byte(snapshot.State),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// LoadSnapshot загружает последний снапшот с версией не больше maxVersion
+// Возвращает nil, nil если снапшота нет
+func (ss *PostgresSnapshotStore) LoadSnapshot(
+	ctx context.Context,
+	aggregateID string,
+	maxVersion int,
+) (*Snapshot, error) {
+	query := `
+        SELECT aggregate_id, aggregate_type, version, state, created_at
+        FROM snapshots
+        WHERE aggregate_id = $1 AND version <= $2
+        ORDER BY version DESC
+        LIMIT 1
+    `
+
+	var snapshot Snapshot
+	err := ss.db.QueryRowContext(ctx, query, aggregateID, maxVersion).Scan(
+		&snapshot.AggregateID,
+		&snapshot.AggregateType,
+		&snapshot.Version,
+		&snapshot.State,
+		&snapshot.CreatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
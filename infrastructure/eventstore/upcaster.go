@@ -0,0 +1,93 @@
+package eventstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// SchemaVersionField - поле JSON события с версией его схемы
+// События без этого поля записаны до введения версионирования и считаются версией 1
+const SchemaVersionField = "schema_version"
+
+// Upcaster переводит JSON события из версии схемы N в N+1
+// Получает поля события и изменяет их на месте (добавить поле, переименовать, задать default)
+type Upcaster func(fields map[string]interface{}) error
+
+// upcasters[eventType][i] переводит версию i+1 в i+2
+var upcasters = struct {
+	mu     sync.RWMutex
+	chains map[string][]Upcaster
+}{chains: make(map[string][]Upcaster)}
+
+// RegisterUpcaster регистрирует следующий шаг цепочки для eventType: fromVersion → fromVersion+1
+// Шаги регистрируются по порядку, начиная с версии 1
+func RegisterUpcaster(eventType string, fromVersion int, up Upcaster) {
+	upcasters.mu.Lock()
+	defer upcasters.mu.Unlock()
+
+	chain := upcasters.chains[eventType]
+	if fromVersion != len(chain)+1 {
+		panic(fmt.Sprintf("eventstore: upcaster for %s must start at version %d, got %d", eventType, len(chain)+1, fromVersion))
+	}
+	upcasters.chains[eventType] = append(chain, up)
+}
+
+// CurrentSchemaVersion возвращает текущую версию схемы события (1 + число upcaster'ов)
+func CurrentSchemaVersion(eventType string) int {
+	upcasters.mu.RLock()
+	defer upcasters.mu.RUnlock()
+
+	return len(upcasters.chains[eventType]) + 1
+}
+
+// Upcast приводит JSON события к текущей версии схемы
+// Вызывается при чтении из Event Store, до десериализации в доменные структуры
+func Upcast(eventType string, eventData []byte) ([]byte, error) {
+	upcasters.mu.RLock()
+	chain := upcasters.chains[eventType]
+	upcasters.mu.RUnlock()
+
+	if len(chain) == 0 {
+		return eventData, nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(eventData, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode %s for upcasting: %w", eventType, err)
+	}
+
+	version := 1
+	if v, ok := fields[SchemaVersionField].(float64); ok && v >= 1 {
+		version = int(v)
+	}
+
+	if version > len(chain) {
+		return eventData, nil // Уже текущая версия
+	}
+
+	for ; version <= len(chain); version++ {
+		if err := chain[version-1](fields); err != nil {
+			return nil, fmt.Errorf("failed to upcast %s from schema v%d: %w", eventType, version, err)
+		}
+	}
+	fields[SchemaVersionField] = version
+
+	return json.Marshal(fields)
+}
+
+// withSchemaVersion проставляет текущую версию схемы в JSON нового события
+func withSchemaVersion(eventData []byte, eventType string) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(eventData, &fields); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(CurrentSchemaVersion(eventType))
+	if err != nil {
+		return nil, err
+	}
+	fields[SchemaVersionField] = raw
+
+	return json.Marshal(fields)
+}
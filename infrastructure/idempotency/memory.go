@@ -0,0 +1,140 @@
+package idempotency
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryProcessedEventStore is a ProcessedEventStore in process memory (test harnesses, local saga runs)
+// Same semantics as ProcessedEventsRepository: the first claim or mark of an event ID wins
+type MemoryProcessedEventStore struct {
+	mu     sync.Mutex
+	events map[string]memoryProcessedEvent // event_id → record
+	seq    int64
+}
+
+type memoryProcessedEvent struct {
+	ProcessedEvent
+	seq int64 // Insertion order: tie-break of equal ProcessedAt (id in processed_events)
+}
+
+var _ ProcessedEventStore = (*MemoryProcessedEventStore)(nil)
+
+func NewMemoryProcessedEventStore() *MemoryProcessedEventStore {
+	return &MemoryProcessedEventStore{events: make(map[string]memoryProcessedEvent)}
+}
+
+// IsProcessed checks if an event has already been processed
+func (s *MemoryProcessedEventStore) IsProcessed(ctx context.Context, eventID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.events[eventID]
+	return ok, nil
+}
+
+// MarkAsProcessed marks an event as processed; an already processed event is left as is
+func (s *MemoryProcessedEventStore) MarkAsProcessed(ctx context.Context, eventID, aggregateID, eventType, processedBy string) error {
+	s.ClaimEvent(ctx, eventID, aggregateID, eventType, processedBy)
+	return nil
+}
+
+// MarkManyAsProcessed marks a batch of events as processed; ProcessedAt is ignored (now)
+func (s *MemoryProcessedEventStore) MarkManyAsProcessed(ctx context.Context, records []ProcessedEvent) error {
+	for _, rec := range records {
+		s.ClaimEvent(ctx, rec.EventID, rec.AggregateID, rec.EventType, rec.ProcessedBy)
+	}
+	return nil
+}
+
+// ClaimEvent claims an event; only the first caller gets true
+func (s *MemoryProcessedEventStore) ClaimEvent(ctx context.Context, eventID, aggregateID, eventType, processedBy string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.events[eventID]; ok {
+		return false, nil
+	}
+	s.seq++
+	s.events[eventID] = memoryProcessedEvent{
+		ProcessedEvent: ProcessedEvent{
+			EventID:     eventID,
+			AggregateID: aggregateID,
+			EventType:   eventType,
+			ProcessedBy: processedBy,
+			ProcessedAt: time.Now(),
+		},
+		seq: s.seq,
+	}
+	return true, nil
+}
+
+// ReleaseEvent drops a claim so the event can be processed again
+func (s *MemoryProcessedEventStore) ReleaseEvent(ctx context.Context, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.events, eventID)
+	return nil
+}
+
+// GetProcessedEvents returns the processed events of an aggregate, oldest first
+// since (exclusive, zero = from the start) and limit (<= 0 = no limit) as in ProcessedEventsRepository
+func (s *MemoryProcessedEventStore) GetProcessedEvents(ctx context.Context, aggregateID string, since time.Time, limit int) ([]ProcessedEvent, error) {
+	records := s.aggregateEvents(aggregateID)
+
+	events := make([]ProcessedEvent, 0, len(records))
+	for _, rec := range records {
+		if !since.IsZero() && !rec.ProcessedAt.After(since) {
+			continue
+		}
+		if limit > 0 && len(events) == limit {
+			break
+		}
+		events = append(events, rec.ProcessedEvent)
+	}
+	return events, nil
+}
+
+// GetConsumerActivity returns per-consumer totals for an aggregate, by consumer name
+func (s *MemoryProcessedEventStore) GetConsumerActivity(ctx context.Context, aggregateID string) ([]ConsumerActivity, error) {
+	byConsumer := make(map[string]*ConsumerActivity)
+	for _, rec := range s.aggregateEvents(aggregateID) {
+		a, ok := byConsumer[rec.ProcessedBy]
+		if !ok {
+			a = &ConsumerActivity{ProcessedBy: rec.ProcessedBy, FirstProcessedAt: rec.ProcessedAt}
+			byConsumer[rec.ProcessedBy] = a
+		}
+		a.Events++
+		a.LastProcessedAt = rec.ProcessedAt
+	}
+
+	activity := make([]ConsumerActivity, 0, len(byConsumer))
+	for _, a := range byConsumer {
+		activity = append(activity, *a)
+	}
+	sort.Slice(activity, func(i, j int) bool { return activity[i].ProcessedBy < activity[j].ProcessedBy })
+	return activity, nil
+}
+
+// aggregateEvents returns the records of an aggregate in processing order
+func (s *MemoryProcessedEventStore) aggregateEvents(aggregateID string) []memoryProcessedEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var records []memoryProcessedEvent
+	for _, rec := range s.events {
+		if rec.AggregateID == aggregateID {
+			records = append(records, rec)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].ProcessedAt.Equal(records[j].ProcessedAt) {
+			return records[i].ProcessedAt.Before(records[j].ProcessedAt)
+		}
+		return records[i].seq < records[j].seq
+	})
+	return records
+}
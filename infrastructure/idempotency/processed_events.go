@@ -3,10 +3,34 @@ package idempotency
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"time"
+
+	"github.com/lib/pq"
 )
 
+// DefaultRetention - how long processed events are kept for idempotency checks
+// Must be far longer than any redelivery delay (RabbitMQ requeue, outbox retries,
+// replay from outbox_dead), otherwise an old event could be processed again
+const DefaultRetention = 30 * 24 * time.Hour
+
+// ProcessedEventStore - idempotency keys of the event consumers
+// ProcessedEventsRepository keeps them in Postgres, MemoryProcessedEventStore in process memory
+type ProcessedEventStore interface {
+	IsProcessed(ctx context.Context, eventID string) (bool, error)
+	MarkAsProcessed(ctx context.Context, eventID, aggregateID, eventType, processedBy string) error
+	MarkManyAsProcessed(ctx context.Context, records []ProcessedEvent) error
+	// ClaimEvent - only the first caller gets true (see ProcessedEventsRepository.ClaimEvent)
+	ClaimEvent(ctx context.Context, eventID, aggregateID, eventType, processedBy string) (bool, error)
+	ReleaseEvent(ctx context.Context, eventID string) error
+	GetProcessedEvents(ctx context.Context, aggregateID string, since time.Time, limit int) ([]ProcessedEvent, error)
+	GetConsumerActivity(ctx context.Context, aggregateID string) ([]ConsumerActivity, error)
+}
+
+var _ ProcessedEventStore = (*ProcessedEventsRepository)(nil)
+
 // ProcessedEventsRepository manages idempotency checks for event processing
 type ProcessedEventsRepository struct {
 	db *sql.DB
@@ -49,25 +73,161 @@ func (r *ProcessedEventsRepository) MarkAsProcessed(
 	return nil
 }
 
-// GetProcessedEvents returns all processed events for an aggregate (audit/debug)
+// FilterUnprocessed returns the eventIDs not processed yet, in input order, with a single query
+// Replaces a burst of IsProcessed calls (catch-up replays, redelivered batches)
+func (r *ProcessedEventsRepository) FilterUnprocessed(ctx context.Context, eventIDs []string) ([]string, error) {
+	if len(eventIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT event_id FROM processed_events WHERE event_id = ANY($1::uuid[])`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(eventIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check processed events: %w", err)
+	}
+	defer rows.Close()
+
+	processed := make(map[string]bool, len(eventIDs))
+	for rows.Next() {
+		var eventID string
+		if err := rows.Scan(&eventID); err != nil {
+			return nil, fmt.Errorf("failed to scan processed event: %w", err)
+		}
+		processed[eventID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to check processed events: %w", err)
+	}
+
+	unprocessed := make([]string, 0, len(eventIDs))
+	for _, eventID := range eventIDs {
+		if !processed[eventID] {
+			unprocessed = append(unprocessed, eventID)
+		}
+	}
+	return unprocessed, nil
+}
+
+// MarkManyAsProcessed marks a batch of events as processed with a single INSERT
+// Already processed events are skipped (ON CONFLICT DO NOTHING); ProcessedAt is ignored (NOW())
+func (r *ProcessedEventsRepository) MarkManyAsProcessed(ctx context.Context, records []ProcessedEvent) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	eventIDs := make([]string, len(records))
+	aggregateIDs := make([]string, len(records))
+	eventTypes := make([]string, len(records))
+	processedBy := make([]string, len(records))
+	for i, rec := range records {
+		eventIDs[i] = rec.EventID
+		aggregateIDs[i] = rec.AggregateID
+		eventTypes[i] = rec.EventType
+		processedBy[i] = rec.ProcessedBy
+	}
+
+	query := `
+		INSERT INTO processed_events (event_id, aggregate_id, event_type, processed_by, processed_at)
+		SELECT event_id, aggregate_id, event_type, processed_by, NOW()
+		FROM unnest($1::uuid[], $2::uuid[], $3::varchar[], $4::varchar[])
+			AS batch(event_id, aggregate_id, event_type, processed_by)
+		ON CONFLICT (event_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		pq.Array(eventIDs), pq.Array(aggregateIDs), pq.Array(eventTypes), pq.Array(processedBy),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark events as processed: %w", err)
+	}
+
+	log.Printf("✅ Marked %d events as processed", len(records))
+	return nil
+}
+
+// DeleteOlderThan removes processed events older than the retention window
+// Returns the number of deleted rows
+func (r *ProcessedEventsRepository) DeleteOlderThan(ctx context.Context, d time.Duration) (int64, error) {
+	query := `DELETE FROM processed_events WHERE processed_at < NOW() - make_interval(secs => $1)`
+
+	res, err := r.db.ExecContext(ctx, query, d.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete old processed events: %w", err)
+	}
+
+	return res.RowsAffected()
+}
+
+// ClaimEvent atomically claims an event for processing
+// Only the first caller gets claimed = true; duplicates (redeliveries, concurrent
+// consumers) get false and must skip the event. Unlike IsProcessed + MarkAsProcessed
+// there is no window in which two deliveries can both pass the check
+func (r *ProcessedEventsRepository) ClaimEvent(
+	ctx context.Context,
+	eventID, aggregateID, eventType, processedBy string,
+) (bool, error) {
+	query := `
+		INSERT INTO processed_events (event_id, aggregate_id, event_type, processed_by, processed_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (event_id) DO NOTHING
+		RETURNING event_id
+	`
+
+	var claimedID string
+	err := r.db.QueryRowContext(ctx, query, eventID, aggregateID, eventType, processedBy).Scan(&claimedID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil // Already claimed
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim event: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleaseEvent drops a claim so the event can be processed again
+// Called when the handler fails after claiming, so redelivery retries the event
+func (r *ProcessedEventsRepository) ReleaseEvent(ctx context.Context, eventID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM processed_events WHERE event_id = $1`, eventID); err != nil {
+		return fmt.Errorf("failed to release event: %w", err)
+	}
+	return nil
+}
+
+// GetProcessedEvents returns the processed events of an aggregate, oldest first (audit/debug)
+// since (exclusive, zero = from the start) and limit (<= 0 = no limit) page through the history:
+// the next page starts after the last ProcessedAt
 func (r *ProcessedEventsRepository) GetProcessedEvents(
 	ctx context.Context,
 	aggregateID string,
+	since time.Time,
+	limit int,
 ) ([]ProcessedEvent, error) {
 	query := `
-		SELECT event_id, aggregate_id, event_type, processed_by, processed_at
+		SELECT event_id, aggregate_id, event_type, COALESCE(processed_by, ''), processed_at
 		FROM processed_events
-		WHERE aggregate_id = $1
-		ORDER BY processed_at ASC
+		WHERE aggregate_id = $1 AND ($2::timestamp IS NULL OR processed_at > $2)
+		ORDER BY processed_at ASC, id ASC
+		LIMIT $3
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, aggregateID)
+	var sinceArg interface{}
+	if !since.IsZero() {
+		sinceArg = since
+	}
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, aggregateID, sinceArg, limitArg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query processed events: %w", err)
 	}
 	defer rows.Close()
 
-	var events []ProcessedEvent
+	events := make([]ProcessedEvent, 0)
 	for rows.Next() {
 		var e ProcessedEvent
 		err := rows.Scan(&e.EventID, &e.AggregateID, &e.EventType, &e.ProcessedBy, &e.ProcessedAt)
@@ -80,11 +240,48 @@ func (r *ProcessedEventsRepository) GetProcessedEvents(
 	return events, rows.Err()
 }
 
+// ConsumerActivity summarizes the events of an aggregate handled by one consumer
+type ConsumerActivity struct {
+	ProcessedBy      string
+	Events           int
+	FirstProcessedAt time.Time
+	LastProcessedAt  time.Time
+}
+
+// GetConsumerActivity returns per-consumer (processed_by) totals for an aggregate, by consumer name
+// A released claim (failed attempt) leaves no row: only the attempts that succeeded are counted
+func (r *ProcessedEventsRepository) GetConsumerActivity(ctx context.Context, aggregateID string) ([]ConsumerActivity, error) {
+	query := `
+		SELECT COALESCE(processed_by, ''), COUNT(*), MIN(processed_at), MAX(processed_at)
+		FROM processed_events
+		WHERE aggregate_id = $1
+		GROUP BY 1
+		ORDER BY 1 ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query consumer activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := make([]ConsumerActivity, 0)
+	for rows.Next() {
+		var a ConsumerActivity
+		if err := rows.Scan(&a.ProcessedBy, &a.Events, &a.FirstProcessedAt, &a.LastProcessedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan consumer activity: %w", err)
+		}
+		activity = append(activity, a)
+	}
+
+	return activity, rows.Err()
+}
+
 // ProcessedEvent represents a processed event record
 type ProcessedEvent struct {
 	EventID     string
 	AggregateID string
 	EventType   string
 	ProcessedBy string
-	ProcessedAt string
+	ProcessedAt time.Time
 }
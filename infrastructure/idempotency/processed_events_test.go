@@ -0,0 +1,163 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func newTestRepository(t *testing.T) (*ProcessedEventsRepository, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewProcessedEventsRepository(db), mock
+}
+
+func TestFilterUnprocessedUsesOneQuery(t *testing.T) {
+	r, mock := newTestRepository(t)
+	ids := []string{"event-1", "event-2", "event-3", "event-4"}
+
+	mock.ExpectQuery(`SELECT event_id FROM processed_events WHERE event_id = ANY\(\$1::uuid\[\]\)`).
+		WithArgs(pq.Array(ids)).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id"}).AddRow("event-3").AddRow("event-1"))
+
+	unprocessed, err := r.FilterUnprocessed(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("FilterUnprocessed: %v", err)
+	}
+	// Input order is kept
+	if len(unprocessed) != 2 || unprocessed[0] != "event-2" || unprocessed[1] != "event-4" {
+		t.Errorf("unprocessed = %v, want [event-2 event-4]", unprocessed)
+	}
+
+	// No IDs, no query
+	if unprocessed, err := r.FilterUnprocessed(context.Background(), nil); err != nil || len(unprocessed) != 0 {
+		t.Errorf("FilterUnprocessed(nil) = %v, %v", unprocessed, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMarkManyAsProcessedInsertsBatch(t *testing.T) {
+	r, mock := newTestRepository(t)
+	records := []ProcessedEvent{
+		{EventID: "event-1", AggregateID: "order-1", EventType: "OrderAccepted", ProcessedBy: "order-projection"},
+		{EventID: "event-2", AggregateID: "order-2", EventType: "PriceQuoted", ProcessedBy: "order-projection"},
+	}
+
+	mock.ExpectExec(`INSERT INTO processed_events .* FROM unnest\(.*\) .* ON CONFLICT \(event_id\) DO NOTHING`).
+		WithArgs(
+			pq.Array([]string{"event-1", "event-2"}),
+			pq.Array([]string{"order-1", "order-2"}),
+			pq.Array([]string{"OrderAccepted", "PriceQuoted"}),
+			pq.Array([]string{"order-projection", "order-projection"}),
+		).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	if err := r.MarkManyAsProcessed(context.Background(), records); err != nil {
+		t.Fatalf("MarkManyAsProcessed: %v", err)
+	}
+	if err := r.MarkManyAsProcessed(context.Background(), nil); err != nil {
+		t.Fatalf("MarkManyAsProcessed(nil): %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetProcessedEventsPagesBySince(t *testing.T) {
+	r, mock := newTestRepository(t)
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	columns := []string{"event_id", "aggregate_id", "event_type", "processed_by", "processed_at"}
+	query := `SELECT event_id, aggregate_id, event_type, .* FROM processed_events\s+WHERE aggregate_id = \$1 AND .* ORDER BY processed_at ASC, id ASC\s+LIMIT \$3`
+
+	// First page: no cursor, two rows
+	mock.ExpectQuery(query).WithArgs("order-1", nil, 2).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("event-1", "order-1", "OrderAccepted", "order-saga-step1", t0).
+		AddRow("event-1b", "order-1", "OrderAccepted", "order-projection", t0.Add(time.Second)))
+	// Next page starts after the last ProcessedAt; no limit
+	mock.ExpectQuery(query).WithArgs("order-1", t0.Add(time.Second), nil).WillReturnRows(sqlmock.NewRows(columns).
+		AddRow("event-2", "order-1", "PriceQuoted", "order-saga-step2", t0.Add(2*time.Second)))
+
+	page, err := r.GetProcessedEvents(context.Background(), "order-1", time.Time{}, 2)
+	if err != nil {
+		t.Fatalf("GetProcessedEvents: %v", err)
+	}
+	if len(page) != 2 || page[1].ProcessedBy != "order-projection" || !page[1].ProcessedAt.Equal(t0.Add(time.Second)) {
+		t.Fatalf("first page = %+v", page)
+	}
+
+	next, err := r.GetProcessedEvents(context.Background(), "order-1", page[len(page)-1].ProcessedAt, 0)
+	if err != nil {
+		t.Fatalf("GetProcessedEvents: %v", err)
+	}
+	if len(next) != 1 || next[0].EventID != "event-2" {
+		t.Errorf("next page = %+v, want event-2", next)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGetConsumerActivity(t *testing.T) {
+	r, mock := newTestRepository(t)
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT COALESCE\(processed_by, ''\), COUNT\(\*\), MIN\(processed_at\), MAX\(processed_at\)`).
+		WithArgs("order-1").
+		WillReturnRows(sqlmock.NewRows([]string{"processed_by", "count", "min", "max"}).
+			AddRow("order-saga-step1", 2, t0, t0.Add(time.Minute)).
+			AddRow("order-projection", 5, t0, t0.Add(2*time.Minute)))
+
+	activity, err := r.GetConsumerActivity(context.Background(), "order-1")
+	if err != nil {
+		t.Fatalf("GetConsumerActivity: %v", err)
+	}
+	if len(activity) != 2 || activity[0].ProcessedBy != "order-saga-step1" || activity[0].Events != 2 || !activity[0].LastProcessedAt.Equal(t0.Add(time.Minute)) {
+		t.Errorf("activity = %+v", activity)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMemoryProcessedEventsPaging(t *testing.T) {
+	ctx := context.Background()
+	s := NewMemoryProcessedEventStore()
+	for _, rec := range []struct{ eventID, consumer string }{
+		{"event-1", "order-saga-step1"},
+		{"event-2", "order-saga-step2"},
+		{"event-3", "order-saga-step1"},
+	} {
+		if err := s.MarkAsProcessed(ctx, rec.eventID, "order-1", "OrderAccepted", rec.consumer); err != nil {
+			t.Fatalf("MarkAsProcessed: %v", err)
+		}
+		time.Sleep(time.Millisecond) // Distinct ProcessedAt
+	}
+
+	first, err := s.GetProcessedEvents(ctx, "order-1", time.Time{}, 2)
+	if err != nil || len(first) != 2 || first[0].EventID != "event-1" {
+		t.Fatalf("first page = %+v, err %v", first, err)
+	}
+	rest, err := s.GetProcessedEvents(ctx, "order-1", first[1].ProcessedAt, 0)
+	if err != nil || len(rest) != 1 || rest[0].EventID != "event-3" {
+		t.Fatalf("next page = %+v, err %v", rest, err)
+	}
+
+	activity, err := s.GetConsumerActivity(ctx, "order-1")
+	if err != nil {
+		t.Fatalf("GetConsumerActivity: %v", err)
+	}
+	if len(activity) != 2 || activity[0].ProcessedBy != "order-saga-step1" || activity[0].Events != 2 {
+		t.Errorf("activity = %+v, want order-saga-step1 with 2 events first", activity)
+	}
+}
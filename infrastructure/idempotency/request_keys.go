@@ -0,0 +1,103 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Request key statuses
+const (
+	RequestKeyInProgress = "in_progress"
+	RequestKeyCompleted  = "completed"
+)
+
+// RequestKey maps a client Idempotency-Key to the order created for it
+type RequestKey struct {
+	UserID    string
+	Key       string
+	OrderID   string
+	Status    string
+	CreatedAt time.Time
+}
+
+// RequestKeyRepository stores Idempotency-Key → order_id for POST /orders
+type RequestKeyRepository struct {
+	db *sql.DB
+}
+
+func NewRequestKeyRepository(db *sql.DB) *RequestKeyRepository {
+	return &RequestKeyRepository{db: db}
+}
+
+// Reserve claims the key for orderID
+// Returns false if the key is already taken (completed or still in progress)
+func (r *RequestKeyRepository) Reserve(ctx context.Context, userID, key, orderID string) (bool, error) {
+	query := `
+		INSERT INTO request_keys (user_id, idempotency_key, order_id, status, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (user_id, idempotency_key) DO NOTHING
+	`
+
+	res, err := r.db.ExecContext(ctx, query, userID, key, orderID, RequestKeyInProgress)
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve request key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to reserve request key: %w", err)
+	}
+
+	return n == 1, nil
+}
+
+// Get returns the stored key, or nil if it does not exist
+func (r *RequestKeyRepository) Get(ctx context.Context, userID, key string) (*RequestKey, error) {
+	query := `
+		SELECT user_id, idempotency_key, order_id, status, created_at
+		FROM request_keys
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	var rk RequestKey
+	err := r.db.QueryRowContext(ctx, query, userID, key).Scan(&rk.UserID, &rk.Key, &rk.OrderID, &rk.Status, &rk.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request key: %w", err)
+	}
+
+	return &rk, nil
+}
+
+// Complete marks the key as completed once the order has been saved
+func (r *RequestKeyRepository) Complete(ctx context.Context, userID, key string) error {
+	query := `
+		UPDATE request_keys
+		SET status = $3, completed_at = NOW()
+		WHERE user_id = $1 AND idempotency_key = $2
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, key, RequestKeyCompleted); err != nil {
+		return fmt.Errorf("failed to complete request key: %w", err)
+	}
+	return nil
+}
+
+// Release deletes an in-progress key so the client can retry after a failure
+// Only the reservation made for orderID is deleted (a newer one is left alone)
+func (r *RequestKeyRepository) Release(ctx context.Context, userID, key, orderID string) error {
+	query := `
+		DELETE FROM request_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND order_id = $3 AND status = $4
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, key, orderID, RequestKeyInProgress); err != nil {
+		return fmt.Errorf("failed to release request key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package messaging
+
+import "context"
+
+// MessageBus - broker-agnostic publish/subscribe used by the saga, notifications and the outbox
+// Events are routed by aggregate and event type, optionally qualified (Order.OrderAccepted.market,
+// routing.go); RabbitMQ implements it with a topic exchange, Kafka with a topic per event type
+type MessageBus interface {
+	Publish(eventType string, eventData []byte) error
+	// PublishEvent is Publish for callers that already know the event_id (outbox)
+	PublishEvent(eventType, eventID string, eventData []byte) error
+	// Subscribe handles eventType with handler; handler contexts derive from ctx
+	// eventType may be qualified by its routing field ("OrderAccepted.limit") to get a subset of the type
+	Subscribe(ctx context.Context, eventType string, handler EventHandler) error
+	SubscribeWithOptions(ctx context.Context, eventType string, handler EventHandler, opts SubscribeOptions) error
+	// SubscribeAs gives a named consumer every event of the type, instead of sharing them with other consumers
+	SubscribeAs(ctx context.Context, consumer, eventType string, handler EventHandler) error
+	// SubscribePattern gives a named consumer every event whose routing key matches a topic pattern ("Order.#")
+	SubscribePattern(ctx context.Context, consumer, pattern string, handler EventHandler) error
+	// SubscribeTransient is SubscribeAs for this process only: events published while it is down are not delivered
+	SubscribeTransient(ctx context.Context, consumer, eventType string, handler EventHandler) error
+
+	// Drained is closed once in-flight handlers finished after Shutdown
+	Drained() <-chan struct{}
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+var _ MessageBus = (*RabbitMQ)(nil)
@@ -0,0 +1,557 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ===============================================
+// Kafka (MESSAGE_BUS=kafka)
+// ===============================================
+//
+// Every event type is a topic ("OrderCompleted"), keyed by aggregate_id so the events
+// of one aggregate stay in order on one partition. A subscription is a consumer group
+// named like the RabbitMQ queue (queue.{eventType}, queue.{consumer}.{eventType}):
+// instances of a service share its messages, different consumers each get all of them.
+// Qualified subscriptions ("OrderAccepted.limit") and patterns ("Order.#") read the
+// event type's topics and skip messages whose routing key (routing.go) does not match.
+//
+// Kafka has no per-message nack: a failed message is retried in the consumer with
+// backoff (RetryBaseDelay doubled per attempt) and holds back its partition meanwhile.
+// After MaxAttempts, or at once for ErrNonRetryable, it is written to {topic}.dlq
+// with the failure in its headers. The offset is committed only after the message was
+// handled or dead-lettered, so a message interrupted by shutdown is delivered again.
+
+// Kafka defaults
+const (
+	DefaultKafkaWriteTimeout    = 10 * time.Second
+	DefaultTopicRefreshInterval = 30 * time.Second
+
+	// kafkaBatchTimeout - publishes are synchronous: don't wait for a batch to fill
+	kafkaBatchTimeout = 10 * time.Millisecond
+
+	// kafkaDialTimeout bounds the metadata requests of Healthy and topic discovery
+	kafkaDialTimeout = 5 * time.Second
+
+	// deadLetterTopicSuffix - dead-lettered messages of OrderCompleted go to OrderCompleted.dlq
+	deadLetterTopicSuffix = ".dlq"
+
+	// Headers of Kafka messages
+	eventIDHeader    = "event_id"
+	routingKeyHeader = "routing_key"
+	attemptsHeader   = "x-attempts"
+)
+
+// Kafka implements MessageBus on Kafka topics
+type Kafka struct {
+	brokers []string
+	writer  *kafka.Writer
+	client  *kafka.Client
+
+	mu      sync.Mutex
+	readers []*kafka.Reader
+	cancels []context.CancelFunc // Stop fetching (Shutdown)
+
+	// Running consumer goroutines per group (ConsumerCounts)
+	statsMu   sync.Mutex
+	consumers map[string]int
+
+	// Graceful shutdown: in-flight handlers are awaited before the readers close
+	drainMu  sync.Mutex
+	draining bool
+	inflight sync.WaitGroup
+	drained  chan struct{} // closed when Shutdown finished draining
+
+	// MaxAttempts - after this many failed attempts the message is dead-lettered
+	MaxAttempts int
+	// RetryBaseDelay - delay before the first retry, doubled on every attempt
+	RetryBaseDelay time.Duration
+	// TopicRefreshInterval - how often pattern subscriptions look for new topics
+	TopicRefreshInterval time.Duration
+}
+
+var _ MessageBus = (*Kafka)(nil)
+
+// NewKafka creates a Kafka bus for the given brokers ("host:port")
+// Topics are created on first publish (auto.create.topics.enable on the brokers)
+func NewKafka(brokers []string) *Kafka {
+	return &Kafka{
+		brokers: brokers,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			BatchTimeout:           kafkaBatchTimeout,
+			WriteTimeout:           DefaultKafkaWriteTimeout,
+			AllowAutoTopicCreation: true,
+		},
+		client:               &kafka.Client{Addr: kafka.TCP(brokers...), Timeout: kafkaDialTimeout},
+		drained:              make(chan struct{}),
+		MaxAttempts:          DefaultMaxAttempts,
+		RetryBaseDelay:       DefaultRetryBaseDelay,
+		TopicRefreshInterval: DefaultTopicRefreshInterval,
+	}
+}
+
+// Publish publishes an event to its topic and blocks until all in-sync replicas have it
+// The event_id is read from the event JSON (see PublishEvent)
+func (k *Kafka) Publish(eventType string, eventData []byte) error {
+	var event struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to read event_id of %s: %w", eventType, err)
+	}
+	return k.PublishEvent(eventType, event.EventID, eventData)
+}
+
+// PublishEvent publishes an event whose ID is already known, with an event_id header
+// Re-published copies of an event share the header, so consumers can deduplicate on it
+func (k *Kafka) PublishEvent(eventType, eventID string, eventData []byte) error {
+	var event struct {
+		AggregateID string `json:"aggregate_id"`
+	}
+	_ = json.Unmarshal(eventData, &event) // Events without aggregate_id are spread by the balancer
+
+	routingKey := RoutingKey(eventType, eventData)
+	msg := kafka.Message{
+		Topic: eventType,
+		Key:   []byte(event.AggregateID),
+		Value: eventData,
+		Headers: []kafka.Header{
+			{Key: eventIDHeader, Value: []byte(eventID)},
+			{Key: routingKeyHeader, Value: []byte(routingKey)},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultKafkaWriteTimeout)
+	defer cancel()
+
+	if err := k.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", eventType, err)
+	}
+
+	log.Printf("📤 Published event: %s", routingKey)
+	return nil
+}
+
+// Subscribe handles eventType, optionally qualified by its routing field ("OrderAccepted.limit")
+// Instances share the messages through the consumer group queue.{eventType}
+func (k *Kafka) Subscribe(ctx context.Context, eventType string, handler EventHandler) error {
+	return k.SubscribeWithOptions(ctx, eventType, handler, SubscribeOptions{})
+}
+
+// SubscribeWithOptions is Subscribe with per-subscription settings
+// Concurrency starts that many group members; they only work in parallel on different
+// partitions, so the topic needs at least Concurrency partitions. Prefetch is ignored
+func (k *Kafka) SubscribeWithOptions(ctx context.Context, eventType string, handler EventHandler, opts SubscribeOptions) error {
+	return k.subscribeGroup(ctx, fmt.Sprintf("queue.%s", eventType), eventType, bindingKey(eventType), handler, opts)
+}
+
+// SubscribeAs subscribes a named consumer through its own consumer group (queue.{consumer}.{eventType})
+// Every consumer receives every event, instead of competing with other consumers
+func (k *Kafka) SubscribeAs(ctx context.Context, consumer, eventType string, handler EventHandler) error {
+	return k.subscribeGroup(ctx, fmt.Sprintf("queue.%s.%s", consumer, eventType), eventType, bindingKey(eventType), handler, SubscribeOptions{})
+}
+
+// SubscribePattern subscribes a named consumer to every event whose routing key matches
+// a topic pattern ("Order.#"), through its own consumer group (queue.{consumer}.{pattern})
+// A pattern with a wildcard event type reads every topic; topics created later are picked
+// up within TopicRefreshInterval
+func (k *Kafka) SubscribePattern(ctx context.Context, consumer, pattern string, handler EventHandler) error {
+	return k.subscribeGroup(ctx, fmt.Sprintf("queue.%s.%s", consumer, pattern), pattern, pattern, handler, SubscribeOptions{})
+}
+
+// SubscribeTransient subscribes through a per-process consumer group (queue.{consumer}.{eventType})
+// that starts at the end of the topic; consumer must be unique per instance
+// Events published while the process is down are not delivered to it
+func (k *Kafka) SubscribeTransient(ctx context.Context, consumer, eventType string, handler EventHandler) error {
+	return k.subscribeGroup(ctx, fmt.Sprintf("queue.%s.%s", consumer, eventType), eventType, bindingKey(eventType), handler, SubscribeOptions{Transient: true})
+}
+
+// subscribeGroup starts the consumers of group for the topics binding may match
+// eventType names the subscription in logs
+func (k *Kafka) subscribeGroup(ctx context.Context, group, eventType, binding string, handler EventHandler, opts SubscribeOptions) error {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	k.drainMu.Lock()
+	draining := k.draining
+	k.drainMu.Unlock()
+	if draining {
+		return ErrBusClosed
+	}
+
+	fetchCtx, cancel := context.WithCancel(ctx)
+	k.mu.Lock()
+	k.cancels = append(k.cancels, cancel)
+	k.mu.Unlock()
+
+	start := func(topic string) {
+		for i := 0; i < opts.Concurrency; i++ {
+			reader := k.newReader(group, topic, opts.Transient)
+			go k.consume(ctx, fetchCtx, reader, group, eventType, binding, handler)
+		}
+	}
+
+	// The event type is the topic: only a wildcard in it needs topic discovery
+	if topic, ok := bindingTopic(binding); ok {
+		start(topic)
+		return nil
+	}
+
+	topics, err := k.topics(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	seen := make(map[string]bool, len(topics))
+	for _, topic := range topics {
+		seen[topic] = true
+		start(topic)
+	}
+	go k.watchTopics(fetchCtx, seen, start)
+	return nil
+}
+
+// newReader creates a consumer group member for one topic
+func (k *Kafka) newReader(group, topic string, transient bool) *kafka.Reader {
+	startOffset := kafka.FirstOffset
+	if transient {
+		startOffset = kafka.LastOffset
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     k.brokers,
+		GroupID:     group,
+		Topic:       topic,
+		StartOffset: startOffset,
+		MaxWait:     time.Second,
+	})
+
+	k.mu.Lock()
+	k.readers = append(k.readers, reader)
+	k.mu.Unlock()
+	return reader
+}
+
+// bindingTopic returns the topic of a binding whose event type is literal
+// ("*.OrderAccepted.limit" → OrderAccepted); false for wildcard event types ("Order.#")
+func bindingTopic(binding string) (string, bool) {
+	words := strings.Split(binding, ".")
+	if len(words) < 2 || words[1] == "*" || words[1] == "#" {
+		return "", false
+	}
+	return words[1], true
+}
+
+// topics lists the event topics of the cluster (no internal or dead-letter topics)
+func (k *Kafka) topics(ctx context.Context) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, kafkaDialTimeout)
+	defer cancel()
+
+	metadata, err := k.client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Kafka topics: %w", err)
+	}
+
+	var topics []string
+	for _, t := range metadata.Topics {
+		if t.Internal || t.Error != nil || strings.HasPrefix(t.Name, "_") || strings.HasSuffix(t.Name, deadLetterTopicSuffix) {
+			continue
+		}
+		topics = append(topics, t.Name)
+	}
+	return topics, nil
+}
+
+// watchTopics starts consumers for topics created after a pattern subscription
+func (k *Kafka) watchTopics(ctx context.Context, seen map[string]bool, start func(topic string)) {
+	ticker := time.NewTicker(k.TopicRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		topics, err := k.topics(ctx)
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+			continue
+		}
+		for _, topic := range topics {
+			if !seen[topic] {
+				seen[topic] = true
+				log.Printf("🆕 New topic %s, subscribing", topic)
+				start(topic)
+			}
+		}
+	}
+}
+
+// consume runs handler for the reader's messages until fetchCtx is cancelled (ctx, Shutdown)
+// Per-message contexts derive from the subscriber's ctx, so Shutdown lets in-flight handlers finish
+func (k *Kafka) consume(ctx, fetchCtx context.Context, reader *kafka.Reader, group, eventType, binding string, handler EventHandler) {
+	k.consumerStarted(group)
+	defer k.consumerStopped(group)
+
+	for {
+		msg, err := reader.FetchMessage(fetchCtx)
+		if err != nil {
+			if fetchCtx.Err() == nil {
+				log.Printf("❌ Kafka consumer %s stopped: %v", group, err)
+			}
+			return
+		}
+
+		if !MatchRoutingKey(binding, messageRoutingKey(msg)) {
+			// Another subscription's subset of the topic
+			k.commit(reader, msg, eventType)
+			continue
+		}
+
+		if !k.beginDelivery() {
+			// Shutting down: the uncommitted message is delivered again after restart
+			return
+		}
+		handled := k.handle(ctx, fetchCtx, msg, eventType, handler)
+		if handled {
+			k.commit(reader, msg, eventType)
+		}
+		k.endDelivery()
+
+		if !handled {
+			// Interrupted by shutdown; the offset stays at this message
+			return
+		}
+	}
+}
+
+// handle runs handler for a message, retrying with backoff and dead-lettering it at the end
+// Returns false when the message must not be committed (interrupted by shutdown)
+func (k *Kafka) handle(ctx, fetchCtx context.Context, msg kafka.Message, eventType string, handler EventHandler) bool {
+	eventID := header(msg, eventIDHeader)
+	msgCtx := context.WithValue(ctx, messageIDKey{}, eventID)
+
+	log.Printf("📥 Received event: %s (%s)", eventType, eventID)
+
+	for attempt := 1; ; attempt++ {
+		err := handler(msgCtx, msg.Value)
+		switch {
+		case err == nil:
+			log.Printf("✅ Successfully processed event: %s", eventType)
+			return true
+		case ctx.Err() != nil:
+			// Interrupted by shutdown, not a failure
+			log.Printf("⚠️  Event %s interrupted by shutdown, leaving it uncommitted: %v", eventType, err)
+			return false
+		case attempt >= k.MaxAttempts || errors.Is(err, ErrNonRetryable):
+			log.Printf("☠️  Event %s failed after %d attempt(s), dead-lettering: %v", eventType, attempt, err)
+			return k.deadLetter(fetchCtx, msg, attempt, err)
+		}
+
+		delay := k.RetryBaseDelay * time.Duration(1<<(attempt-1))
+		log.Printf("🔁 Retrying event %s in %s (attempt %d/%d): %v", eventType, delay, attempt+1, k.MaxAttempts, err)
+		if !sleep(fetchCtx, delay) {
+			return false
+		}
+	}
+}
+
+// deadLetter writes a failed message to the dead-letter topic of its topic ({topic}.dlq),
+// retrying the write until it succeeds or the bus shuts down
+func (k *Kafka) deadLetter(ctx context.Context, msg kafka.Message, attempts int, cause error) bool {
+	dl := kafka.Message{
+		Topic: msg.Topic + deadLetterTopicSuffix,
+		Key:   msg.Key,
+		Value: msg.Value,
+		Headers: append(append([]kafka.Header(nil), msg.Headers...),
+			kafka.Header{Key: originalRoutingKeyHeader, Value: []byte(messageRoutingKey(msg))},
+			kafka.Header{Key: failureReasonHeader, Value: []byte(cause.Error())},
+			kafka.Header{Key: attemptsHeader, Value: []byte(strconv.Itoa(attempts))},
+		),
+	}
+
+	for delay := k.RetryBaseDelay; ; delay = min(2*delay, maxReconnectDelay) {
+		err := k.writer.WriteMessages(ctx, dl)
+		if err == nil {
+			return true
+		}
+		log.Printf("❌ Failed to dead-letter event %s, retrying in %s: %v", msg.Topic, delay, err)
+		if !sleep(ctx, delay) {
+			return false
+		}
+	}
+}
+
+// commit marks a message as consumed by the group
+// A failed commit only means the message may be delivered again (handlers are idempotent)
+func (k *Kafka) commit(reader *kafka.Reader, msg kafka.Message, eventType string) {
+	if err := reader.CommitMessages(context.Background(), msg); err != nil {
+		log.Printf("⚠️  Failed to commit event %s at offset %d: %v", eventType, msg.Offset, err)
+	}
+}
+
+// messageRoutingKey returns the routing key a message was published with
+// Messages published by other producers have no header: the key is computed from the event
+func messageRoutingKey(msg kafka.Message) string {
+	if key := header(msg, routingKeyHeader); key != "" {
+		return key
+	}
+	return RoutingKey(msg.Topic, msg.Value)
+}
+
+// header returns the value of a message header, "" if it is missing
+func header(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// sleep waits for d; false when ctx is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// beginDelivery registers an in-flight handler; false once Shutdown has started
+func (k *Kafka) beginDelivery() bool {
+	k.drainMu.Lock()
+	defer k.drainMu.Unlock()
+
+	if k.draining {
+		return false
+	}
+	k.inflight.Add(1)
+	return true
+}
+
+// endDelivery marks an in-flight handler as finished (message committed or left for redelivery)
+func (k *Kafka) endDelivery() {
+	k.inflight.Done()
+}
+
+// ConsumerCounts returns the consumer goroutines currently running per consumer group
+func (k *Kafka) ConsumerCounts() map[string]int {
+	k.statsMu.Lock()
+	defer k.statsMu.Unlock()
+
+	counts := make(map[string]int, len(k.consumers))
+	for group, n := range k.consumers {
+		counts[group] = n
+	}
+	return counts
+}
+
+func (k *Kafka) consumerStarted(group string) {
+	k.statsMu.Lock()
+	defer k.statsMu.Unlock()
+
+	if k.consumers == nil {
+		k.consumers = make(map[string]int)
+	}
+	k.consumers[group]++
+}
+
+func (k *Kafka) consumerStopped(group string) {
+	k.statsMu.Lock()
+	defer k.statsMu.Unlock()
+
+	if k.consumers[group]--; k.consumers[group] <= 0 {
+		delete(k.consumers, group)
+	}
+}
+
+// Healthy reports whether a broker answers a metadata request
+func (k *Kafka) Healthy() error {
+	ctx, cancel := context.WithTimeout(context.Background(), kafkaDialTimeout)
+	defer cancel()
+
+	if _, err := k.client.Metadata(ctx, &kafka.MetadataRequest{}); err != nil {
+		return fmt.Errorf("brokers unreachable: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops fetching and waits for in-flight handlers to finish and commit
+// Messages fetched but not yet handled stay uncommitted and are delivered again
+func (k *Kafka) Shutdown(ctx context.Context) error {
+	k.drainMu.Lock()
+	if k.draining {
+		k.drainMu.Unlock()
+		<-k.drained
+		return nil
+	}
+	k.draining = true
+	k.drainMu.Unlock()
+
+	defer close(k.drained)
+
+	k.mu.Lock()
+	cancels := append([]context.CancelFunc(nil), k.cancels...)
+	readers := append([]*kafka.Reader(nil), k.readers...)
+	k.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		k.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		log.Println("✅ Kafka consumers drained")
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out waiting for in-flight handlers: %w", ctx.Err())
+		log.Printf("⚠️  %v", err)
+	}
+
+	// Leave the consumer groups, so their partitions are reassigned right away
+	for _, reader := range readers {
+		if closeErr := reader.Close(); closeErr != nil {
+			log.Printf("⚠️  Failed to close Kafka reader: %v", closeErr)
+		}
+	}
+	return err
+}
+
+// Drained is closed once Shutdown has finished draining consumers
+func (k *Kafka) Drained() <-chan struct{} {
+	return k.drained
+}
+
+// Close drains the consumers (DefaultDrainTimeout) and closes the writer
+func (k *Kafka) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultDrainTimeout)
+	defer cancel()
+
+	drainErr := k.Shutdown(ctx)
+	return errors.Join(drainErr, k.writer.Close())
+}
@@ -0,0 +1,107 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestBindingTopic(t *testing.T) {
+	tests := []struct {
+		binding   string
+		wantTopic string
+		wantOK    bool
+	}{
+		{binding: bindingKey("OrderCompleted"), wantTopic: "OrderCompleted", wantOK: true},
+		{binding: bindingKey("OrderAccepted.limit"), wantTopic: "OrderAccepted", wantOK: true},
+		{binding: "Order.#", wantOK: false},
+		{binding: "Order.*.limit", wantOK: false},
+		{binding: "#", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		topic, ok := bindingTopic(tt.binding)
+		if topic != tt.wantTopic || ok != tt.wantOK {
+			t.Errorf("bindingTopic(%q) = %q, %v, want %q, %v", tt.binding, topic, ok, tt.wantTopic, tt.wantOK)
+		}
+	}
+}
+
+func TestMessageRoutingKey(t *testing.T) {
+	data := []byte(`{"event_id":"e-1","aggregate_type":"Order","order_type":"limit"}`)
+	RegisterRoutingField("OrderAccepted", "order_type", "market")
+
+	withHeader := kafka.Message{Topic: "OrderAccepted", Value: data, Headers: []kafka.Header{{Key: routingKeyHeader, Value: []byte("Order.OrderAccepted.market")}}}
+	if got := messageRoutingKey(withHeader); got != "Order.OrderAccepted.market" {
+		t.Errorf("routing key from header = %q", got)
+	}
+
+	// Messages of other producers have no header: the key is computed from the event
+	withoutHeader := kafka.Message{Topic: "OrderAccepted", Value: data}
+	if got := messageRoutingKey(withoutHeader); got != "Order.OrderAccepted.limit" {
+		t.Errorf("computed routing key = %q, want Order.OrderAccepted.limit", got)
+	}
+}
+
+func TestKafkaHandleRetriesFailedMessage(t *testing.T) {
+	k := NewKafka([]string{"localhost:9092"})
+	k.RetryBaseDelay = time.Millisecond
+
+	msg := kafka.Message{Topic: "OrderCompleted", Value: []byte(`{}`), Headers: []kafka.Header{{Key: eventIDHeader, Value: []byte("e-1")}}}
+	var attempts int
+	handler := func(ctx context.Context, data []byte) error {
+		if id, _ := MessageIDFromContext(ctx); id != "e-1" {
+			t.Errorf("message ID = %q, want e-1", id)
+		}
+		if attempts++; attempts < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}
+
+	if !k.handle(context.Background(), context.Background(), msg, "OrderCompleted", handler) {
+		t.Fatal("handled message must be committed")
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestKafkaHandleLeavesInterruptedMessageUncommitted(t *testing.T) {
+	k := NewKafka([]string{"localhost:9092"})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	handler := func(ctx context.Context, data []byte) error {
+		cancel()
+		return ctx.Err()
+	}
+	if k.handle(ctx, ctx, kafka.Message{Topic: "OrderCompleted"}, "OrderCompleted", handler) {
+		t.Error("message interrupted by shutdown must not be committed")
+	}
+}
+
+func TestMemoryBusSubscribePattern(t *testing.T) {
+	bus := NewMemoryBus()
+	var got []string
+	err := bus.SubscribePattern(context.Background(), "test", "Order.#", func(ctx context.Context, data []byte) error {
+		id, _ := MessageIDFromContext(ctx)
+		got = append(got, id)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus.PublishEvent("OrderCompleted", "order-event", []byte(`{"aggregate_type":"Order"}`))
+	bus.PublishEvent("PositionClosed", "position-event", []byte(`{"aggregate_type":"Position"}`))
+	if err := bus.Deliver(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 1 || got[0] != "order-event" {
+		t.Errorf("delivered %v, want [order-event]", got)
+	}
+}
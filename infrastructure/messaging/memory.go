@@ -0,0 +1,187 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrBusClosed is returned when publishing to a MemoryBus after Shutdown
+var ErrBusClosed = errors.New("message bus closed")
+
+// MemoryBus is a MessageBus in process memory (test harnesses, local saga runs)
+//
+// Publish only queues the message. Deliver hands queued messages to the subscribers
+// synchronously, in publish order, including the messages published by the handlers
+// themselves, so a whole saga can be driven step by step without RabbitMQ.
+// Subscribe options (prefetch, concurrency, transient queues) do not apply
+type MemoryBus struct {
+	mu       sync.Mutex
+	handlers []memorySubscription
+	queue    []memoryMessage
+	closed   bool
+
+	drained   chan struct{}
+	closeOnce sync.Once
+}
+
+type memorySubscription struct {
+	binding string // Topic pattern the subscription matches (bindingKey)
+	ctx     context.Context
+	handler EventHandler
+}
+
+type memoryMessage struct {
+	eventType  string
+	routingKey string
+	eventID    string
+	eventData  []byte
+}
+
+var _ MessageBus = (*MemoryBus)(nil)
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		drained: make(chan struct{}),
+	}
+}
+
+// Publish queues an event; the event_id is read from the event JSON
+func (b *MemoryBus) Publish(eventType string, eventData []byte) error {
+	var event struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to read event_id of %s: %w", eventType, err)
+	}
+	return b.PublishEvent(eventType, event.EventID, eventData)
+}
+
+// PublishEvent queues an event whose ID is already known
+func (b *MemoryBus) PublishEvent(eventType, eventID string, eventData []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBusClosed
+	}
+	b.queue = append(b.queue, memoryMessage{
+		eventType:  eventType,
+		routingKey: RoutingKey(eventType, eventData),
+		eventID:    eventID,
+		eventData:  eventData,
+	})
+	return nil
+}
+
+// Subscribe handles eventType (optionally qualified: OrderAccepted.limit) with handler
+// Handler contexts derive from ctx
+func (b *MemoryBus) Subscribe(ctx context.Context, eventType string, handler EventHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, memorySubscription{binding: bindingKey(eventType), ctx: ctx, handler: handler})
+	return nil
+}
+
+// SubscribeWithOptions is Subscribe: delivery is synchronous, options are ignored
+func (b *MemoryBus) SubscribeWithOptions(ctx context.Context, eventType string, handler EventHandler, _ SubscribeOptions) error {
+	return b.Subscribe(ctx, eventType, handler)
+}
+
+// SubscribeAs is Subscribe: every subscription receives every matching message
+func (b *MemoryBus) SubscribeAs(ctx context.Context, consumer, eventType string, handler EventHandler) error {
+	return b.Subscribe(ctx, eventType, handler)
+}
+
+// SubscribePattern handles every message whose routing key matches pattern
+func (b *MemoryBus) SubscribePattern(ctx context.Context, consumer, pattern string, handler EventHandler) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.handlers = append(b.handlers, memorySubscription{binding: pattern, ctx: ctx, handler: handler})
+	return nil
+}
+
+// SubscribeTransient is Subscribe
+func (b *MemoryBus) SubscribeTransient(ctx context.Context, consumer, eventType string, handler EventHandler) error {
+	return b.Subscribe(ctx, eventType, handler)
+}
+
+// Deliver hands queued messages to their subscribers until the queue is empty
+// Handler errors are collected and returned; a failed message is not redelivered
+// (the caller may publish it again). Subscribers whose context is cancelled are skipped
+func (b *MemoryBus) Deliver() error {
+	var errs []error
+	for {
+		msg, ok := b.next()
+		if !ok {
+			return errors.Join(errs...)
+		}
+
+		b.mu.Lock()
+		subs := append([]memorySubscription(nil), b.handlers...)
+		b.mu.Unlock()
+
+		for _, sub := range subs {
+			if sub.ctx.Err() != nil || !MatchRoutingKey(sub.binding, msg.routingKey) {
+				continue
+			}
+			ctx := context.WithValue(sub.ctx, messageIDKey{}, msg.eventID)
+			if err := sub.handler(ctx, msg.eventData); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", msg.eventType, msg.eventID, err))
+			}
+		}
+	}
+}
+
+// Subscriptions returns the number of subscriptions (harnesses wait for consumers started in goroutines)
+func (b *MemoryBus) Subscriptions() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.handlers)
+}
+
+// Pending returns the number of queued, not yet delivered messages
+func (b *MemoryBus) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.queue)
+}
+
+// next pops the oldest queued message
+func (b *MemoryBus) next() (memoryMessage, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(b.queue) == 0 {
+		return memoryMessage{}, false
+	}
+	msg := b.queue[0]
+	b.queue = b.queue[1:]
+	return msg, true
+}
+
+// Drained is closed by Shutdown: handlers only run inside Deliver, so nothing is in flight
+func (b *MemoryBus) Drained() <-chan struct{} {
+	return b.drained
+}
+
+// Shutdown stops accepting publishes; queued messages are dropped
+func (b *MemoryBus) Shutdown(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.queue = nil
+	b.mu.Unlock()
+
+	b.closeOnce.Do(func() { close(b.drained) })
+	return nil
+}
+
+func (b *MemoryBus) Close() error {
+	return b.Shutdown(context.Background())
+}
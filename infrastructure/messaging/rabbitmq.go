@@ -2,41 +2,191 @@ package messaging
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
+	"time"
 
 	"github.com/rabbitmq/amqp091-go"
+
+	"market_order/pkg/metrics"
+)
+
+// Retry defaults for message processing
+const (
+	DefaultMaxAttempts    = 5
+	DefaultRetryBaseDelay = 500 * time.Millisecond
+
+	// retryCountHeader tracks how many times a message has been retried
+	retryCountHeader = "x-retry-count"
+
+	// deadLetterExchange receives events that exhausted their retries
+	deadLetterExchange = "events.dlx"
+
+	// Headers attached to dead-lettered messages
+	originalRoutingKeyHeader = "x-original-routing-key"
+	failureReasonHeader      = "x-failure-reason"
+)
+
+// DefaultPrefetch - unacked messages a consumer may hold when SubscribeOptions.Prefetch is not set
+const DefaultPrefetch = 10
+
+// ErrPublishNacked is returned when the broker rejects a published message
+var ErrPublishNacked = errors.New("broker nacked published event")
+
+// ErrNonRetryable - handlers wrap it for failures a redelivery can't fix (malformed message):
+// the message is dead-lettered at once instead of being retried
+var ErrNonRetryable = errors.New("non-retryable event")
+
+// Connection defaults
+const (
+	DefaultPublishTimeout     = 5 * time.Second
+	DefaultReconnectBaseDelay = 1 * time.Second
+	maxReconnectDelay         = 30 * time.Second
 )
 
 // RabbitMQ provides message bus functionality
 type RabbitMQ struct {
+	mu      sync.RWMutex
 	conn    *amqp091.Connection
 	channel *amqp091.Channel
+	ready   chan struct{} // closed while a connection is established
+	closing bool
 	url     string
+
+	// Active subscriptions, replayed after reconnection
+	subscriptions []subscription
+
+	// consumeMu keeps Qos + Consume atomic: Qos applies to the next consumer on the shared channel
+	consumeMu sync.Mutex
+
+	// Consumers of the current channel, cancelled on Shutdown
+	consumerTags []string
+
+	// Running consumer goroutines per queue (ConsumerCounts)
+	statsMu   sync.Mutex
+	consumers map[string]int
+
+	// Graceful shutdown: in-flight handlers are awaited before the channel closes
+	drainMu  sync.Mutex
+	draining bool
+	inflight sync.WaitGroup
+	drained  chan struct{} // closed when Shutdown finished draining
+
+	// MaxAttempts - after this many failed attempts the message is dead-lettered
+	MaxAttempts int
+	// RetryBaseDelay - delay before the first retry, doubled on every attempt
+	RetryBaseDelay time.Duration
+	// PublishTimeout - how long Publish waits for a (re)connection and for the broker confirm
+	PublishTimeout time.Duration
+	// ReconnectBaseDelay - delay before the first reconnection attempt, doubled on failure
+	ReconnectBaseDelay time.Duration
+}
+
+// subscription is a consumer registration that can be replayed on a new channel
+type subscription struct {
+	eventType string
+	start     func() error
+}
+
+// SubscribeOptions tunes a single subscription
+type SubscribeOptions struct {
+	// Prefetch - max unacked messages delivered to this consumer (0 = DefaultPrefetch)
+	// Use 1 for slow handlers so other workers can pick up the rest of the queue
+	Prefetch int
+
+	// Concurrency - goroutines processing this subscription's deliveries (0 = 1)
+	// Each worker acks/nacks its own message; Prefetch is raised to at least Concurrency.
+	// With more than one worker messages are no longer handled in queue order:
+	// only opt in when events of the same aggregate can't be in the queue at the same time
+	Concurrency int
+
+	// Transient - non-durable queue deleted when this process disconnects
+	// For in-process fan-out (every instance sees every event); events are lost while disconnected
+	Transient bool
 }
 
 // EventHandler is a function that processes event data
 type EventHandler func(ctx context.Context, eventData []byte) error
 
+// messageIDKey carries the delivery's AMQP MessageId (the event_id) in the handler context
+type messageIDKey struct{}
+
+// MessageIDFromContext returns the event_id of the message being handled
+// Lets handlers claim the event without unmarshalling it first
+func MessageIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(messageIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// DeadLetter is an event that repeatedly failed processing
+type DeadLetter struct {
+	MessageID          string // event_id
+	EventType          string
+	OriginalRoutingKey string
+	FailureReason      string
+	Attempts           int
+	EventData          []byte
+}
+
+// DeadLetterHandler processes dead-lettered events (inspection / re-drive tooling)
+type DeadLetterHandler func(ctx context.Context, dl DeadLetter) error
+
 func NewRabbitMQ(url string) *RabbitMQ {
-	return &RabbitMQ{url: url}
+	return &RabbitMQ{
+		url:                url,
+		ready:              make(chan struct{}),
+		drained:            make(chan struct{}),
+		MaxAttempts:        DefaultMaxAttempts,
+		RetryBaseDelay:     DefaultRetryBaseDelay,
+		PublishTimeout:     DefaultPublishTimeout,
+		ReconnectBaseDelay: DefaultReconnectBaseDelay,
+	}
 }
 
 // Connect establishes connection to RabbitMQ
+// The connection is re-established automatically if the broker goes away
 func (r *RabbitMQ) Connect() error {
+	l, err := r.dial()
+	if err != nil {
+		return err
+	}
+
+	r.setConnection(l)
+	go r.watchConnection(l)
+
+	log.Println("✅ Connected to RabbitMQ")
+	return nil
+}
+
+// link is an established connection/channel pair with its close notifications
+type link struct {
+	conn       *amqp091.Connection
+	channel    *amqp091.Channel
+	connClosed chan *amqp091.Error
+	chanClosed chan *amqp091.Error
+}
+
+// dial opens a connection and channel and declares the exchanges
+func (r *RabbitMQ) dial() (*link, error) {
 	conn, err := amqp091.Dial(r.url)
 	if err != nil {
-		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
 	}
 
 	ch, err := conn.Channel()
 	if err != nil {
 		conn.Close()
-		return fmt.Errorf("failed to open channel: %w", err)
+		return nil, fmt.Errorf("failed to open channel: %w", err)
 	}
 
-	r.conn = conn
-	r.channel = ch
+	// Enable publisher confirms: broker acks every published message
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
 
 	// Declare exchange for events
 	err = ch.ExchangeDeclare(
@@ -49,68 +199,212 @@ func (r *RabbitMQ) Connect() error {
 		nil,      // arguments
 	)
 	if err != nil {
-		return fmt.Errorf("failed to declare exchange: %w", err)
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	log.Println("✅ Connected to RabbitMQ")
-	return nil
+	// Declare dead-letter exchange for poison messages
+	err = ch.ExchangeDeclare(
+		deadLetterExchange, // name
+		"direct",           // type
+		true,               // durable
+		false,              // auto-deleted
+		false,              // internal
+		false,              // no-wait
+		nil,                // arguments
+	)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare dead-letter exchange: %w", err)
+	}
+
+	// Register close notifications right away so no close is missed
+	return &link{
+		conn:       conn,
+		channel:    ch,
+		connClosed: conn.NotifyClose(make(chan *amqp091.Error, 1)),
+		chanClosed: ch.NotifyClose(make(chan *amqp091.Error, 1)),
+	}, nil
 }
 
-// Publish publishes an event to RabbitMQ
+// Publish publishes an event to RabbitMQ and blocks until the broker confirms it
+// MessageId is the event_id read from the event JSON (see PublishEvent)
 func (r *RabbitMQ) Publish(eventType string, eventData []byte) error {
-	if r.channel == nil {
-		return fmt.Errorf("RabbitMQ channel not initialized")
+	var event struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		return fmt.Errorf("failed to read event_id of %s: %w", eventType, err)
+	}
+	return r.PublishEvent(eventType, event.EventID, eventData)
+}
+
+// PublishEvent publishes an event whose ID is already known, with AMQP MessageId = eventID
+// Re-published copies of an event share the MessageId, so consumers can deduplicate on it
+// While reconnecting, PublishEvent waits up to PublishTimeout for a new channel
+func (r *RabbitMQ) PublishEvent(eventType, eventID string, eventData []byte) error {
+	ch, err := r.waitForChannel(r.PublishTimeout)
+	if err != nil {
+		return err
 	}
 
-	// Routing key = event type (e.g., "OrderAccepted", "SwapExecuted")
-	routingKey := eventType
+	// Routing key = aggregate type + event type (e.g., "Order.SwapExecuted"), qualified
+	// by a routing field for some types (e.g., "Order.OrderAccepted.limit"); see routing.go
+	routingKey := RoutingKey(eventType, eventData)
 
-	err := r.channel.PublishWithContext(
-		context.Background(),
-		"events",   // exchange
+	err = publishConfirmed(amqpConfirmChannel{ch}, r.PublishTimeout, "events", routingKey, amqp091.Publishing{
+		ContentType:  "application/json",
+		MessageId:    eventID,
+		Body:         eventData,
+		DeliveryMode: amqp091.Persistent, // Persistent messages
+	})
+	if errors.Is(err, ErrPublishNacked) {
+		metrics.RabbitMQNacksTotal.Inc(eventType)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish event %s: %w", eventType, err)
+	}
+
+	log.Printf("📤 Published event: %s", routingKey)
+	return nil
+}
+
+// confirmChannel is the publishing side of a channel in confirm mode
+// *amqp091.Channel is adapted by amqpConfirmChannel; tests substitute a fake broker
+type confirmChannel interface {
+	PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) (publishConfirmation, error)
+}
+
+// publishConfirmation is the pending broker confirm of one published message
+type publishConfirmation interface {
+	WaitContext(ctx context.Context) (bool, error)
+}
+
+// amqpConfirmChannel adapts *amqp091.Channel to confirmChannel
+type amqpConfirmChannel struct {
+	*amqp091.Channel
+}
+
+func (c amqpConfirmChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) (publishConfirmation, error) {
+	confirm, err := c.Channel.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, immediate, msg)
+	if err != nil {
+		return nil, err
+	}
+	return confirm, nil
+}
+
+// publishConfirmed publishes msg and blocks until the broker confirms it
+// A nack returns ErrPublishNacked; no confirm within timeout returns context.DeadlineExceeded
+func publishConfirmed(ch confirmChannel, timeout time.Duration, exchange, routingKey string, msg amqp091.Publishing) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(
+		ctx,
+		exchange,   // exchange
 		routingKey, // routing key
 		false,      // mandatory
 		false,      // immediate
-		amqp091.Publishing{
-			ContentType:  "application/json",
-			Body:         eventData,
-			DeliveryMode: amqp091.Persistent, // Persistent messages
-		},
+		msg,
 	)
-
 	if err != nil {
-		return fmt.Errorf("failed to publish event %s: %w", eventType, err)
+		return err
 	}
 
-	log.Printf("📤 Published event: %s", eventType)
+	// Wait for broker ack: only then is the event safely persisted
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("no publisher confirm: %w", err)
+	}
+	if !acked {
+		return ErrPublishNacked
+	}
 	return nil
 }
 
 // Subscribe subscribes to events and processes them with the handler
-func (r *RabbitMQ) Subscribe(eventType string, handler EventHandler) error {
-	if r.channel == nil {
+// eventType is an event type (every event of the type), optionally qualified by its
+// routing field ("OrderAccepted.limit"), see routing.go
+// The subscription is re-registered automatically after reconnection
+// Handler contexts derive from ctx: once it is cancelled, in-flight handlers see the
+// cancellation and new deliveries are requeued instead of handled
+func (r *RabbitMQ) Subscribe(ctx context.Context, eventType string, handler EventHandler) error {
+	return r.SubscribeWithOptions(ctx, eventType, handler, SubscribeOptions{})
+}
+
+// SubscribeWithOptions is Subscribe with per-subscription settings (prefetch, concurrency)
+func (r *RabbitMQ) SubscribeWithOptions(ctx context.Context, eventType string, handler EventHandler, opts SubscribeOptions) error {
+	return r.subscribeQueue(ctx, fmt.Sprintf("queue.%s", eventType), eventType, bindingKey(eventType), handler, opts)
+}
+
+// SubscribeAs subscribes a named consumer to events through its own queue (queue.{consumer}.{eventType})
+// Every consumer receives every event, instead of competing with other consumers
+// for queue.{eventType}
+func (r *RabbitMQ) SubscribeAs(ctx context.Context, consumer, eventType string, handler EventHandler) error {
+	return r.subscribeQueue(ctx, fmt.Sprintf("queue.%s.%s", consumer, eventType), eventType, bindingKey(eventType), handler, SubscribeOptions{})
+}
+
+// SubscribePattern subscribes a named consumer to every event whose routing key matches
+// a topic pattern, through its own queue (queue.{consumer}.{pattern})
+// E.g. "Order.#" delivers all events of the Order aggregate to one handler
+func (r *RabbitMQ) SubscribePattern(ctx context.Context, consumer, pattern string, handler EventHandler) error {
+	return r.subscribeQueue(ctx, fmt.Sprintf("queue.%s.%s", consumer, pattern), pattern, pattern, handler, SubscribeOptions{})
+}
+
+// SubscribeTransient subscribes through a per-process queue (queue.{consumer}.{eventType})
+// that disappears with the connection; consumer must be unique per instance
+func (r *RabbitMQ) SubscribeTransient(ctx context.Context, consumer, eventType string, handler EventHandler) error {
+	return r.subscribeQueue(ctx, fmt.Sprintf("queue.%s.%s", consumer, eventType), eventType, bindingKey(eventType), handler, SubscribeOptions{Transient: true})
+}
+
+// subscribeQueue binds queueName to the events exchange with binding (a topic pattern)
+// eventType names the subscription in logs and its dead-letter queue
+func (r *RabbitMQ) subscribeQueue(ctx context.Context, queueName, eventType, binding string, handler EventHandler, opts SubscribeOptions) error {
+	if opts.Prefetch <= 0 {
+		opts.Prefetch = DefaultPrefetch
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Prefetch < opts.Concurrency {
+		// Idle workers would otherwise wait for acks of the busy ones
+		opts.Prefetch = opts.Concurrency
+	}
+
+	start := func() error { return r.subscribe(ctx, queueName, eventType, binding, handler, opts) }
+	if err := start(); err != nil {
+		return err
+	}
+
+	r.trackSubscription(eventType, start)
+	return nil
+}
+
+func (r *RabbitMQ) subscribe(ctx context.Context, queueName, eventType, binding string, handler EventHandler, opts SubscribeOptions) error {
+	ch := r.currentChannel()
+	if ch == nil {
 		return fmt.Errorf("RabbitMQ channel not initialized")
 	}
 
 	// Create queue for this event type
-	queueName := fmt.Sprintf("queue.%s", eventType)
-
-	queue, err := r.channel.QueueDeclare(
-		queueName, // name
-		true,      // durable
-		false,     // delete when unused
-		false,     // exclusive
-		false,     // no-wait
-		nil,       // arguments
+	// No x-dead-letter-* arguments: failed messages are dead-lettered explicitly (retryOrDeadLetter),
+	// and changing the arguments of an existing queue fails the declare with PRECONDITION_FAILED
+	queue, err := ch.QueueDeclare(
+		queueName,       // name
+		!opts.Transient, // durable
+		opts.Transient,  // delete when unused
+		opts.Transient,  // exclusive
+		false,           // no-wait
+		nil,             // arguments
 	)
 	if err != nil {
 		return fmt.Errorf("failed to declare queue: %w", err)
 	}
 
-	// Bind queue to exchange with routing key = event type
-	err = r.channel.QueueBind(
+	// Bind queue to exchange with the event type's routing key pattern
+	err = ch.QueueBind(
 		queue.Name, // queue name
-		eventType,  // routing key
+		binding,    // routing key
 		"events",   // exchange
 		false,      // no-wait
 		nil,        // arguments
@@ -119,10 +413,26 @@ func (r *RabbitMQ) Subscribe(eventType string, handler EventHandler) error {
 		return fmt.Errorf("failed to bind queue: %w", err)
 	}
 
-	// Start consuming
-	msgs, err := r.channel.Consume(
+	// Delay queues for retries, dead-letter queue for messages that exhausted them
+	if err := r.declareRetryQueues(ch, queue.Name, opts.Transient); err != nil {
+		return err
+	}
+	if _, err := r.declareDeadLetterQueue(ch, eventType); err != nil {
+		return err
+	}
+
+	// Start consuming (at most opts.Prefetch unacked messages in flight)
+	r.consumeMu.Lock()
+	defer r.consumeMu.Unlock()
+
+	if err := ch.Qos(opts.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("failed to set prefetch: %w", err)
+	}
+
+	tag := nextConsumerTag(queue.Name)
+	msgs, err := ch.Consume(
 		queue.Name, // queue
-		"",         // consumer tag
+		tag,        // consumer tag (cancelled on Shutdown)
 		false,      // auto-ack (manual ack for reliability)
 		false,      // exclusive
 		false,      // no-local
@@ -132,36 +442,286 @@ func (r *RabbitMQ) Subscribe(eventType string, handler EventHandler) error {
 	if err != nil {
 		return fmt.Errorf("failed to consume: %w", err)
 	}
+	r.trackConsumer(tag)
+
+	log.Printf("👂 Subscribed to event: %s (queue: %s, binding: %s, prefetch: %d, concurrency: %d)",
+		eventType, queueName, binding, opts.Prefetch, opts.Concurrency)
+
+	// Process messages in goroutines: workers share the delivery channel
+	for i := 0; i < opts.Concurrency; i++ {
+		go r.consume(ctx, msgs, eventType, queueName, handler)
+	}
+
+	return nil
+}
+
+// consume runs handler for deliveries until the channel is closed (cancel, reconnect)
+// Per-message contexts derive from the subscriber's ctx
+func (r *RabbitMQ) consume(ctx context.Context, msgs <-chan amqp091.Delivery, eventType, queueName string, handler EventHandler) {
+	r.consumerStarted(queueName)
+	defer r.consumerStopped(queueName)
+
+	for msg := range msgs {
+		if ctx.Err() != nil || !r.beginDelivery() {
+			// Shutting down: leave the message for the next consumer
+			requeue(msg)
+			continue
+		}
+
+		msgCtx := context.WithValue(ctx, messageIDKey{}, msg.MessageId)
+
+		log.Printf("📥 Received event: %s (%s)", eventType, msg.MessageId)
+
+		// Process event with handler
+		err := handler(msgCtx, msg.Body)
+
+		if err != nil && ctx.Err() != nil {
+			// Interrupted by shutdown, not a failure: no retry attempt is spent
+			log.Printf("⚠️  Event %s interrupted by shutdown, requeueing: %v", eventType, err)
+			requeue(msg)
+		} else if err != nil {
+			log.Printf("❌ Failed to process event %s: %v", eventType, err)
+			r.retryOrDeadLetter(msg, eventType, queueName, err)
+		} else {
+			log.Printf("✅ Successfully processed event: %s", eventType)
+			// ACK - acknowledge successful processing
+			msg.Ack(false)
+		}
+
+		r.endDelivery()
+	}
+}
+
+// retryOrDeadLetter moves a failed message to the delay queue of its next attempt,
+// or to the dead-letter exchange once MaxAttempts is reached (or right away for ErrNonRetryable)
+// The delivery goroutine never sleeps: the delay queue's TTL holds the message back.
+// The original is acked only after the broker confirmed the copy, otherwise it is requeued
+func (r *RabbitMQ) retryOrDeadLetter(msg amqp091.Delivery, eventType, queueName string, cause error) {
+	attempt := retryCount(msg.Headers) + 1
+
+	headers := amqp091.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[retryCountHeader] = int32(attempt)
+
+	// Default exchange routes directly to the named delay queue
+	exchange, routingKey := "", ""
+	if attempt >= r.MaxAttempts || errors.Is(cause, ErrNonRetryable) {
+		log.Printf("☠️  Event %s failed after %d attempt(s), dead-lettering: %v", eventType, attempt, cause)
+		exchange, routingKey = deadLetterExchange, eventType
+		headers[originalRoutingKeyHeader] = msg.RoutingKey
+		headers[failureReasonHeader] = cause.Error()
+	} else {
+		delay := r.retryDelay(attempt)
+		log.Printf("🔁 Retrying event %s in %s (attempt %d/%d)", eventType, delay, attempt+1, r.MaxAttempts)
+		routingKey = retryQueueName(queueName, delay)
+	}
+
+	ch := r.currentChannel()
+	if ch == nil {
+		log.Printf("❌ No channel to republish event %s, requeueing", eventType)
+		msg.Nack(false, true)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.PublishTimeout)
+	defer cancel()
+
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(
+		ctx,
+		exchange,   // exchange
+		routingKey, // routing key
+		false,      // mandatory
+		false,      // immediate
+		amqp091.Publishing{
+			ContentType:  msg.ContentType,
+			MessageId:    msg.MessageId,
+			Body:         msg.Body,
+			Headers:      headers,
+			DeliveryMode: amqp091.Persistent,
+		},
+	)
+	if err == nil {
+		var acked bool
+		if acked, err = confirm.WaitContext(ctx); err == nil && !acked {
+			err = ErrPublishNacked
+		}
+	}
+	if err != nil {
+		log.Printf("❌ Failed to republish event %s, requeueing: %v", eventType, err)
+		// NACK - requeue so the message is not lost
+		msg.Nack(false, true)
+		return
+	}
+
+	// Original delivery is replaced by the confirmed copy
+	msg.Ack(false)
+}
+
+// retryDelay - how long a message waits before retry attempt+1: RetryBaseDelay doubled per attempt
+func (r *RabbitMQ) retryDelay(attempt int) time.Duration {
+	return r.RetryBaseDelay * time.Duration(1<<(attempt-1))
+}
+
+// retryQueueName - delay queue of queueName for one retry delay (queue.OrderCompleted.retry.1s)
+// The delay is part of the name: the TTL of an existing queue can't be changed by redeclaring it
+func retryQueueName(queueName string, delay time.Duration) string {
+	return fmt.Sprintf("%s.retry.%s", queueName, delay)
+}
+
+// declareRetryQueues declares a delay queue for every retry attempt of queueName
+// A message published to it expires after the attempt's delay (x-message-ttl) and is
+// dead-lettered through the default exchange straight back to queueName
+func (r *RabbitMQ) declareRetryQueues(ch *amqp091.Channel, queueName string, transient bool) error {
+	for attempt := 1; attempt < r.MaxAttempts; attempt++ {
+		delay := r.retryDelay(attempt)
+		_, err := ch.QueueDeclare(
+			retryQueueName(queueName, delay), // name
+			!transient,                       // durable
+			false,                            // delete when unused (never consumed)
+			false,                            // exclusive
+			false,                            // no-wait
+			amqp091.Table{ // arguments
+				"x-message-ttl":             delay.Milliseconds(),
+				"x-dead-letter-exchange":    "",
+				"x-dead-letter-routing-key": queueName,
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to declare retry queue: %w", err)
+		}
+	}
+	return nil
+}
+
+// declareDeadLetterQueue declares queue.{eventType}.dlq bound to the dead-letter exchange
+func (r *RabbitMQ) declareDeadLetterQueue(ch *amqp091.Channel, eventType string) (string, error) {
+	dlqName := fmt.Sprintf("queue.%s.dlq", eventType)
+
+	_, err := ch.QueueDeclare(
+		dlqName, // name
+		true,    // durable
+		false,   // delete when unused
+		false,   // exclusive
+		false,   // no-wait
+		nil,     // arguments
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to declare dead-letter queue: %w", err)
+	}
+
+	err = ch.QueueBind(
+		dlqName,            // queue name
+		eventType,          // routing key
+		deadLetterExchange, // exchange
+		false,              // no-wait
+		nil,                // arguments
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to bind dead-letter queue: %w", err)
+	}
+
+	return dlqName, nil
+}
+
+// ConsumeDeadLetters drains queue.{eventType}.dlq with the given handler
+// Successfully handled messages are removed, failed ones stay in the DLQ
+func (r *RabbitMQ) ConsumeDeadLetters(eventType string, handler DeadLetterHandler) error {
+	start := func() error { return r.consumeDeadLetters(eventType, handler) }
+	if err := start(); err != nil {
+		return err
+	}
+
+	r.trackSubscription(eventType+".dlq", start)
+	return nil
+}
+
+func (r *RabbitMQ) consumeDeadLetters(eventType string, handler DeadLetterHandler) error {
+	ch := r.currentChannel()
+	if ch == nil {
+		return fmt.Errorf("RabbitMQ channel not initialized")
+	}
+
+	dlqName, err := r.declareDeadLetterQueue(ch, eventType)
+	if err != nil {
+		return err
+	}
+
+	tag := nextConsumerTag(dlqName)
+	msgs, err := ch.Consume(
+		dlqName, // queue
+		tag,     // consumer tag (cancelled on Shutdown)
+		false,   // auto-ack
+		false,   // exclusive
+		false,   // no-local
+		false,   // no-wait
+		nil,     // args
+	)
+	if err != nil {
+		return fmt.Errorf("failed to consume dead letters: %w", err)
+	}
+	r.trackConsumer(tag)
 
-	// Process messages in goroutine
 	go func() {
-		log.Printf("👂 Subscribed to event: %s (queue: %s)", eventType, queueName)
+		r.consumerStarted(dlqName)
+		defer r.consumerStopped(dlqName)
 
-		for msg := range msgs {
-			ctx := context.Background()
+		log.Printf("👂 Draining dead letters: %s (queue: %s)", eventType, dlqName)
 
-			log.Printf("📥 Received event: %s", eventType)
+		for msg := range msgs {
+			if !r.beginDelivery() {
+				requeue(msg)
+				continue
+			}
 
-			// Process event with handler
-			err := handler(ctx, msg.Body)
+			dl := DeadLetter{
+				MessageID: msg.MessageId,
+				EventType: eventType,
+				Attempts:  retryCount(msg.Headers),
+				EventData: msg.Body,
+			}
+			dl.OriginalRoutingKey, _ = msg.Headers[originalRoutingKeyHeader].(string)
+			dl.FailureReason, _ = msg.Headers[failureReasonHeader].(string)
 
-			if err != nil {
-				log.Printf("❌ Failed to process event %s: %v", eventType, err)
-				// NACK - requeue message for retry
+			if err := handler(context.Background(), dl); err != nil {
+				log.Printf("❌ Failed to handle dead letter %s: %v", eventType, err)
+				// Keep it in the DLQ, back off to avoid a hot loop
+				time.Sleep(r.RetryBaseDelay)
 				msg.Nack(false, true)
-			} else {
-				log.Printf("✅ Successfully processed event: %s", eventType)
-				// ACK - acknowledge successful processing
-				msg.Ack(false)
+				r.endDelivery()
+				continue
 			}
+
+			msg.Ack(false)
+			r.endDelivery()
 		}
 	}()
 
 	return nil
 }
 
-// Close closes the RabbitMQ connection
+// retryCount extracts the retry counter from message headers
+func retryCount(headers amqp091.Table) int {
+	switch v := headers[retryCountHeader].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	default:
+		return 0
+	}
+}
+
+// Close closes the RabbitMQ connection and stops reconnection
 func (r *RabbitMQ) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closing = true
+
 	if r.channel != nil {
 		r.channel.Close()
 	}
@@ -0,0 +1,67 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// fakeConfirmChannel records published messages and confirms them like a broker would
+type fakeConfirmChannel struct {
+	published []amqp091.Publishing
+	confirm   fakeConfirmation
+}
+
+func (c *fakeConfirmChannel) PublishWithDeferredConfirmWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp091.Publishing) (publishConfirmation, error) {
+	c.published = append(c.published, msg)
+	return c.confirm, nil
+}
+
+// fakeConfirmation acks or nacks; a nil ack is a confirm that never arrives
+type fakeConfirmation struct {
+	ack *bool
+}
+
+func (c fakeConfirmation) WaitContext(ctx context.Context) (bool, error) {
+	if c.ack == nil {
+		<-ctx.Done()
+		return false, ctx.Err()
+	}
+	return *c.ack, nil
+}
+
+func TestPublishConfirmed(t *testing.T) {
+	acked, nacked := true, false
+
+	tests := []struct {
+		name    string
+		confirm fakeConfirmation
+		wantErr error
+	}{
+		{name: "ack", confirm: fakeConfirmation{ack: &acked}},
+		{name: "nack", confirm: fakeConfirmation{ack: &nacked}, wantErr: ErrPublishNacked},
+		{name: "confirm timeout", confirm: fakeConfirmation{}, wantErr: context.DeadlineExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := &fakeConfirmChannel{confirm: tt.confirm}
+			msg := amqp091.Publishing{MessageId: "e-1", Body: []byte(`{}`)}
+
+			start := time.Now()
+			err := publishConfirmed(ch, 20*time.Millisecond, "events", "Order.OrderCompleted", msg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("publishConfirmed() = %v, want %v", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("publishConfirmed waited %s, want at most the publish timeout", elapsed)
+			}
+			if len(ch.published) != 1 || ch.published[0].MessageId != "e-1" {
+				t.Errorf("published %+v, want the message once", ch.published)
+			}
+		})
+	}
+}
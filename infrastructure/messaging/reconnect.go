@@ -0,0 +1,171 @@
+package messaging
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ===============================================
+// Connection lifecycle (automatic reconnection)
+// ===============================================
+
+// setConnection installs a fresh connection and wakes up waiting publishers
+func (r *RabbitMQ) setConnection(l *link) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.conn = l.conn
+	r.channel = l.channel
+	r.consumerTags = nil // Consumers are re-registered on the new channel
+
+	select {
+	case <-r.ready:
+		// Already signalled
+	default:
+		close(r.ready)
+	}
+}
+
+// currentChannel returns the active channel or nil while disconnected
+func (r *RabbitMQ) currentChannel() *amqp091.Channel {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.channel == nil || r.channel.IsClosed() {
+		return nil
+	}
+	return r.channel
+}
+
+// Healthy reports whether the connection and its channel are open
+// Returns the reason otherwise (reconnecting, shut down)
+func (r *RabbitMQ) Healthy() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	switch {
+	case r.conn == nil || r.conn.IsClosed():
+		return fmt.Errorf("connection closed")
+	case r.channel == nil || r.channel.IsClosed():
+		return fmt.Errorf("channel closed")
+	}
+	return nil
+}
+
+// Ready returns a channel that is closed while a connection is established
+// While reconnecting it is closed by the next successful reconnect (outbox resumes on it)
+func (r *RabbitMQ) Ready() <-chan struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.ready
+}
+
+// waitForChannel blocks until a channel is available or the timeout expires
+func (r *RabbitMQ) waitForChannel(timeout time.Duration) (*amqp091.Channel, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		r.mu.RLock()
+		ch, ready := r.channel, r.ready
+		r.mu.RUnlock()
+
+		if ch != nil && !ch.IsClosed() {
+			return ch, nil
+		}
+
+		select {
+		case <-ready:
+			// Connection (re)established - re-check the channel
+		case <-timer.C:
+			return nil, fmt.Errorf("RabbitMQ channel not available after %s", timeout)
+		}
+	}
+}
+
+// trackSubscription remembers a subscription so it can be replayed after reconnection
+func (r *RabbitMQ) trackSubscription(eventType string, start func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscriptions = append(r.subscriptions, subscription{eventType: eventType, start: start})
+}
+
+// watchConnection waits for the connection or channel to drop and triggers reconnection
+func (r *RabbitMQ) watchConnection(l *link) {
+	var amqpErr *amqp091.Error
+	select {
+	case amqpErr = <-l.connClosed:
+	case amqpErr = <-l.chanClosed:
+		// Channel-level exception: drop the connection too and start over
+		if amqpErr != nil {
+			l.conn.Close()
+		}
+	}
+	if amqpErr == nil {
+		return // Graceful Close()
+	}
+
+	r.mu.Lock()
+	if r.closing {
+		r.mu.Unlock()
+		return
+	}
+	// Publishers block on the new ready channel until reconnection completes
+	r.ready = make(chan struct{})
+	r.mu.Unlock()
+
+	log.Printf("⚠️  RabbitMQ connection lost: %v", amqpErr)
+	r.reconnect()
+}
+
+// reconnect re-dials with exponential backoff and replays all subscriptions
+func (r *RabbitMQ) reconnect() {
+	delay := r.ReconnectBaseDelay
+
+	for attempt := 1; ; attempt++ {
+		r.mu.RLock()
+		closing := r.closing
+		r.mu.RUnlock()
+		if closing {
+			return
+		}
+
+		time.Sleep(delay)
+
+		l, err := r.dial()
+		if err != nil {
+			log.Printf("⏳ Reconnect attempt %d failed: %v", attempt, err)
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+			continue
+		}
+
+		r.setConnection(l)
+		go r.watchConnection(l)
+
+		log.Printf("✅ Reconnected to RabbitMQ after %d attempt(s)", attempt)
+		r.resubscribe()
+		return
+	}
+}
+
+// resubscribe re-registers all tracked subscriptions on the new channel
+func (r *RabbitMQ) resubscribe() {
+	r.mu.RLock()
+	subs := make([]subscription, len(r.subscriptions))
+	copy(subs, r.subscriptions)
+	r.mu.RUnlock()
+
+	for _, sub := range subs {
+		if err := sub.start(); err != nil {
+			log.Printf("❌ Failed to re-subscribe to %s: %v", sub.eventType, err)
+		}
+	}
+}
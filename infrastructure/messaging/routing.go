@@ -0,0 +1,103 @@
+package messaging
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+)
+
+// Routing keys: an event is published as "{aggregateType}.{eventType}" (Order.OrderCompleted)
+// or, when its type has a routing field, as "{aggregateType}.{eventType}.{value}"
+// (Order.OrderAccepted.market). The aggregate type is read from the event's aggregate_type.
+//
+// Subscribe takes an event type, optionally qualified: "OrderAccepted" receives every
+// OrderAccepted, "OrderAccepted.limit" only limit orders. SubscribePattern binds a raw
+// topic pattern, e.g. "Order.#" for every event of the Order aggregate
+
+// unknownAggregate - first word of the routing key of events without aggregate_type
+const unknownAggregate = "Event"
+
+// routingField - JSON field qualifying the routing key of an event type
+type routingField struct {
+	name     string
+	fallback string // Used when the event has no value (events written before the field existed)
+}
+
+var routingFields = struct {
+	mu     sync.RWMutex
+	fields map[string]routingField
+}{fields: make(map[string]routingField)}
+
+// RegisterRoutingField qualifies the routing key of eventType with the value of a JSON field
+// fallback is used for events without the field; "" publishes them under the bare event type
+func RegisterRoutingField(eventType, field, fallback string) {
+	routingFields.mu.Lock()
+	defer routingFields.mu.Unlock()
+
+	routingFields.fields[eventType] = routingField{name: field, fallback: fallback}
+}
+
+// RoutingKey returns the routing key an event is published with
+func RoutingKey(eventType string, eventData []byte) string {
+	var fields map[string]interface{}
+	_ = json.Unmarshal(eventData, &fields) // Malformed events are routed like events without the fields
+
+	aggregateType, _ := fields["aggregate_type"].(string)
+	if aggregateType == "" {
+		aggregateType = unknownAggregate
+	}
+	key := aggregateType + "." + eventType
+
+	routingFields.mu.RLock()
+	field, ok := routingFields.fields[eventType]
+	routingFields.mu.RUnlock()
+
+	if !ok {
+		return key
+	}
+
+	value, _ := fields[field.name].(string)
+	if value == "" {
+		value = field.fallback
+	}
+	if value == "" {
+		return key
+	}
+	return key + "." + value
+}
+
+// bindingKey returns the topic binding of an event type subscription (Subscribe)
+// Any aggregate type; a bare event type matches its qualified keys too:
+// "OrderAccepted" → "*.OrderAccepted.#", "OrderAccepted.limit" → "*.OrderAccepted.limit"
+func bindingKey(eventType string) string {
+	if strings.ContainsAny(eventType, ".*#") {
+		return "*." + eventType
+	}
+	return "*." + eventType + ".#"
+}
+
+// MatchRoutingKey reports whether a routing key matches a topic pattern
+// (topic exchange rules: "*" is exactly one word, "#" zero or more)
+func MatchRoutingKey(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && matchWords(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && key[0] == pattern[0] && matchWords(pattern[1:], key[1:])
+	}
+}
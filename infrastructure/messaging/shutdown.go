@@ -0,0 +1,117 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/rabbitmq/amqp091-go"
+)
+
+// ===============================================
+// Graceful shutdown (consumer draining)
+// ===============================================
+
+// DefaultDrainTimeout bounds how long Shutdown waits for in-flight handlers
+const DefaultDrainTimeout = 15 * time.Second
+
+// consumerSeq makes consumer tags unique within the process
+var consumerSeq atomic.Int64
+
+// nextConsumerTag returns a tag used to cancel the consumer on Shutdown
+func nextConsumerTag(queueName string) string {
+	return fmt.Sprintf("%s.%d", queueName, consumerSeq.Add(1))
+}
+
+// trackConsumer remembers a consumer tag of the current channel
+func (r *RabbitMQ) trackConsumer(tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.consumerTags = append(r.consumerTags, tag)
+}
+
+// beginDelivery registers an in-flight handler
+// Returns false once Shutdown has started: the delivery must be requeued, not handled
+func (r *RabbitMQ) beginDelivery() bool {
+	r.drainMu.Lock()
+	defer r.drainMu.Unlock()
+
+	if r.draining {
+		return false
+	}
+	r.inflight.Add(1)
+	return true
+}
+
+// endDelivery marks an in-flight handler as finished (message acked or nacked)
+func (r *RabbitMQ) endDelivery() {
+	r.inflight.Done()
+}
+
+// requeue returns a delivery that arrived during shutdown to the queue
+func requeue(msg amqp091.Delivery) {
+	msg.Nack(false, true)
+}
+
+// Shutdown stops consuming and waits for in-flight handlers to finish and ack
+// 1. Consumers are cancelled: the broker stops sending new deliveries
+// 2. Already prefetched deliveries are requeued unprocessed
+// 3. Running handlers are awaited until ctx expires
+// 4. The channel is closed (Close still closes the connection)
+func (r *RabbitMQ) Shutdown(ctx context.Context) error {
+	r.drainMu.Lock()
+	if r.draining {
+		r.drainMu.Unlock()
+		<-r.drained
+		return nil
+	}
+	r.draining = true
+	r.drainMu.Unlock()
+
+	defer close(r.drained)
+
+	r.mu.Lock()
+	r.closing = true // A channel closed by Shutdown is not a reason to reconnect
+	ch := r.channel
+	tags := append([]string(nil), r.consumerTags...)
+	r.mu.Unlock()
+
+	if ch != nil && !ch.IsClosed() {
+		for _, tag := range tags {
+			if err := ch.Cancel(tag, false); err != nil {
+				log.Printf("⚠️  Failed to cancel consumer %s: %v", tag, err)
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		r.inflight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		log.Println("✅ RabbitMQ consumers drained")
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out waiting for in-flight handlers: %w", ctx.Err())
+		log.Printf("⚠️  %v", err)
+	}
+
+	if ch != nil && !ch.IsClosed() {
+		ch.Close()
+	}
+
+	return err
+}
+
+// Drained is closed once Shutdown has finished draining consumers
+// Services block on it after their context is cancelled, so they return only
+// when none of their handlers is running
+func (r *RabbitMQ) Drained() <-chan struct{} {
+	return r.drained
+}
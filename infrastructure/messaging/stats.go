@@ -0,0 +1,39 @@
+package messaging
+
+// ===============================================
+// Consumer stats (GET /admin/stats)
+// ===============================================
+
+// ConsumerCounts returns the consumer goroutines currently running per queue
+// A count that keeps growing across reconnects points at consumers that never exit
+func (r *RabbitMQ) ConsumerCounts() map[string]int {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+
+	counts := make(map[string]int, len(r.consumers))
+	for queue, n := range r.consumers {
+		counts[queue] = n
+	}
+	return counts
+}
+
+// consumerStarted counts a consumer goroutine of queueName; pair with consumerStopped
+func (r *RabbitMQ) consumerStarted(queueName string) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+
+	if r.consumers == nil {
+		r.consumers = make(map[string]int)
+	}
+	r.consumers[queueName]++
+}
+
+// consumerStopped uncounts a consumer goroutine that returned (channel closed)
+func (r *RabbitMQ) consumerStopped(queueName string) {
+	r.statsMu.Lock()
+	defer r.statsMu.Unlock()
+
+	if r.consumers[queueName]--; r.consumers[queueName] <= 0 {
+		delete(r.consumers, queueName)
+	}
+}
@@ -0,0 +1,64 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// DefaultEmailSubject - subject of notification e-mails
+const DefaultEmailSubject = "Order notification"
+
+// EmailNotifier sends notifications by e-mail through an SMTP relay
+// The recipient address comes from the context (WithRecipient)
+type EmailNotifier struct {
+	addr string // host:port of the SMTP relay
+	from string
+	auth smtp.Auth // nil - relay without authentication
+
+	// Subject - e-mail subject line
+	Subject string
+}
+
+// NewEmailNotifier creates a notifier for the relay at addr ("smtp.example.com:587")
+// username = "" sends without authentication
+func NewEmailNotifier(addr, from, username, password string) *EmailNotifier {
+	var auth smtp.Auth
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &EmailNotifier{addr: addr, from: from, auth: auth, Subject: DefaultEmailSubject}
+}
+
+// SendMessage implements notification.Notifier
+// net/smtp does not take a context: only a context cancelled before sending is honoured
+func (n *EmailNotifier) SendMessage(ctx context.Context, userID, message string) error {
+	to, ok := RecipientFromContext(ctx)
+	if !ok {
+		return errors.New("no e-mail address for user " + userID)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Header values must not contain line breaks (header injection)
+	if strings.ContainsAny(to, "\r\n") {
+		return fmt.Errorf("invalid e-mail address %q", to)
+	}
+
+	body := "From: " + n.from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + n.Subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=UTF-8\r\n" +
+		"\r\n" +
+		strings.ReplaceAll(message, "\n", "\r\n") + "\r\n"
+
+	if err := smtp.SendMail(n.addr, n.auth, n.from, []string{to}, []byte(body)); err != nil {
+		return fmt.Errorf("failed to send e-mail to %s: %w", to, err)
+	}
+	return nil
+}
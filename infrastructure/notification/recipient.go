@@ -0,0 +1,18 @@
+package notification
+
+import "context"
+
+// recipientKey carries the channel address of the user being notified (e-mail, chat_id, URL)
+type recipientKey struct{}
+
+// WithRecipient returns ctx carrying the address the notifier should deliver to
+// The notifier registry sets it from the user's channel preferences
+func WithRecipient(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, recipientKey{}, address)
+}
+
+// RecipientFromContext returns the address set by WithRecipient
+func RecipientFromContext(ctx context.Context) (string, bool) {
+	address, ok := ctx.Value(recipientKey{}).(string)
+	return address, ok && address != ""
+}
@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultTelegramAPIURL - Telegram Bot API endpoint
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// DefaultNotifierTimeout - per-request timeout of the HTTP notifiers
+const DefaultNotifierTimeout = 10 * time.Second
+
+// TelegramNotifier sends notifications through a Telegram bot
+// The recipient chat_id comes from the context (WithRecipient)
+type TelegramNotifier struct {
+	token  string
+	client *http.Client
+
+	// APIURL - Bot API base URL (DefaultTelegramAPIURL; overridable for a local Bot API server)
+	APIURL string
+}
+
+func NewTelegramNotifier(token string) *TelegramNotifier {
+	return &TelegramNotifier{
+		token:  token,
+		client: &http.Client{Timeout: DefaultNotifierTimeout},
+		APIURL: DefaultTelegramAPIURL,
+	}
+}
+
+// telegramMessage is the sendMessage request body
+type telegramMessage struct {
+	ChatID string `json:"chat_id"`
+	Text   string `json:"text"`
+}
+
+// SendMessage implements notification.Notifier
+func (n *TelegramNotifier) SendMessage(ctx context.Context, userID, message string) error {
+	chatID, ok := RecipientFromContext(ctx)
+	if !ok {
+		return errors.New("no Telegram chat for user " + userID)
+	}
+
+	payload, err := json.Marshal(telegramMessage{ChatID: chatID, Text: message})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.APIURL+"/bot"+n.token+"/sendMessage", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build Telegram request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The URL contains the bot token: report the chat, not the request
+		return fmt.Errorf("Telegram request for chat %s failed: %w", chatID, errors.Unwrap(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("Telegram returned %d for chat %s: %s", resp.StatusCode, chatID, bytes.TrimSpace(body))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the webhook body when a secret is set
+const SignatureHeader = "X-Signature-SHA256"
+
+// WebhookNotifier POSTs notifications as JSON to the user's webhook URL (bots, integrations)
+// The URL comes from the context (WithRecipient)
+type WebhookNotifier struct {
+	secret []byte // nil - unsigned
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a notifier; a non-empty secret signs every body (SignatureHeader)
+func NewWebhookNotifier(secret string) *WebhookNotifier {
+	n := &WebhookNotifier{client: &http.Client{Timeout: DefaultNotifierTimeout}}
+	if secret != "" {
+		n.secret = []byte(secret)
+	}
+	return n
+}
+
+// webhookPayload is the body POSTed to the webhook
+type webhookPayload struct {
+	UserID  string    `json:"user_id"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// SendMessage implements notification.Notifier
+// Any 2xx response counts as delivered
+func (n *WebhookNotifier) SendMessage(ctx context.Context, userID, message string) error {
+	url, ok := RecipientFromContext(ctx)
+	if !ok {
+		return errors.New("no webhook URL for user " + userID)
+	}
+
+	payload, err := json.Marshal(webhookPayload{UserID: userID, Message: message, SentAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != nil {
+		mac := hmac.New(sha256.New, n.secret)
+		mac.Write(payload)
+		req.Header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook %s returned %d", url, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package outbox
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+	"market_order/pkg/logging"
+)
+
+// NotifyChannel - канал pg_notify, в который пишет триггер trg_outbox_notify
+const NotifyChannel = "order_outbox"
+
+// Параметры переподключения listener'а и проверки соединения
+const (
+	listenerMinReconnect = 1 * time.Second
+	listenerMaxReconnect = 30 * time.Second
+	listenerPingInterval = 90 * time.Second
+)
+
+// listen открывает отдельное соединение с LISTEN order_outbox
+// Возвращает канал сигналов "пора публиковать" и функцию остановки
+// Без ListenDSN возвращает nil-канал: select на нём никогда не срабатывает, работает только опрос
+func (op *OutboxPublisher) listen() (<-chan struct{}, func()) {
+	if op.listenDSN == "" {
+		return nil, func() {}
+	}
+
+	listener := pq.NewListener(op.listenDSN, listenerMinReconnect, listenerMaxReconnect,
+		func(ev pq.ListenerEventType, err error) {
+			switch ev {
+			case pq.ListenerEventConnected:
+				op.Logger.Info("Outbox listener connected", "channel", NotifyChannel)
+			case pq.ListenerEventDisconnected:
+				op.Logger.Warn("Outbox listener disconnected, falling back to polling", logging.Err(err))
+			case pq.ListenerEventReconnected:
+				op.Logger.Info("Outbox listener reconnected", "channel", NotifyChannel)
+			case pq.ListenerEventConnectionAttemptFailed:
+				op.Logger.Warn("Outbox listener connection attempt failed", logging.Err(err))
+			}
+		})
+
+	if err := listener.Listen(NotifyChannel); err != nil {
+		op.Logger.Error("Outbox listener failed, using polling only", logging.Err(err))
+		listener.Close()
+		return nil, func() {}
+	}
+
+	wake := make(chan struct{}, 1)
+	done := make(chan struct{})
+
+	go func() {
+		ping := time.NewTicker(listenerPingInterval)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-listener.Notify:
+				// nil после переподключения: уведомления могли потеряться, нужен catch-up
+				// Несколько уведомлений подряд схлопываются в один сигнал
+				select {
+				case wake <- struct{}{}:
+				default:
+				}
+
+			case <-ping.C:
+				// Обнаруживает "тихо" умершее соединение, переподключение делает pq
+				go listener.Ping()
+
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return wake, func() {
+		close(done)
+		listener.Close()
+	}
+}
@@ -3,110 +3,453 @@ package outbox
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/lib/pq"
 	"market_order/infrastructure/messaging"
+	"market_order/pkg/logging"
+	"market_order/pkg/metrics"
 )
 
+// Значения OutboxConfig по умолчанию
+const (
+	// DefaultMaxRetries - после стольких неудачных публикаций событие уходит в outbox_dead
+	DefaultMaxRetries   = 10
+	DefaultPollInterval = 100 * time.Millisecond
+	DefaultBatchSize    = 100
+	// DefaultMaxBackoff - потолок паузы между опросами, пока публикации не проходят
+	DefaultMaxBackoff = 10 * time.Second
+)
+
+// backoffLogInterval - как часто напоминать в логе о продолжающемся backoff
+const backoffLogInterval = time.Minute
+
+// OutboxConfig - настройки OutboxPublisher (нулевые поля заменяются значениями по умолчанию)
+type OutboxConfig struct {
+	// PollInterval - пауза между опросами outbox
+	PollInterval time.Duration
+	// BatchSize - максимум событий за один опрос
+	BatchSize int
+	// MaxRetries - лимит попыток публикации перед переносом в outbox_dead
+	MaxRetries int
+	// Adaptive - если опрос вернул полный batch, следующий запускается сразу,
+	// без ожидания PollInterval (быстрее разгребает накопившийся backlog)
+	Adaptive bool
+	// ListenDSN - строка подключения для LISTEN order_outbox (пусто - только опрос)
+	// С ней события публикуются сразу после коммита, а опрос остаётся страховкой
+	ListenDSN string
+	// MaxBackoff - потолок паузы после проходов, в которых не прошла ни одна публикация
+	// Пауза удваивается с каждым таким проходом и сбрасывается после первой успешной публикации
+	MaxBackoff time.Duration
+}
+
+// DefaultOutboxConfig возвращает настройки по умолчанию (adaptive режим включён)
+func DefaultOutboxConfig() OutboxConfig {
+	return OutboxConfig{
+		PollInterval: DefaultPollInterval,
+		BatchSize:    DefaultBatchSize,
+		MaxRetries:   DefaultMaxRetries,
+		Adaptive:     true,
+		MaxBackoff:   DefaultMaxBackoff,
+	}
+}
+
 // OutboxPublisher читает непубликованные события из outbox и публикует в RabbitMQ
 type OutboxPublisher struct {
 	db         *sql.DB
-	messageBus *messaging.RabbitMQ
+	messageBus messaging.MessageBus
 	interval   time.Duration
+	batchSize  int
+	adaptive   bool
+	listenDSN  string
+	maxBackoff time.Duration
+
+	// Backoff после неудачных публикаций; состояние меняет только горутина Start
+	backoff       time.Duration   // Текущая пауза между опросами (0 - публикации проходят)
+	backoffSince  time.Time       // Начало серии неудачных проходов
+	backoffLogged time.Time       // Последняя запись в лог о backoff
+	failedPasses  int             // Неудачных проходов подряд
+	reconnected   <-chan struct{} // Закрывается при переподключении брокера (nil - не ждём)
+
+	// MaxRetries - лимит попыток публикации перед переносом в outbox_dead
+	MaxRetries int
+
+	// Logger - структурированный логгер (по умолчанию slog.Default())
+	Logger *slog.Logger
 }
 
-func NewOutboxPublisher(db *sql.DB, mb *messaging.RabbitMQ) *OutboxPublisher {
+func NewOutboxPublisher(db *sql.DB, mb messaging.MessageBus, cfg OutboxConfig) *OutboxPublisher {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.MaxRetries <= 0 {
+		cfg.MaxRetries = DefaultMaxRetries
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultMaxBackoff
+	}
+
 	return &OutboxPublisher{
 		db:         db,
 		messageBus: mb,
-		interval:   100 * time.Millisecond,
+		interval:   cfg.PollInterval,
+		batchSize:  cfg.BatchSize,
+		adaptive:   cfg.Adaptive,
+		listenDSN:  cfg.ListenDSN,
+		maxBackoff: cfg.MaxBackoff,
+		MaxRetries: cfg.MaxRetries,
+		Logger:     slog.Default(),
 	}
 }
 
 // Start запускает worker для публикации событий
 func (op *OutboxPublisher) Start(ctx context.Context) error {
-	ticker := time.NewTicker(op.interval)
-	defer ticker.Stop()
+	timer := time.NewTimer(op.interval)
+	defer timer.Stop()
 
-	log.Println("Outbox Publisher started")
+	// NOTIFY от триггера на outbox будит publisher сразу после коммита
+	notified, stopListener := op.listen()
+	defer stopListener()
+
+	op.Logger.Info("Outbox Publisher started",
+		"poll_interval", op.interval.String(), "batch_size", op.batchSize,
+		"adaptive", op.adaptive, "listen", notified != nil, "max_backoff", op.maxBackoff.String())
 
 	for {
 		select {
-		case <-ticker.C:
-			if err := op.publishPendingEvents(ctx); err != nil {
-				log.Printf("Failed to publish events: %v", err)
+		case <-timer.C:
+			op.poll(ctx, timer)
+
+		case <-notified:
+			// Во время backoff новые события ждут очередного опроса по таймеру
+			if op.backoff == 0 {
+				op.poll(ctx, timer)
 			}
 
+		case <-op.reconnected:
+			// Брокер снова доступен: не ждём конца паузы
+			op.reconnected = nil
+			op.Logger.Info("Message broker reconnected, resuming outbox publishing")
+			op.poll(ctx, timer)
+
 		case <-ctx.Done():
-			log.Println("Outbox Publisher stopped")
+			op.Logger.Info("Outbox Publisher stopped")
 			return nil
 		}
 	}
 }
 
-func (op *OutboxPublisher) publishPendingEvents(ctx context.Context) error {
-	// Загружаем непубликованные события
+// poll публикует очередной batch и назначает следующий опрос
+func (op *OutboxPublisher) poll(ctx context.Context, timer *time.Timer) {
+	// Брокер переподключается: проход только израсходовал бы retry_count событий
+	if err := op.brokerHealth(); err != nil {
+		op.backOff("broker unavailable: " + err.Error())
+		timer.Reset(op.backoff)
+		return
+	}
+
+	result, err := op.publishPendingEvents(ctx)
+	if err != nil {
+		op.Logger.Error("Failed to publish events", logging.Err(err))
+		timer.Reset(op.nextDelay())
+		return
+	}
+
+	// Ни одна публикация не прошла - повтор через растущую паузу
+	if result.published == 0 && result.failed > 0 {
+		op.backOff("all publishes failed")
+		timer.Reset(op.backoff)
+		return
+	}
+	op.resetBackoff()
+
+	// Полный batch - в outbox, скорее всего, есть ещё события
+	if op.adaptive && result.full {
+		timer.Reset(0)
+	} else {
+		timer.Reset(op.interval)
+	}
+}
+
+// nextDelay - пауза до следующего опроса: backoff, если он идёт, иначе interval
+func (op *OutboxPublisher) nextDelay() time.Duration {
+	if op.backoff > 0 {
+		return op.backoff
+	}
+	return op.interval
+}
+
+// batchResult - итог одного прохода по outbox
+type batchResult struct {
+	published int
+	failed    int
+	full      bool // Обработан полный batch (очередь не опустела)
+}
+
+// publishPendingEvents публикует до batchSize событий, каждое в своей транзакции
+// Краш между публикацией и коммитом рискует повторной публикацией только одного события
+func (op *OutboxPublisher) publishPendingEvents(ctx context.Context) (batchResult, error) {
+	published := 0
+	full := true
+
+	// События, упавшие в этом проходе, пропускаются, чтобы один
+	// "ядовитый" event не блокировал остальную очередь
+	// Пустой, а не nil слайс: pq.Array(nil) - это NULL, и NOT (id = ANY(NULL)) не выбирает ни одной строки
+	failedIDs := []int64{}
+
+	for attempts := 0; attempts < op.batchSize; attempts++ {
+		id, ok, err := op.publishNext(ctx, failedIDs)
+		if err != nil {
+			return batchResult{}, err
+		}
+		if id == 0 {
+			full = false // Очередь пуста
+			break
+		}
+		if !ok {
+			failedIDs = append(failedIDs, id)
+			continue
+		}
+		published++
+	}
+
+	if published > 0 {
+		op.Logger.Debug("Published events", "count", published)
+	}
+
+	// Все попытки упали (брокер недоступен) - немедленный повтор только нагрузит его
+	if published == 0 {
+		full = false
+	}
+
+	return batchResult{published: published, failed: len(failedIDs), full: full}, nil
+}
+
+// brokerReadiness - MessageBus, который сообщает о состоянии соединения (RabbitMQ)
+type brokerReadiness interface {
+	Healthy() error
+	Ready() <-chan struct{}
+}
+
+// brokerHealth возвращает причину недоступности брокера; nil, если MessageBus о ней не сообщает
+func (op *OutboxPublisher) brokerHealth() error {
+	if bus, ok := op.messageBus.(brokerReadiness); ok {
+		return bus.Healthy()
+	}
+	return nil
+}
+
+// backOff удваивает паузу до следующего опроса (от 2 * interval до maxBackoff)
+// В лог пишутся только переход в backoff и раз в backoffLogInterval - его продолжение
+func (op *OutboxPublisher) backOff(reason string) {
+	now := time.Now()
+	op.failedPasses++
+
+	if op.backoff == 0 {
+		op.backoff = min(2*op.interval, op.maxBackoff)
+		op.backoffSince, op.backoffLogged = now, now
+		op.Logger.Warn("Outbox publishing failed, backing off",
+			"reason", reason, "backoff", op.backoff.String())
+	} else {
+		op.backoff = min(2*op.backoff, op.maxBackoff)
+		if now.Sub(op.backoffLogged) >= backoffLogInterval {
+			op.backoffLogged = now
+			op.Logger.Warn("Outbox publishing still failing",
+				"reason", reason, "failed_passes", op.failedPasses,
+				"since", op.backoffSince.Format(time.RFC3339), "backoff", op.backoff.String())
+		}
+	}
+
+	op.watchReconnect()
+}
+
+// watchReconnect ждёт переподключения брокера, если соединение сейчас разорвано
+// Живое соединение (ошибка не в нём) - ждать нечего, работает только backoff
+func (op *OutboxPublisher) watchReconnect() {
+	bus, ok := op.messageBus.(brokerReadiness)
+	if !ok || op.reconnected != nil {
+		return
+	}
+
+	ready := bus.Ready()
+	select {
+	case <-ready:
+		// Соединение установлено
+	default:
+		op.reconnected = ready
+	}
+}
+
+// resetBackoff возвращает обычный интервал опроса после успешного прохода
+func (op *OutboxPublisher) resetBackoff() {
+	if op.backoff == 0 {
+		return
+	}
+
+	op.Logger.Info("Outbox publishing recovered",
+		"failed_passes", op.failedPasses, "duration", time.Since(op.backoffSince).Round(time.Millisecond).String())
+	op.backoff, op.failedPasses, op.reconnected = 0, 0, nil
+}
+
+// publishNext блокирует одно непубликованное событие (SKIP LOCKED позволяет
+// нескольким publisher'ам работать параллельно), публикует и помечает его
+// Возвращает id = 0, если публиковать нечего, и ok = false, если публикация не удалась
+func (op *OutboxPublisher) publishNext(ctx context.Context, skipIDs []int64) (int64, bool, error) {
+	tx, err := op.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, false, err
+	}
+	defer tx.Rollback()
+
 	query := `
-        SELECT id, event_id, aggregate_id, event_type, event_data
+        SELECT id, event_id, aggregate_id, event_type, event_data, retry_count
         FROM outbox
-        WHERE published = false
+        WHERE published = false AND NOT (id = ANY($1))
         ORDER BY created_at ASC
-        LIMIT 100
+        LIMIT 1
+        FOR UPDATE SKIP LOCKED
     `
 
-	rows, err := op.db.QueryContext(ctx, query)
+	var (
+		id          int64
+		eventID     string
+		aggregateID string
+		eventType   string
+		eventData   []byte
+		retryCount  int
+	)
+
+	err = tx.QueryRowContext(ctx, query, pq.Array(skipIDs)).Scan(&id, &eventID, &aggregateID, &eventType, &eventData, &retryCount)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
 	if err != nil {
-		return err
+		return 0, false, err
 	}
-	defer rows.Close()
-
-	var publishedIDs []int64
 
-	for rows.Next() {
-		var (
-			id          int64
-			eventID     string
-			aggregateID string
-			eventType   string
-			eventData   []byte
-		)
+	// Публикуем в RabbitMQ (Publish ждёт подтверждения от брокера)
+	// event_id становится MessageId: повторная публикация того же события узнаваема
+	if publishErr := op.messageBus.PublishEvent(eventType, eventID, eventData); publishErr != nil {
+		op.Logger.Warn("Failed to publish event",
+			logging.EventID(eventID), logging.EventType(eventType), logging.OrderID(aggregateID),
+			"attempt", retryCount+1, "max_retries", op.MaxRetries, logging.Err(publishErr))
 
-		if err := rows.Scan(&id, &eventID, &aggregateID, &eventType, &eventData); err != nil {
-			log.Printf("Failed to scan row: %v", err)
-			continue
+		if err := op.recordFailure(ctx, tx, id, retryCount+1, publishErr); err != nil {
+			return 0, false, err
 		}
-
-		// Публикуем в RabbitMQ
-		if err := op.messageBus.Publish(eventType, eventData); err != nil {
-			log.Printf("Failed to publish event %s: %v", eventID, err)
-			continue
+		if err := tx.Commit(); err != nil {
+			return 0, false, fmt.Errorf("failed to commit outbox failure %s: %w", eventID, err)
 		}
+		return id, false, nil
+	}
 
-		publishedIDs = append(publishedIDs, id)
+	// Помечаем как опубликованное сразу после подтверждения
+	if err := op.markAsPublished(ctx, tx, id); err != nil {
+		return 0, false, err
 	}
 
-	// Помечаем как опубликованные
-	if len(publishedIDs) > 0 {
-		if err := op.markAsPublished(ctx, publishedIDs); err != nil {
-			return err
+	if err := tx.Commit(); err != nil {
+		return 0, false, fmt.Errorf("failed to commit outbox event %s: %w", eventID, err)
+	}
+	metrics.OutboxPublishedTotal.Inc(eventType)
+
+	return id, true, nil
+}
+
+// recordFailure увеличивает retry_count или переносит событие в outbox_dead
+// после MaxRetries неудачных попыток
+func (op *OutboxPublisher) recordFailure(ctx context.Context, tx *sql.Tx, id int64, retryCount int, cause error) error {
+	if retryCount < op.MaxRetries {
+		query := `
+            UPDATE outbox
+            SET retry_count = $2, last_error = $3
+            WHERE id = $1
+        `
+
+		if _, err := tx.ExecContext(ctx, query, id, retryCount, cause.Error()); err != nil {
+			return fmt.Errorf("failed to record outbox failure: %w", err)
 		}
+		return nil
+	}
+
+	moveQuery := `
+        INSERT INTO outbox_dead (
+            event_id, aggregate_id, event_type, event_data, retry_count, last_error, created_at
+        )
+        SELECT event_id, aggregate_id, event_type, event_data, $2, $3, created_at
+        FROM outbox
+        WHERE id = $1
+    `
+
+	if _, err := tx.ExecContext(ctx, moveQuery, id, retryCount, cause.Error()); err != nil {
+		return fmt.Errorf("failed to move event to outbox_dead: %w", err)
+	}
 
-		log.Printf("Published %d events", len(publishedIDs))
+	if _, err := tx.ExecContext(ctx, `DELETE FROM outbox WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete dead outbox event: %w", err)
 	}
 
+	op.Logger.Error("Outbox event moved to outbox_dead", "outbox_id", id, "attempts", retryCount, logging.Err(cause))
 	return nil
 }
 
-func (op *OutboxPublisher) markAsPublished(ctx context.Context, ids []int64) error {
+func (op *OutboxPublisher) markAsPublished(ctx context.Context, tx *sql.Tx, id int64) error {
 	query := `
         UPDATE outbox
         SET published = true, published_at = NOW()
-        WHERE id = ANY($1)
+        WHERE id = $1
     `
 
-	// Use pq.Array for PostgreSQL array parameter
-	_, err := op.db.ExecContext(ctx, query, pq.Array(ids))
+	_, err := tx.ExecContext(ctx, query, id)
 	return err
 }
+
+// DeadEvent - событие, которое не удалось опубликовать после MaxRetries попыток
+type DeadEvent struct {
+	ID          int64
+	EventID     string
+	AggregateID string
+	EventType   string
+	EventData   []byte
+	RetryCount  int
+	LastError   string
+	CreatedAt   string
+	FailedAt    string
+}
+
+// DeadLetters возвращает события из outbox_dead (для операторов)
+func (op *OutboxPublisher) DeadLetters(ctx context.Context) ([]DeadEvent, error) {
+	query := `
+        SELECT id, event_id, aggregate_id, event_type, event_data,
+               retry_count, last_error, created_at, failed_at
+        FROM outbox_dead
+        ORDER BY failed_at ASC
+    `
+
+	rows, err := op.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query outbox_dead: %w", err)
+	}
+	defer rows.Close()
+
+	var events []DeadEvent
+	for rows.Next() {
+		var e DeadEvent
+		err := rows.Scan(
+			&e.ID, &e.EventID, &e.AggregateID, &e.EventType, &e.EventData,
+			&e.RetryCount, &e.LastError, &e.CreatedAt, &e.FailedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dead event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,123 @@
+package outbox
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"market_order/infrastructure/messaging"
+)
+
+// confirmingBus answers every publish with err (nil: the broker confirmed it)
+type confirmingBus struct {
+	*messaging.MemoryBus
+	err       error
+	published []string
+}
+
+func (b *confirmingBus) PublishEvent(eventType, eventID string, eventData []byte) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.published = append(b.published, eventID)
+	return nil
+}
+
+var outboxColumns = []string{"id", "event_id", "aggregate_id", "event_type", "event_data", "retry_count"}
+
+func newTestPublisher(t *testing.T, bus messaging.MessageBus) (*OutboxPublisher, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := DefaultOutboxConfig()
+	cfg.BatchSize = 10
+	op := NewOutboxPublisher(db, bus, cfg)
+	op.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return op, mock
+}
+
+// expectEmptyOutbox - the pass ends on a poll that finds nothing more to publish
+func expectEmptyOutbox(mock sqlmock.Sqlmock) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, event_id.* FROM outbox WHERE published = false`).WillReturnRows(sqlmock.NewRows(outboxColumns))
+	mock.ExpectRollback()
+}
+
+func TestNackedPublishStaysUnpublished(t *testing.T) {
+	bus := &confirmingBus{MemoryBus: messaging.NewMemoryBus(), err: messaging.ErrPublishNacked}
+	op, mock := newTestPublisher(t, bus)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, event_id.* FROM outbox WHERE published = false`).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).AddRow(1, "event-1", "order-1", "OrderAccepted", []byte(`{}`), 0))
+	// Only the failure is recorded: no UPDATE ... SET published = true
+	mock.ExpectExec(`UPDATE outbox\s+SET retry_count = \$2, last_error = \$3`).
+		WithArgs(1, 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectEmptyOutbox(mock)
+
+	result, err := op.publishPendingEvents(context.Background())
+	if err != nil {
+		t.Fatalf("publishPendingEvents: %v", err)
+	}
+	if result.published != 0 || result.failed != 1 {
+		t.Errorf("result = %+v, want 0 published, 1 failed", result)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestConfirmedPublishIsMarkedPublished(t *testing.T) {
+	bus := &confirmingBus{MemoryBus: messaging.NewMemoryBus()}
+	op, mock := newTestPublisher(t, bus)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, event_id.* FROM outbox WHERE published = false`).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).AddRow(1, "event-1", "order-1", "OrderAccepted", []byte(`{}`), 0))
+	mock.ExpectExec(`UPDATE outbox\s+SET published = true`).
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectEmptyOutbox(mock)
+
+	result, err := op.publishPendingEvents(context.Background())
+	if err != nil {
+		t.Fatalf("publishPendingEvents: %v", err)
+	}
+	if result.published != 1 || len(bus.published) != 1 || bus.published[0] != "event-1" {
+		t.Errorf("result = %+v, published %v, want event-1 published", result, bus.published)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLastFailedPublishMovesToOutboxDead(t *testing.T) {
+	bus := &confirmingBus{MemoryBus: messaging.NewMemoryBus(), err: messaging.ErrPublishNacked}
+	op, mock := newTestPublisher(t, bus)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, event_id.* FROM outbox WHERE published = false`).
+		WillReturnRows(sqlmock.NewRows(outboxColumns).AddRow(1, "event-1", "order-1", "OrderAccepted", []byte(`{}`), DefaultMaxRetries-1))
+	mock.ExpectExec(`INSERT INTO outbox_dead`).WithArgs(1, DefaultMaxRetries, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM outbox WHERE id = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectEmptyOutbox(mock)
+
+	if _, err := op.publishPendingEvents(context.Background()); err != nil {
+		t.Fatalf("publishPendingEvents: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
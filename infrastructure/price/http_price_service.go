@@ -0,0 +1,126 @@
+package price
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+// DefaultCacheTTL - how long a quoted price is reused for the same pair
+const DefaultCacheTTL = 2 * time.Second
+
+// ErrPairNotSupported is returned when the price feed does not quote the pair
+var ErrPairNotSupported = errors.New("currency pair not supported")
+
+// HTTPPriceService implements saga.PriceService on top of a REST price feed
+// GET {baseURL}/price?from=USDT&to=BTC → {"price": 100000.0}
+type HTTPPriceService struct {
+	baseURL string
+	client  *http.Client
+
+	mu    sync.Mutex
+	cache map[string]cachedPrice
+
+	// CacheTTL - quoted prices are reused for this long (0 disables caching)
+	CacheTTL time.Duration
+}
+
+// cachedPrice is a price together with its expiry
+type cachedPrice struct {
+	price     decimal.Decimal
+	expiresAt time.Time
+}
+
+// priceResponse is the price feed response body
+type priceResponse struct {
+	Price decimal.Decimal `json:"price"` // Number or decimal string
+}
+
+func NewHTTPPriceService(baseURL string) *HTTPPriceService {
+	return &HTTPPriceService{
+		baseURL:  baseURL,
+		client:   &http.Client{},
+		cache:    make(map[string]cachedPrice),
+		CacheTTL: DefaultCacheTTL,
+	}
+}
+
+// GetMarketPrice returns the price of 1 unit of `to` in `from`
+// The request is bounded by the caller's context deadline (saga PriceTimeout);
+// any feed failure is returned as an error so the saga can compensate
+func (s *HTTPPriceService) GetMarketPrice(ctx context.Context, from, to string) (decimal.Decimal, error) {
+	pair := from + "/" + to
+
+	if price, ok := s.cached(pair); ok {
+		return price, nil
+	}
+
+	query := url.Values{}
+	query.Set("from", from)
+	query.Set("to", to)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.baseURL+"/price?"+query.Encode(), nil)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to build price request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// Keep context errors visible: the saga distinguishes price_timeout
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return decimal.Zero, fmt.Errorf("price feed request for %s: %w", pair, ctxErr)
+		}
+		return decimal.Zero, fmt.Errorf("price feed request for %s failed: %w", pair, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return decimal.Zero, fmt.Errorf("%w: %s", ErrPairNotSupported, pair)
+	case resp.StatusCode != http.StatusOK:
+		return decimal.Zero, fmt.Errorf("price feed returned %d for %s", resp.StatusCode, pair)
+	}
+
+	var body priceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to decode price for %s: %w", pair, err)
+	}
+
+	if !body.Price.IsPositive() {
+		return decimal.Zero, fmt.Errorf("price feed returned invalid price %v for %s", body.Price, pair)
+	}
+
+	s.store(pair, body.Price)
+	return body.Price, nil
+}
+
+// cached returns a non-expired cached price for the pair
+func (s *HTTPPriceService) cached(pair string) (decimal.Decimal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[pair]
+	if !ok || time.Now().After(entry.expiresAt) {
+		return decimal.Zero, false
+	}
+	return entry.price, true
+}
+
+// store caches a freshly quoted price
+func (s *HTTPPriceService) store(pair string, price decimal.Decimal) {
+	if s.CacheTTL <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cache[pair] = cachedPrice{price: price, expiresAt: time.Now().Add(s.CacheTTL)}
+}
@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOrdersPerMinute - лимит создания ордеров на пользователя по умолчанию
+const DefaultOrdersPerMinute = 60
+
+// Limit - token bucket: Burst токенов, пополняется на Burst за Per
+type Limit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// PerMinute возвращает лимит n запросов в минуту
+func PerMinute(n int) Limit {
+	return Limit{Burst: n, Per: time.Minute}
+}
+
+// Store хранит состояние bucket'ов
+// Take забирает один токен из bucket'а key; если токена нет - возвращает, через сколько он появится
+// Реализации: MemoryStore (один процесс), позже Redis (общий лимит для всех реплик)
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// Limiter ограничивает частоту запросов по пользователю
+type Limiter struct {
+	store   Store
+	Default Limit
+
+	mu        sync.RWMutex
+	overrides map[string]Limit // Повышенные лимиты (например, для маркет-мейкеров)
+}
+
+// NewLimiter создаёт лимитер с общим лимитом для всех пользователей
+func NewLimiter(store Store, defaultLimit Limit) *Limiter {
+	return &Limiter{
+		store:     store,
+		Default:   defaultLimit,
+		overrides: make(map[string]Limit),
+	}
+}
+
+// SetLimit задаёт персональный лимит пользователя
+func (l *Limiter) SetLimit(userID string, limit Limit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.overrides[userID] = limit
+}
+
+// LimitFor возвращает лимит пользователя (персональный или общий)
+func (l *Limiter) LimitFor(userID string) Limit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if limit, ok := l.overrides[userID]; ok {
+		return limit
+	}
+	return l.Default
+}
+
+// Allow забирает токен пользователя
+func (l *Limiter) Allow(ctx context.Context, userID string) (bool, time.Duration, error) {
+	return l.store.Take(ctx, userID, l.LimitFor(userID), time.Now())
+}
+
+// ParseOverrides parses per-minute overrides "user-1:600,user-2:1000"
+func ParseOverrides(s string) (map[string]Limit, error) {
+	overrides := make(map[string]Limit)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		userID, value, ok := strings.Cut(entry, ":")
+		n, err := strconv.Atoi(value)
+		if !ok || userID == "" || err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid rate limit override %q: want user_id:orders_per_minute", entry)
+		}
+		overrides[userID] = PerMinute(n)
+	}
+	return overrides, nil
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// idleTTL - bucket без запросов дольше этого удаляется (он уже полон)
+const idleTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore - Store в памяти процесса
+// Лимит считается на реплику: при нескольких инстансах нужен общий Store
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	if limit.Burst <= 0 || limit.Per <= 0 {
+		return false, limit.Per, nil
+	}
+
+	capacity := float64(limit.Burst)
+	perToken := limit.Per / time.Duration(limit.Burst)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	// Пополняем bucket за прошедшее время
+	elapsed := now.Sub(b.last)
+	if elapsed > 0 {
+		b.tokens += float64(elapsed) / float64(perToken)
+		if b.tokens > capacity {
+			b.tokens = capacity
+		}
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		retryAfter := time.Duration((1 - b.tokens) * float64(perToken))
+		return false, retryAfter, nil
+	}
+
+	b.tokens--
+	return true, 0, nil
+}
+
+// sweep удаляет давно неактивные bucket'ы, чтобы map не росла бесконечно
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > idleTTL {
+			delete(s.buckets, key)
+		}
+	}
+}
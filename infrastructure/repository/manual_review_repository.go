@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+// ManualReview is an order that can be neither completed nor compensated automatically
+// The swap has already moved funds on-chain, so an operator must resolve it
+type ManualReview struct {
+	OrderID         string          `json:"order_id"`
+	PositionID      string          `json:"position_id,omitempty"`
+	Reason          string          `json:"reason"`
+	Attempts        int             `json:"attempts"`
+	TransactionHash string          `json:"transaction_hash"`
+	FromAmount      decimal.Decimal `json:"from_amount"`
+	ToAmount        decimal.Decimal `json:"to_amount"`
+	ExecutedPrice   decimal.Decimal `json:"executed_price"`
+	Fees            decimal.Decimal `json:"fees"`
+	CreatedAt       time.Time       `json:"created_at"`
+	ResolvedAt      *time.Time      `json:"resolved_at,omitempty"`
+}
+
+// ManualReviewStore - the operator queue of orders to resolve by hand
+// ManualReviewRepository keeps it in Postgres, MemoryManualReviewStore in process memory
+type ManualReviewStore interface {
+	Add(ctx context.Context, m ManualReview) error
+	ListPending(ctx context.Context) ([]ManualReview, error)
+}
+
+var _ ManualReviewStore = (*ManualReviewRepository)(nil)
+
+// ManualReviewRepository stores the operator queue (manual_review table)
+type ManualReviewRepository struct {
+	db *sql.DB
+}
+
+func NewManualReviewRepository(db *sql.DB) *ManualReviewRepository {
+	return &ManualReviewRepository{db: db}
+}
+
+// Add puts an order into the queue (one entry per order, repeated adds are ignored)
+func (r *ManualReviewRepository) Add(ctx context.Context, m ManualReview) error {
+	query := `
+		INSERT INTO manual_review (
+			order_id, position_id, reason, attempts, transaction_hash,
+			from_amount, to_amount, executed_price, fees, created_at
+		) VALUES ($1, NULLIF($2, '')::UUID, $3, $4, $5, $6, $7, $8, $9, NOW())
+		ON CONFLICT (order_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		m.OrderID, m.PositionID, m.Reason, m.Attempts, m.TransactionHash,
+		m.FromAmount, m.ToAmount, m.ExecutedPrice, m.Fees,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add manual review: %w", err)
+	}
+
+	return nil
+}
+
+// ListPending returns unresolved entries, oldest first
+func (r *ManualReviewRepository) ListPending(ctx context.Context) ([]ManualReview, error) {
+	query := `
+		SELECT order_id, COALESCE(position_id::TEXT, ''), reason, attempts, COALESCE(transaction_hash, ''),
+		       COALESCE(from_amount, 0), COALESCE(to_amount, 0), COALESCE(executed_price, 0), COALESCE(fees, 0),
+		       created_at, resolved_at
+		FROM manual_review
+		WHERE resolved_at IS NULL
+		ORDER BY created_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query manual review: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []ManualReview{}
+	for rows.Next() {
+		var m ManualReview
+		err := rows.Scan(
+			&m.OrderID, &m.PositionID, &m.Reason, &m.Attempts, &m.TransactionHash,
+			&m.FromAmount, &m.ToAmount, &m.ExecutedPrice, &m.Fees,
+			&m.CreatedAt, &m.ResolvedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan manual review: %w", err)
+		}
+		reviews = append(reviews, m)
+	}
+
+	return reviews, rows.Err()
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemorySagaStore is a SagaStore in process memory (test harnesses, local saga runs)
+// Same semantics as SagaRepository: attempts reset when the step changes,
+// an empty positionID keeps the stored one
+type MemorySagaStore struct {
+	mu       sync.Mutex
+	sagas    map[string]SagaInstance
+	attempts map[string]int
+}
+
+var _ SagaStore = (*MemorySagaStore)(nil)
+
+func NewMemorySagaStore() *MemorySagaStore {
+	return &MemorySagaStore{
+		sagas:    make(map[string]SagaInstance),
+		attempts: make(map[string]int),
+	}
+}
+
+// SaveStep records the step the saga entered for an order
+func (s *MemorySagaStore) SaveStep(ctx context.Context, orderID, step, positionID, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	saga, ok := s.sagas[orderID]
+	if !ok {
+		saga = SagaInstance{OrderID: orderID, StartedAt: now}
+	}
+	if saga.CurrentStep != step {
+		s.attempts[orderID] = 0
+	}
+	saga.CurrentStep = step
+	if positionID != "" {
+		saga.PositionID = positionID
+	}
+	saga.Status = status
+	saga.UpdatedAt = now
+	s.sagas[orderID] = saga
+	return nil
+}
+
+// IncrementAttempts counts a failed attempt of the current step and returns the total
+func (s *MemorySagaStore) IncrementAttempts(ctx context.Context, orderID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saga, ok := s.sagas[orderID]
+	if !ok {
+		return 0, ErrSagaNotFound
+	}
+	s.attempts[orderID]++
+	saga.UpdatedAt = time.Now()
+	s.sagas[orderID] = saga
+	return s.attempts[orderID], nil
+}
+
+// Get returns the saga instance for an order
+func (s *MemorySagaStore) Get(ctx context.Context, orderID string) (*SagaInstance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saga, ok := s.sagas[orderID]
+	if !ok {
+		return nil, ErrSagaNotFound
+	}
+	return &saga, nil
+}
+
+// FindStuck returns running sagas idle for longer than stuckAfter, oldest first
+// Limit orders resting in the order book are not stuck
+func (s *MemorySagaStore) FindStuck(ctx context.Context, stuckAfter time.Duration) ([]SagaInstance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-stuckAfter)
+	var sagas []SagaInstance
+	for _, saga := range s.sagas {
+		if saga.Status == SagaStatusRunning && saga.UpdatedAt.Before(cutoff) && saga.CurrentStep != SagaStepInOrderBook {
+			sagas = append(sagas, saga)
+		}
+	}
+	sort.Slice(sagas, func(i, j int) bool { return sagas[i].UpdatedAt.Before(sagas[j].UpdatedAt) })
+	return sagas, nil
+}
+
+// MemoryManualReviewStore is a ManualReviewStore in process memory (test harnesses, local saga runs)
+type MemoryManualReviewStore struct {
+	mu      sync.Mutex
+	reviews []ManualReview
+}
+
+var _ ManualReviewStore = (*MemoryManualReviewStore)(nil)
+
+func NewMemoryManualReviewStore() *MemoryManualReviewStore {
+	return &MemoryManualReviewStore{}
+}
+
+// Add puts an order into the queue (one entry per order, repeated adds are ignored)
+func (s *MemoryManualReviewStore) Add(ctx context.Context, m ManualReview) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.reviews {
+		if r.OrderID == m.OrderID {
+			return nil
+		}
+	}
+	m.CreatedAt = time.Now()
+	s.reviews = append(s.reviews, m)
+	return nil
+}
+
+// ListPending returns unresolved entries, oldest first
+func (s *MemoryManualReviewStore) ListPending(ctx context.Context) ([]ManualReview, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reviews := []ManualReview{}
+	for _, r := range s.reviews {
+		if r.ResolvedAt == nil {
+			reviews = append(reviews, r)
+		}
+	}
+	return reviews, nil
+}
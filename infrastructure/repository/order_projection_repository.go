@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOrderProjectionNotFound is returned when the order has no projection row yet
+var ErrOrderProjectionNotFound = errors.New("order projection not found")
+
+// OrderProjection is one row of the per-user order list
+type OrderProjection struct {
+	OrderID       string    `json:"order_id"`
+	UserID        string    `json:"user_id"`
+	Status        string    `json:"status"`
+	OrderType     string    `json:"order_type"`
+	FromAmount    float64   `json:"from_amount"`
+	FromCurrency  string    `json:"from_currency"`
+	ToCurrency    string    `json:"to_currency"`
+	ClientOrderID string    `json:"client_order_id,omitempty"` // metadata.client_order_id of the create request
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OrderProjectionRepository stores the order_projection read model
+// Writes are version-guarded: an event older than the stored row is ignored
+type OrderProjectionRepository struct {
+	db *sql.DB
+}
+
+func NewOrderProjectionRepository(db *sql.DB) *OrderProjectionRepository {
+	return &OrderProjectionRepository{db: db}
+}
+
+// Insert creates the projection row for a newly accepted order
+func (r *OrderProjectionRepository) Insert(ctx context.Context, p OrderProjection) error {
+	query := `
+		INSERT INTO order_projection (
+			order_id, user_id, status, order_type, from_amount,
+			from_currency, to_currency, client_order_id, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $10, $11)
+		ON CONFLICT (order_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		p.OrderID, p.UserID, p.Status, p.OrderType, p.FromAmount,
+		p.FromCurrency, p.ToCurrency, p.ClientOrderID, p.Version, p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert order projection: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatus applies a status change if the event is newer than the stored row
+// Returns ErrOrderProjectionNotFound when OrderAccepted has not been projected yet
+func (r *OrderProjectionRepository) UpdateStatus(ctx context.Context, orderID, status string, version int, updatedAt time.Time) error {
+	query := `
+		UPDATE order_projection
+		SET status = $2, version = $3, updated_at = $4
+		WHERE order_id = $1 AND version < $3
+	`
+
+	res, err := r.db.ExecContext(ctx, query, orderID, status, version, updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update order projection: %w", err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update order projection: %w", err)
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	// Nothing updated: either a stale event (fine) or the row does not exist yet
+	var exists bool
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM order_projection WHERE order_id = $1)`, orderID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check order projection: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrOrderProjectionNotFound, orderID)
+	}
+
+	return nil
+}
+
+// ListByUser returns a user's orders, newest first
+// Empty status / clientOrderID return orders with any status / client order ID
+func (r *OrderProjectionRepository) ListByUser(ctx context.Context, userID, status, clientOrderID string, limit, offset int) ([]OrderProjection, error) {
+	query := `
+		SELECT order_id, user_id, status, order_type, from_amount,
+		       from_currency, to_currency, COALESCE(client_order_id, ''), version, created_at, updated_at
+		FROM order_projection
+		WHERE user_id = $1 AND ($2 = '' OR status = $2) AND ($3 = '' OR client_order_id = $3)
+		ORDER BY created_at DESC
+		LIMIT $4 OFFSET $5
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, status, clientOrderID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query order projection: %w", err)
+	}
+	defer rows.Close()
+
+	orders := make([]OrderProjection, 0)
+	for rows.Next() {
+		var p OrderProjection
+		err := rows.Scan(
+			&p.OrderID, &p.UserID, &p.Status, &p.OrderType, &p.FromAmount,
+			&p.FromCurrency, &p.ToCurrency, &p.ClientOrderID, &p.Version, &p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan order projection: %w", err)
+		}
+		orders = append(orders, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// Truncate removes all projection rows (before a full rebuild)
+func (r *OrderProjectionRepository) Truncate(ctx context.Context) error {
+	if _, err := r.db.ExecContext(ctx, `TRUNCATE order_projection`); err != nil {
+		return fmt.Errorf("failed to truncate order projection: %w", err)
+	}
+	return nil
+}
@@ -10,59 +10,32 @@ import (
 	"market_order/infrastructure/eventstore"
 )
 
+// ErrOrderNotFound is returned when the order has no events
+var ErrOrderNotFound = errors.New("order not found")
+
 type OrderRepository struct {
-	eventStore eventstore.EventStore
+	repo *Repository[*order.Order]
 }
 
 func NewOrderRepository(es eventstore.EventStore) *OrderRepository {
-	return &OrderRepository{eventStore: es}
+	return &OrderRepository{
+		repo: NewRepository(es, "Order", order.NewOrder, deserializeOrderEvent, ErrOrderNotFound),
+	}
 }
 
 // Get восстанавливает Order aggregate из Event Store
 func (r *OrderRepository) Get(ctx context.Context, orderID string) (*order.Order, error) {
-	// Загружаем события
-	events, err := r.eventStore.Load(ctx, orderID)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(events) == 0 {
-		return nil, errors.New("order not found")
-	}
-
-	// Создаём пустой агрегат
-	o := order.NewOrder()
-
-	// Восстанавливаем состояние, применяя события
-	for _, evt := range events {
-		domainEvent, err := deserializeOrderEvent(evt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to deserialize event: %w", err)
-		}
-
-		if err := o.When(domainEvent); err != nil {
-			return nil, fmt.Errorf("failed to apply event: %w", err)
-		}
-	}
+	return r.repo.Get(ctx, orderID)
+}
 
-	return o, nil
+// GetAtVersion восстанавливает Order aggregate на версии version
+func (r *OrderRepository) GetAtVersion(ctx context.Context, orderID string, version int) (*order.Order, error) {
+	return r.repo.GetAtVersion(ctx, orderID, version)
 }
 
 // Save сохраняет новые события
 func (r *OrderRepository) Save(ctx context.Context, o *order.Order) error {
-	if len(o.Changes) == 0 {
-		return nil // Нечего сохранять
-	}
-
-	// Сохраняем в Event Store
-	if err := r.eventStore.Save(ctx, o.Changes); err != nil {
-		return err
-	}
-
-	// Очищаем Changes после успешного сохранения
-	o.Changes = nil
-
-	return nil
+	return r.repo.Save(ctx, o)
 }
 
 // deserializeOrderEvent конвертирует сохранённое событие в доменное
@@ -83,6 +56,13 @@ func deserializeOrderEvent(evt eventstore.Event) (interface{}, error) {
 		}
 		return e, nil
 
+	case "QuoteRefreshed":
+		var e order.QuoteRefreshed
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
 	case "SwapExecuting":
 		var e order.SwapExecuting
 		if err := json.Unmarshal(evt.EventData, &e); err != nil {
@@ -111,6 +91,104 @@ func deserializeOrderEvent(evt eventstore.Event) (interface{}, error) {
 		}
 		return e, nil
 
+	case "OrderInitialized":
+		var e order.OrderInitialized
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitPriceSet":
+		var e order.LimitPriceSet
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderUpdated":
+		var e order.OrderUpdated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "PositionLinkedToOrder":
+		var e order.PositionLinkedToOrder
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderCancelled":
+		var e order.OrderCancelled
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "BalanceCheckPassed":
+		var e order.BalanceCheckPassed
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "BalanceCheckFailed":
+		var e order.BalanceCheckFailed
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderPlacedInBook":
+		var e order.OrderPlacedInBook
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderPartiallyFilled":
+		var e order.OrderPartiallyFilled
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitOrderExpired":
+		var e order.LimitOrderExpired
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderExpired":
+		var e order.OrderExpired
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "SwapRejectedSlippage":
+		var e order.SwapRejectedSlippage
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "SwapTimedOut":
+		var e order.SwapTimedOut
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrderNeedsManualReview":
+		var e order.OrderNeedsManualReview
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
 	default:
 		return nil, fmt.Errorf("unknown event type: %s", evt.EventType)
 	}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"market_order/domain/order"
+	"market_order/infrastructure/eventstore"
+	"market_order/pkg/decimal"
+	pkguuid "market_order/pkg/uuid"
+)
+
+func TestOrderRepositoryLoadsEveryEventType(t *testing.T) {
+	ctx := context.Background()
+	repo := NewOrderRepository(eventstore.NewMemoryEventStore())
+
+	o := order.NewOrder()
+	orderID := pkguuid.New()
+	err := errors.Join(
+		o.AcceptOrder(orderID, "user-1", decimal.MustParse("1000"), "USDT", "BTC", "limit", decimal.MustParse("50000"), 0, "", time.Time{}, nil),
+		o.InitializeOrder(),
+		o.SetLimitPrice(decimal.MustParse("49000")),
+		o.UpdateOrder(map[string]interface{}{"from_amount": "800"}),
+		o.CheckBalances(decimal.MustParse("5000")),
+		o.PlaceInOrderBook("book-1"),
+		o.StartSwapExecution("fill-key"),
+		o.PartiallyFill(decimal.MustParse("300"), decimal.MustParse("0.006"), decimal.MustParse("50000"), "0xfill"),
+	)
+	if err != nil {
+		t.Fatalf("commands: %v", err)
+	}
+	if err := repo.Save(ctx, o); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	loaded, err := repo.Get(ctx, orderID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	o.Changes, loaded.Changes = nil, nil
+	got, _ := json.Marshal(loaded)
+	want, _ := json.Marshal(o)
+	if string(got) != string(want) {
+		t.Errorf("reloaded order:\n%s\nwant:\n%s", got, want)
+	}
+
+	// GetAtVersion replays only up to the version: before the fill
+	atPlacement, err := repo.GetAtVersion(ctx, orderID, 6)
+	if err != nil {
+		t.Fatalf("GetAtVersion(6): %v", err)
+	}
+	if atPlacement.Version != 6 || atPlacement.Status != order.OrderStatusPending || !atPlacement.FilledAmount.IsZero() {
+		t.Errorf("order at version 6 = v%d %s filled %s, want v6 pending filled 0", atPlacement.Version, atPlacement.Status, atPlacement.FilledAmount)
+	}
+}
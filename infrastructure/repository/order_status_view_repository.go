@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrOrderStatusViewNotFound is returned when the order has no order_status_view row yet
+var ErrOrderStatusViewNotFound = errors.New("order status view not found")
+
+// OrderStatusView is the latest known state of one order
+type OrderStatusView struct {
+	OrderID       string    `json:"order_id"`
+	UserID        string    `json:"user_id"`
+	Status        string    `json:"status"`
+	OrderType     string    `json:"order_type"`
+	FromAmount    float64   `json:"from_amount"`
+	FromCurrency  string    `json:"from_currency"`
+	ToAmount      float64   `json:"to_amount"`
+	ToCurrency    string    `json:"to_currency"`
+	ExecutedPrice float64   `json:"executed_price"`
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// OrderStatusViewRepository stores the order_status_view read model
+// Upserts are version-guarded: a row is only replaced by a newer version of the order
+type OrderStatusViewRepository struct {
+	db *sql.DB
+}
+
+func NewOrderStatusViewRepository(db *sql.DB) *OrderStatusViewRepository {
+	return &OrderStatusViewRepository{db: db}
+}
+
+// Upsert inserts the row or replaces it if v is newer than the stored version
+// Repeating an upsert (redelivery) or applying an older one (out-of-order) is a no-op
+func (r *OrderStatusViewRepository) Upsert(ctx context.Context, v OrderStatusView) error {
+	query := `
+		INSERT INTO order_status_view (
+			order_id, user_id, status, order_type, from_amount, from_currency,
+			to_amount, to_currency, executed_price, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (order_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			from_amount = EXCLUDED.from_amount,
+			to_amount = EXCLUDED.to_amount,
+			executed_price = EXCLUDED.executed_price,
+			version = EXCLUDED.version,
+			updated_at = EXCLUDED.updated_at
+		WHERE order_status_view.version < EXCLUDED.version
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		v.OrderID, v.UserID, v.Status, v.OrderType, v.FromAmount, v.FromCurrency,
+		v.ToAmount, v.ToCurrency, v.ExecutedPrice, v.Version, v.CreatedAt, v.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert order status view: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the view row of an order
+func (r *OrderStatusViewRepository) Get(ctx context.Context, orderID string) (*OrderStatusView, error) {
+	query := `
+		SELECT order_id, user_id, status, order_type, from_amount, from_currency,
+		       to_amount, to_currency, executed_price, version, created_at, updated_at
+		FROM order_status_view
+		WHERE order_id = $1
+	`
+
+	var v OrderStatusView
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&v.OrderID, &v.UserID, &v.Status, &v.OrderType, &v.FromAmount, &v.FromCurrency,
+		&v.ToAmount, &v.ToCurrency, &v.ExecutedPrice, &v.Version, &v.CreatedAt, &v.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrOrderStatusViewNotFound, orderID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order status view: %w", err)
+	}
+
+	return &v, nil
+}
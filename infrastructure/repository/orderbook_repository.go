@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"market_order/domain/orderbook"
+	"market_order/infrastructure/eventstore"
+)
+
+// ErrOrderBookNotFound is returned when the order book has no events
+var ErrOrderBookNotFound = errors.New("order book not found")
+
+type OrderBookRepository struct {
+	repo *Repository[*orderbook.OrderBook]
+}
+
+func NewOrderBookRepository(es eventstore.EventStore) *OrderBookRepository {
+	return &OrderBookRepository{
+		repo: NewRepository(es, "OrderBook", orderbook.NewOrderBook, deserializeOrderBookEvent, ErrOrderBookNotFound),
+	}
+}
+
+// Get восстанавливает OrderBook aggregate из Event Store
+func (r *OrderBookRepository) Get(ctx context.Context, orderBookID string) (*orderbook.OrderBook, error) {
+	return r.repo.Get(ctx, orderBookID)
+}
+
+// Save сохраняет новые события
+func (r *OrderBookRepository) Save(ctx context.Context, ob *orderbook.OrderBook) error {
+	return r.repo.Save(ctx, ob)
+}
+
+// deserializeOrderBookEvent конвертирует сохранённое событие в доменное
+func deserializeOrderBookEvent(evt eventstore.Event) (interface{}, error) {
+	switch evt.EventType {
+	case "OrderBookCreated":
+		var e orderbook.OrderBookCreated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitOrderAdded":
+		var e orderbook.LimitOrderAdded
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "OrdersMatched":
+		var e orderbook.OrdersMatched
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "LimitOrderCancelled":
+		var e orderbook.LimitOrderCancelled
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	case "PriceUpdated":
+		var e orderbook.PriceUpdated
+		if err := json.Unmarshal(evt.EventData, &e); err != nil {
+			return nil, err
+		}
+		return e, nil
+
+	default:
+		return nil, fmt.Errorf("unknown event type: %s", evt.EventType)
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"market_order/pkg/decimal"
+)
+
+// ErrPositionProjectionNotFound is returned when the position has no projection row yet
+var ErrPositionProjectionNotFound = errors.New("position projection not found")
+
+// PositionProjection is one row of the per-user position list
+type PositionProjection struct {
+	PositionID      string    `json:"position_id"`
+	UserID          string    `json:"user_id"`
+	Currency        string    `json:"currency"` // Currency of Cost (the order's FromCurrency); "" for positions created before it was recorded
+	RemainingAmount float64   `json:"remaining_amount"`
+	TotalValue      float64   `json:"total_value"`
+	Cost            float64   `json:"cost"` // Amount of Currency spent on the open remainder
+	RealizedPnL     float64   `json:"realized_pnl"`
+	UnrealizedPnL   float64   `json:"unrealized_pnl"`
+	PnL             float64   `json:"pnl"`
+	Status          string    `json:"status"`
+	Version         int       `json:"version"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// PositionProjectionRepository stores the position_projection read model
+// Writes are version-guarded: an event older than the stored row is ignored
+type PositionProjectionRepository struct {
+	db *sql.DB
+}
+
+func NewPositionProjectionRepository(db *sql.DB) *PositionProjectionRepository {
+	return &PositionProjectionRepository{db: db}
+}
+
+// Insert creates the projection row for a newly created position
+func (r *PositionProjectionRepository) Insert(ctx context.Context, p PositionProjection) error {
+	query := `
+		INSERT INTO position_projection (
+			position_id, user_id, currency, remaining_amount, total_value, realized_pnl,
+			unrealized_pnl, pnl, status, version, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (position_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		p.PositionID, p.UserID, p.Currency, p.RemainingAmount, p.TotalValue, p.RealizedPnL,
+		p.UnrealizedPnL, p.PnL, p.Status, p.Version, p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert position projection: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateAmounts applies the amounts and PnL of a PositionUpdated if it is newer than the stored row
+// Returns ErrPositionProjectionNotFound when PositionCreated has not been projected yet
+func (r *PositionProjectionRepository) UpdateAmounts(ctx context.Context, p PositionProjection) error {
+	query := `
+		UPDATE position_projection
+		SET remaining_amount = $2, total_value = $3, realized_pnl = $4,
+		    unrealized_pnl = $5, pnl = $6, version = $7, updated_at = $8, cost = $9
+		WHERE position_id = $1 AND version < $7
+	`
+
+	res, err := r.db.ExecContext(ctx, query,
+		p.PositionID, p.RemainingAmount, p.TotalValue, p.RealizedPnL,
+		p.UnrealizedPnL, p.PnL, p.Version, p.UpdatedAt, p.Cost,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update position projection: %w", err)
+	}
+
+	return r.checkApplied(ctx, res, p.PositionID)
+}
+
+// Close flips the row to "closed" if the event is newer than the stored row
+// closingPrice > 0 also realizes the remaining amount: realized_pnl becomes final, unrealized_pnl zero
+func (r *PositionProjectionRepository) Close(ctx context.Context, positionID string, closingPrice, realizedPnL float64, version int, closedAt time.Time) error {
+	query := `
+		UPDATE position_projection
+		SET status = 'closed',
+		    total_value = CASE WHEN $2 > 0 THEN remaining_amount * $2 ELSE total_value END,
+		    realized_pnl = CASE WHEN $2 > 0 THEN $3 ELSE realized_pnl END,
+		    unrealized_pnl = CASE WHEN $2 > 0 THEN 0 ELSE unrealized_pnl END,
+		    pnl = CASE WHEN $2 > 0 THEN $3 ELSE pnl END,
+		    version = $4, updated_at = $5
+		WHERE position_id = $1 AND version < $4
+	`
+
+	res, err := r.db.ExecContext(ctx, query, positionID, closingPrice, realizedPnL, version, closedAt)
+	if err != nil {
+		return fmt.Errorf("failed to close position projection: %w", err)
+	}
+
+	return r.checkApplied(ctx, res, positionID)
+}
+
+// OpenExposure returns how much currency the user has spent on open positions
+// Sums cost, which is in the position's currency (total_value is in the bought one).
+// Positions without a recorded currency are not counted
+func (r *PositionProjectionRepository) OpenExposure(ctx context.Context, userID, currency string) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(cost), 0)
+		FROM position_projection
+		WHERE user_id = $1 AND currency = $2 AND status = 'open'
+	`
+
+	var exposure decimal.Decimal
+	if err := r.db.QueryRowContext(ctx, query, userID, currency).Scan(&exposure); err != nil {
+		return decimal.Zero, fmt.Errorf("failed to query open exposure: %w", err)
+	}
+	return exposure, nil
+}
+
+// checkApplied tells a stale event (fine) from a row that does not exist yet
+func (r *PositionProjectionRepository) checkApplied(ctx context.Context, res sql.Result, positionID string) error {
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update position projection: %w", err)
+	}
+	if affected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM position_projection WHERE position_id = $1)`, positionID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check position projection: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrPositionProjectionNotFound, positionID)
+	}
+
+	return nil
+}
+
+// ListByUser returns a user's positions, newest first
+// Empty status returns positions in any status
+func (r *PositionProjectionRepository) ListByUser(ctx context.Context, userID, status string, limit, offset int) ([]PositionProjection, error) {
+	query := `
+		SELECT position_id, user_id, currency, remaining_amount, total_value, realized_pnl,
+		       unrealized_pnl, pnl, status, version, created_at, updated_at
+		FROM position_projection
+		WHERE user_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, status, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query position projection: %w", err)
+	}
+	defer rows.Close()
+
+	positions := make([]PositionProjection, 0)
+	for rows.Next() {
+		var p PositionProjection
+		err := rows.Scan(
+			&p.PositionID, &p.UserID, &p.Currency, &p.RemainingAmount, &p.TotalValue, &p.RealizedPnL,
+			&p.UnrealizedPnL, &p.PnL, &p.Status, &p.Version, &p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan position projection: %w", err)
+		}
+		positions = append(positions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
@@ -10,51 +10,25 @@ import (
 	"market_order/infrastructure/eventstore"
 )
 
+// ErrPositionNotFound is returned when the position has no events
+var ErrPositionNotFound = errors.New("position not found")
+
 type PositionRepository struct {
-	eventStore eventstore.EventStore
+	repo *Repository[*position.Position]
 }
 
 func NewPositionRepository(es eventstore.EventStore) *PositionRepository {
-	return &PositionRepository{eventStore: es}
+	return &PositionRepository{
+		repo: NewRepository(es, "Position", position.NewPosition, deserializePositionEvent, ErrPositionNotFound),
+	}
 }
 
 func (r *PositionRepository) Get(ctx context.Context, positionID string) (*position.Position, error) {
-	events, err := r.eventStore.Load(ctx, positionID)
-	if err != nil {
-		return nil, err
-	}
-
-	if len(events) == 0 {
-		return nil, errors.New("position not found")
-	}
-
-	p := position.NewPosition()
-
-	for _, evt := range events {
-		domainEvent, err := deserializePositionEvent(evt)
-		if err != nil {
-			return nil, err
-		}
-
-		if err := p.When(domainEvent); err != nil {
-			return nil, err
-		}
-	}
-
-	return p, nil
+	return r.repo.Get(ctx, positionID)
 }
 
 func (r *PositionRepository) Save(ctx context.Context, p *position.Position) error {
-	if len(p.Changes) == 0 {
-		return nil
-	}
-
-	if err := r.eventStore.Save(ctx, p.Changes); err != nil {
-		return err
-	}
-
-	p.Changes = nil
-	return nil
+	return r.repo.Save(ctx, p)
 }
 
 func deserializePositionEvent(evt eventstore.Event) (interface{}, error) {
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"market_order/infrastructure/eventstore"
+)
+
+// Aggregate - event-sourced агрегат, который умеет Repository
+type Aggregate interface {
+	When(event interface{}) error
+	GetChanges() []interface{}
+	ClearChanges()
+}
+
+// Deserializer конвертирует сохранённое событие в доменное
+type Deserializer func(evt eventstore.Event) (interface{}, error)
+
+// Repository - общий репозиторий агрегата поверх Event Store
+// Get восстанавливает агрегат из событий, Save дописывает его Changes
+type Repository[T Aggregate] struct {
+	eventStore    eventstore.EventStore
+	aggregateType string
+	newAggr       func() T
+	deserialize   Deserializer
+	notFound      error
+}
+
+// NewRepository создаёт репозиторий для агрегата T
+// aggregateType - aggregate_type его событий: события другого типа под тем же ID не загружаются
+// newAggregate создаёт пустой агрегат, notFound возвращается, если у агрегата нет событий
+// (вместе с eventstore.ErrAggregateNotFound - errors.Is срабатывает для обоих)
+func NewRepository[T Aggregate](es eventstore.EventStore, aggregateType string, newAggregate func() T, deserialize Deserializer, notFound error) *Repository[T] {
+	return &Repository[T]{
+		eventStore:    es,
+		aggregateType: aggregateType,
+		newAggr:       newAggregate,
+		deserialize:   deserialize,
+		notFound:      notFound,
+	}
+}
+
+// Get восстанавливает агрегат из Event Store
+func (r *Repository[T]) Get(ctx context.Context, aggregateID string) (T, error) {
+	var zero T
+
+	// Загружаем события (только своего типа)
+	events, err := r.eventStore.LoadByAggregateType(ctx, aggregateID, r.aggregateType)
+	if errors.Is(err, eventstore.ErrAggregateNotFound) {
+		return zero, fmt.Errorf("%w: %w", r.notFound, err)
+	}
+	if err != nil {
+		return zero, fmt.Errorf("failed to load events: %w", err)
+	}
+
+	return r.replay(events)
+}
+
+// GetAtVersion восстанавливает агрегат таким, каким он был на версии version
+// (состояние на момент события, а не последнее)
+func (r *Repository[T]) GetAtVersion(ctx context.Context, aggregateID string, version int) (T, error) {
+	var zero T
+
+	events, err := r.eventStore.LoadRangeByAggregateType(ctx, aggregateID, r.aggregateType, 1, version)
+	if err != nil {
+		return zero, fmt.Errorf("failed to load events: %w", err)
+	}
+	if len(events) == 0 {
+		return zero, fmt.Errorf("%w: %w: %s", r.notFound, eventstore.ErrAggregateNotFound, aggregateID)
+	}
+
+	return r.replay(events)
+}
+
+// replay применяет события к новому агрегату
+func (r *Repository[T]) replay(events []eventstore.Event) (T, error) {
+	var zero T
+
+	// Восстанавливаем состояние, применяя события
+	aggr := r.newAggr()
+	for _, evt := range events {
+		domainEvent, err := r.deserialize(evt)
+		if err != nil {
+			return zero, fmt.Errorf("failed to deserialize event: %w", err)
+		}
+
+		if err := aggr.When(domainEvent); err != nil {
+			return zero, fmt.Errorf("failed to apply event: %w", err)
+		}
+	}
+
+	return aggr, nil
+}
+
+// Save сохраняет новые события
+func (r *Repository[T]) Save(ctx context.Context, aggr T) error {
+	changes := aggr.GetChanges()
+	if len(changes) == 0 {
+		return nil // Нечего сохранять
+	}
+
+	if err := r.eventStore.Save(ctx, changes); err != nil {
+		return err
+	}
+
+	// Очищаем Changes после успешного сохранения
+	aggr.ClearChanges()
+	return nil
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Saga steps (current_step in saga_instances)
+const (
+	SagaStepCheckingBalance  = "checking_balance"
+	SagaStepPricing          = "pricing"
+	SagaStepCreatingPosition = "creating_position"
+	SagaStepExecutingSwap    = "executing_swap"
+	SagaStepCompleting       = "completing"
+	SagaStepInOrderBook      = "in_order_book" // Limit order resting in the book
+	SagaStepDone             = "done"
+)
+
+// Saga statuses
+const (
+	SagaStatusRunning     = "running"
+	SagaStatusCompleted   = "completed"
+	SagaStatusFailed      = "failed"
+	SagaStatusNeedsReview = "needs_review"
+)
+
+// ErrSagaNotFound is returned when no saga instance exists for the order
+var ErrSagaNotFound = errors.New("saga instance not found")
+
+// SagaInstance is the persisted progress of an order saga
+type SagaInstance struct {
+	OrderID     string
+	CurrentStep string
+	PositionID  string
+	Status      string
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// SagaStore - persisted saga progress
+// SagaRepository keeps it in Postgres, MemorySagaStore in process memory
+type SagaStore interface {
+	SaveStep(ctx context.Context, orderID, step, positionID, status string) error
+	IncrementAttempts(ctx context.Context, orderID string) (int, error)
+	Get(ctx context.Context, orderID string) (*SagaInstance, error)
+	FindStuck(ctx context.Context, stuckAfter time.Duration) ([]SagaInstance, error)
+}
+
+var _ SagaStore = (*SagaRepository)(nil)
+
+// SagaRepository stores saga progress so in-flight orders survive a restart
+type SagaRepository struct {
+	db *sql.DB
+}
+
+func NewSagaRepository(db *sql.DB) *SagaRepository {
+	return &SagaRepository{db: db}
+}
+
+// SaveStep records the step the saga entered for an order
+// An empty positionID keeps the previously stored one
+func (r *SagaRepository) SaveStep(ctx context.Context, orderID, step, positionID, status string) error {
+	query := `
+		INSERT INTO saga_instances (order_id, current_step, position_id, status, started_at, updated_at)
+		VALUES ($1, $2, NULLIF($3, '')::UUID, $4, NOW(), NOW())
+		ON CONFLICT (order_id) DO UPDATE SET
+			attempts     = CASE WHEN saga_instances.current_step = EXCLUDED.current_step
+			                    THEN saga_instances.attempts ELSE 0 END,
+			current_step = EXCLUDED.current_step,
+			position_id  = COALESCE(EXCLUDED.position_id, saga_instances.position_id),
+			status       = EXCLUDED.status,
+			updated_at   = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, orderID, step, positionID, status)
+	if err != nil {
+		return fmt.Errorf("failed to save saga step: %w", err)
+	}
+
+	return nil
+}
+
+// IncrementAttempts counts a failed attempt of the current step and returns the total
+// The counter is reset by SaveStep when the saga moves to another step
+func (r *SagaRepository) IncrementAttempts(ctx context.Context, orderID string) (int, error) {
+	query := `
+		UPDATE saga_instances
+		SET attempts = attempts + 1, updated_at = NOW()
+		WHERE order_id = $1
+		RETURNING attempts
+	`
+
+	var attempts int
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(&attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrSagaNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to increment saga attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// Get returns the saga instance for an order
+func (r *SagaRepository) Get(ctx context.Context, orderID string) (*SagaInstance, error) {
+	query := `
+		SELECT order_id, current_step, COALESCE(position_id::TEXT, ''), status, started_at, updated_at
+		FROM saga_instances
+		WHERE order_id = $1
+	`
+
+	var s SagaInstance
+	err := r.db.QueryRowContext(ctx, query, orderID).Scan(
+		&s.OrderID, &s.CurrentStep, &s.PositionID, &s.Status, &s.StartedAt, &s.UpdatedAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrSagaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saga instance: %w", err)
+	}
+
+	return &s, nil
+}
+
+// FindStuck returns running sagas that have not advanced for longer than stuckAfter
+// Limit orders resting in the order book are waiting for a match, not stuck
+func (r *SagaRepository) FindStuck(ctx context.Context, stuckAfter time.Duration) ([]SagaInstance, error) {
+	query := `
+		SELECT order_id, current_step, COALESCE(position_id::TEXT, ''), status, started_at, updated_at
+		FROM saga_instances
+		WHERE status = $1 AND updated_at < $2 AND current_step <> $3
+		ORDER BY updated_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, SagaStatusRunning, time.Now().Add(-stuckAfter), SagaStepInOrderBook)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stuck sagas: %w", err)
+	}
+	defer rows.Close()
+
+	var sagas []SagaInstance
+	for rows.Next() {
+		var s SagaInstance
+		err := rows.Scan(&s.OrderID, &s.CurrentStep, &s.PositionID, &s.Status, &s.StartedAt, &s.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan saga instance: %w", err)
+		}
+		sagas = append(sagas, s)
+	}
+
+	return sagas, rows.Err()
+}
@@ -0,0 +1,127 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"market_order/pkg/metrics"
+)
+
+// Defaults for a breaker in front of a remote dependency
+const (
+	DefaultFailureThreshold = 5
+	DefaultCooldown         = 30 * time.Second
+)
+
+// ErrOpen is reported by Healthy while calls are short-circuited
+var ErrOpen = errors.New("circuit breaker open")
+
+// State of a breaker
+type State string
+
+const (
+	StateClosed   State = "closed"    // Calls pass; consecutive failures are counted
+	StateOpen     State = "open"      // Calls are short-circuited until Cooldown passes
+	StateHalfOpen State = "half-open" // One trial call decides between closed and open
+)
+
+// stateValues - circuit_breaker_state metric values
+var stateValues = map[State]float64{StateClosed: 0, StateHalfOpen: 1, StateOpen: 2}
+
+// Breaker stops calling a failing dependency
+//
+// FailureThreshold consecutive failures open it. After Cooldown one trial call is
+// allowed (half-open): a success closes the breaker, a failure opens it again.
+// A trial without a reported result (e.g. the caller skipped the call) is replaced
+// by a new one after another Cooldown
+type Breaker struct {
+	name string
+
+	// FailureThreshold - consecutive failures that open the breaker
+	FailureThreshold int
+	// Cooldown - how long the breaker stays open before a trial call
+	Cooldown time.Duration
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time // Opened, or the last trial started (half-open)
+}
+
+// New creates a closed breaker; name labels the circuit_breaker_state metric
+func New(name string) *Breaker {
+	b := &Breaker{
+		name:             name,
+		FailureThreshold: DefaultFailureThreshold,
+		Cooldown:         DefaultCooldown,
+		state:            StateClosed,
+	}
+	metrics.CircuitBreakerState.Set(stateValues[StateClosed], name)
+	return b
+}
+
+// Allow reports whether a call may go through
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateClosed {
+		return true
+	}
+	if time.Since(b.openedAt) < b.Cooldown {
+		return false
+	}
+
+	// Cooldown over: let one trial call through
+	b.setState(StateHalfOpen)
+	b.openedAt = time.Now()
+	return true
+}
+
+// Success records a successful call: the breaker closes
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.setState(StateClosed)
+}
+
+// Failure records a failed call: a failed trial or FailureThreshold failures open the breaker
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.FailureThreshold {
+		b.setState(StateOpen)
+		b.openedAt = time.Now()
+	}
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// Healthy returns ErrOpen while calls are short-circuited (readiness checks)
+// An open breaker past its Cooldown counts as healthy: the trial call needs traffic
+func (b *Breaker) Healthy() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen && time.Since(b.openedAt) < b.Cooldown {
+		return ErrOpen
+	}
+	return nil
+}
+
+// setState updates the state and its metric; called with mu held
+func (b *Breaker) setState(state State) {
+	b.state = state
+	metrics.CircuitBreakerState.Set(stateValues[state], b.name)
+}
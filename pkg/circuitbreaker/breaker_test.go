@@ -0,0 +1,57 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakerOpensAndRecovers(t *testing.T) {
+	b := New("test")
+	b.FailureThreshold = 3
+	b.Cooldown = 20 * time.Millisecond
+
+	// A success resets the consecutive failure count
+	b.Failure()
+	b.Failure()
+	b.Success()
+	b.Failure()
+	b.Failure()
+	if b.State() != StateClosed || !b.Allow() {
+		t.Fatalf("state = %s after non-consecutive failures, want closed", b.State())
+	}
+
+	b.Failure()
+	if b.State() != StateOpen || b.Allow() {
+		t.Fatalf("state = %s after %d failures, want open and short-circuiting", b.State(), b.FailureThreshold)
+	}
+	if err := b.Healthy(); !errors.Is(err, ErrOpen) {
+		t.Errorf("Healthy() = %v, want ErrOpen", err)
+	}
+
+	// After the cooldown one trial call passes; it fails and the breaker opens again
+	time.Sleep(b.Cooldown)
+	if err := b.Healthy(); err != nil {
+		t.Errorf("Healthy() after cooldown = %v, want nil", err)
+	}
+	if !b.Allow() || b.State() != StateHalfOpen {
+		t.Fatalf("trial call: state = %s, want half-open", b.State())
+	}
+	if b.Allow() {
+		t.Error("second call during the trial: want short-circuited")
+	}
+	b.Failure()
+	if b.State() != StateOpen {
+		t.Fatalf("state after failed trial = %s, want open", b.State())
+	}
+
+	// A successful trial closes it
+	time.Sleep(b.Cooldown)
+	if !b.Allow() {
+		t.Fatal("trial call after the second cooldown: want allowed")
+	}
+	b.Success()
+	if b.State() != StateClosed || !b.Allow() {
+		t.Errorf("state after successful trial = %s, want closed", b.State())
+	}
+}
@@ -0,0 +1,300 @@
+package decimal
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Scale - digits after the decimal point (wei precision: 18 covers every supported currency)
+const Scale = 18
+
+var scaleFactor = new(big.Int).Exp(big.NewInt(10), big.NewInt(Scale), nil)
+
+// Zero is the zero Decimal (same as the zero value)
+var Zero = Decimal{}
+
+// Decimal is a fixed-point number for amounts and prices
+//
+// Addition and subtraction are exact; Mul and Div round half away from zero
+// to Scale digits, so 0.1 + 0.2 == 0.3 and sums of fills match the order amount.
+// The zero value is 0. Values are immutable: operations return a new Decimal.
+//
+// JSON: marshalled as a string ("0.3"); numbers are accepted when unmarshalling,
+// so events and snapshots written with float64 amounts still load
+type Decimal struct {
+	units *big.Int // value * 10^Scale; nil is 0
+}
+
+// NewFromInt returns i as a Decimal
+func NewFromInt(i int64) Decimal {
+	return Decimal{units: new(big.Int).Mul(big.NewInt(i), scaleFactor)}
+}
+
+// NewFromFloat converts f via its shortest decimal representation: 0.1 becomes exactly 0.1
+// Boundary conversion for float64 sources (price feeds, balance services); NaN and ±Inf give Zero
+func NewFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Zero
+	}
+	d, err := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	if err != nil {
+		return Zero
+	}
+	return d
+}
+
+// MaxLength - longest string Parse accepts; bounds the allocation of untrusted input
+const MaxLength = 64
+
+// Parse parses a plain decimal string "[-]digits[.digits]" ("12.5", "-0.001")
+// Fractional digits beyond Scale are rounded half away from zero; exponents,
+// fractions like "1/3" and strings longer than MaxLength are rejected
+func Parse(s string) (Decimal, error) {
+	str := strings.TrimSpace(s)
+	if len(str) > MaxLength {
+		return Zero, fmt.Errorf("invalid decimal: longer than %d characters", MaxLength)
+	}
+
+	digits, negative := strings.CutPrefix(str, "-")
+	intPart, fracPart, hasPoint := strings.Cut(digits, ".")
+	if !isDigits(intPart) || (hasPoint && !isDigits(fracPart)) {
+		return Zero, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	roundUp := false
+	if len(fracPart) > Scale {
+		roundUp = fracPart[Scale] >= '5'
+		fracPart = fracPart[:Scale]
+	}
+
+	units, _ := new(big.Int).SetString(intPart+fracPart+strings.Repeat("0", Scale-len(fracPart)), 10)
+	if roundUp {
+		units.Add(units, big.NewInt(1))
+	}
+	if negative {
+		units.Neg(units)
+	}
+	return Decimal{units: units}, nil
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// MustParse is Parse that panics on error (constants)
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// Min returns the smaller of a and b
+func Min(a, b Decimal) Decimal {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func (d Decimal) value() *big.Int {
+	if d.units == nil {
+		return new(big.Int)
+	}
+	return d.units
+}
+
+// Add returns d + other
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{units: new(big.Int).Add(d.value(), other.value())}
+}
+
+// Sub returns d - other
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{units: new(big.Int).Sub(d.value(), other.value())}
+}
+
+// Mul returns d * other rounded to Scale digits
+func (d Decimal) Mul(other Decimal) Decimal {
+	product := new(big.Int).Mul(d.value(), other.value())
+	return Decimal{units: quoRound(product, scaleFactor)}
+}
+
+// Div returns d / other rounded to Scale digits; panics if other is zero
+func (d Decimal) Div(other Decimal) Decimal {
+	if other.IsZero() {
+		panic("decimal: division by zero")
+	}
+	num := new(big.Int).Mul(d.value(), scaleFactor)
+	return Decimal{units: quoRound(num, other.value())}
+}
+
+// Neg returns -d
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: new(big.Int).Neg(d.value())}
+}
+
+// Cmp compares d and other: -1 if d < other, 0 if equal, +1 if d > other
+func (d Decimal) Cmp(other Decimal) int {
+	return d.value().Cmp(other.value())
+}
+
+// Equal reports whether d == other
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+// LessThan reports whether d < other
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.Cmp(other) < 0
+}
+
+// GreaterThan reports whether d > other
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.Cmp(other) > 0
+}
+
+// Sign returns -1, 0 or +1
+func (d Decimal) Sign() int {
+	return d.value().Sign()
+}
+
+// IsZero reports whether d == 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// IsPositive reports whether d > 0
+func (d Decimal) IsPositive() bool {
+	return d.Sign() > 0
+}
+
+// Float64 returns the nearest float64 (metrics, read models); not for further money math
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.value(), scaleFactor).Float64()
+	return f
+}
+
+// String formats d without exponent and trailing zeros: "0.3", "-12", "0.000001"
+func (d Decimal) String() string {
+	units := d.value()
+	digits := new(big.Int).Abs(units).String()
+
+	sign := ""
+	if units.Sign() < 0 {
+		sign = "-"
+	}
+
+	if len(digits) <= Scale {
+		digits = strings.Repeat("0", Scale-len(digits)+1) + digits
+	}
+	intPart, fracPart := digits[:len(digits)-Scale], strings.TrimRight(digits[len(digits)-Scale:], "0")
+	if fracPart == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + fracPart
+}
+
+// MarshalJSON encodes d as a JSON string
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON accepts a JSON string, a JSON number (float64-era events) or null (Zero)
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Zero
+		return nil
+	}
+	quoted := strings.HasPrefix(s, `"`)
+	if quoted {
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := Parse(s)
+	if err != nil && !quoted {
+		// float64-era numbers may have an exponent ("1e-07"): read them as the float they were
+		f, floatErr := strconv.ParseFloat(s, 64)
+		if floatErr != nil {
+			return err
+		}
+		parsed, err = NewFromFloat(f), nil
+	}
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// Value stores d in a NUMERIC column
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// Scan reads d from a NUMERIC column
+func (d *Decimal) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*d = Zero
+		return nil
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	case int64:
+		*d = NewFromInt(v)
+		return nil
+	case float64:
+		*d = NewFromFloat(v)
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into decimal", src)
+}
+
+func (d *Decimal) scanString(s string) error {
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
+
+// quoRound returns num / den rounded half away from zero
+func quoRound(num, den *big.Int) *big.Int {
+	quo, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Sign() == 0 {
+		return quo
+	}
+
+	// Round away from zero when |rem| * 2 >= |den|
+	twiceRem := new(big.Int).Abs(rem)
+	twiceRem.Lsh(twiceRem, 1)
+	if twiceRem.Cmp(new(big.Int).Abs(den)) >= 0 {
+		if (num.Sign() < 0) != (den.Sign() < 0) {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
@@ -0,0 +1,134 @@
+package decimal
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "0", want: "0"},
+		{in: "12.5", want: "12.5"},
+		{in: "-0.001", want: "-0.001"},
+		{in: " 100.000 ", want: "100"},
+		{in: "007.10", want: "7.1"},
+		{in: "1234567890123456789.123456789", want: "1234567890123456789.123456789"},
+		{in: "0.000000000000000001", want: "0.000000000000000001"},
+		// Beyond Scale digits: rounded half away from zero
+		{in: "0.0000000000000000014", want: "0.000000000000000001"},
+		{in: "0.0000000000000000015", want: "0.000000000000000002"},
+		{in: "-0.0000000000000000015", want: "-0.000000000000000002"},
+		{in: "0.9999999999999999999", want: "1"},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %v", tt.in, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsNonPlainDecimals(t *testing.T) {
+	inputs := []string{
+		"", "-", ".", ".5", "5.", "+5", "--5", "1.2.3", "1,5", "abc", "0x10", " ", "1 000",
+		"1/3", "1e5", "1e100000", "1E-5", "NaN", "Inf",
+		strings.Repeat("9", MaxLength+1),
+	}
+
+	for _, in := range inputs {
+		if d, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %s, want error", in, d)
+		}
+	}
+}
+
+func TestArithmetic(t *testing.T) {
+	a, b := MustParse("0.1"), MustParse("0.2")
+	if sum := a.Add(b); !sum.Equal(MustParse("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, want 0.3", sum)
+	}
+	if diff := a.Sub(b); diff.String() != "-0.1" {
+		t.Errorf("0.1 - 0.2 = %s, want -0.1", diff)
+	}
+	if product := MustParse("1.5").Mul(MustParse("-2.25")); product.String() != "-3.375" {
+		t.Errorf("1.5 * -2.25 = %s, want -3.375", product)
+	}
+
+	// Div rounds to Scale digits, half away from zero
+	if q := NewFromInt(1).Div(NewFromInt(3)); q.String() != "0.333333333333333333" {
+		t.Errorf("1 / 3 = %s, want 0.333333333333333333", q)
+	}
+	if q := NewFromInt(-2).Div(NewFromInt(3)); q.String() != "-0.666666666666666667" {
+		t.Errorf("-2 / 3 = %s, want -0.666666666666666667", q)
+	}
+	// Mul rounds too: 0.000000000000000005 * 0.5 is half a unit
+	if product := MustParse("0.000000000000000005").Mul(MustParse("0.5")); product.String() != "0.000000000000000003" {
+		t.Errorf("product = %s, want 0.000000000000000003", product)
+	}
+
+	if !Min(a, b).Equal(a) || !a.LessThan(b) || !b.GreaterThan(a) || a.Neg().Sign() != -1 || !Zero.IsZero() {
+		t.Error("comparisons of 0.1 and 0.2 are inconsistent")
+	}
+}
+
+func TestNewFromFloat(t *testing.T) {
+	tests := []struct {
+		in   float64
+		want string
+	}{
+		{in: 0.1, want: "0.1"},
+		{in: 1e-7, want: "0.0000001"},
+		{in: 50000, want: "50000"},
+		{in: -2.5, want: "-2.5"},
+	}
+
+	for _, tt := range tests {
+		if got := NewFromFloat(tt.in); got.String() != tt.want {
+			t.Errorf("NewFromFloat(%v) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	data, err := json.Marshal(MustParse("0.30"))
+	if err != nil || string(data) != `"0.3"` {
+		t.Fatalf("Marshal = %s, %v, want \"0.3\"", data, err)
+	}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `"12.5"`, want: "12.5"},
+		{in: `12.5`, want: "12.5"},
+		{in: `null`, want: "0"},
+		// float64-era events: Go encoded small amounts with an exponent
+		{in: `1e-07`, want: "0.0000001"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s) error: %v", tt.in, err)
+			continue
+		}
+		if d.String() != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, d, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"1e-07"`, `"1/3"`, `1e100000`, `"abc"`} {
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) = %s, want error", in, d)
+		}
+	}
+}
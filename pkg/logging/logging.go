@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Field keys shared by all components (queryable in log aggregators, e.g. order_id=X)
+const (
+	KeyOrderID   = "order_id"
+	KeyEventID   = "event_id"
+	KeyEventType = "event_type"
+	KeySagaStep  = "saga_step"
+	KeyError     = "error"
+)
+
+// New creates a JSON logger writing to stdout at the given level
+func New(level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+}
+
+// FromEnv creates a JSON logger with the level taken from LOG_LEVEL (default "info")
+func FromEnv() *slog.Logger {
+	return New(ParseLevel(os.Getenv("LOG_LEVEL")))
+}
+
+// ParseLevel converts "debug", "info", "warn" or "error" to a slog level
+// Unknown values fall back to info
+func ParseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// OrderID is the order_id field
+func OrderID(id string) slog.Attr {
+	return slog.String(KeyOrderID, id)
+}
+
+// EventID is the event_id field
+func EventID(id string) slog.Attr {
+	return slog.String(KeyEventID, id)
+}
+
+// EventType is the event_type field
+func EventType(eventType string) slog.Attr {
+	return slog.String(KeyEventType, eventType)
+}
+
+// SagaStep is the saga_step field
+func SagaStep(step string) slog.Attr {
+	return slog.String(KeySagaStep, step)
+}
+
+// Err is the error field
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets - histogram buckets in seconds (covers fast steps up to the slow swap)
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// collector is a metric family that can render itself in Prometheus text format
+type collector interface {
+	write(w io.Writer)
+}
+
+// registry holds all registered metric families
+var registry = struct {
+	mu         sync.Mutex
+	collectors []collector
+}{}
+
+func register(c collector) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.collectors = append(registry.collectors, c)
+}
+
+// Handler serves all registered metrics in Prometheus text exposition format (GET /metrics)
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		registry.mu.Lock()
+		collectors := append([]collector(nil), registry.collectors...)
+		registry.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, c := range collectors {
+			c.write(w)
+		}
+	})
+}
+
+// ===============================================
+// Counter
+// ===============================================
+
+// CounterVec is a monotonically increasing counter partitioned by labels
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // key: joined label values
+}
+
+// NewCounterVec creates and registers a counter
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	register(c)
+	return c
+}
+
+// Inc increments the counter for the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v (must be >= 0) to the counter for the given label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := labelKey(c.labels, labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key] += v
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, key, ""), formatValue(c.values[key]))
+	}
+}
+
+// ===============================================
+// Gauge
+// ===============================================
+
+// GaugeVec is a value that can go up and down (e.g. a state), partitioned by labels
+type GaugeVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // key: joined label values
+}
+
+// NewGaugeVec creates and registers a gauge
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	register(g)
+	return g
+}
+
+// Set sets the gauge for the given label values
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	key := labelKey(g.labels, labelValues)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.values[key] = v
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", g.name, g.help, g.name)
+	for _, key := range sortedKeys(g.values) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, key, ""), formatValue(g.values[key]))
+	}
+}
+
+// ===============================================
+// Histogram
+// ===============================================
+
+// HistogramVec observes value distributions (e.g. durations) partitioned by labels
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogram
+}
+
+// histogram is a single label combination of a HistogramVec
+type histogram struct {
+	counts []uint64 // cumulative per bucket
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec creates and registers a histogram (nil buckets = DefaultBuckets)
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+	register(h)
+	return h
+}
+
+// Observe records one value for the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := labelKey(h.labels, labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", h.name, h.help, h.name)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			le := `le="` + formatValue(upper) + `"`
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, le), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, key, `le="+Inf"`), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, key, ""), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, key, ""), s.count)
+	}
+}
+
+// ===============================================
+// Helpers
+// ===============================================
+
+// labelSeparator joins label values into a map key (cannot appear in label values we use)
+const labelSeparator = "\xff"
+
+func labelKey(labels, values []string) string {
+	if len(values) != len(labels) {
+		panic(fmt.Sprintf("metrics: expected %d label values, got %d", len(labels), len(values)))
+	}
+	return strings.Join(values, labelSeparator)
+}
+
+func formatLabels(labels []string, key, extra string) string {
+	pairs := make([]string, 0, len(labels)+1)
+	if len(labels) > 0 {
+		for i, value := range strings.Split(key, labelSeparator) {
+			pairs = append(pairs, labels[i]+"="+strconv.Quote(value))
+		}
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,22 @@
+package metrics
+
+// Service metrics (scraped from GET /metrics)
+var (
+	// SagaStepTotal counts saga step executions by outcome ("success", "error")
+	SagaStepTotal = NewCounterVec("saga_step_total", "Saga step executions by outcome.", "step", "outcome")
+
+	// SagaStepDuration measures saga step latency
+	SagaStepDuration = NewHistogramVec("saga_step_duration_seconds", "Saga step duration in seconds.", nil, "step")
+
+	// SagaCompensationsTotal counts compensations by type and reason
+	SagaCompensationsTotal = NewCounterVec("saga_compensations_total", "Saga compensations by type and reason.", "type", "reason")
+
+	// OutboxPublishedTotal counts outbox events confirmed by the broker
+	OutboxPublishedTotal = NewCounterVec("outbox_events_published_total", "Outbox events published to RabbitMQ.", "event_type")
+
+	// RabbitMQNacksTotal counts publishes rejected by the broker
+	RabbitMQNacksTotal = NewCounterVec("rabbitmq_publish_nacks_total", "Publishes nacked by RabbitMQ.", "event_type")
+
+	// CircuitBreakerState is the state of a circuit breaker: 0 closed, 1 half-open, 2 open
+	CircuitBreakerState = NewGaugeVec("circuit_breaker_state", "Circuit breaker state (0 closed, 1 half-open, 2 open).", "name")
+)
@@ -0,0 +1,126 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// MetadataKey - event metadata key carrying the W3C trace context between saga steps
+const MetadataKey = "traceparent"
+
+// spanContextKey is the context key of the active span context
+type spanContextKey struct{}
+
+// SpanContext identifies a span within a trace (W3C Trace Context)
+type SpanContext struct {
+	TraceID string // 32 hex chars
+	SpanID  string // 16 hex chars
+}
+
+// Span is a timed operation within a trace
+// Finished spans are exported to the log (one line per span, grep by trace_id)
+type Span struct {
+	Name     string
+	Context  SpanContext
+	ParentID string // "" for the root span
+	Start    time.Time
+
+	err error
+}
+
+// StartSpan starts a child span of the span in ctx, or a new trace if there is none
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	span := &Span{
+		Name:  name,
+		Start: time.Now(),
+	}
+
+	if parent, ok := FromContext(ctx); ok {
+		span.Context = SpanContext{TraceID: parent.TraceID, SpanID: randomHex(8)}
+		span.ParentID = parent.SpanID
+	} else {
+		span.Context = SpanContext{TraceID: randomHex(16), SpanID: randomHex(8)}
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, span.Context), span
+}
+
+// RecordError marks the span as failed
+func (s *Span) RecordError(err error) {
+	s.err = err
+}
+
+// End finishes the span and exports it
+func (s *Span) End() {
+	status := "ok"
+	if s.err != nil {
+		status = "error: " + s.err.Error()
+	}
+
+	log.Printf("🔭 span=%q trace_id=%s span_id=%s parent_id=%s duration=%s status=%s",
+		s.Name, s.Context.TraceID, s.Context.SpanID, s.ParentID, time.Since(s.Start), status)
+}
+
+// FromContext returns the active span context
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Traceparent formats the active span context as a W3C traceparent header ("" if none)
+func Traceparent(ctx context.Context) string {
+	sc, ok := FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", sc.TraceID, sc.SpanID)
+}
+
+// ContextWithTraceparent makes a remote span (from event metadata) the parent for new spans
+// Invalid or empty traceparent values leave ctx unchanged
+func ContextWithTraceparent(ctx context.Context, traceparent string) context.Context {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, SpanContext{TraceID: parts[1], SpanID: parts[2]})
+}
+
+// Inject adds the active trace context to event metadata (creates the map if nil)
+func Inject(ctx context.Context, metadata map[string]interface{}) map[string]interface{} {
+	traceparent := Traceparent(ctx)
+	if traceparent == "" {
+		return metadata
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata[MetadataKey] = traceparent
+	return metadata
+}
+
+// ExtractFromEvent continues the trace carried in a serialized event's metadata
+func ExtractFromEvent(ctx context.Context, eventData []byte) context.Context {
+	var evt struct {
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := json.Unmarshal(eventData, &evt); err != nil {
+		return ctx
+	}
+
+	traceparent, _ := evt.Metadata[MetadataKey].(string)
+	return ContextWithTraceparent(ctx, traceparent)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
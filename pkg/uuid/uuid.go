@@ -9,6 +9,12 @@ func New() string {
 	return uuid.New().String()
 }
 
+// NewFromName generates a deterministic UUID v5 for the given name
+// The same name always yields the same UUID (e.g. order book per trading pair)
+func NewFromName(name string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
 // NewUUID is an alias for New
 func NewUUID() string {
 	return New()
@@ -0,0 +1,405 @@
+// Package websocket is a minimal WebSocket (RFC 6455) implementation on top of net/http:
+// server handshake (Upgrade), client handshake (Dial), text/binary messages, ping/pong and close
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// acceptGUID is appended to Sec-WebSocket-Key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// MaxMessageSize - larger incoming messages are rejected
+const MaxMessageSize = 64 << 10
+
+// Opcodes
+const (
+	continuationFrame = 0x0
+	TextMessage       = 0x1
+	BinaryMessage     = 0x2
+	CloseMessage      = 0x8
+	PingMessage       = 0x9
+	PongMessage       = 0xA
+)
+
+// Close codes
+const (
+	CloseNormalClosure    = 1000
+	CloseGoingAway        = 1001
+	CloseProtocolError    = 1002
+	CloseMessageTooBig    = 1009
+	CloseInternalErr      = 1011
+	closeNoStatusReceived = 1005
+)
+
+// ErrClosed is returned by ReadMessage once the peer sent a close frame
+var ErrClosed = errors.New("websocket: connection closed by peer")
+
+// Conn is a WebSocket connection: server side from Upgrade, client side from Dial
+// Writes are safe for concurrent use; reads must happen from a single goroutine
+type Conn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+	client  bool // Client frames are masked, server frames are not
+
+	// PongHandler is called for every pong (e.g. to extend the read deadline)
+	PongHandler func()
+}
+
+// Upgrade performs the WebSocket handshake and takes over the HTTP connection
+// On failure an HTTP error has already been written to w
+func Upgrade(w http.ResponseWriter, r *http.Request) (*Conn, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket: method is not GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "WebSocket upgrade required", http.StatusUpgradeRequired)
+		return nil, errors.New("websocket: not an upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusBadRequest)
+		return nil, errors.New("websocket: unsupported version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		http.Error(w, "Missing Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("websocket: missing key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("websocket: response does not support hijacking")
+	}
+
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("websocket: hijack failed: %w", err)
+	}
+
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+
+	return &Conn{conn: netConn, br: brw.Reader}, nil
+}
+
+// Dial opens a client connection to a ws:// or wss:// URL
+// ctx bounds the TCP/TLS connect and the handshake
+func Dial(ctx context.Context, rawURL string) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("websocket: invalid url: %w", err)
+	}
+
+	port := u.Port()
+	var dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	switch u.Scheme {
+	case "ws":
+		if port == "" {
+			port = "80"
+		}
+		dial = (&net.Dialer{}).DialContext
+	case "wss":
+		if port == "" {
+			port = "443"
+		}
+		dial = (&tls.Dialer{Config: &tls.Config{ServerName: u.Hostname()}}).DialContext
+	default:
+		return nil, fmt.Errorf("websocket: unsupported scheme %q", u.Scheme)
+	}
+
+	netConn, err := dial(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return nil, fmt.Errorf("websocket: dial failed: %w", err)
+	}
+
+	conn, err := clientHandshake(ctx, netConn, u)
+	if err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func clientHandshake(ctx context.Context, netConn net.Conn, u *url.URL) (*Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		netConn.SetDeadline(deadline)
+		defer netConn.SetDeadline(time.Time{})
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("websocket: failed to generate key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	request := "GET " + u.RequestURI() + " HTTP/1.1\r\n" +
+		"Host: " + u.Host + "\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := netConn.Write([]byte(request)); err != nil {
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+
+	br := bufio.NewReader(netConn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodGet})
+	if err != nil {
+		return nil, fmt.Errorf("websocket: handshake failed: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		return nil, fmt.Errorf("websocket: handshake rejected: %s", resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		return nil, errors.New("websocket: invalid Sec-WebSocket-Accept")
+	}
+
+	return &Conn{conn: netConn, br: br, client: true}, nil
+}
+
+func acceptKey(key string) string {
+	h := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteMessage sends a text or binary message in a single frame
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	return c.writeFrame(messageType, data)
+}
+
+// WritePing sends a ping; the client answers with a pong
+func (c *Conn) WritePing(data []byte) error {
+	return c.writeFrame(PingMessage, data)
+}
+
+// WriteClose starts the closing handshake
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	copy(payload[2:], reason)
+	return c.writeFrame(CloseMessage, payload)
+}
+
+// SetReadDeadline / SetWriteDeadline set deadlines on the underlying connection
+func (c *Conn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *Conn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
+
+// Close closes the underlying connection without a closing handshake
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// writeFrame writes one frame: masked by clients, unmasked by servers
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := make([]byte, 2, 14)
+	header[0] = 0x80 | byte(opcode) // FIN + opcode
+
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		header[1] |= 0x80
+		header = append(header, mask[:]...)
+
+		masked := make([]byte, len(payload))
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// ReadMessage returns the next text or binary message
+// Pings are answered, pongs go to PongHandler; a close frame is echoed and ErrClosed returned
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		messageType int
+		message     []byte
+	)
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			if err := c.writeFrame(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+
+		case PongMessage:
+			if c.PongHandler != nil {
+				c.PongHandler()
+			}
+			continue
+
+		case CloseMessage:
+			code := closeNoStatusReceived
+			if len(payload) >= 2 {
+				code = int(binary.BigEndian.Uint16(payload))
+			}
+			if code == closeNoStatusReceived {
+				code = CloseNormalClosure
+			}
+			c.WriteClose(code, "")
+			return 0, nil, ErrClosed
+
+		case TextMessage, BinaryMessage:
+			if message != nil {
+				return 0, nil, c.protocolError("new message inside a fragmented one")
+			}
+			messageType = opcode
+
+		case continuationFrame:
+			if message == nil {
+				return 0, nil, c.protocolError("continuation without a message")
+			}
+
+		default:
+			return 0, nil, c.protocolError(fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		if len(message)+len(payload) > MaxMessageSize {
+			c.WriteClose(CloseMessageTooBig, "")
+			return 0, nil, errors.New("websocket: message too big")
+		}
+		message = append(message, payload...)
+		if message == nil {
+			message = []byte{}
+		}
+
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame reads one frame, unmasking client frames
+func (c *Conn) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = head[0]&0x80 != 0
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.protocolError("reserved bits set")
+	}
+	opcode = int(head[0] & 0x0F)
+
+	// Clients must mask every frame, servers must not
+	masked := head[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, c.protocolError("invalid frame masking")
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if opcode >= CloseMessage && (length > 125 || !fin) {
+		return false, 0, nil, c.protocolError("invalid control frame")
+	}
+	if length > MaxMessageSize {
+		c.WriteClose(CloseMessageTooBig, "")
+		return false, 0, nil, errors.New("websocket: frame too big")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+func (c *Conn) protocolError(msg string) error {
+	c.WriteClose(CloseProtocolError, msg)
+	return errors.New("websocket: protocol error: " + msg)
+}